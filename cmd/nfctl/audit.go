@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/pkg/client"
+)
+
+// runAudit 处理 audit 子命令
+func runAudit(args []string) error {
+	const verbs = "list|export"
+	verb, args, err := verbArgs("audit", args, verbs)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("audit "+verb, flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	actor := fs.String("actor", "", "only entries of this actor (token name or ID)")
+	mac := fs.String("mac", "", "only entries targeting this node")
+	since := fs.String("since", "", "start time, RFC 3339 or a duration ago such as 24h")
+	until := fs.String("until", "", "end time, RFC 3339 or a duration ago such as 1h")
+	var limit *int
+	var file *string
+	switch verb {
+	case "list", "ls":
+		limit = fs.Int("limit", 0, "maximum number of entries (default: server default)")
+	case "export":
+		file = fs.String("f", "", "write JSON lines to this file instead of stdout")
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	filter := &client.AuditOptions{Actor: *actor, MAC: *mac}
+	if filter.Since, err = parseTimeFlag("since", *since); err != nil {
+		return err
+	}
+	if filter.Until, err = parseTimeFlag("until", *until); err != nil {
+		return err
+	}
+
+	switch verb {
+	case "list", "ls":
+		if err := expectArgs(positional, 0, "audit list [flags]"); err != nil {
+			return err
+		}
+		c, err := opts.newClient()
+		if err != nil {
+			return err
+		}
+
+		filter.Limit = *limit
+		list, err := c.ListAudit(context.Background(), filter)
+		if err != nil {
+			return err
+		}
+		return printOutput(opts.output, list.Entries, func(w *tabwriter.Writer) {
+			fmt.Fprintln(w, "TIME\tACTOR\tREMOTE\tACTION\tTARGET\tSTATUS")
+			for _, entry := range list.Entries {
+				target := entry.MAC
+				if target == "" {
+					target = entry.Target
+				}
+				status := fmt.Sprint(entry.Status)
+				if entry.Code != "" {
+					status += " " + entry.Code
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					entry.Time.Local().Format(time.RFC3339), entry.Actor, entry.RemoteAddr,
+					entry.Action, orNone(target), status)
+			}
+		})
+
+	case "export":
+		if err := expectArgs(positional, 0, "audit export [-f file] [flags]"); err != nil {
+			return err
+		}
+		c, err := opts.newClient()
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if *file != "" {
+			f, err := os.Create(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		return c.ExportAudit(context.Background(), filter, w)
+
+	default:
+		return unknownVerb("audit", verb, verbs)
+	}
+}
+
+// parseTimeFlag 解析时间参数：RFC 3339 时间，或表示多久之前的时长（如 24h）
+func parseTimeFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid -%s %q: expected RFC 3339 time or duration", name, value)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/lucheng0127/nodefoundry/pkg/client"
+)
+
+// connect 根据连接配置创建 API 客户端
+func connect(ctx *Context) (*client.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: ctx.InsecureSkipVerify}
+	if ctx.CAFile != "" {
+		pem, err := os.ReadFile(ctx.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", ctx.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return client.New(ctx.Server,
+		client.WithToken(ctx.Token),
+		client.WithHTTPClient(&http.Client{Transport: transport}),
+		client.WithUserAgent("nfctl"),
+	)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/pkg/client"
+)
+
+// commandPollInterval -wait 时轮询命令状态的间隔
+const commandPollInterval = time.Second
+
+// argList 可重复的 -arg key=value 标志
+type argList map[string]interface{}
+
+func (a argList) String() string {
+	pairs := make([]string, 0, len(a))
+	for k, v := range a {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, v))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (a argList) Set(value string) error {
+	key, raw, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	// 数字和布尔值按 JSON 类型发送，其余为字符串
+	if b, err := strconv.ParseBool(raw); err == nil {
+		a[key] = b
+	} else if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		a[key] = n
+	} else {
+		a[key] = raw
+	}
+	return nil
+}
+
+// runCommands 处理 commands 子命令
+func runCommands(args []string) error {
+	const verbs = "send|list|get"
+	verb, args, err := verbArgs("commands", args, verbs)
+	if err != nil {
+		return err
+	}
+
+	switch verb {
+	case "send":
+		return commandsSend(args)
+	case "list", "ls":
+		return commandsList(args)
+	case "get":
+		return commandsGet(args)
+	default:
+		return unknownVerb("commands", verb, verbs)
+	}
+}
+
+// commandsSend 向节点 agent 下发命令
+func commandsSend(args []string) error {
+	fs := flag.NewFlagSet("commands send", flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	commandArgs := argList{}
+	fs.Var(commandArgs, "arg", "command argument key=value (repeatable)")
+	timeout := fs.Duration("timeout", 0, "command deadline, e.g. 10m (default: server default)")
+	idempotencyKey := fs.String("idempotency-key", "", "idempotency key, retries with the same key are sent only once")
+	wait := fs.Bool("wait", false, "wait for the command to finish, exit non-zero unless it succeeded")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(positional, 2, "commands send <mac> <command> [-arg key=value]... [flags]"); err != nil {
+		return err
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+
+	req := &client.CommandRequest{
+		Command:        positional[1],
+		TimeoutSeconds: int(timeout.Seconds()),
+		IdempotencyKey: *idempotencyKey,
+	}
+	if len(commandArgs) > 0 {
+		req.Args = commandArgs
+	}
+	execution, err := c.SendCommand(context.Background(), positional[0], req)
+	if err != nil {
+		return err
+	}
+
+	if *wait {
+		if opts.output == outputTable {
+			fmt.Fprintf(os.Stderr, "command %s sent, waiting for result...\n", execution.ID)
+		}
+		if execution, err = waitCommand(c, execution); err != nil {
+			return err
+		}
+	}
+
+	if err := printCommand(opts.output, execution); err != nil {
+		return err
+	}
+	if *wait && execution.Status != client.CommandSucceeded {
+		return fmt.Errorf("command %s %s", execution.ID, execution.Status)
+	}
+	return nil
+}
+
+// waitCommand 轮询直到命令结束，期间输出 agent 上报的进度
+func waitCommand(c *client.Client, execution *client.CommandExecution) (*client.CommandExecution, error) {
+	progress := execution.Progress
+	for !execution.Finished() {
+		time.Sleep(commandPollInterval)
+		latest, err := c.GetCommand(context.Background(), execution.MAC, execution.ID)
+		if err != nil {
+			return nil, err
+		}
+		execution = latest
+		if execution.Progress != "" && execution.Progress != progress {
+			progress = execution.Progress
+			fmt.Fprintf(os.Stderr, "progress: %s\n", progress)
+		}
+	}
+	return execution, nil
+}
+
+// commandsList 列出命令（指定 MAC 时只列出该节点的命令）
+func commandsList(args []string) error {
+	fs := flag.NewFlagSet("commands list", flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	status := fs.String("status", "", "filter by status: pending, running, succeeded, failed, timed_out")
+	limit := fs.Int("limit", 0, "maximum number of commands (default: server default)")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return expectArgs(positional, 1, "commands list [mac] [-status STATUS] [-limit N]")
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+
+	query := &client.ListCommandsOptions{Status: *status, Limit: *limit}
+	var executions []*client.CommandExecution
+	if len(positional) == 1 {
+		executions, err = c.ListNodeCommands(context.Background(), positional[0], query)
+	} else {
+		executions, err = c.ListCommands(context.Background(), query)
+	}
+	if err != nil {
+		return err
+	}
+	return printOutput(opts.output, executions, func(w *tabwriter.Writer) {
+		printCommandTable(w, executions)
+	})
+}
+
+// commandsGet 获取单个命令
+func commandsGet(args []string) error {
+	fs := flag.NewFlagSet("commands get", flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(positional, 2, "commands get <mac> <id>"); err != nil {
+		return err
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+
+	execution, err := c.GetCommand(context.Background(), positional[0], positional[1])
+	if err != nil {
+		if client.IsNotFound(err) {
+			return fmt.Errorf("command %s not found on node %s", positional[1], positional[0])
+		}
+		return err
+	}
+	return printCommand(opts.output, execution)
+}
+
+// printCommand 输出单个命令；表格格式下附带输出内容
+func printCommand(format string, execution *client.CommandExecution) error {
+	return printOutput(format, execution, func(w *tabwriter.Writer) {
+		printCommandTable(w, []*client.CommandExecution{execution})
+		if execution.Error != "" {
+			fmt.Fprintf(w, "\nError: %s\n", execution.Error)
+		}
+		if execution.Stdout != "" {
+			fmt.Fprintf(w, "\nStdout:\n%s\n", strings.TrimRight(execution.Stdout, "\n"))
+		}
+		if execution.Stderr != "" {
+			fmt.Fprintf(w, "\nStderr:\n%s\n", strings.TrimRight(execution.Stderr, "\n"))
+		}
+	})
+}
+
+// printCommandTable 以表格输出命令
+func printCommandTable(w *tabwriter.Writer, executions []*client.CommandExecution) {
+	fmt.Fprintln(w, "ID\tMAC\tCOMMAND\tSTATUS\tEXIT\tPROGRESS\tAGE")
+	for _, e := range executions {
+		exitCode := "<none>"
+		if e.ExitCode != nil {
+			exitCode = strconv.Itoa(*e.ExitCode)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.ID, e.MAC, e.Command, e.Status, exitCode, orNone(e.Progress), formatAge(e.CreatedAt))
+	}
+}
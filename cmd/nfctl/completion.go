@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const bashCompletion = `# nfctl bash completion
+# 加载: source <(nfctl completion bash)
+_nfctl() {
+    local cur prev resource
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    resource="${COMP_WORDS[1]}"
+
+    case "$prev" in
+        -o)
+            COMPREPLY=($(compgen -W "table json yaml" -- "$cur"))
+            return
+            ;;
+        -context|use-context|delete-context)
+            COMPREPLY=($(compgen -W "$(nfctl config get-contexts -o json 2>/dev/null | sed -n 's/.*"name": *"\([^"]*\)".*/\1/p')" -- "$cur"))
+            return
+            ;;
+    esac
+
+    if [ "$COMP_CWORD" -eq 1 ]; then
+        COMPREPLY=($(compgen -W "nodes commands events leases audit profiles config completion" -- "$cur"))
+        return
+    fi
+
+    if [ "$COMP_CWORD" -eq 2 ]; then
+        local verbs
+        case "$resource" in
+            nodes|node) verbs="list get register install reinstall label delete" ;;
+            commands|command) verbs="send list get" ;;
+            events) verbs="watch" ;;
+            leases|lease) verbs="list release" ;;
+            audit) verbs="list export" ;;
+            profiles|profile) verbs="list get create update delete" ;;
+            config) verbs="view get-contexts current-context use-context set-context delete-context" ;;
+            completion) verbs="bash zsh" ;;
+        esac
+        COMPREPLY=($(compgen -W "$verbs" -- "$cur"))
+        return
+    fi
+
+    if [[ "$cur" == -* ]]; then
+        local flags="-context -server -token -o -h"
+        case "$resource ${COMP_WORDS[2]}" in
+            "nodes list"|"node list") flags="$flags -status -label -ip -hostname -sort -limit" ;;
+            "nodes register"|"node register") flags="$flags -ip -netmask -gateway -dns" ;;
+            "commands send"|"command send") flags="$flags -arg -timeout -idempotency-key -wait" ;;
+            "commands list"|"command list") flags="$flags -status -limit" ;;
+            "events watch") flags="$flags -mac -type -label" ;;
+            "audit list") flags="$flags -actor -mac -since -until -limit" ;;
+            "audit export") flags="$flags -actor -mac -since -until -f" ;;
+            "profiles create"|"profile create"|"profiles update"|"profile update") flags="$flags -f" ;;
+            "config set-context") flags="$flags -ca-file -insecure-skip-verify" ;;
+        esac
+        COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+    fi
+}
+complete -F _nfctl nfctl
+`
+
+const zshCompletion = `#compdef nfctl
+# nfctl zsh completion
+# 加载: source <(nfctl completion zsh)
+autoload -U +X bashcompinit && bashcompinit
+` + bashCompletion
+
+// runCompletion 输出 shell 补全脚本
+func runCompletion(args []string) error {
+	const verbs = "bash|zsh"
+	shell, _, err := verbArgs("completion", args, verbs)
+	if err != nil {
+		return err
+	}
+
+	switch shell {
+	case "bash":
+		fmt.Fprint(os.Stdout, bashCompletion)
+	case "zsh":
+		fmt.Fprint(os.Stdout, zshCompletion)
+	default:
+		return unknownVerb("completion", shell, verbs)
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/lucheng0127/nodefoundry/pkg/client"
+)
+
+// Config nfctl 配置文件（$NFCTL_CONFIG，默认 ~/.config/nfctl/config.yaml）
+type Config struct {
+	CurrentContext string     `yaml:"current-context" json:"current-context"`
+	Contexts       []*Context `yaml:"contexts" json:"contexts"`
+}
+
+// Context 一个 NodeFoundry 服务器的连接配置
+type Context struct {
+	Name   string `yaml:"name" json:"name"`
+	Server string `yaml:"server" json:"server"`
+	Token  string `yaml:"token,omitempty" json:"token,omitempty"`
+	// CAFile 校验服务器证书的 CA（如服务器自动生成的本地 CA）
+	CAFile string `yaml:"ca-file,omitempty" json:"ca-file,omitempty"`
+	// InsecureSkipVerify 不校验服务器证书（仅用于测试）
+	InsecureSkipVerify bool `yaml:"insecure-skip-verify,omitempty" json:"insecure-skip-verify,omitempty"`
+}
+
+// defaultServer 未配置任何 context 时使用的服务器地址
+const defaultServer = "http://localhost:8080"
+
+// configPath 配置文件路径
+func configPath() (string, error) {
+	if path := os.Getenv("NFCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "nfctl", "config.yaml"), nil
+}
+
+// loadConfig 读取配置文件，不存在时返回空配置
+func loadConfig() (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// save 写入配置文件（包含 token，仅当前用户可读）
+func (c *Config) save() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// find 按名称查找 context
+func (c *Config) find(name string) *Context {
+	for _, ctx := range c.Contexts {
+		if ctx.Name == name {
+			return ctx
+		}
+	}
+	return nil
+}
+
+// globalOptions 所有子命令共用的选项
+type globalOptions struct {
+	context string
+	server  string
+	token   string
+	output  string
+}
+
+// addGlobalFlags 注册全局选项
+func addGlobalFlags(fs *flag.FlagSet) *globalOptions {
+	opts := &globalOptions{}
+	fs.StringVar(&opts.context, "context", "", "config context to use (default: current context)")
+	fs.StringVar(&opts.server, "server", os.Getenv("NFCTL_SERVER"), "server URL, overrides the context")
+	fs.StringVar(&opts.token, "token", os.Getenv("NFCTL_TOKEN"), "API token, overrides the context")
+	fs.StringVar(&opts.output, "o", outputTable, "output format: table, json, yaml")
+	return opts
+}
+
+// resolveContext 按命令行选项、环境变量、配置文件的优先级确定连接配置
+func (o *globalOptions) resolveContext() (*Context, error) {
+	if err := validateOutput(o.output); err != nil {
+		return nil, err
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &Context{Server: defaultServer}
+	name := o.context
+	if name == "" {
+		name = config.CurrentContext
+	}
+	if name != "" {
+		ctx := config.find(name)
+		if ctx == nil {
+			if o.context != "" {
+				return nil, fmt.Errorf("context %q not found", name)
+			}
+		} else {
+			copied := *ctx
+			resolved = &copied
+		}
+	}
+
+	if o.server != "" {
+		resolved.Server = o.server
+	}
+	if o.token != "" {
+		resolved.Token = o.token
+	}
+	return resolved, nil
+}
+
+// newClient 根据全局选项创建 API 客户端
+func (o *globalOptions) newClient() (*client.Client, error) {
+	ctx, err := o.resolveContext()
+	if err != nil {
+		return nil, err
+	}
+	return connect(ctx)
+}
+
+// runConfig 处理 config 子命令
+func runConfig(args []string) error {
+	const verbs = "view|get-contexts|current-context|use-context|set-context|delete-context"
+	verb, args, err := verbArgs("config", args, verbs)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("config "+verb, flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	caFile := fs.String("ca-file", "", "CA certificate file (set-context)")
+	insecure := fs.Bool("insecure-skip-verify", false, "skip server certificate verification (set-context)")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(opts.output); err != nil {
+		return err
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	switch verb {
+	case "view":
+		// 不输出 token 明文
+		redacted := &Config{CurrentContext: config.CurrentContext}
+		for _, ctx := range config.Contexts {
+			copied := *ctx
+			if copied.Token != "" {
+				copied.Token = "REDACTED"
+			}
+			redacted.Contexts = append(redacted.Contexts, &copied)
+		}
+		if opts.output == outputTable {
+			opts.output = outputYAML
+		}
+		return printOutput(opts.output, redacted, nil)
+
+	case "get-contexts":
+		return printOutput(opts.output, config.Contexts, func(w *tabwriter.Writer) {
+			fmt.Fprintln(w, "CURRENT\tNAME\tSERVER\tAUTH")
+			for _, ctx := range config.Contexts {
+				current := ""
+				if ctx.Name == config.CurrentContext {
+					current = "*"
+				}
+				auth := "none"
+				if ctx.Token != "" {
+					auth = "token"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, ctx.Name, ctx.Server, auth)
+			}
+		})
+
+	case "current-context":
+		if config.CurrentContext == "" {
+			return errors.New("current context is not set")
+		}
+		fmt.Println(config.CurrentContext)
+		return nil
+
+	case "use-context":
+		if err := expectArgs(positional, 1, "config use-context <name>"); err != nil {
+			return err
+		}
+		if config.find(positional[0]) == nil {
+			return fmt.Errorf("context %q not found", positional[0])
+		}
+		config.CurrentContext = positional[0]
+		if err := config.save(); err != nil {
+			return err
+		}
+		fmt.Printf("switched to context %q\n", positional[0])
+		return nil
+
+	case "set-context":
+		if err := expectArgs(positional, 1, "config set-context <name> [-server URL] [-token TOKEN] [-ca-file FILE] [-insecure-skip-verify]"); err != nil {
+			return err
+		}
+		ctx := config.find(positional[0])
+		if ctx == nil {
+			ctx = &Context{Name: positional[0], Server: defaultServer}
+			config.Contexts = append(config.Contexts, ctx)
+		}
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "server":
+				ctx.Server = opts.server
+			case "token":
+				ctx.Token = opts.token
+			case "ca-file":
+				ctx.CAFile = *caFile
+			case "insecure-skip-verify":
+				ctx.InsecureSkipVerify = *insecure
+			}
+		})
+		if config.CurrentContext == "" {
+			config.CurrentContext = ctx.Name
+		}
+		if err := config.save(); err != nil {
+			return err
+		}
+		fmt.Printf("context %q saved\n", ctx.Name)
+		return nil
+
+	case "delete-context":
+		if err := expectArgs(positional, 1, "config delete-context <name>"); err != nil {
+			return err
+		}
+		contexts := config.Contexts[:0]
+		found := false
+		for _, ctx := range config.Contexts {
+			if ctx.Name == positional[0] {
+				found = true
+				continue
+			}
+			contexts = append(contexts, ctx)
+		}
+		if !found {
+			return fmt.Errorf("context %q not found", positional[0])
+		}
+		config.Contexts = contexts
+		if config.CurrentContext == positional[0] {
+			config.CurrentContext = ""
+		}
+		if err := config.save(); err != nil {
+			return err
+		}
+		fmt.Printf("context %q deleted\n", positional[0])
+		return nil
+
+	default:
+		return unknownVerb("config", verb, verbs)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/lucheng0127/nodefoundry/pkg/client"
+)
+
+// runEvents 处理 events 子命令
+func runEvents(args []string) error {
+	const verbs = "watch"
+	verb, args, err := verbArgs("events", args, verbs)
+	if err != nil {
+		return err
+	}
+
+	switch verb {
+	case "watch":
+		return eventsWatch(args)
+	default:
+		return unknownVerb("events", verb, verbs)
+	}
+}
+
+// eventsWatch 订阅事件流，断开后自动重连续传，Ctrl-C 结束
+func eventsWatch(args []string) error {
+	fs := flag.NewFlagSet("events watch", flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	mac := fs.String("mac", "", "only events of these nodes (comma separated)")
+	eventType := fs.String("type", "", "only these event types, supports prefixes like node.* (comma separated)")
+	label := fs.String("label", "", "only events of nodes matching the label selector")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(positional, 0, "events watch [-mac MAC] [-type TYPE] [-label SELECTOR]"); err != nil {
+		return err
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+
+	filter := &client.EventFilter{
+		MACs:   splitFlag(*mac),
+		Types:  splitFlag(*eventType),
+		Labels: *label,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = c.StreamEvents(ctx, filter, func(e *client.Event) error {
+		return printEvent(opts.output, e)
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// printEvent 输出一条事件：table 每行一条摘要，json 每行一个 JSON 对象，yaml 为多文档
+func printEvent(format string, e *client.Event) error {
+	switch format {
+	case outputJSON:
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Println(string(data))
+		return err
+
+	case outputYAML:
+		data, err := toYAML(e)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Printf("---\n%s", data)
+		return err
+	}
+
+	// 缓冲溢出导致的事件缺口不是节点事件，单独提示
+	if e.Type == client.EventStreamGap {
+		fmt.Fprintf(os.Stderr, "warning: some events were missed (%s)\n", formatData(e.Data))
+		return nil
+	}
+
+	_, err := fmt.Printf("%s  %-24s  %-17s  %s\n",
+		e.Time.Local().Format("2006-01-02 15:04:05"), e.Type, orNone(e.MAC), formatData(e.Data))
+	return err
+}
+
+// formatData 将事件数据格式化为 k=v 列表
+func formatData(data map[string]interface{}) string {
+	if len(data) == 0 {
+		return ""
+	}
+	labels := make(map[string]string, len(data))
+	for k, v := range data {
+		switch v := v.(type) {
+		case string:
+			labels[k] = v
+		default:
+			encoded, _ := json.Marshal(v)
+			labels[k] = string(encoded)
+		}
+	}
+	return formatLabels(labels)
+}
+
+// splitFlag 拆分逗号分隔的标志值
+func splitFlag(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
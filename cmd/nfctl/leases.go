@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+// runLeases 处理 leases 子命令
+func runLeases(args []string) error {
+	const verbs = "list|release"
+	verb, args, err := verbArgs("leases", args, verbs)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("leases "+verb, flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	switch verb {
+	case "list", "ls":
+		if err := expectArgs(positional, 0, "leases list"); err != nil {
+			return err
+		}
+		c, err := opts.newClient()
+		if err != nil {
+			return err
+		}
+
+		list, err := c.ListLeases(context.Background())
+		if err != nil {
+			return err
+		}
+		return printOutput(opts.output, list, func(w *tabwriter.Writer) {
+			fmt.Fprintf(w, "Subnet: %s  Size: %d  Allocated: %d  Expired: %d\n\n",
+				list.Subnet, list.Stats.Size, list.Stats.Allocated, list.Stats.Expired)
+			fmt.Fprintln(w, "MAC\tIP\tEXPIRES\tEXPIRED")
+			for _, lease := range list.Leases {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\n",
+					lease.MAC, lease.IP, lease.ExpiresAt.Local().Format(time.RFC3339), lease.Expired)
+			}
+		})
+
+	case "release":
+		if err := expectArgs(positional, 1, "leases release <mac>"); err != nil {
+			return err
+		}
+		c, err := opts.newClient()
+		if err != nil {
+			return err
+		}
+
+		if err := c.ReleaseLease(context.Background(), positional[0]); err != nil {
+			return err
+		}
+		fmt.Printf("lease of %s released\n", positional[0])
+		return nil
+
+	default:
+		return unknownVerb("leases", verb, verbs)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const usage = `nfctl - NodeFoundry command-line client
+
+Usage:
+  nfctl <resource> <verb> [arguments] [flags]
+
+Resources:
+  nodes       list | get | register | install | reinstall | label | delete
+  commands    send | list | get
+  events      watch
+  leases      list | release
+  audit       list | export
+  profiles    list | get | create | update | delete
+  config      view | get-contexts | current-context | use-context | set-context | delete-context
+  completion  bash | zsh
+
+Global flags (accepted by every subcommand):
+  -context string   config context to use (default: current context)
+  -server string    server URL, overrides the context ($NFCTL_SERVER)
+  -token string     API token, overrides the context ($NFCTL_TOKEN)
+  -o string         output format: table, json, yaml (default "table")
+
+Run "nfctl <resource> <verb> -h" for the flags of a subcommand.
+`
+
+// errUsage 参数错误（已输出用法说明）
+var errUsage = errors.New("usage error")
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, errUsage) && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+}
+
+// run 分发子命令
+func run(args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		fmt.Print(usage)
+		if len(args) == 0 {
+			return errUsage
+		}
+		return nil
+	}
+
+	resource, rest := args[0], args[1:]
+	switch resource {
+	case "nodes", "node":
+		return runNodes(rest)
+	case "commands", "command":
+		return runCommands(rest)
+	case "events":
+		return runEvents(rest)
+	case "leases", "lease":
+		return runLeases(rest)
+	case "audit":
+		return runAudit(rest)
+	case "profiles", "profile":
+		return runProfiles(rest)
+	case "config":
+		return runConfig(rest)
+	case "completion":
+		return runCompletion(rest)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown resource %q\n\n%s", resource, usage)
+		return errUsage
+	}
+}
+
+// verbArgs 拆分动词和其余参数，缺少动词时输出可用动词
+func verbArgs(resource string, args []string, verbs string) (string, []string, error) {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprintf(os.Stderr, "usage: nfctl %s <%s>\n", resource, verbs)
+		return "", nil, errUsage
+	}
+	return args[0], args[1:], nil
+}
+
+// unknownVerb 未知动词错误
+func unknownVerb(resource, verb, verbs string) error {
+	fmt.Fprintf(os.Stderr, "unknown %s verb %q, expected one of: %s\n", resource, verb, verbs)
+	return errUsage
+}
+
+// parseFlags 解析参数，允许标志和位置参数交错出现（如 nodes get aa:bb:... -o json）
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// expectArgs 检查位置参数数量
+func expectArgs(positional []string, n int, usage string) error {
+	if len(positional) != n {
+		fmt.Fprintf(os.Stderr, "usage: nfctl %s\n", usage)
+		return errUsage
+	}
+	return nil
+}
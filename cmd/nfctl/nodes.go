@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lucheng0127/nodefoundry/pkg/client"
+)
+
+// runNodes 处理 nodes 子命令
+func runNodes(args []string) error {
+	const verbs = "list|get|register|install|reinstall|label|delete"
+	verb, args, err := verbArgs("nodes", args, verbs)
+	if err != nil {
+		return err
+	}
+
+	switch verb {
+	case "list", "ls":
+		return nodesList(args)
+	case "get":
+		return nodesGet(args)
+	case "register":
+		return nodesRegister(args)
+	case "install", "reinstall":
+		return nodesAction(verb, args)
+	case "label":
+		return nodesLabel(args)
+	case "delete", "rm":
+		return nodesDelete(args)
+	default:
+		return unknownVerb("nodes", verb, verbs)
+	}
+}
+
+// nodesList 列出节点（自动跟随分页游标）
+func nodesList(args []string) error {
+	fs := flag.NewFlagSet("nodes list", flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	status := fs.String("status", "", "filter by status (comma separated)")
+	label := fs.String("label", "", "label selector, e.g. rack=a1,!maintenance")
+	ip := fs.String("ip", "", "filter by IP address or CIDR")
+	hostname := fs.String("hostname", "", "filter by hostname glob, e.g. edge-*")
+	sortBy := fs.String("sort", "", "sort fields, prefix with - for descending, e.g. -created_at")
+	limit := fs.Int("limit", 0, "maximum number of nodes to show, 0 means all")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(positional, 0, "nodes list [flags]"); err != nil {
+		return err
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+
+	query := &client.ListNodesOptions{
+		Status:   *status,
+		Label:    *label,
+		IP:       *ip,
+		Hostname: *hostname,
+		Sort:     *sortBy,
+	}
+	nodes := []*client.Node{}
+	for {
+		if *limit > 0 {
+			query.Limit = *limit - len(nodes)
+		}
+
+		page, err := c.ListNodes(context.Background(), query)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, page.Nodes...)
+
+		if page.NextCursor == "" || (*limit > 0 && len(nodes) >= *limit) {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	return printOutput(opts.output, nodes, func(w *tabwriter.Writer) {
+		printNodeTable(w, nodes)
+	})
+}
+
+// nodesGet 获取单个节点
+func nodesGet(args []string) error {
+	fs := flag.NewFlagSet("nodes get", flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(positional, 1, "nodes get <mac>"); err != nil {
+		return err
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+
+	node, err := c.GetNode(context.Background(), positional[0])
+	if err != nil {
+		return err
+	}
+	return printNode(opts.output, node)
+}
+
+// nodesRegister 手动注册节点
+func nodesRegister(args []string) error {
+	fs := flag.NewFlagSet("nodes register", flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	ip := fs.String("ip", "", "static IP address (requires -netmask)")
+	netmask := fs.String("netmask", "", "static netmask")
+	gateway := fs.String("gateway", "", "static gateway")
+	dns := fs.String("dns", "", "static DNS servers (comma separated)")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(positional, 1, "nodes register <mac> [-ip IP -netmask MASK [-gateway GW] [-dns DNS]]"); err != nil {
+		return err
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+
+	node, err := c.RegisterNode(context.Background(), &client.RegisterNodeRequest{
+		MAC: positional[0], IP: *ip, Netmask: *netmask, Gateway: *gateway, DNS: *dns,
+	})
+	if err != nil {
+		return err
+	}
+	return printNode(opts.output, node)
+}
+
+// nodesAction 触发安装或重装
+func nodesAction(action string, args []string) error {
+	fs := flag.NewFlagSet("nodes "+action, flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(positional, 1, "nodes "+action+" <mac>"); err != nil {
+		return err
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+
+	node, err := c.UpdateNode(context.Background(), positional[0], &client.UpdateNodeRequest{Action: action})
+	if err != nil {
+		return err
+	}
+	return printNode(opts.output, node)
+}
+
+// nodesLabel 修改节点标签：key=value 设置，key- 删除
+func nodesLabel(args []string) error {
+	fs := flag.NewFlagSet("nodes label", flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	const usage = "nodes label <mac> key=value... key-..."
+	if len(positional) < 2 {
+		return expectArgs(nil, 2, usage)
+	}
+
+	labels := make(map[string]*string)
+	for _, arg := range positional[1:] {
+		if key, ok := strings.CutSuffix(arg, "-"); ok && !strings.Contains(arg, "=") {
+			labels[key] = nil
+			continue
+		}
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid label %q, expected key=value or key-", arg)
+		}
+		labels[key] = &value
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+
+	node, err := c.PatchNode(context.Background(), positional[0], &client.NodePatch{Labels: labels})
+	if err != nil {
+		return err
+	}
+	return printNode(opts.output, node)
+}
+
+// nodesDelete 删除节点
+func nodesDelete(args []string) error {
+	fs := flag.NewFlagSet("nodes delete", flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(positional, 1, "nodes delete <mac>"); err != nil {
+		return err
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return err
+	}
+
+	if err := c.DeleteNode(context.Background(), positional[0]); err != nil {
+		return err
+	}
+	fmt.Printf("node %s deleted\n", positional[0])
+	return nil
+}
+
+// printNode 输出单个节点
+func printNode(format string, node *client.Node) error {
+	return printOutput(format, node, func(w *tabwriter.Writer) {
+		printNodeTable(w, []*client.Node{node})
+	})
+}
+
+// printNodeTable 以表格输出节点
+func printNodeTable(w *tabwriter.Writer, nodes []*client.Node) {
+	fmt.Fprintln(w, "MAC\tHOSTNAME\tIP\tSTATUS\tLABELS\tHEARTBEAT\tAGE")
+	for _, node := range nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			node.MAC,
+			orNone(node.Hostname),
+			orNone(node.IP),
+			node.Status,
+			formatLabels(node.Labels),
+			formatAge(node.LastHeartbeat),
+			formatAge(node.CreatedAt),
+		)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/lucheng0127/nodefoundry/pkg/client"
+)
+
+// runProfiles 处理 profiles 子命令
+func runProfiles(args []string) error {
+	const verbs = "list|get|create|update|delete"
+	verb, args, err := verbArgs("profiles", args, verbs)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("profiles "+verb, flag.ContinueOnError)
+	opts := addGlobalFlags(fs)
+	var file *string
+	switch verb {
+	case "create", "update":
+		file = fs.String("f", "", "profile definition in YAML or JSON (- for stdin)")
+	}
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	switch verb {
+	case "list", "ls":
+		if err := expectArgs(positional, 0, "profiles list"); err != nil {
+			return err
+		}
+		c, err := opts.newClient()
+		if err != nil {
+			return err
+		}
+
+		profiles, err := c.ListProfiles(context.Background())
+		if err != nil {
+			return err
+		}
+		return printOutput(opts.output, profiles, func(w *tabwriter.Writer) {
+			fmt.Fprintln(w, "NAME\tDISTRO\tRELEASE\tARCH\tGROUPS\tANSWER FILE\tAGE")
+			for _, p := range profiles {
+				answer := "built-in preseed"
+				if p.AnswerFile != "" {
+					answer = "custom"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					p.Name, p.Distro, orNone(p.Release), orNone(p.Arch),
+					orNone(strings.Join(p.Groups, ",")), answer, formatAge(p.CreatedAt))
+			}
+		})
+
+	case "get":
+		if err := expectArgs(positional, 1, "profiles get <name>"); err != nil {
+			return err
+		}
+		c, err := opts.newClient()
+		if err != nil {
+			return err
+		}
+
+		profile, err := c.GetProfile(context.Background(), positional[0])
+		if err != nil {
+			return err
+		}
+		// 应答文件为多行文本，table 格式也输出 YAML
+		return printOutput(opts.output, profile, nil)
+
+	case "create":
+		if err := expectArgs(positional, 0, "profiles create -f <file>"); err != nil {
+			return err
+		}
+		req, err := readProfileFile(*file)
+		if err != nil {
+			return err
+		}
+		c, err := opts.newClient()
+		if err != nil {
+			return err
+		}
+
+		profile, err := c.CreateProfile(context.Background(), req)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("profile %s created\n", profile.Name)
+		return nil
+
+	case "update":
+		if err := expectArgs(positional, 1, "profiles update <name> -f <file>"); err != nil {
+			return err
+		}
+		req, err := readProfileFile(*file)
+		if err != nil {
+			return err
+		}
+		c, err := opts.newClient()
+		if err != nil {
+			return err
+		}
+
+		// 文件中的名称可以省略，但不能与参数不同
+		if req.Name != nil && *req.Name != positional[0] {
+			return fmt.Errorf("profile name %q in file does not match %q", *req.Name, positional[0])
+		}
+		req.Name = nil
+		profile, err := c.UpdateProfile(context.Background(), positional[0], req)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("profile %s updated\n", profile.Name)
+		return nil
+
+	case "delete", "rm":
+		if err := expectArgs(positional, 1, "profiles delete <name>"); err != nil {
+			return err
+		}
+		c, err := opts.newClient()
+		if err != nil {
+			return err
+		}
+
+		if err := c.DeleteProfile(context.Background(), positional[0]); err != nil {
+			return err
+		}
+		fmt.Printf("profile %s deleted\n", positional[0])
+		return nil
+
+	default:
+		return unknownVerb("profiles", verb, verbs)
+	}
+}
+
+// readProfileFile 读取 YAML 或 JSON 格式的安装配置，字段名与 API 相同（如 kernel_url）
+// 文件中省略的字段在修改时保持不变
+func readProfileFile(path string) (*client.ProfileRequest, error) {
+	if path == "" {
+		return nil, fmt.Errorf("-f is required")
+	}
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML 是 JSON 的超集：统一转换为 JSON 后按 API 字段名解析，拒绝未知字段以发现拼写错误
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile file %s: %w", path, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.DisallowUnknownFields()
+	var file struct {
+		client.ProfileRequest
+		// 只读字段，允许直接使用 profiles get -o yaml 的输出
+		CreatedAt interface{} `json:"created_at"`
+		UpdatedAt interface{} `json:"updated_at"`
+	}
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid profile file %s: %w", path, err)
+	}
+	return &file.ProfileRequest, nil
+}
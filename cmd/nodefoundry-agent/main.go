@@ -44,8 +44,18 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// 已执行命令记录（重复投递的命令不再执行）
+	executed, err := agent.NewExecutedStore(cfg.StateDir)
+	if err != nil {
+		logger.Warn("failed to load executed commands, keeping them in memory only",
+			zap.String("state_dir", cfg.StateDir),
+			zap.Error(err),
+		)
+		executed, _ = agent.NewExecutedStore("")
+	}
+
 	// 创建命令分发器
-	dispatcher := agent.NewDispatcher(logger)
+	dispatcher := agent.NewDispatcher(executed, logger)
 	dispatcher.Register(command.NewRebootCommand())
 
 	// 创建 MQTT 客户端
@@ -54,6 +64,7 @@ func main() {
 			logger.Error("command dispatch failed", zap.Error(err))
 		}
 	})
+	dispatcher.SetResultPublisher(mqttClient)
 
 	// 连接 MQTT
 	if err := mqttClient.Connect(); err != nil {
@@ -14,6 +14,15 @@ func main() {
 	// 加载配置
 	config := server.LoadConfig()
 
+	// 子命令
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		if err := runTokenCommand(config, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 初始化 logger
 	logger := newLogger(config.LogLevel)
 
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/auth"
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+	"github.com/lucheng0127/nodefoundry/internal/server"
+)
+
+// runTokenCommand 处理 token 子命令（直接操作数据库，需在服务器停止时执行）
+//
+//	nodefoundry token create -name <name> -role <viewer|operator|admin> [-ttl 720h]
+//	nodefoundry token list
+//	nodefoundry token revoke <id>
+func runTokenCommand(config *server.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: nodefoundry token <create|list|revoke>")
+	}
+
+	logger := zap.NewNop()
+	boltDB, err := db.InitializeDB(config.DBPath, logger)
+	if err != nil {
+		return fmt.Errorf("%w (is the server running? use the /api/v1/tokens API instead)", err)
+	}
+	defer boltDB.Close()
+
+	repo := db.NewBoltTokenRepository(boltDB, logger)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("token create", flag.ContinueOnError)
+		name := fs.String("name", "", "token name")
+		role := fs.String("role", model.ROLE_VIEWER, "token role (viewer, operator, admin)")
+		ttl := fs.Duration("ttl", 0, "token lifetime, 0 means never expires")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *name == "" {
+			return fmt.Errorf("-name is required")
+		}
+
+		token, plaintext, err := auth.NewAPIToken(*name, *role, *ttl)
+		if err != nil {
+			return err
+		}
+		if err := repo.Create(ctx, token); err != nil {
+			return err
+		}
+
+		fmt.Printf("id:    %s\nname:  %s\nrole:  %s\ntoken: %s\n", token.ID, token.Name, token.Role, plaintext)
+		fmt.Println("Store the token now, it cannot be shown again.")
+		return nil
+
+	case "list":
+		tokens, err := repo.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tROLE\tCREATED\tEXPIRES\tSTATE")
+		now := time.Now()
+		for _, token := range tokens {
+			expires := "never"
+			if !token.ExpiresAt.IsZero() {
+				expires = token.ExpiresAt.Format(time.RFC3339)
+			}
+			state := "active"
+			if !token.Active(now) {
+				state = "inactive"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				token.ID, token.Name, token.Role, token.CreatedAt.Format(time.RFC3339), expires, state)
+		}
+		return w.Flush()
+
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: nodefoundry token revoke <id>")
+		}
+		if err := repo.Revoke(ctx, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("token %s revoked\n", args[1])
+		return nil
+
+	default:
+		return fmt.Errorf("unknown token command: %s", args[0])
+	}
+}
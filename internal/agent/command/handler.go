@@ -4,6 +4,16 @@ import (
 	"context"
 )
 
+// Result 命令执行结果
+type Result struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+// ProgressFunc 上报命令执行进度
+type ProgressFunc func(message string)
+
 // Handler 命令处理器接口
 type Handler interface {
 	// Name 返回命令名称
@@ -11,5 +21,6 @@ type Handler interface {
 
 	// Execute 执行命令
 	// args: 命令参数（可选）
-	Execute(ctx context.Context, args map[string]interface{}) error
+	// progress: 上报执行进度（可多次调用）
+	Execute(ctx context.Context, args map[string]interface{}, progress ProgressFunc) (*Result, error)
 }
@@ -20,14 +20,14 @@ func (c *RebootCommand) Name() string {
 }
 
 // Execute 执行重启命令
-func (c *RebootCommand) Execute(ctx context.Context, args map[string]interface{}) error {
+func (c *RebootCommand) Execute(ctx context.Context, args map[string]interface{}, progress ProgressFunc) (*Result, error) {
 	// 使用 systemctl reboot 命令
 	// 这是最安全的重启方式，会正确处理 systemd 环境
 	cmd := exec.Command("systemctl", "reboot")
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to execute reboot: %w", err)
+		return nil, fmt.Errorf("failed to execute reboot: %w", err)
 	}
 
 	// 命令已启动，系统即将重启
-	return nil
+	return &Result{ExitCode: 0}, nil
 }
@@ -16,6 +16,8 @@ type Config struct {
 	MAC string
 	// 心跳间隔（秒）
 	HeartbeatInterval int
+	// 状态目录（保存已执行命令记录）
+	StateDir string
 }
 
 // LoadConfig 从环境变量加载配置
@@ -31,6 +33,7 @@ func LoadConfig() *Config {
 		LogLevel:           getEnv("NF_LOG_LEVEL", "info"),
 		MAC:                getEnv("NF_MAC", ""),
 		HeartbeatInterval:  heartbeatInterval,
+		StateDir:           getEnv("NF_STATE_DIR", "/var/lib/nodefoundry-agent"),
 	}
 }
 
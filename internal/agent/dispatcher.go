@@ -4,39 +4,83 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/lucheng0127/nodefoundry/internal/agent/command"
 )
 
+// 命令结果状态
+const (
+	ResultRunning   = "running"   // 已确认开始执行（Progress 非空时为进度更新）
+	ResultSucceeded = "succeeded" // 执行成功
+	ResultFailed    = "failed"    // 执行失败、命令未知或已过截止时间
+)
+
+// outputExcerpt 上报的 stdout/stderr 最大长度
+const outputExcerpt = 4096
+
 // CommandMessage 命令消息结构
 type CommandMessage struct {
+	// ID 命令 ID，上报结果时原样带回
+	ID      string                 `json:"id,omitempty"`
 	Command string                 `json:"command"`
 	Args    map[string]interface{} `json:"args,omitempty"`
+	// Deadline 截止时间，过期的命令不再执行
+	Deadline time.Time `json:"deadline,omitempty"`
+	// IdempotencyKey 幂等键，为空时使用 ID
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// CommandResult 命令结果消息（node/{mac}/command/result）
+type CommandResult struct {
+	ID       string `json:"id,omitempty"`
+	Command  string `json:"command"`
+	Status   string `json:"status"`
+	Progress string `json:"progress,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ResultPublisher 发布命令结果
+type ResultPublisher interface {
+	PublishCommandResult(result *CommandResult) error
 }
 
 // Dispatcher 命令分发器
 type Dispatcher struct {
-	handlers map[string]command.Handler
-	logger   *zap.Logger
+	handlers  map[string]command.Handler
+	executed  *ExecutedStore
+	publisher ResultPublisher
+	logger    *zap.Logger
 }
 
 // NewDispatcher 创建命令分发器
-func NewDispatcher(logger *zap.Logger) *Dispatcher {
+func NewDispatcher(executed *ExecutedStore, logger *zap.Logger) *Dispatcher {
 	return &Dispatcher{
 		handlers: make(map[string]command.Handler),
+		executed: executed,
 		logger:   logger,
 	}
 }
 
+// SetResultPublisher 设置命令结果发布器
+func (d *Dispatcher) SetResultPublisher(publisher ResultPublisher) {
+	d.publisher = publisher
+}
+
 // Register 注册命令处理器
 func (d *Dispatcher) Register(handler command.Handler) {
 	d.handlers[handler.Name()] = handler
 	d.logger.Info("command handler registered", zap.String("command", handler.Name()))
 }
 
-// Dispatch 分发并执行命令
+// Dispatch 分发并执行命令，并上报确认、进度和最终结果
+// 幂等键已执行过的命令（重连后重复投递）直接忽略
 func (d *Dispatcher) Dispatch(ctx context.Context, payload []byte) error {
 	// 解析命令消息
 	var msg CommandMessage
@@ -44,26 +88,107 @@ func (d *Dispatcher) Dispatch(ctx context.Context, payload []byte) error {
 		return fmt.Errorf("failed to parse command message: %w", err)
 	}
 
+	key := msg.IdempotencyKey
+	if key == "" {
+		key = msg.ID
+	}
+	if key != "" {
+		// 执行前先记录，避免 reboot 等命令在 agent 重启后被再次执行
+		first, err := d.executed.MarkExecuted(key)
+		if err != nil {
+			d.logger.Warn("failed to persist executed command", zap.String("id", msg.ID), zap.Error(err))
+		}
+		if !first {
+			d.logger.Info("ignoring duplicate command",
+				zap.String("id", msg.ID),
+				zap.String("command", msg.Command),
+			)
+			return nil
+		}
+	}
+
+	if !msg.Deadline.IsZero() && time.Now().After(msg.Deadline) {
+		d.report(&msg, ResultFailed, nil, "deadline exceeded")
+		return fmt.Errorf("command %s deadline exceeded", msg.Command)
+	}
+
 	// 查找处理器
 	handler, exists := d.handlers[msg.Command]
 	if !exists {
+		d.report(&msg, ResultFailed, nil, "unknown command: "+msg.Command)
 		return fmt.Errorf("unknown command: %s", msg.Command)
 	}
 
 	d.logger.Info("executing command",
+		zap.String("id", msg.ID),
 		zap.String("command", msg.Command),
 		zap.Any("args", msg.Args),
 	)
+	d.report(&msg, ResultRunning, nil, "")
+
+	if !msg.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, msg.Deadline)
+		defer cancel()
+	}
+
+	progress := func(message string) {
+		d.publish(&CommandResult{ID: msg.ID, Command: msg.Command, Status: ResultRunning, Progress: message})
+	}
 
 	// 执行命令
-	if err := handler.Execute(ctx, msg.Args); err != nil {
+	result, err := handler.Execute(ctx, msg.Args, progress)
+	if err != nil {
 		d.logger.Error("command execution failed",
+			zap.String("id", msg.ID),
 			zap.String("command", msg.Command),
 			zap.Error(err),
 		)
+		d.report(&msg, ResultFailed, result, err.Error())
 		return fmt.Errorf("command %s failed: %w", msg.Command, err)
 	}
 
-	d.logger.Info("command executed successfully", zap.String("command", msg.Command))
+	d.logger.Info("command executed successfully", zap.String("id", msg.ID), zap.String("command", msg.Command))
+	d.report(&msg, ResultSucceeded, result, "")
 	return nil
 }
+
+// report 上报命令状态
+func (d *Dispatcher) report(msg *CommandMessage, status string, result *command.Result, errMsg string) {
+	report := &CommandResult{
+		ID:      msg.ID,
+		Command: msg.Command,
+		Status:  status,
+		Error:   errMsg,
+	}
+	if result != nil {
+		exitCode := result.ExitCode
+		report.ExitCode = &exitCode
+		report.Stdout = excerpt(result.Stdout)
+		report.Stderr = excerpt(result.Stderr)
+	}
+	d.publish(report)
+}
+
+// publish 发布命令结果，失败时仅记录日志
+func (d *Dispatcher) publish(result *CommandResult) {
+	if d.publisher == nil || result.Command == "" {
+		return
+	}
+	if err := d.publisher.PublishCommandResult(result); err != nil {
+		d.logger.Warn("failed to publish command result",
+			zap.String("id", result.ID),
+			zap.String("status", result.Status),
+			zap.Error(err),
+		)
+	}
+}
+
+// excerpt 截取输出开头部分
+func excerpt(s string) string {
+	if len(s) <= outputExcerpt {
+		return s
+	}
+	// 截断处可能位于多字节字符中间
+	return strings.ToValidUTF8(s[:outputExcerpt], "")
+}
@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/agent/command"
+)
+
+// fakePublisher 记录发布的命令结果
+type fakePublisher struct {
+	mu      sync.Mutex
+	results []CommandResult
+}
+
+func (p *fakePublisher) PublishCommandResult(result *CommandResult) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results = append(p.results, *result)
+	return nil
+}
+
+// statuses 按发布顺序返回结果状态
+func (p *fakePublisher) statuses() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var statuses []string
+	for _, r := range p.results {
+		statuses = append(statuses, r.Status)
+	}
+	return statuses
+}
+
+// countingHandler 记录执行次数的命令处理器
+type countingHandler struct {
+	calls int
+}
+
+func (h *countingHandler) Name() string { return "echo" }
+
+func (h *countingHandler) Execute(ctx context.Context, args map[string]interface{}, progress command.ProgressFunc) (*command.Result, error) {
+	h.calls++
+	progress("working")
+	return &command.Result{ExitCode: 0, Stdout: "ok"}, nil
+}
+
+// newTestDispatcher 创建使用临时状态目录的分发器
+func newTestDispatcher(t *testing.T, dir string) (*Dispatcher, *countingHandler, *fakePublisher) {
+	t.Helper()
+
+	executed, err := NewExecutedStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := &countingHandler{}
+	publisher := &fakePublisher{}
+	d := NewDispatcher(executed, zap.NewNop())
+	d.Register(handler)
+	d.SetResultPublisher(publisher)
+	return d, handler, publisher
+}
+
+// commandPayload 编码命令消息
+func commandPayload(t *testing.T, msg CommandMessage) []byte {
+	t.Helper()
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestDispatchDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	d, handler, publisher := newTestDispatcher(t, dir)
+	payload := commandPayload(t, CommandMessage{ID: "c1", Command: "echo", IdempotencyKey: "key-1"})
+
+	if err := d.Dispatch(context.Background(), payload); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	want := []string{ResultRunning, ResultRunning, ResultSucceeded}
+	if got := publisher.statuses(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+
+	// 重连后重复投递的命令被忽略，不再上报结果
+	if err := d.Dispatch(context.Background(), payload); err != nil {
+		t.Fatalf("duplicate Dispatch: %v", err)
+	}
+	if handler.calls != 1 || len(publisher.statuses()) != len(want) {
+		t.Errorf("duplicate executed: calls = %d, results = %v", handler.calls, publisher.statuses())
+	}
+
+	// agent 重启后仍然忽略
+	restarted, restartedHandler, restartedPublisher := newTestDispatcher(t, dir)
+	if err := restarted.Dispatch(context.Background(), payload); err != nil {
+		t.Fatalf("Dispatch after restart: %v", err)
+	}
+	if restartedHandler.calls != 0 || len(restartedPublisher.statuses()) != 0 {
+		t.Errorf("executed after restart: calls = %d, results = %v", restartedHandler.calls, restartedPublisher.statuses())
+	}
+
+	// 未设置幂等键时使用命令 ID
+	other := commandPayload(t, CommandMessage{ID: "c2", Command: "echo"})
+	for i := 0; i < 2; i++ {
+		if err := restarted.Dispatch(context.Background(), other); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+	}
+	if restartedHandler.calls != 1 {
+		t.Errorf("command without idempotency key executed %d times, want 1", restartedHandler.calls)
+	}
+}
+
+func TestDispatchDeadlineExceeded(t *testing.T) {
+	d, handler, publisher := newTestDispatcher(t, t.TempDir())
+	payload := commandPayload(t, CommandMessage{ID: "c1", Command: "echo", Deadline: time.Now().Add(-time.Second)})
+
+	if err := d.Dispatch(context.Background(), payload); err == nil {
+		t.Fatal("Dispatch succeeded after deadline")
+	}
+	if handler.calls != 0 {
+		t.Errorf("handler called %d times after deadline", handler.calls)
+	}
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	if len(publisher.results) != 1 {
+		t.Fatalf("results = %+v, want one failure", publisher.results)
+	}
+	if r := publisher.results[0]; r.ID != "c1" || r.Status != ResultFailed || r.Error != "deadline exceeded" {
+		t.Errorf("result = %+v", r)
+	}
+}
+
+func TestDispatchUnknownCommand(t *testing.T) {
+	d, _, publisher := newTestDispatcher(t, t.TempDir())
+
+	if err := d.Dispatch(context.Background(), commandPayload(t, CommandMessage{ID: "c1", Command: "missing"})); err == nil {
+		t.Fatal("Dispatch of unknown command succeeded")
+	}
+	if got := publisher.statuses(); !reflect.DeepEqual(got, []string{ResultFailed}) {
+		t.Errorf("statuses = %v, want [failed]", got)
+	}
+}
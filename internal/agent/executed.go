@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 已执行命令记录
+const (
+	// executedFile 记录文件名（位于状态目录）
+	executedFile = "executed-commands.json"
+	// executedLimit 保留的记录数
+	executedLimit = 256
+	// executedRetention 记录保留时间
+	executedRetention = 7 * 24 * time.Hour
+)
+
+// ExecutedStore 记录已执行命令的幂等键，避免重连后重复投递的命令被再次执行
+// 记录持久化到状态目录，agent 重启（如执行 reboot 后）仍然有效
+type ExecutedStore struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// NewExecutedStore 创建已执行命令记录，dir 为空时仅保存在内存中
+func NewExecutedStore(dir string) (*ExecutedStore, error) {
+	s := &ExecutedStore{entries: make(map[string]time.Time)}
+	if dir == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	s.path = filepath.Join(dir, executedFile)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read executed commands: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		// 记录损坏时重新开始，不影响 agent 启动
+		s.entries = make(map[string]time.Time)
+	}
+	return s, nil
+}
+
+// MarkExecuted 记录幂等键，已存在时返回 false
+func (s *ExecutedStore) MarkExecuted(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; exists {
+		return false, nil
+	}
+
+	now := time.Now()
+	s.entries[key] = now
+	s.prune(now)
+	return true, s.save()
+}
+
+// prune 删除过期记录，超过上限时删除最旧的
+func (s *ExecutedStore) prune(now time.Time) {
+	for key, at := range s.entries {
+		if now.Sub(at) > executedRetention {
+			delete(s.entries, key)
+		}
+	}
+
+	for len(s.entries) > executedLimit {
+		var oldestKey string
+		var oldest time.Time
+		for key, at := range s.entries {
+			if oldestKey == "" || at.Before(oldest) {
+				oldestKey, oldest = key, at
+			}
+		}
+		delete(s.entries, oldestKey)
+	}
+}
+
+// save 原子写入记录文件
+func (s *ExecutedStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.entries)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write executed commands: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write executed commands: %w", err)
+	}
+	return nil
+}
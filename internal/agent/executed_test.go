@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExecutedStorePersists(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewExecutedStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first, err := s.MarkExecuted("cmd-1"); !first || err != nil {
+		t.Fatalf("MarkExecuted = %v, %v, want first", first, err)
+	}
+	if first, _ := s.MarkExecuted("cmd-1"); first {
+		t.Error("duplicate key marked as first")
+	}
+
+	// 重新加载（模拟 agent 重启）后记录仍然有效
+	reopened, err := NewExecutedStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first, _ := reopened.MarkExecuted("cmd-1"); first {
+		t.Error("key executed before restart marked as first")
+	}
+	if first, _ := reopened.MarkExecuted("cmd-2"); !first {
+		t.Error("new key after restart not marked as first")
+	}
+}
+
+func TestExecutedStoreCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, executedFile), []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 记录损坏时重新开始
+	s, err := NewExecutedStore(dir)
+	if err != nil {
+		t.Fatalf("NewExecutedStore: %v", err)
+	}
+	if first, err := s.MarkExecuted("cmd-1"); !first || err != nil {
+		t.Errorf("MarkExecuted = %v, %v, want first", first, err)
+	}
+}
+
+func TestExecutedStorePrune(t *testing.T) {
+	s, err := NewExecutedStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	s.entries["expired"] = now.Add(-executedRetention - time.Minute)
+	for i := 0; i < executedLimit; i++ {
+		s.entries[fmt.Sprintf("cmd-%d", i)] = now.Add(time.Duration(i-executedLimit) * time.Second)
+	}
+
+	if first, _ := s.MarkExecuted("latest"); !first {
+		t.Fatal("new key not marked as first")
+	}
+	if len(s.entries) != executedLimit {
+		t.Errorf("entries = %d, want %d", len(s.entries), executedLimit)
+	}
+	for _, key := range []string{"expired", "cmd-0"} {
+		if _, ok := s.entries[key]; ok {
+			t.Errorf("%s not pruned", key)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -34,6 +35,8 @@ func (m *MQTTClient) Connect() error {
 	opts := mqtt.NewClientOptions()
 	opts.AddBroker(m.broker)
 	opts.SetClientID(fmt.Sprintf("nodefoundry-agent-%s", m.mac))
+	// 保留会话：短暂断线期间下发的命令在重连后补投（重复投递由 Dispatcher 去重）
+	opts.SetCleanSession(false)
 	opts.SetAutoReconnect(true)
 	opts.SetMaxReconnectInterval(10 * time.Second)
 	opts.SetOnConnectHandler(m.onConnect)
@@ -61,7 +64,7 @@ func (m *MQTTClient) Connect() error {
 func (m *MQTTClient) onConnect(client mqtt.Client) {
 	// 订阅命令主题
 	commandTopic := fmt.Sprintf("node/%s/command", m.mac)
-	if token := client.Subscribe(commandTopic, 0, m.onCommandMessage); token.Wait() && token.Error() != nil {
+	if token := client.Subscribe(commandTopic, 1, m.onCommandMessage); token.Wait() && token.Error() != nil {
 		m.logger.Error("failed to subscribe to command topic", zap.Error(token.Error()))
 		return
 	}
@@ -118,6 +121,27 @@ func (m *MQTTClient) PublishStatus(status string, ip, hostname string, uptime in
 	return nil
 }
 
+// PublishCommandResult 发布命令结果（node/{mac}/command/result）
+func (m *MQTTClient) PublishCommandResult(result *CommandResult) error {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	topic := fmt.Sprintf("node/%s/command/result", m.mac)
+	token := m.client.Publish(topic, 1, false, payload)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("failed to publish command result: %w", token.Error())
+	}
+
+	m.logger.Debug("command result published",
+		zap.String("topic", topic),
+		zap.String("id", result.ID),
+		zap.String("status", result.Status),
+	)
+	return nil
+}
+
 // Disconnect 断开连接
 func (m *MQTTClient) Disconnect() {
 	if m.client != nil && m.client.IsConnected() {
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 审计记录查询数量限制
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+	// auditExportBatch 导出时每次读取的记录数（避免长时间持有读事务）
+	auditExportBatch = 500
+)
+
+// gin context 中的审计信息
+const (
+	// contextKeyAuditDetails 处理器补充的操作参数
+	contextKeyAuditDetails = "nodefoundry.audit_details"
+	// contextKeyAuditMAC 目标节点不在路径中时由处理器指定
+	contextKeyAuditMAC = "nodefoundry.audit_mac"
+	// contextKeyErrorCode 错误响应的错误码
+	contextKeyErrorCode = "nodefoundry.error_code"
+)
+
+// SetAuditLog 设置审计记录存储并开始记录修改类 API 请求
+func (h *Handler) SetAuditLog(audit db.AuditRepository) {
+	h.audit = audit
+}
+
+// auditDetail 为当前请求的审计记录补充操作参数
+func auditDetail(c *gin.Context, key string, value interface{}) {
+	v, _ := c.Get(contextKeyAuditDetails)
+	details, _ := v.(map[string]interface{})
+	if details == nil {
+		details = make(map[string]interface{})
+		c.Set(contextKeyAuditDetails, details)
+	}
+	details[key] = value
+}
+
+// auditNode 指定当前请求的目标节点（用于请求体中携带 MAC 的请求）
+func auditNode(c *gin.Context, mac string) {
+	c.Set(contextKeyAuditMAC, mac)
+}
+
+// auditRequests 记录修改类 /api/v1 请求的审计日志，需要在认证之前注册：
+// 因角色不足或校验失败被拒绝的请求同样会记录；未通过认证的请求操作者未知，不记录（见访问日志）
+func (h *Handler) auditRequests(routes []apiRoute) gin.HandlerFunc {
+	// 方法+完整路径 → 操作名称
+	actions := make(map[string]string)
+	for i := range routes {
+		route := &routes[i]
+		if route.group != groupAPI {
+			continue
+		}
+		switch route.method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			continue
+		}
+		actions[route.method+" "+route.fullPath()] = operationID(route)
+	}
+
+	return func(c *gin.Context) {
+		action, ok := actions[c.Request.Method+" "+c.FullPath()]
+		if !ok || h.audit == nil {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+		if c.Writer.Status() == http.StatusUnauthorized {
+			return
+		}
+
+		entry := &model.AuditEntry{
+			Time:       start,
+			RequestID:  c.GetString(contextKeyRequestID),
+			Actor:      model.ACTOR_ANONYMOUS,
+			RemoteAddr: c.RemoteIP(),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Action:     action,
+			Target:     auditTarget(c),
+			Status:     c.Writer.Status(),
+			Code:       c.GetString(contextKeyErrorCode),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if token := tokenFromContext(c); token != nil {
+			entry.Actor = token.Name
+			entry.TokenID = token.ID
+			entry.Role = token.Role
+		}
+		if mac := c.Param("mac"); model.IsValidMAC(mac) {
+			entry.MAC = model.NormalizeMAC(mac)
+		} else {
+			entry.MAC = c.GetString(contextKeyAuditMAC)
+		}
+		if v, ok := c.Get(contextKeyAuditDetails); ok {
+			entry.Details, _ = v.(map[string]interface{})
+		}
+
+		// 响应已发送，写入失败只记录日志；客户端断开也要完成写入
+		ctx := context.WithoutCancel(c.Request.Context())
+		if err := h.audit.Append(ctx, entry); err != nil {
+			h.logger.Error("failed to write audit log",
+				zap.String("action", entry.Action),
+				zap.String("actor", entry.Actor),
+				zap.String("request_id", entry.RequestID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// auditTarget 路径中的目标资源（token、webhook 的 ID，安装配置的名称）
+func auditTarget(c *gin.Context) string {
+	if id := c.Param("id"); id != "" {
+		return id
+	}
+	return c.Param("name")
+}
+
+// auditEnabled 检查是否启用审计记录，未启用时返回 503
+func (h *Handler) auditEnabled(c *gin.Context) bool {
+	if h.audit == nil {
+		errorResponse(c, http.StatusServiceUnavailable, CodeFeatureDisabled, "audit log not available")
+		return false
+	}
+	return true
+}
+
+// parseAuditFilter 解析审计查询参数：actor、mac、since、until（RFC 3339）
+func parseAuditFilter(query url.Values) (db.AuditFilter, error) {
+	filter := db.AuditFilter{Actor: query.Get("actor")}
+
+	if mac := query.Get("mac"); mac != "" {
+		if !model.IsValidMAC(mac) {
+			return filter, fmt.Errorf("invalid mac: %q", mac)
+		}
+		filter.MAC = model.NormalizeMAC(mac)
+	}
+
+	for _, bound := range []struct {
+		name   string
+		target *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		raw := query.Get(bound.name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 time", bound.name)
+		}
+		*bound.target = t
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return filter, fmt.Errorf("since must be before until")
+	}
+	return filter, nil
+}
+
+// ListAudit 查询审计记录（最新的在前），存在下一页时返回 X-Next-Cursor 和 Link 头
+func (h *Handler) ListAudit(c *gin.Context) {
+	if !h.auditEnabled(c) {
+		return
+	}
+
+	query := c.Request.URL.Query()
+	filter, err := parseAuditFilter(query)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	limit := defaultAuditLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			errorResponse(c, http.StatusBadRequest, CodeInvalidRequest,
+				fmt.Sprintf("limit must be between 1 and %d", maxAuditLimit))
+			return
+		}
+		limit = n
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid cursor")
+			return
+		}
+		filter.Cursor = cursor
+	}
+
+	entries, err := h.audit.List(c.Request.Context(), filter, limit)
+	if err != nil {
+		h.writeError(c, err, "failed to list audit log")
+		return
+	}
+	if entries == nil {
+		entries = []*model.AuditEntry{}
+	}
+
+	if len(entries) == limit {
+		cursor := strconv.FormatUint(entries[len(entries)-1].ID, 10)
+		next := *c.Request.URL
+		query.Set("cursor", cursor)
+		next.RawQuery = query.Encode()
+		c.Header("X-Next-Cursor", cursor)
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// ExportAudit 以 JSON Lines 格式导出审计记录（按时间升序，每行一条记录）
+func (h *Handler) ExportAudit(c *gin.Context) {
+	if !h.auditEnabled(c) {
+		return
+	}
+
+	filter, err := parseAuditFilter(c.Request.URL.Query())
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	filter.Ascending = true
+
+	ctx := c.Request.Context()
+	entries, err := h.audit.List(ctx, filter, auditExportBatch)
+	if err != nil {
+		h.writeError(c, err, "failed to export audit log")
+		return
+	}
+
+	c.Header("Content-Type", contentNDJSON)
+	c.Header("Content-Disposition",
+		fmt.Sprintf("attachment; filename=nodefoundry-audit-%s.jsonl", time.Now().UTC().Format("20060102T150405Z")))
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	for len(entries) > 0 {
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				// 客户端已断开
+				return
+			}
+		}
+		c.Writer.Flush()
+		if len(entries) < auditExportBatch {
+			return
+		}
+
+		filter.Cursor = entries[len(entries)-1].ID
+		entries, err = h.audit.List(ctx, filter, auditExportBatch)
+		if err != nil {
+			// 响应已开始，只能中断输出
+			h.logger.Error("failed to export audit log", zap.Error(err))
+			return
+		}
+	}
+}
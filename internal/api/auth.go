@@ -0,0 +1,187 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/auth"
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// contextKeyToken gin context 中保存已认证 token 的键
+const contextKeyToken = "nodefoundry.token"
+
+// contextKeyQueryToken gin context 中保存从查询参数取出的 token 的键
+const contextKeyQueryToken = "nodefoundry.query_token"
+
+// tokenTouchInterval token 最近使用时间的最小更新间隔（避免每个请求都写库）
+const tokenTouchInterval = time.Minute
+
+// SetTokenRepository 设置 API token 存储并启用 /api/v1 认证
+func (h *Handler) SetTokenRepository(tokens db.TokenRepository) {
+	h.tokens = tokens
+}
+
+// SetNodeAllowedNetworks 设置允许访问节点端点（/boot、/preseed、/agent）的来源网段
+// 为空表示不限制来源
+func (h *Handler) SetNodeAllowedNetworks(networks []*net.IPNet) {
+	h.nodeNetworks = networks
+}
+
+// authenticate 校验 Bearer token，并按请求方法检查最低角色：
+// 只读请求需要 viewer，其余请求需要 operator
+func (h *Handler) authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.tokens == nil {
+			c.Next()
+			return
+		}
+
+		plaintext := auth.BearerToken(c.GetHeader("Authorization"))
+		if plaintext == "" {
+			plaintext = c.GetString(contextKeyQueryToken)
+		}
+		if plaintext == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="nodefoundry"`)
+			errorResponse(c, http.StatusUnauthorized, CodeUnauthenticated, "missing bearer token")
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		token, err := h.tokens.FindByHash(ctx, auth.HashToken(plaintext))
+		now := time.Now()
+		if err != nil || !token.Active(now) {
+			h.logger.Warn("rejected API token",
+				zap.String("remote", c.RemoteIP()),
+				zap.String("path", c.Request.URL.Path),
+			)
+			c.Header("WWW-Authenticate", `Bearer realm="nodefoundry", error="invalid_token"`)
+			errorResponse(c, http.StatusUnauthorized, CodeUnauthenticated, "invalid or expired token")
+			c.Abort()
+			return
+		}
+
+		if now.Sub(token.LastUsedAt) > tokenTouchInterval {
+			if err := h.tokens.Touch(ctx, token.ID, now); err != nil {
+				h.logger.Warn("failed to record token usage", zap.String("token_id", token.ID), zap.Error(err))
+			}
+		}
+
+		c.Set(contextKeyToken, token)
+
+		required := model.ROLE_OPERATOR
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			required = model.ROLE_VIEWER
+		}
+		if !model.RoleAllows(token.Role, required) {
+			errorResponse(c, http.StatusForbidden, CodeForbidden, "role '"+token.Role+"' is not allowed to perform this request")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// metricsAccess /metrics 访问控制：与 API 使用相同的 token 认证，配置为公开时放行
+func (h *Handler) metricsAccess() gin.HandlerFunc {
+	authenticate := h.authenticate()
+	return func(c *gin.Context) {
+		if h.metricsPublic {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
+
+// ExtractQueryToken 取出事件流请求的 access_token 查询参数并从 URL 中移除
+// 浏览器的 EventSource 和 WebSocket 无法设置 Authorization 请求头，只能通过查询参数传递 token；
+// 需要在访问日志中间件之前注册，避免 token 被写入日志
+func ExtractQueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		token := query.Get("access_token")
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		query.Del("access_token")
+		c.Request.URL.RawQuery = query.Encode()
+		if isStreamRequest(c.Request) {
+			c.Set(contextKeyQueryToken, token)
+		}
+		c.Next()
+	}
+}
+
+// isStreamRequest 是否为事件流请求（WebSocket 升级或 SSE）
+func isStreamRequest(r *http.Request) bool {
+	return websocket.IsWebSocketUpgrade(r) || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// requireRole 要求已认证 token 至少具有指定角色（未启用认证时放行）
+func (h *Handler) requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.tokens == nil {
+			c.Next()
+			return
+		}
+
+		token := tokenFromContext(c)
+		if token == nil || !model.RoleAllows(token.Role, role) {
+			errorResponse(c, http.StatusForbidden, CodeForbidden, "this request requires role '"+role+"'")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// nodeAccess 节点端点访问控制：仅允许来自配置网段的请求
+// 节点在 PXE/安装阶段无法携带 API token，因此使用独立的来源地址限制
+func (h *Handler) nodeAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(h.nodeNetworks) == 0 {
+			c.Next()
+			return
+		}
+
+		// 使用连接的对端地址，不信任 X-Forwarded-For
+		ip := net.ParseIP(c.RemoteIP())
+		for _, network := range h.nodeNetworks {
+			if ip != nil && network.Contains(ip) {
+				c.Next()
+				return
+			}
+		}
+
+		h.logger.Warn("node endpoint access denied",
+			zap.String("remote", c.RemoteIP()),
+			zap.String("path", c.Request.URL.Path),
+		)
+		errorResponse(c, http.StatusForbidden, CodeForbidden, "access denied")
+		c.Abort()
+	}
+}
+
+// tokenFromContext 获取当前请求已认证的 token
+func tokenFromContext(c *gin.Context) *model.APIToken {
+	v, ok := c.Get(contextKeyToken)
+	if !ok {
+		return nil
+	}
+	token, _ := v.(*model.APIToken)
+	return token
+}
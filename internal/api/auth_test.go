@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/auth"
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/metrics"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// setTestTokens 为处理器启用基于临时数据库的 API token 认证
+func setTestTokens(t *testing.T, h *Handler) *db.BoltTokenRepository {
+	t.Helper()
+
+	bdb, err := db.InitializeDB(filepath.Join(t.TempDir(), "tokens.db"), zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitializeDB: %v", err)
+	}
+	t.Cleanup(func() { bdb.Close() })
+
+	tokens := db.NewBoltTokenRepository(bdb, zap.NewNop())
+	h.SetTokenRepository(tokens)
+	return tokens
+}
+
+// createTestToken 创建指定角色的 token，返回明文
+func createTestToken(t *testing.T, tokens db.TokenRepository, role string, ttl time.Duration) string {
+	t.Helper()
+
+	token, plaintext, err := auth.NewAPIToken(role+"-token", role, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.Create(context.Background(), token); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return plaintext
+}
+
+func TestMetricsAuth(t *testing.T) {
+	h, r, _ := newTestHandler(t)
+	h.SetMetrics(metrics.New())
+	viewer := createTestToken(t, setTestTokens(t, h), model.ROLE_VIEWER, 0)
+
+	if w := serve(r, http.MethodGet, "/metrics", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("without token status = %d, want 401", w.Code)
+	}
+	w := serve(r, http.MethodGet, "/metrics", "", "Authorization", "Bearer "+viewer)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "go_goroutines") {
+		t.Errorf("viewer status = %d: %.200s", w.Code, w.Body.String())
+	}
+
+	// 显式配置为公开后无需 token
+	h.SetMetricsPublic(true)
+	if w := serve(r, http.MethodGet, "/metrics", ""); w.Code != http.StatusOK {
+		t.Errorf("public status = %d, want 200", w.Code)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	tokens := setTestTokens(t, h)
+	viewer := createTestToken(t, tokens, model.ROLE_VIEWER, 0)
+	operator := createTestToken(t, tokens, model.ROLE_OPERATOR, 0)
+	admin := createTestToken(t, tokens, model.ROLE_ADMIN, time.Hour)
+
+	expiredToken, expired, err := auth.NewAPIToken("expired", model.ROLE_ADMIN, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiredToken.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := tokens.Create(context.Background(), expiredToken); err != nil {
+		t.Fatal(err)
+	}
+	revokedToken, revoked, err := auth.NewAPIToken("revoked", model.ROLE_ADMIN, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.Create(context.Background(), revokedToken); err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.Revoke(context.Background(), revokedToken.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 查询参数中的 token 需要在路由之前取出
+	r := gin.New()
+	r.Use(ExtractQueryToken())
+	h.RegisterRoutes(r)
+
+	sse := []string{"Accept", "text/event-stream"}
+	websocket := []string{"Connection", "Upgrade", "Upgrade", "websocket",
+		"Sec-WebSocket-Version", "13", "Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ=="}
+	bearer := func(token string) []string { return []string{"Authorization", "Bearer " + token} }
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		headers    []string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "missing token", method: http.MethodGet, path: "/api/v1/nodes",
+			wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthenticated},
+		{name: "invalid token", method: http.MethodGet, path: "/api/v1/nodes", headers: bearer("nf_invalid"),
+			wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthenticated},
+		{name: "expired token", method: http.MethodGet, path: "/api/v1/nodes", headers: bearer(expired),
+			wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthenticated},
+		{name: "revoked token", method: http.MethodGet, path: "/api/v1/nodes", headers: bearer(revoked),
+			wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthenticated},
+		{name: "non-bearer scheme", method: http.MethodGet, path: "/api/v1/nodes", headers: []string{"Authorization", "Basic " + viewer},
+			wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthenticated},
+
+		// 只读请求需要 viewer，修改类请求需要 operator
+		{name: "viewer read", method: http.MethodGet, path: "/api/v1/nodes", headers: bearer(viewer),
+			wantStatus: http.StatusOK},
+		{name: "viewer write", method: http.MethodPost, path: "/api/v1/nodes", body: `{"mac":"aabbccddeeff"}`, headers: bearer(viewer),
+			wantStatus: http.StatusForbidden, wantCode: CodeForbidden},
+		{name: "operator write", method: http.MethodPost, path: "/api/v1/nodes", body: `{"mac":"aabbccddeeff"}`, headers: bearer(operator),
+			wantStatus: http.StatusCreated},
+
+		// token 管理仅限 admin
+		{name: "viewer list tokens", method: http.MethodGet, path: "/api/v1/tokens", headers: bearer(viewer),
+			wantStatus: http.StatusForbidden, wantCode: CodeForbidden},
+		{name: "operator list tokens", method: http.MethodGet, path: "/api/v1/tokens", headers: bearer(operator),
+			wantStatus: http.StatusForbidden, wantCode: CodeForbidden},
+		{name: "operator create token", method: http.MethodPost, path: "/api/v1/tokens", body: `{"name":"ci","role":"viewer"}`, headers: bearer(operator),
+			wantStatus: http.StatusForbidden, wantCode: CodeForbidden},
+		{name: "admin list tokens", method: http.MethodGet, path: "/api/v1/tokens", headers: bearer(admin),
+			wantStatus: http.StatusOK},
+		{name: "admin create token", method: http.MethodPost, path: "/api/v1/tokens", body: `{"name":"ci","role":"viewer"}`, headers: bearer(admin),
+			wantStatus: http.StatusCreated},
+
+		// 事件流（SSE、WebSocket）可通过 access_token 查询参数传递 token，事件总线未配置时返回 503
+		{name: "sse query token", method: http.MethodGet, path: "/api/v1/events?access_token=" + viewer, headers: sse,
+			wantStatus: http.StatusServiceUnavailable, wantCode: CodeFeatureDisabled},
+		{name: "websocket query token", method: http.MethodGet, path: "/api/v1/events?access_token=" + viewer, headers: websocket,
+			wantStatus: http.StatusServiceUnavailable, wantCode: CodeFeatureDisabled},
+		{name: "sse invalid query token", method: http.MethodGet, path: "/api/v1/events?access_token=nf_invalid", headers: sse,
+			wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthenticated},
+		{name: "query token outside stream", method: http.MethodGet, path: "/api/v1/nodes?access_token=" + viewer,
+			wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.method, tt.path, tt.body, tt.headers...)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := responseCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+			}
+			if tt.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("missing WWW-Authenticate header")
+			}
+		})
+	}
+}
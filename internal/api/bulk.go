@@ -0,0 +1,559 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 批量操作限制
+const (
+	// DefaultBulkConcurrency 默认并发数
+	DefaultBulkConcurrency = 4
+	// MaxBulkConcurrency 最大并发数
+	MaxBulkConcurrency = 64
+	// MaxBulkWaveInterval 批次间最大等待时间
+	MaxBulkWaveInterval = 10 * time.Minute
+	// MaxBulkWaveDuration 所有批次间等待时间之和的上限（分批操作在后台执行，不能无限期运行）
+	MaxBulkWaveDuration = 10 * time.Minute
+	// bulkOperationRetention 已完成的后台批量操作保留时间
+	bulkOperationRetention = time.Hour
+	// maxBulkPollInterval 建议客户端查询后台批量操作的最长间隔
+	maxBulkPollInterval = 5 * time.Second
+)
+
+// 后台批量操作状态
+const (
+	BulkOperationRunning   = "running"
+	BulkOperationCompleted = "completed"
+)
+
+// 单个节点的批量操作结果
+const (
+	BulkResultSucceeded = "succeeded"
+	BulkResultFailed    = "failed"
+	BulkResultSkipped   = "skipped"
+	BulkResultPlanned   = "planned"
+)
+
+// BulkSelector 批量操作节点选择器，多个条件同时满足才匹配
+type BulkSelector struct {
+	// MACs 节点 MAC 列表
+	MACs []string `json:"macs,omitempty"`
+	// Labels 标签选择器（语法同 GET /api/v1/nodes 的 label 参数）
+	Labels string `json:"labels,omitempty"`
+	// Status 节点状态（逗号分隔）
+	Status string `json:"status,omitempty"`
+}
+
+// BulkRequest 批量操作请求
+type BulkRequest struct {
+	Selector BulkSelector `json:"selector"`
+	// Action install、reinstall、reboot、label、delete
+	Action string `json:"action" binding:"required" enum:"install,reinstall,reboot,label,delete"`
+	// Labels label 操作的标签变更，值为 null 表示删除
+	Labels map[string]*string `json:"labels,omitempty"`
+	// DryRun 仅返回将受影响的节点，不执行操作
+	DryRun bool `json:"dry_run,omitempty"`
+	// Concurrency 每批并发处理的节点数
+	Concurrency int `json:"concurrency,omitempty"`
+	// WaveSize 每批节点数，0 表示一次处理全部
+	WaveSize int `json:"wave_size,omitempty"`
+	// WaveIntervalSeconds 批次间隔（秒），避免大量节点同时 PXE 引导
+	WaveIntervalSeconds int `json:"wave_interval_seconds,omitempty"`
+}
+
+// BulkResult 单个节点的操作结果
+type BulkResult struct {
+	MAC    string `json:"mac"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+	// Code 失败或跳过原因的错误码
+	Code string      `json:"code,omitempty"`
+	Node *model.Node `json:"node,omitempty"`
+}
+
+// BulkResponse 批量操作响应
+type BulkResponse struct {
+	Action    string       `json:"action"`
+	DryRun    bool         `json:"dry_run"`
+	Matched   int          `json:"matched"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Skipped   int          `json:"skipped"`
+	Results   []BulkResult `json:"results"`
+}
+
+// BulkOperation 后台执行的批量操作（批次之间需要等待时返回 202）
+type BulkOperation struct {
+	ID string `json:"id"`
+	// Status running 或 completed
+	Status  string `json:"status" enum:"running,completed"`
+	Action  string `json:"action"`
+	Matched int    `json:"matched"`
+	// WaitSeconds 批次间等待的总时间
+	WaitSeconds int        `json:"wait_seconds"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	// Result 完成后的批量操作结果
+	Result *BulkResponse `json:"result,omitempty"`
+	// pollSeconds 建议客户端查询的间隔（Retry-After）
+	pollSeconds int
+}
+
+// nodeCollectionVerb 处理 POST /api/v1/nodes:<verb>
+// gin 不支持转义路由中的冒号，:verb 会包含前导冒号
+func (h *Handler) nodeCollectionVerb(c *gin.Context) {
+	switch strings.TrimPrefix(c.Param("verb"), ":") {
+	case "bulk":
+		h.BulkNodes(c)
+	default:
+		errorResponse(c, http.StatusNotFound, CodeUnknownOperation, "unknown operation")
+	}
+}
+
+// BulkNodes 按选择器批量操作节点
+func (h *Handler) BulkNodes(c *gin.Context) {
+	var req BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	auditDetail(c, "action", req.Action)
+	auditDetail(c, "selector", req.Selector)
+	auditDetail(c, "dry_run", req.DryRun)
+
+	op, err := h.bulkOperation(&req)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if req.Concurrency <= 0 {
+		req.Concurrency = DefaultBulkConcurrency
+	}
+	if req.Concurrency > MaxBulkConcurrency {
+		req.Concurrency = MaxBulkConcurrency
+	}
+	waveInterval := time.Duration(req.WaveIntervalSeconds) * time.Second
+	if waveInterval < 0 || waveInterval > MaxBulkWaveInterval {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest,
+			fmt.Sprintf("wave_interval_seconds must be between 0 and %d", int(MaxBulkWaveInterval.Seconds())))
+		return
+	}
+
+	ctx := c.Request.Context()
+	nodes, missing, err := h.selectNodes(ctx, &req.Selector)
+	if err != nil {
+		h.writeError(c, err, "failed to select nodes")
+		return
+	}
+	wait := bulkWaveWait(len(nodes), req.WaveSize, waveInterval)
+	if wait > MaxBulkWaveDuration {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest,
+			fmt.Sprintf("%d nodes in waves of %d wait %s between waves, exceeds %s; increase wave_size or reduce wave_interval_seconds",
+				len(nodes), req.WaveSize, wait, MaxBulkWaveDuration))
+		return
+	}
+
+	resp := &BulkResponse{
+		Action:  req.Action,
+		DryRun:  req.DryRun,
+		Matched: len(nodes),
+		Results: make([]BulkResult, 0, len(nodes)+len(missing)),
+	}
+
+	for _, mac := range missing {
+		resp.Results = append(resp.Results, BulkResult{MAC: mac, Result: BulkResultFailed, Error: "node not found", Code: CodeNodeNotFound})
+	}
+
+	if req.DryRun {
+		for _, node := range nodes {
+			result := BulkResult{MAC: node.MAC, Result: BulkResultPlanned, Node: node}
+			if err := op.check(node); err != nil {
+				result.Result = BulkResultSkipped
+				result.Error = err.Error()
+				result.Code = errorCode(err)
+			}
+			resp.Results = append(resp.Results, result)
+		}
+	} else if wait > 0 {
+		// 批次之间需要等待时在后台执行，避免长时间占用请求连接（代理超时）
+		operation := h.startBulkOperation(op, nodes, resp, &req, waveInterval, wait)
+		auditDetail(c, "matched", resp.Matched)
+		auditDetail(c, "operation_id", operation.ID)
+		c.Header("Location", "/api/v1/operations/"+operation.ID)
+		c.Header("Retry-After", strconv.Itoa(operation.pollSeconds))
+		c.JSON(http.StatusAccepted, operation)
+		return
+	} else {
+		resp.Results = append(resp.Results, h.runBulk(ctx, op, nodes, &req, waveInterval)...)
+	}
+
+	h.finishBulk(resp)
+
+	auditDetail(c, "matched", resp.Matched)
+	auditDetail(c, "succeeded", resp.Succeeded)
+	auditDetail(c, "failed", resp.Failed)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// finishBulk 汇总各节点结果并记录日志
+func (h *Handler) finishBulk(resp *BulkResponse) {
+	for _, result := range resp.Results {
+		switch result.Result {
+		case BulkResultSucceeded:
+			resp.Succeeded++
+		case BulkResultFailed:
+			resp.Failed++
+		case BulkResultSkipped:
+			resp.Skipped++
+		}
+	}
+
+	h.logger.Info("bulk operation finished",
+		zap.String("action", resp.Action),
+		zap.Bool("dry_run", resp.DryRun),
+		zap.Int("matched", resp.Matched),
+		zap.Int("succeeded", resp.Succeeded),
+		zap.Int("failed", resp.Failed),
+	)
+}
+
+// startBulkOperation 在后台执行批量操作，返回操作的当前快照
+// 操作只保存在内存中，完成后保留 bulkOperationRetention
+func (h *Handler) startBulkOperation(op *bulkOp, nodes []*model.Node, resp *BulkResponse, req *BulkRequest, waveInterval, wait time.Duration) BulkOperation {
+	now := time.Now()
+	operation := &BulkOperation{
+		ID:          newRequestID(),
+		Status:      BulkOperationRunning,
+		Action:      resp.Action,
+		Matched:     resp.Matched,
+		WaitSeconds: int(wait.Seconds()),
+		CreatedAt:   now,
+		pollSeconds: bulkPollSeconds(waveInterval),
+	}
+
+	h.bulkOpsMu.Lock()
+	if h.bulkOps == nil {
+		h.bulkOps = make(map[string]*BulkOperation)
+	}
+	for id, existing := range h.bulkOps {
+		if existing.FinishedAt != nil && now.Sub(*existing.FinishedAt) > bulkOperationRetention {
+			delete(h.bulkOps, id)
+		}
+	}
+	h.bulkOps[operation.ID] = operation
+	snapshot := *operation
+	h.bulkOpsMu.Unlock()
+
+	h.logger.Info("bulk operation started",
+		zap.String("id", operation.ID),
+		zap.String("action", resp.Action),
+		zap.Int("matched", resp.Matched),
+		zap.Duration("wait", wait),
+	)
+
+	go func() {
+		resp.Results = append(resp.Results, h.runBulk(context.Background(), op, nodes, req, waveInterval)...)
+		h.finishBulk(resp)
+
+		finished := time.Now()
+		h.bulkOpsMu.Lock()
+		operation.Status = BulkOperationCompleted
+		operation.FinishedAt = &finished
+		operation.Result = resp
+		h.bulkOpsMu.Unlock()
+	}()
+
+	return snapshot
+}
+
+// bulkPollSeconds 建议客户端查询后台批量操作的间隔（秒）
+func bulkPollSeconds(waveInterval time.Duration) int {
+	if waveInterval > maxBulkPollInterval {
+		waveInterval = maxBulkPollInterval
+	}
+	if seconds := int(waveInterval.Seconds()); seconds > 1 {
+		return seconds
+	}
+	return 1
+}
+
+// GetBulkOperation 查询后台批量操作的进度和结果（服务重启后清空）
+func (h *Handler) GetBulkOperation(c *gin.Context) {
+	h.bulkOpsMu.Lock()
+	operation, ok := h.bulkOps[c.Param("id")]
+	var snapshot BulkOperation
+	if ok {
+		snapshot = *operation
+	}
+	h.bulkOpsMu.Unlock()
+
+	if !ok {
+		errorResponse(c, http.StatusNotFound, CodeNotFound, "bulk operation not found")
+		return
+	}
+	if snapshot.Status == BulkOperationRunning {
+		c.Header("Retry-After", strconv.Itoa(snapshot.pollSeconds))
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// bulkOp 批量操作：check 在不修改节点的情况下检查是否可执行，run 执行操作
+type bulkOp struct {
+	check func(node *model.Node) error
+	run   func(ctx context.Context, mac string) (*model.Node, error)
+}
+
+// bulkOperation 根据请求构造批量操作
+func (h *Handler) bulkOperation(req *BulkRequest) (*bulkOp, error) {
+	switch req.Action {
+	case ActionInstall, ActionReinstall:
+		mutate := installMutation
+		if req.Action == ActionReinstall {
+			mutate = reinstallMutation
+		}
+		return &bulkOp{
+			check: func(node *model.Node) error {
+				copied := *node
+				return mutate(&copied)
+			},
+			run: func(ctx context.Context, mac string) (*model.Node, error) {
+				from := ""
+				node, err := db.UpdateWithRetry(ctx, h.repo, mac, func(node *model.Node) error {
+					from = node.Status
+					return mutate(node)
+				})
+				if err == nil {
+					h.events.Publish(events.StatusChanged(node, from, "api"))
+				}
+				if err != nil || req.Action == ActionInstall {
+					return node, err
+				}
+				if err := h.requestReboot(mac); err != nil {
+					return node, fmt.Errorf("node marked for reinstall but reboot was not sent: %w", err)
+				}
+				return node, nil
+			},
+		}, nil
+
+	case ActionReboot:
+		check := func(node *model.Node) error {
+			if node.Status != model.STATE_INSTALLED {
+				return fmt.Errorf("node with status '%s' has no running agent", node.Status)
+			}
+			if h.commands == nil {
+				return errors.New("command channel not available")
+			}
+			return nil
+		}
+		return &bulkOp{
+			check: check,
+			run: func(ctx context.Context, mac string) (*model.Node, error) {
+				node, err := h.repo.FindByMAC(ctx, mac)
+				if err != nil {
+					return nil, err
+				}
+				if err := check(node); err != nil {
+					return nil, err
+				}
+				return node, h.requestReboot(mac)
+			},
+		}, nil
+
+	case ActionLabel:
+		if len(req.Labels) == 0 {
+			return nil, errors.New("labels are required for label action")
+		}
+		raw, err := json.Marshal(req.Labels)
+		if err != nil {
+			return nil, err
+		}
+		patch := nodePatch{"labels": raw}
+		if err := patch.apply(&model.Node{}); err != nil {
+			return nil, err
+		}
+		return &bulkOp{
+			check: func(node *model.Node) error {
+				copied := *node
+				return patch.apply(&copied)
+			},
+			run: func(ctx context.Context, mac string) (*model.Node, error) {
+				node, err := db.UpdateWithRetry(ctx, h.repo, mac, patch.apply)
+				if err == nil {
+					h.events.Publish(events.NodeEvent(events.EVENT_NODE_UPDATED, node, map[string]interface{}{
+						"fields": patch.fields(),
+					}))
+				}
+				return node, err
+			},
+		}, nil
+
+	case ActionDelete:
+		return &bulkOp{
+			check: func(node *model.Node) error { return nil },
+			run: func(ctx context.Context, mac string) (*model.Node, error) {
+				node, err := h.repo.FindByMAC(ctx, mac)
+				if err != nil {
+					return nil, err
+				}
+				if err := h.repo.Delete(ctx, mac); err != nil {
+					return nil, err
+				}
+				h.cleanupNode(node)
+				return nil, nil
+			},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown action: %s", req.Action)
+	}
+}
+
+// selectNodes 返回满足选择器的节点（按 MAC 排序）以及 MAC 列表中不存在的节点
+func (h *Handler) selectNodes(ctx context.Context, selector *BulkSelector) ([]*model.Node, []string, error) {
+	if len(selector.MACs) == 0 && selector.Labels == "" && selector.Status == "" {
+		return nil, nil, &apiError{status: http.StatusBadRequest, code: CodeValidationFailed, message: "selector must not be empty",
+			details: map[string]interface{}{"field": "body.selector"}}
+	}
+
+	labels, err := model.ParseLabelSelector(selector.Labels)
+	if err != nil {
+		return nil, nil, &apiError{status: http.StatusBadRequest, code: CodeInvalidRequest, message: err.Error()}
+	}
+
+	var statuses map[string]bool
+	if selector.Status != "" {
+		statuses = make(map[string]bool)
+		for _, status := range splitList(selector.Status) {
+			if !model.IsValidStatus(status) {
+				return nil, nil, &apiError{status: http.StatusBadRequest, code: CodeInvalidRequest, message: "invalid status: " + status}
+			}
+			statuses[status] = true
+		}
+	}
+
+	var wanted map[string]bool
+	if len(selector.MACs) > 0 {
+		wanted = make(map[string]bool, len(selector.MACs))
+		for _, mac := range selector.MACs {
+			if !model.IsValidMAC(mac) {
+				return nil, nil, &apiError{status: http.StatusBadRequest, code: CodeInvalidMAC, message: "invalid MAC address: " + mac}
+			}
+			wanted[model.NormalizeMAC(mac)] = true
+		}
+	}
+
+	all, err := h.repo.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var selected []*model.Node
+	found := make(map[string]bool)
+	for _, node := range all {
+		if wanted != nil && !wanted[node.MAC] {
+			continue
+		}
+		found[node.MAC] = true
+		if statuses != nil && !statuses[node.Status] {
+			continue
+		}
+		if !labels.Matches(node.Labels) {
+			continue
+		}
+		selected = append(selected, node)
+	}
+
+	sort.Slice(selected, func(i, j int) bool { return selected[i].MAC < selected[j].MAC })
+
+	var missing []string
+	for mac := range wanted {
+		if !found[mac] {
+			missing = append(missing, mac)
+		}
+	}
+	sort.Strings(missing)
+
+	return selected, missing, nil
+}
+
+// bulkWaveWait 返回分批执行 nodes 个节点时批次间等待的总时间
+func bulkWaveWait(nodes, waveSize int, waveInterval time.Duration) time.Duration {
+	if waveSize <= 0 || nodes <= waveSize {
+		return 0
+	}
+	waves := (nodes + waveSize - 1) / waveSize
+	return time.Duration(waves-1) * waveInterval
+}
+
+// runBulk 分批并发执行批量操作，批次之间等待 waveInterval
+// 请求被取消时，尚未开始的节点标记为 skipped
+func (h *Handler) runBulk(ctx context.Context, op *bulkOp, nodes []*model.Node, req *BulkRequest, waveInterval time.Duration) []BulkResult {
+	results := make([]BulkResult, len(nodes))
+
+	waveSize := req.WaveSize
+	if waveSize <= 0 || waveSize > len(nodes) {
+		waveSize = len(nodes)
+	}
+
+	for start := 0; start < len(nodes); start += waveSize {
+		end := start + waveSize
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+
+		// 非首批次等待间隔
+		if start > 0 && waveInterval > 0 {
+			select {
+			case <-time.After(waveInterval):
+			case <-ctx.Done():
+			}
+		}
+
+		if ctx.Err() != nil {
+			for i := start; i < len(nodes); i++ {
+				results[i] = BulkResult{MAC: nodes[i].MAC, Result: BulkResultSkipped, Error: "request cancelled"}
+			}
+			break
+		}
+
+		sem := make(chan struct{}, req.Concurrency)
+		var wg sync.WaitGroup
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				mac := nodes[i].MAC
+				node, err := op.run(ctx, mac)
+				if err != nil {
+					results[i] = BulkResult{MAC: mac, Result: BulkResultFailed, Error: err.Error(), Code: errorCode(err), Node: node}
+					return
+				}
+				results[i] = BulkResult{MAC: mac, Result: BulkResultSucceeded, Node: node}
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	return results
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+func TestBulkWaveWait(t *testing.T) {
+	tests := []struct {
+		nodes, waveSize int
+		interval        time.Duration
+		want            time.Duration
+	}{
+		{nodes: 10, waveSize: 0, interval: time.Minute, want: 0},
+		{nodes: 10, waveSize: 10, interval: time.Minute, want: 0},
+		{nodes: 10, waveSize: 3, interval: time.Minute, want: 3 * time.Minute},
+		{nodes: 9, waveSize: 3, interval: time.Minute, want: 2 * time.Minute},
+		{nodes: 0, waveSize: 3, interval: time.Minute, want: 0},
+	}
+	for _, tt := range tests {
+		if got := bulkWaveWait(tt.nodes, tt.waveSize, tt.interval); got != tt.want {
+			t.Errorf("bulkWaveWait(%d, %d, %s) = %s, want %s", tt.nodes, tt.waveSize, tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestBulkNodesWaveDurationCap(t *testing.T) {
+	_, r, repo := newTestHandler(t)
+	for i := 0; i < 4; i++ {
+		saveNode(t, repo, fmt.Sprintf("aabbccddee%02x", i), func(node *model.Node) {
+			node.Labels = map[string]string{"rack": "a1"}
+		})
+	}
+
+	tests := []struct {
+		name       string
+		waves      string
+		wantStatus int
+	}{
+		// 4 个批次，等待 3 × 300s = 15 分钟
+		{name: "exceeds cap", waves: `"wave_size":1,"wave_interval_seconds":300`, wantStatus: http.StatusBadRequest},
+		// 2 个批次，等待 1 × 600s = 10 分钟
+		{name: "at cap", waves: `"wave_size":2,"wave_interval_seconds":600`, wantStatus: http.StatusOK},
+		{name: "single wave", waves: `"wave_size":4,"wave_interval_seconds":600`, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"selector":{"labels":"rack=a1"},"action":"label","labels":{"env":"prod"},"dry_run":true,` + tt.waves + `}`
+			w := serve(r, http.MethodPost, "/api/v1/nodes:bulk", body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code == http.StatusBadRequest {
+				if code := responseCode(t, w); code != CodeInvalidRequest {
+					t.Errorf("code = %s, want %s", code, CodeInvalidRequest)
+				}
+			}
+		})
+	}
+}
+
+func TestBulkNodesBackgroundOperation(t *testing.T) {
+	_, r, repo := newTestHandler(t)
+	for i := 0; i < 3; i++ {
+		saveNode(t, repo, fmt.Sprintf("aabbccddee%02x", i), func(node *model.Node) {
+			node.Labels = map[string]string{"rack": "a1"}
+		})
+	}
+
+	// 3 个批次，批次间等待 1s，在后台执行
+	body := `{"selector":{"labels":"rack=a1"},"action":"label","labels":{"env":"prod"},"wave_size":1,"wave_interval_seconds":1}`
+	w := serve(r, http.MethodPost, "/api/v1/nodes:bulk", body)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202: %s", w.Code, w.Body.String())
+	}
+	var operation BulkOperation
+	if err := json.Unmarshal(w.Body.Bytes(), &operation); err != nil {
+		t.Fatal(err)
+	}
+	location := w.Header().Get("Location")
+	if operation.Status != BulkOperationRunning || operation.Matched != 3 || location != "/api/v1/operations/"+operation.ID {
+		t.Fatalf("operation = %+v, Location = %q", operation, location)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Retry-After = %q, want 1", w.Header().Get("Retry-After"))
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for operation.Status != BulkOperationCompleted {
+		if time.Now().After(deadline) {
+			t.Fatalf("operation not completed: %+v", operation)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		w = serve(r, http.MethodGet, location, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("get operation status = %d: %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &operation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if operation.FinishedAt == nil || operation.Result == nil || operation.Result.Succeeded != 3 {
+		t.Fatalf("completed operation = %+v", operation)
+	}
+	node, err := repo.FindByMAC(context.Background(), "aabbccddee02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Labels["env"] != "prod" {
+		t.Errorf("labels = %v, want env=prod", node.Labels)
+	}
+
+	w = serve(r, http.MethodGet, "/api/v1/operations/unknown", "")
+	if w.Code != http.StatusNotFound || responseCode(t, w) != CodeNotFound {
+		t.Errorf("unknown operation status = %d: %s", w.Code, w.Body.String())
+	}
+}
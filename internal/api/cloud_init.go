@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/ipxe"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// GetCloudInit 获取 cloud-init NoCloud 数据（user-data、meta-data、vendor-data、network-config）
+// 仅向安装中的节点提供；安装器会多次读取，安装令牌只校验不标记使用
+func (h *Handler) GetCloudInit(c *gin.Context) {
+	mac := c.Param("mac")
+	file := path.Base(c.FullPath())
+
+	node, err := h.repo.FindByMAC(c.Request.Context(), mac)
+	if err != nil {
+		if db.IsNodeNotFound(err) {
+			h.metrics.CloudInitRequest(file, "not_found")
+		}
+		h.writeError(c, err, "failed to find node")
+		return
+	}
+	if node.Status != model.STATE_INSTALLING {
+		h.metrics.CloudInitRequest(file, "not_installing")
+		h.logger.Warn("cloud-init data requested for node that is not installing",
+			zap.String("mac", node.MAC),
+			zap.String("file", file),
+			zap.String("status", node.Status),
+			zap.String("remote", c.RemoteIP()),
+		)
+		errorResponse(c, http.StatusForbidden, CodeNodeNotInstalling, "node is not installing")
+		return
+	}
+
+	if !h.verifyInstallToken(c, node.MAC, model.INSTALL_RESOURCE_CLOUD_INIT) {
+		h.metrics.CloudInitRequest(file, "denied")
+		return
+	}
+
+	data, err := h.preseedGen.GenerateCloudInit(c.Request.Context(), node.MAC, file, c.Query("token"))
+	if err != nil {
+		h.writeError(c, err, "failed to generate cloud-init "+file)
+		return
+	}
+	h.metrics.CloudInitRequest(file, "served")
+
+	c.Header("Content-Type", "text/plain")
+	c.String(http.StatusOK, data)
+}
+
+// cloudInitRoutes cloud-init NoCloud 数据源路由（每个文件一个路由，共用处理器）
+func (h *Handler) cloudInitRoutes() []apiRoute {
+	summaries := map[string]string{
+		ipxe.CloudInitUserData:      "cloud-init user-data：安装配置中的应答文件或内置 cloud-config（主机名、SSH 公钥、安装 agent）",
+		ipxe.CloudInitMetaData:      "cloud-init meta-data：instance-id 与主机名",
+		ipxe.CloudInitVendorData:    "cloud-init vendor-data（空配置）",
+		ipxe.CloudInitNetworkConfig: "cloud-init network-config：节点静态网络配置，未分配 IP 时使用 DHCP",
+	}
+
+	routes := make([]apiRoute, 0, len(ipxe.CloudInitFiles))
+	for _, file := range ipxe.CloudInitFiles {
+		routes = append(routes, apiRoute{
+			method: http.MethodGet, path: "/cloud-init/:mac/" + file, group: groupNode, handler: h.GetCloudInit, plain: true,
+			tag: "provisioning", summary: summaries[file] + "（仅 installing 节点）",
+			params: []apiParam{{name: "token", in: "query", typ: "string", description: "安装令牌（启用安装令牌时必填，可重复使用）"}},
+			status: http.StatusOK, response: "", contentType: contentText,
+			errors: []int{http.StatusForbidden, http.StatusNotFound},
+		})
+	}
+	return routes
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 命令查询数量
+const (
+	defaultCommandLimit = 20
+	maxCommandLimit     = db.CommandHistoryLimit
+)
+
+// 命令超时（秒）
+const (
+	defaultCommandTimeout = 300
+	maxCommandTimeout     = 3600
+)
+
+// maxIdempotencyKeyLength Idempotency-Key 请求头最大长度
+const maxIdempotencyKeyLength = 128
+
+// CommandSender 下发并跟踪节点命令
+type CommandSender interface {
+	// Send 记录并下发命令；幂等键重复时返回已有命令，下发失败时返回已标记为 failed 的记录和错误
+	Send(ctx context.Context, execution *model.CommandExecution) (*model.CommandExecution, error)
+}
+
+// CommandRequest 下发命令请求
+type CommandRequest struct {
+	Command string                 `json:"command"`
+	Args    map[string]interface{} `json:"args,omitempty"`
+	// TimeoutSeconds 截止时间（秒），默认 300，最大 3600
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// SetCommands 设置命令存储和下发服务
+func (h *Handler) SetCommands(repo db.CommandRepository, sender CommandSender) {
+	h.commandRepo = repo
+	h.commandSender = sender
+}
+
+// CreateNodeCommand 向节点 agent 下发命令，返回 202 和命令记录
+// 携带 Idempotency-Key 请求头时，同一节点相同键的重复请求返回 200 和已有命令
+func (h *Handler) CreateNodeCommand(c *gin.Context) {
+	if !h.commandsEnabled(c) {
+		return
+	}
+
+	var req CommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.Command == "" {
+		errorDetails(c, http.StatusBadRequest, CodeValidationFailed, "command is required",
+			map[string]interface{}{"field": "body.command", "reason": "is required"})
+		return
+	}
+	auditDetail(c, "command", req.Command)
+	if err := model.ValidateCommandName(req.Command); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if req.TimeoutSeconds == 0 {
+		req.TimeoutSeconds = defaultCommandTimeout
+	}
+	if req.TimeoutSeconds < 1 || req.TimeoutSeconds > maxCommandTimeout {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("timeout_seconds must be between 1 and %d", maxCommandTimeout))
+		return
+	}
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("Idempotency-Key must not exceed %d characters", maxIdempotencyKeyLength))
+		return
+	}
+
+	node, ok := h.findCommandNode(c)
+	if !ok {
+		return
+	}
+	if node.Status != model.STATE_INSTALLED {
+		errorResponse(c, http.StatusConflict, CodeAgentNotRunning, fmt.Sprintf("node with status '%s' has no running agent", node.Status))
+		return
+	}
+
+	timeout := time.Duration(req.TimeoutSeconds) * time.Second
+	execution := model.NewCommandExecution(node.MAC, req.Command, req.Args, timeout, time.Now())
+	execution.IdempotencyKey = idempotencyKey
+	if token := tokenFromContext(c); token != nil {
+		execution.RequestedBy = token.Name
+	}
+
+	created, err := h.commandSender.Send(c.Request.Context(), execution)
+	if err != nil {
+		if created != nil {
+			// 已记录但下发失败，记录中包含失败原因
+			errorResponse(c, http.StatusServiceUnavailable, CodeServiceUnavailable, "failed to send command")
+			return
+		}
+		h.logger.Error("failed to create command", zap.String("mac", node.MAC), zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to create command")
+		return
+	}
+
+	auditDetail(c, "command_id", created.ID)
+	c.Header("Location", fmt.Sprintf("/api/v1/nodes/%s/commands/%s", node.MAC, created.ID))
+	if created.ID != execution.ID {
+		// 幂等键重复，返回已有命令
+		c.JSON(http.StatusOK, created)
+		return
+	}
+	c.JSON(http.StatusAccepted, created)
+}
+
+// ListNodeCommands 列出节点的命令（最新的在前），支持 status、limit 参数
+func (h *Handler) ListNodeCommands(c *gin.Context) {
+	if !h.commandsEnabled(c) {
+		return
+	}
+
+	node, ok := h.findCommandNode(c)
+	if !ok {
+		return
+	}
+	h.listCommands(c, node.MAC)
+}
+
+// ListCommands 列出所有节点的命令（最新的在前），支持 mac、status、limit 参数
+func (h *Handler) ListCommands(c *gin.Context) {
+	if !h.commandsEnabled(c) {
+		return
+	}
+
+	mac := c.Query("mac")
+	if mac != "" && !model.IsValidMAC(mac) {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidMAC, "invalid MAC address format")
+		return
+	}
+	h.listCommands(c, mac)
+}
+
+// GetNodeCommand 获取节点的命令
+func (h *Handler) GetNodeCommand(c *gin.Context) {
+	if !h.commandsEnabled(c) {
+		return
+	}
+
+	mac := model.NormalizeMAC(c.Param("mac"))
+	id := c.Param("id")
+	execution, err := h.commandRepo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if db.IsCommandNotFound(err) {
+			errorResponse(c, http.StatusNotFound, CodeCommandNotFound, "command not found")
+			return
+		}
+		h.logger.Error("failed to find command", zap.String("id", id), zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to find command")
+		return
+	}
+	if execution.MAC != mac {
+		errorResponse(c, http.StatusNotFound, CodeCommandNotFound, "command not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, execution)
+}
+
+// listCommands 按查询参数列出命令
+func (h *Handler) listCommands(c *gin.Context, mac string) {
+	status := c.Query("status")
+	if status != "" && !model.IsValidCommandStatus(status) {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("invalid command status: %s", status))
+		return
+	}
+
+	limit := defaultCommandLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxCommandLimit {
+			errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", maxCommandLimit))
+			return
+		}
+		limit = n
+	}
+
+	commands, err := h.commandRepo.List(c.Request.Context(), mac, status, limit)
+	if err != nil {
+		h.logger.Error("failed to list commands", zap.String("mac", mac), zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to list commands")
+		return
+	}
+
+	if commands == nil {
+		commands = []*model.CommandExecution{}
+	}
+	c.JSON(http.StatusOK, commands)
+}
+
+// commandsEnabled 检查命令功能是否可用
+func (h *Handler) commandsEnabled(c *gin.Context) bool {
+	if h.commandRepo == nil || h.commandSender == nil {
+		errorResponse(c, http.StatusServiceUnavailable, CodeFeatureDisabled, "command channel not available")
+		return false
+	}
+	return true
+}
+
+// findCommandNode 读取路径参数指定的节点，不存在时写入错误响应
+func (h *Handler) findCommandNode(c *gin.Context) (*model.Node, bool) {
+	mac := c.Param("mac")
+	if !model.IsValidMAC(mac) {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidMAC, "invalid MAC address format")
+		return nil, false
+	}
+
+	node, err := h.repo.FindByMAC(c.Request.Context(), mac)
+	if err != nil {
+		h.writeError(c, err, "failed to find node")
+		return nil, false
+	}
+	return node, true
+}
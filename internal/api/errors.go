@@ -0,0 +1,222 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/dhcp"
+)
+
+// 错误码：稳定的机器可读标识，客户端应按错误码而不是错误信息判断错误类型
+const (
+	// 请求错误
+	CodeInvalidRequest   = "INVALID_REQUEST"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeInvalidMAC       = "INVALID_MAC"
+	CodeUnknownOperation = "UNKNOWN_OPERATION"
+
+	// 认证与授权
+	CodeUnauthenticated     = "UNAUTHENTICATED"
+	CodeForbidden           = "FORBIDDEN"
+	CodeInstallTokenInvalid = "INSTALL_TOKEN_INVALID"
+
+	// 资源不存在
+	CodeNotFound        = "NOT_FOUND"
+	CodeNodeNotFound    = "NODE_NOT_FOUND"
+	CodeCommandNotFound = "COMMAND_NOT_FOUND"
+	CodeTokenNotFound   = "TOKEN_NOT_FOUND"
+	CodeWebhookNotFound = "WEBHOOK_NOT_FOUND"
+	CodeLeaseNotFound   = "LEASE_NOT_FOUND"
+	CodeProfileNotFound = "PROFILE_NOT_FOUND"
+
+	// 状态冲突
+	CodeNodeAlreadyExists    = "NODE_ALREADY_EXISTS"
+	CodeProfileAlreadyExists = "PROFILE_ALREADY_EXISTS"
+	CodeProfileGroupConflict = "PROFILE_GROUP_CONFLICT"
+	CodeInvalidTransition    = "INVALID_TRANSITION"
+	CodeNodeNotInstalling    = "NODE_NOT_INSTALLING"
+	CodeAgentNotRunning      = "AGENT_NOT_RUNNING"
+	CodeVersionConflict      = "VERSION_CONFLICT"
+	CodePreconditionFailed   = "PRECONDITION_FAILED"
+
+	// 限速
+	CodeRateLimited = "RATE_LIMITED"
+
+	// 服务端
+	CodePoolExhausted      = "POOL_EXHAUSTED"
+	CodeFeatureDisabled    = "FEATURE_DISABLED"
+	CodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+	CodeInternal           = "INTERNAL_ERROR"
+)
+
+// 请求 ID
+const (
+	// HeaderRequestID 请求 ID 请求头/响应头
+	HeaderRequestID = "X-Request-ID"
+	// contextKeyRequestID gin 上下文中的请求 ID
+	contextKeyRequestID = "request_id"
+	// maxRequestIDLength 接受客户端提供的请求 ID 的最大长度
+	maxRequestIDLength = 128
+)
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	// Error 错误信息（供人阅读，内容可能变化）
+	Error string `json:"error"`
+	// Code 错误码
+	Code string `json:"code"`
+	// Details 错误详情，如校验失败的字段、冲突的状态
+	Details map[string]interface{} `json:"details,omitempty"`
+	// RequestID 请求 ID，与响应头 X-Request-ID 相同，用于关联服务端日志
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// errorResponse 返回错误响应
+func errorResponse(c *gin.Context, status int, code, message string) {
+	errorDetails(c, status, code, message, nil)
+}
+
+// errorDetails 返回带详情的错误响应
+func errorDetails(c *gin.Context, status int, code, message string, details map[string]interface{}) {
+	c.Set(contextKeyErrorCode, code)
+	c.JSON(status, ErrorResponse{
+		Error:     message,
+		Code:      code,
+		Details:   details,
+		RequestID: c.GetString(contextKeyRequestID),
+	})
+}
+
+// apiError 携带 HTTP 状态码和错误码的错误，由 writeError 转换为响应
+type apiError struct {
+	status  int
+	code    string
+	message string
+	details map[string]interface{}
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// toAPIError 将已知错误映射到 HTTP 状态码和错误码，未知错误返回 nil
+func toAPIError(err error) *apiError {
+	var (
+		apiErr     *apiError
+		notFound   *db.ErrNodeNotFound
+		exists     *db.ErrNodeAlreadyExists
+		transition *db.ErrInvalidStatusTransition
+		conflict   *db.ErrVersionConflict
+		// 安装配置
+		profileNotFound *db.ErrProfileNotFound
+		profileExists   *db.ErrProfileAlreadyExists
+		groupConflict   *db.ErrProfileGroupConflict
+	)
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.As(err, &notFound):
+		return &apiError{status: http.StatusNotFound, code: CodeNodeNotFound, message: "node not found",
+			details: map[string]interface{}{"mac": notFound.MAC}}
+	case errors.As(err, &exists):
+		return &apiError{status: http.StatusConflict, code: CodeNodeAlreadyExists, message: "node already exists",
+			details: map[string]interface{}{"mac": exists.MAC}}
+	case errors.As(err, &transition):
+		return &apiError{status: http.StatusConflict, code: CodeInvalidTransition,
+			message: "cannot change node status from '" + transition.From + "' to '" + transition.To + "'",
+			details: map[string]interface{}{"from": transition.From, "to": transition.To}}
+	case errors.As(err, &conflict):
+		return &apiError{status: http.StatusConflict, code: CodeVersionConflict,
+			message: "node is being modified concurrently, please retry",
+			details: map[string]interface{}{"mac": conflict.MAC}}
+	case errors.As(err, &profileNotFound):
+		return &apiError{status: http.StatusNotFound, code: CodeProfileNotFound, message: "install profile not found",
+			details: map[string]interface{}{"name": profileNotFound.Name}}
+	case errors.As(err, &profileExists):
+		return &apiError{status: http.StatusConflict, code: CodeProfileAlreadyExists, message: "install profile already exists",
+			details: map[string]interface{}{"name": profileExists.Name}}
+	case errors.As(err, &groupConflict):
+		return &apiError{status: http.StatusConflict, code: CodeProfileGroupConflict, message: groupConflict.Error(),
+			details: map[string]interface{}{"group": groupConflict.Group, "profile": groupConflict.Profile}}
+	case errors.Is(err, dhcp.ErrIPPoolExhausted):
+		return &apiError{status: http.StatusServiceUnavailable, code: CodePoolExhausted, message: "DHCP IP pool exhausted"}
+	case errors.Is(err, dhcp.ErrLeaseNotFound):
+		return &apiError{status: http.StatusNotFound, code: CodeLeaseNotFound, message: "lease not found"}
+	}
+	return nil
+}
+
+// errorCode 返回错误对应的错误码（批量操作的单节点结果使用）
+func errorCode(err error) string {
+	if apiErr := toAPIError(err); apiErr != nil {
+		return apiErr.code
+	}
+	return CodeInternal
+}
+
+// writeError 将错误转换为 HTTP 响应：已知错误映射到对应状态码和错误码，
+// 其他错误记录日志并返回 500，internalMessage 为返回给客户端的信息
+func (h *Handler) writeError(c *gin.Context, err error, internalMessage string) {
+	if apiErr := toAPIError(err); apiErr != nil {
+		errorDetails(c, apiErr.status, apiErr.code, apiErr.message, apiErr.details)
+		return
+	}
+
+	h.logger.Error(internalMessage,
+		zap.String("path", c.Request.URL.Path),
+		zap.String("request_id", c.GetString(contextKeyRequestID)),
+		zap.Error(err),
+	)
+	errorResponse(c, http.StatusInternalServerError, CodeInternal, internalMessage)
+}
+
+// RequestID 为每个请求分配请求 ID：沿用客户端提供的 X-Request-ID，否则生成新的 ID；
+// 请求 ID 写入响应头和错误响应，需要在其他中间件之前注册
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(contextKeyRequestID, id)
+		c.Header(HeaderRequestID, id)
+		c.Next()
+	}
+}
+
+// validRequestID 客户端提供的请求 ID 仅允许可打印 ASCII 字符，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	return !strings.ContainsFunc(id, func(r rune) bool {
+		return r < 0x21 || r > 0x7e
+	})
+}
+
+// newRequestID 生成随机请求 ID
+func newRequestID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Recovery 捕获处理器 panic 并返回统一格式的 500 错误
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, _ interface{}) {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "internal server error")
+		c.Abort()
+	})
+}
+
+// noRoute 未注册的路径返回统一格式的 404 错误
+func noRoute(c *gin.Context) {
+	errorResponse(c, http.StatusNotFound, CodeNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// nodeETag 根据节点资源版本生成强 ETag
+func nodeETag(node *model.Node) string {
+	return `"` + strconv.FormatUint(node.ResourceVersion, 10) + `"`
+}
+
+// setNodeETag 在响应中设置节点 ETag
+func setNodeETag(c *gin.Context, node *model.Node) {
+	c.Header("ETag", nodeETag(node))
+}
+
+// etagMatchesStrong 判断 If-Match 头是否与节点 ETag 匹配（RFC 7232 强比较）
+// 支持 "*" 与逗号分隔的多个 ETag，弱 ETag（W/ 前缀）不满足前置条件
+func etagMatchesStrong(header string, node *model.Node) bool {
+	return etagMatches(header, node, false)
+}
+
+// etagMatchesWeak 判断 If-None-Match 头是否与节点 ETag 匹配（RFC 7232 弱比较）
+// 支持 "*" 与逗号分隔的多个 ETag，忽略弱 ETag 前缀 W/
+func etagMatchesWeak(header string, node *model.Node) bool {
+	return etagMatches(header, node, true)
+}
+
+// etagMatches 逐个比较头中的 ETag，weak 为 false 时跳过弱 ETag
+func etagMatches(header string, node *model.Node, weak bool) bool {
+	current := nodeETag(node)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == current {
+			return true
+		}
+	}
+	return false
+}
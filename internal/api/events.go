@@ -0,0 +1,239 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 事件流保活间隔
+const (
+	sseKeepaliveInterval = 15 * time.Second
+	wsPingInterval       = 30 * time.Second
+	wsWriteTimeout       = 10 * time.Second
+)
+
+// EVENT_STREAM_GAP 续传时请求的事件已不在缓冲区中（客户端应重新获取完整节点列表）
+const EVENT_STREAM_GAP = "stream.gap"
+
+// wsUpgrader WebSocket 升级器（默认仅允许同源浏览器连接）
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// SetEventBus 设置节点事件总线
+func (h *Handler) SetEventBus(bus *events.Bus) {
+	h.events = bus
+}
+
+// StreamEvents 节点事件流
+// 默认使用 Server-Sent Events，请求为 WebSocket 升级时使用 WebSocket。
+// 查询参数：mac（逗号分隔）、type（逗号分隔，支持 node.* 形式）、labels（标签选择器）、
+// last_event_id（续传，SSE 也可使用 Last-Event-ID 请求头）
+func (h *Handler) StreamEvents(c *gin.Context) {
+	if h.events == nil {
+		errorResponse(c, http.StatusServiceUnavailable, CodeFeatureDisabled, "event stream not available")
+		return
+	}
+
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	lastID := c.Query("last_event_id")
+	if lastID == "" {
+		lastID = c.GetHeader("Last-Event-ID")
+	}
+	var afterID uint64
+	if lastID != "" {
+		afterID, err = strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid last event id")
+			return
+		}
+	}
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.streamWebSocket(c, filter, afterID)
+		return
+	}
+	h.streamSSE(c, filter, afterID)
+}
+
+// parseEventFilter 解析事件过滤参数
+func parseEventFilter(c *gin.Context) (*events.Filter, error) {
+	filter := &events.Filter{}
+
+	if macs := splitList(c.Query("mac")); len(macs) > 0 {
+		filter.MACs = make(map[string]bool, len(macs))
+		for _, mac := range macs {
+			if !model.IsValidMAC(mac) {
+				return nil, fmt.Errorf("invalid MAC address: %s", mac)
+			}
+			filter.MACs[model.NormalizeMAC(mac)] = true
+		}
+	}
+
+	if types := splitList(c.Query("type")); len(types) > 0 {
+		filter.Types = make(map[string]bool, len(types))
+		for _, t := range types {
+			if !events.IsValidTypePattern(t) {
+				return nil, fmt.Errorf("invalid event type: %s", t)
+			}
+			filter.Types[t] = true
+		}
+	}
+
+	labels, err := model.ParseLabelSelector(c.Query("labels"))
+	if err != nil {
+		return nil, err
+	}
+	filter.Labels = labels
+
+	return filter, nil
+}
+
+// gapData 无法完整续传时发送给客户端的提示
+func gapData(afterID uint64, replay []events.Event) map[string]interface{} {
+	data := map[string]interface{}{"last_event_id": afterID}
+	if len(replay) > 0 {
+		data["resumed_from_id"] = replay[0].ID
+	}
+	return data
+}
+
+// streamSSE 以 Server-Sent Events 推送事件
+func (h *Handler) streamSSE(c *gin.Context, filter *events.Filter, afterID uint64) {
+	sub, replay, complete := h.events.Subscribe(filter, afterID)
+	defer sub.Close()
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// 禁止反向代理缓冲
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if !complete {
+		writeSSE(w, "", EVENT_STREAM_GAP, gapData(afterID, replay))
+	}
+	for i := range replay {
+		writeSSE(w, strconv.FormatUint(replay[i].ID, 10), replay[i].Type, &replay[i])
+	}
+	w.Flush()
+
+	keepalive := time.NewTicker(sseKeepaliveInterval)
+	defer keepalive.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				// 消费过慢被断开或服务关闭，客户端会携带 Last-Event-ID 重连续传
+				return
+			}
+			writeSSE(w, strconv.FormatUint(e.ID, 10), e.Type, &e)
+			w.Flush()
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			w.Flush()
+		}
+	}
+}
+
+// writeSSE 写入一条 SSE 消息（id 为空时不设置，避免覆盖客户端的续传位置）
+func writeSSE(w gin.ResponseWriter, id, eventType string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, data)
+}
+
+// streamWebSocket 以 WebSocket 推送事件（每条消息为一个 JSON 事件）
+func (h *Handler) streamWebSocket(c *gin.Context, filter *events.Filter, afterID uint64) {
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 已向客户端返回错误
+		h.logger.Debug("websocket upgrade failed", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	sub, replay, complete := h.events.Subscribe(filter, afterID)
+	defer sub.Close()
+
+	// 读取循环：处理 pong/close，客户端断开时结束
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(2 * wsPingInterval))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * wsPingInterval))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(v interface{}) bool {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteJSON(v) == nil
+	}
+
+	if !complete && !write(events.Event{Type: EVENT_STREAM_GAP, Time: time.Now(), Data: gapData(afterID, replay)}) {
+		return
+	}
+	for i := range replay {
+		if !write(&replay[i]) {
+			return
+		}
+	}
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "resume with last_event_id"),
+					time.Now().Add(wsWriteTimeout))
+				return
+			}
+			if !write(&e) {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
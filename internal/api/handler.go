@@ -1,26 +1,79 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/dhcp"
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/health"
 	"github.com/lucheng0127/nodefoundry/internal/ipxe"
+	"github.com/lucheng0127/nodefoundry/internal/metrics"
 	"github.com/lucheng0127/nodefoundry/internal/model"
 )
 
 // Handler API 处理器
 type Handler struct {
-	repo           db.NodeRepository
-	ipxeGen        *ipxe.Generator
-	preseedGen     *ipxe.PreseedGenerator
-	logger         *zap.Logger
-	startTime      time.Time
+	repo       db.NodeRepository
+	ipxeGen    *ipxe.Generator
+	preseedGen *ipxe.PreseedGenerator
+	leases     LeaseReleaser
+	leasePool  LeasePool
+	commands   CommandPublisher
+	tokens     db.TokenRepository
+	// 节点安装令牌
+	installTokens db.InstallTokenRepository
+	// 允许访问节点端点的来源网段
+	nodeNetworks []*net.IPNet
+	// 服务器 CA 证书（PEM），通过 /ca.crt 提供下载
+	caPEM []byte
+	// 组件健康检查
+	health *health.Checker
+	// 指标收集
+	metrics *metrics.Metrics
+	// /metrics 无需认证
+	metricsPublic bool
+	// 节点事件总线
+	events *events.Bus
+	// webhook 订阅和测试投递
+	webhooks      db.WebhookRepository
+	webhookTester WebhookTester
+	// 操作系统安装配置
+	profiles db.ProfileRepository
+	// 节点命令记录和下发
+	commandRepo   db.CommandRepository
+	commandSender CommandSender
+	// 修改类请求的审计记录
+	audit db.AuditRepository
+	// 各路由分组的限速器
+	rateLimits map[string]*groupLimiters
+	// 最近一次向各节点提供的引导脚本
+	bootScripts   map[string]*BootScriptRecord
+	bootScriptsMu sync.Mutex
+	// 后台执行的批量操作
+	bulkOps   map[string]*BulkOperation
+	bulkOpsMu sync.Mutex
+	// 由路由表生成的 OpenAPI 文档
+	openAPI   openAPI
+	logger    *zap.Logger
+	startTime time.Time
+}
+
+// LeaseReleaser 释放节点持有的 DHCP 租约
+type LeaseReleaser interface {
+	ReleaseByMAC(mac string) error
 }
 
 // NewHandler 创建 API 处理器
@@ -39,65 +92,150 @@ func NewHandler(
 	}
 }
 
-// RegisterRoutes 注册路由
+// SetMetrics 设置指标收集
+func (h *Handler) SetMetrics(m *metrics.Metrics) {
+	h.metrics = m
+}
+
+// SetMetricsPublic 设置 /metrics 是否无需认证（默认与 API 相同，启用认证时需要 viewer token）
+func (h *Handler) SetMetricsPublic(public bool) {
+	h.metricsPublic = public
+}
+
+// GetMetrics 以 Prometheus 文本格式输出指标
+func (h *Handler) GetMetrics(c *gin.Context) {
+	if h.metrics == nil {
+		errorResponse(c, http.StatusServiceUnavailable, CodeFeatureDisabled, "metrics not available")
+		return
+	}
+	h.metrics.Handler().ServeHTTP(c.Writer, c.Request)
+}
+
+// SetLeaseReleaser 设置 DHCP 租约释放器（删除节点时释放租约）
+func (h *Handler) SetLeaseReleaser(leases LeaseReleaser) {
+	h.leases = leases
+}
+
+// RegisterRoutes 注册路由（路由表见 routes，OpenAPI 文档由同一路由表生成）
 func (h *Handler) RegisterRoutes(r *gin.Engine) {
-	// API v1
-	v1 := r.Group("/api/v1")
-	{
-		nodes := v1.Group("/nodes")
-		{
-			nodes.GET("", h.ListNodes)
-			nodes.GET("/:mac", h.GetNode)
-			nodes.POST("", h.RegisterNode)
-			nodes.PUT("/:mac", h.UpdateNode)
-		}
+	routes := h.routes()
+	h.openAPI.build(routes)
+
+	// API v1（启用认证时需要 Bearer token，修改类请求记录审计日志）
+	v1 := r.Group("/api/v1", h.rateLimit(groupAPI), h.auditRequests(routes), h.authenticate())
+	// 节点端点（PXE/安装阶段访问，按来源网段限制）
+	node := r.Group("", h.rateLimit(groupNode), h.nodeAccess())
+	// Prometheus 指标（启用认证时需要 viewer token，可配置为公开）
+	metricsGroup := r.Group("", h.metricsAccess())
+	groups := map[string]gin.IRoutes{groupAPI: v1, groupNode: node, groupMetrics: metricsGroup, groupPublic: r}
+
+	for i := range routes {
+		h.registerRoute(groups[routes[i].group], &routes[i])
 	}
+	r.NoRoute(noRoute)
+}
 
-	// iPXE 端点
-	r.GET("/boot/:mac/boot.ipxe", h.GetBootScript)
+// RegisterPlainRoutes 注册启用 TLS 后仍通过 HTTP 提供的路由
+// 仅包含无法使用 TLS 的固件和安装器需要的端点：iPXE 脚本、preseed、cloud-init 数据、安装进度上报以及用于引导信任的 CA 证书
+func (h *Handler) RegisterPlainRoutes(r *gin.Engine) {
+	routes := h.routes()
+	h.openAPI.build(routes)
 
-	// preseed 端点
-	r.GET("/preseed/:mac/preseed.cfg", h.GetPreseed)
+	node := r.Group("", h.rateLimit(groupNode), h.nodeAccess())
+	groups := map[string]gin.IRoutes{groupNode: node, groupPublic: r}
 
-	// Agent 下载端点
-	r.GET("/agent/nodefoundry-agent", h.GetAgentBinary)
-	r.GET("/agent/nodefoundry-agent.service", h.GetAgentServiceFile)
+	for i := range routes {
+		if routes[i].plain {
+			h.registerRoute(groups[routes[i].group], &routes[i])
+		}
+	}
+	r.NoRoute(noRoute)
+}
 
-	// 健康检查
-	r.GET("/health", h.HealthCheck)
+// registerRoute 注册单个路由：角色检查、请求校验、处理器
+func (h *Handler) registerRoute(group gin.IRoutes, route *apiRoute) {
+	var handlers []gin.HandlerFunc
+	if route.role != "" {
+		handlers = append(handlers, h.requireRole(route.role))
+	}
+	if validator := h.validateRequest(route); validator != nil {
+		handlers = append(handlers, validator)
+	}
+	handlers = append(handlers, route.handler)
+	group.Handle(route.method, route.path, handlers...)
 }
 
-// ErrorResponse 错误响应
-type ErrorResponse struct {
-	Error string `json:"error"`
+// SetCACertificate 设置通过 /ca.crt 提供下载的 CA 证书（PEM）
+func (h *Handler) SetCACertificate(caPEM []byte) {
+	h.caPEM = caPEM
 }
 
-// errorResponse 返回错误响应
-func errorResponse(c *gin.Context, code int, message string) {
-	c.JSON(code, ErrorResponse{Error: message})
+// GetCACertificate 获取服务器 CA 证书（用于编译 iPXE 或手动安装到节点）
+func (h *Handler) GetCACertificate(c *gin.Context) {
+	c.Header("Content-Disposition", "attachment; filename=nodefoundry-ca.crt")
+	c.Data(http.StatusOK, "application/x-pem-file", h.caPEM)
 }
 
 // RegisterNodeRequest 注册节点请求
+// 指定 ip 时必须同时指定 netmask，节点网络配置固定为静态（DHCP 不再覆盖）
 type RegisterNodeRequest struct {
-	MAC string `json:"mac" binding:"required"`
-	IP  string `json:"ip,omitempty"`
+	MAC     string `json:"mac" binding:"required"`
+	IP      string `json:"ip,omitempty"`
+	Netmask string `json:"netmask,omitempty"`
+	Gateway string `json:"gateway,omitempty"`
+	DNS     string `json:"dns,omitempty"`
 }
 
 // UpdateNodeRequest 更新节点请求
 type UpdateNodeRequest struct {
-	Action string `json:"action" binding:"required"`
+	Action string `json:"action" binding:"required" enum:"install,reinstall"`
 }
 
-// ListNodes 列出所有节点
+// ListNodes 列出节点（支持过滤、排序、字段选择和游标分页，参数见 parseNodeQuery）
+// 响应头 X-Total-Count 为过滤后的节点总数，存在下一页时返回 X-Next-Cursor 和 Link 头
 func (h *Handler) ListNodes(c *gin.Context) {
+	query, err := parseNodeQuery(c.Request.URL.Query())
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
 	nodes, err := h.repo.List(c.Request.Context())
 	if err != nil {
 		h.logger.Error("failed to list nodes", zap.Error(err))
-		errorResponse(c, http.StatusInternalServerError, "failed to list nodes")
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to list nodes")
+		return
+	}
+
+	page, err := query.apply(nodes, time.Now())
+	if err != nil {
+		h.logger.Error("failed to paginate nodes", zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to list nodes")
 		return
 	}
 
-	c.JSON(http.StatusOK, nodes)
+	c.Header("X-Total-Count", strconv.Itoa(page.total))
+	if page.nextCursor != "" {
+		next := *c.Request.URL
+		params := next.Query()
+		params.Set("cursor", page.nextCursor)
+		next.RawQuery = params.Encode()
+		c.Header("X-Next-Cursor", page.nextCursor)
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+
+	if len(query.fields) > 0 {
+		projected, err := query.project(page.nodes)
+		if err != nil {
+			h.logger.Error("failed to select node fields", zap.Error(err))
+			errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to list nodes")
+			return
+		}
+		c.JSON(http.StatusOK, projected)
+		return
+	}
+
+	c.JSON(http.StatusOK, page.nodes)
 }
 
 // GetNode 获取单个节点
@@ -106,7 +244,13 @@ func (h *Handler) GetNode(c *gin.Context) {
 
 	node, err := h.repo.FindByMAC(c.Request.Context(), mac)
 	if err != nil {
-		errorResponse(c, http.StatusNotFound, "node not found")
+		h.writeError(c, err, "failed to find node")
+		return
+	}
+
+	setNodeETag(c, node)
+	if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatchesWeak(inm, node) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
@@ -117,21 +261,31 @@ func (h *Handler) GetNode(c *gin.Context) {
 func (h *Handler) RegisterNode(c *gin.Context) {
 	var req RegisterNodeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	// 验证 MAC 地址格式
 	if !model.IsValidMAC(req.MAC) {
-		errorResponse(c, http.StatusBadRequest, "invalid MAC address format")
+		errorResponse(c, http.StatusBadRequest, CodeInvalidMAC, "invalid MAC address format")
+		return
+	}
+
+	if err := model.ValidateNetworkConfig(req.IP, req.Netmask, req.Gateway, req.DNS); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeValidationFailed, err.Error())
 		return
 	}
 
 	// 检查节点是否已存在
 	normalizedMAC := model.NormalizeMAC(req.MAC)
+	auditNode(c, normalizedMAC)
 	_, err := h.repo.FindByMAC(c.Request.Context(), normalizedMAC)
 	if err == nil {
-		errorResponse(c, http.StatusConflict, "node already exists")
+		h.writeError(c, &db.ErrNodeAlreadyExists{MAC: normalizedMAC}, "failed to register node")
+		return
+	}
+	if !db.IsNodeNotFound(err) {
+		h.writeError(c, err, "failed to find node")
 		return
 	}
 
@@ -139,78 +293,233 @@ func (h *Handler) RegisterNode(c *gin.Context) {
 	node, err := model.NewNode(normalizedMAC, model.STATE_DISCOVERED)
 	if err != nil {
 		h.logger.Error("failed to create node", zap.Error(err))
-		errorResponse(c, http.StatusInternalServerError, "failed to create node")
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to create node")
 		return
 	}
 
 	if req.IP != "" {
 		node.IP = req.IP
+		node.Netmask = req.Netmask
+		node.Gateway = req.Gateway
+		node.DNS = req.DNS
+		node.StaticNetwork = true
 	}
 
 	// 保存节点
 	if err := h.repo.Save(c.Request.Context(), node); err != nil {
-		h.logger.Error("failed to save node", zap.Error(err))
-		errorResponse(c, http.StatusInternalServerError, "failed to save node")
+		if db.IsVersionConflict(err) {
+			// 并发注册或 DHCP 已创建该节点
+			err = &db.ErrNodeAlreadyExists{MAC: normalizedMAC}
+		}
+		h.writeError(c, err, "failed to save node")
 		return
 	}
 
+	h.events.Publish(events.NodeEvent(events.EVENT_NODE_DISCOVERED, node, map[string]interface{}{
+		"source": "api",
+	}))
+
 	c.Header("Location", "/api/v1/nodes/"+normalizedMAC)
+	setNodeETag(c, node)
 	c.JSON(http.StatusCreated, node)
 }
 
-// UpdateNode 更新节点（支持 action: install）
+// UpdateNode 更新节点（支持 action: install、reinstall）
+// 请求携带 If-Match 时仅在节点版本匹配时执行，否则返回 412
 func (h *Handler) UpdateNode(c *gin.Context) {
 	mac := c.Param("mac")
 
 	var req UpdateNodeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
 		return
 	}
+	auditDetail(c, "action", req.Action)
 
-	node, err := h.repo.FindByMAC(c.Request.Context(), mac)
-	if err != nil {
-		errorResponse(c, http.StatusNotFound, "node not found")
+	var mutate func(node *model.Node) error
+	switch req.Action {
+	case ActionInstall:
+		mutate = installMutation
+	case ActionReinstall:
+		mutate = reinstallMutation
+	default:
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "unknown action")
 		return
 	}
 
-	switch req.Action {
-	case "install":
-		// MVP: 仅支持 discovered → installing
-		if node.Status != model.STATE_DISCOVERED {
-			errorResponse(c, http.StatusBadRequest,
-				fmt.Sprintf("cannot install node with status '%s', only 'discovered' nodes can be installed", node.Status))
-			return
-		}
+	from := ""
+	node, err := h.mutateNode(c, mac, func(node *model.Node) error {
+		from = node.Status
+		return mutate(node)
+	})
+	if err != nil {
+		h.writeError(c, err, "failed to update node")
+		return
+	}
+	h.events.Publish(events.StatusChanged(node, from, "api"))
 
-		// 更新状态为 installing
-		if err := h.repo.UpdateStatus(c.Request.Context(), mac, model.STATE_INSTALLING); err != nil {
-			h.logger.Error("failed to update node status",
-				zap.String("mac", mac),
+	// 重装需要重启节点进入 PXE 引导，重启失败不影响状态变更
+	if req.Action == ActionReinstall {
+		if err := h.requestReboot(node.MAC); err != nil {
+			h.logger.Warn("node marked for reinstall but reboot was not sent",
+				zap.String("mac", node.MAC),
 				zap.Error(err),
 			)
-			errorResponse(c, http.StatusInternalServerError, "failed to update node status")
-			return
 		}
+	}
+
+	setNodeETag(c, node)
+	c.JSON(http.StatusOK, node)
+}
+
+// PatchNode 编辑节点属性（JSON Merge Patch）
+// 可编辑字段：hostname、ip、netmask、gateway、dns、labels、notes
+func (h *Handler) PatchNode(c *gin.Context) {
+	mac := c.Param("mac")
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	patch, err := parseNodePatch(body)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	auditDetail(c, "fields", patch.fields())
+
+	node, err := h.mutateNode(c, mac, func(node *model.Node) error {
+		if err := patch.apply(node); err != nil {
+			return &apiError{status: http.StatusBadRequest, code: CodeValidationFailed, message: err.Error()}
+		}
+		return nil
+	})
+	if err != nil {
+		h.writeError(c, err, "failed to update node")
+		return
+	}
 
-		// 获取更新后的节点
-		node, _ = h.repo.FindByMAC(c.Request.Context(), mac)
-		c.JSON(http.StatusOK, node)
+	h.logger.Info("node updated",
+		zap.String("mac", node.MAC),
+		zap.Uint64("resource_version", node.ResourceVersion),
+	)
+	h.events.Publish(events.NodeEvent(events.EVENT_NODE_UPDATED, node, map[string]interface{}{
+		"fields": patch.fields(),
+	}))
 
-	default:
-		errorResponse(c, http.StatusBadRequest, "unknown action")
+	setNodeETag(c, node)
+	c.JSON(http.StatusOK, node)
+}
+
+// DeleteNode 删除节点，同时释放其 DHCP 租约
+// 请求携带 If-Match 时仅在节点版本匹配时删除
+func (h *Handler) DeleteNode(c *gin.Context) {
+	ctx := c.Request.Context()
+	mac := model.NormalizeMAC(c.Param("mac"))
+
+	node, err := h.repo.FindByMAC(ctx, mac)
+	if err != nil {
+		h.writeError(c, err, "failed to delete node")
+		return
+	}
+
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !etagMatchesStrong(ifMatch, node) {
+		h.writeError(c, errPreconditionFailed, "failed to delete node")
+		return
+	}
+
+	if err := h.repo.Delete(ctx, mac); err != nil {
+		h.writeError(c, err, "failed to delete node")
+		return
+	}
+
+	h.cleanupNode(node)
+
+	h.logger.Info("node deleted", zap.String("mac", mac))
+	c.Status(http.StatusNoContent)
+}
+
+// cleanupNode 清理已删除节点的关联数据并发布删除事件
+func (h *Handler) cleanupNode(node *model.Node) {
+	mac := node.MAC
+	h.events.Publish(events.NodeEvent(events.EVENT_NODE_DELETED, node, nil))
+	h.revokeInstallToken(mac)
+	h.forgetBootScript(mac)
+	if h.commandRepo != nil {
+		if err := h.commandRepo.DeleteByNode(context.Background(), mac); err != nil {
+			h.logger.Warn("failed to delete node commands", zap.String("mac", mac), zap.Error(err))
+		}
+	}
+	if h.leases != nil {
+		if err := h.leases.ReleaseByMAC(mac); err != nil && !errors.Is(err, dhcp.ErrLeaseNotFound) {
+			h.logger.Warn("failed to release DHCP lease", zap.String("mac", mac), zap.Error(err))
+		}
 	}
 }
 
+// errPreconditionFailed If-Match 与节点当前版本不匹配
+var errPreconditionFailed = &apiError{
+	status:  http.StatusPreconditionFailed,
+	code:    CodePreconditionFailed,
+	message: "node has been modified, resource version mismatch",
+}
+
+// mutateNode 对节点执行读-改-写
+// 请求带 If-Match 时只尝试一次，版本不匹配返回 errPreconditionFailed；
+// 否则在版本冲突时基于最新节点重试
+func (h *Handler) mutateNode(c *gin.Context, mac string, mutate func(node *model.Node) error) (*model.Node, error) {
+	ctx := c.Request.Context()
+	ifMatch := c.GetHeader("If-Match")
+
+	if ifMatch == "" {
+		return db.UpdateWithRetry(ctx, h.repo, mac, mutate)
+	}
+
+	node, err := h.repo.FindByMAC(ctx, mac)
+	if err != nil {
+		return nil, err
+	}
+	if !etagMatchesStrong(ifMatch, node) {
+		return nil, errPreconditionFailed
+	}
+	if err := mutate(node); err != nil {
+		return nil, err
+	}
+	if err := h.repo.Save(ctx, node); err != nil {
+		if db.IsVersionConflict(err) {
+			return nil, errPreconditionFailed
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
 // GetBootScript 获取 iPXE 引导脚本
 func (h *Handler) GetBootScript(c *gin.Context) {
 	mac := c.Param("mac")
 
-	script, err := h.ipxeGen.GenerateByStatus(c.Request.Context(), mac)
+	node, err := h.repo.FindByMAC(c.Request.Context(), mac)
+	if db.IsNodeNotFound(err) {
+		h.metrics.BootScriptRequest("unknown")
+		errorResponse(c, http.StatusNotFound, CodeNodeNotFound, "node not found")
+		return
+	}
+	if err == nil {
+		h.metrics.BootScriptRequest(node.Status)
+	}
+
+	script := ""
+	if err == nil {
+		script, err = h.ipxeGen.GenerateForNode(c.Request.Context(), node)
+	}
 	if err != nil {
-		errorResponse(c, http.StatusNotFound, "node not found")
+		h.logger.Error("failed to generate boot script", zap.String("mac", mac), zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to generate boot script")
 		return
 	}
+	h.recordBootScript(c, node, script)
 
 	c.Header("Content-Type", "text/plain")
 	c.String(http.StatusOK, script)
@@ -220,50 +529,99 @@ func (h *Handler) GetBootScript(c *gin.Context) {
 func (h *Handler) GetPreseed(c *gin.Context) {
 	mac := c.Param("mac")
 
+	// 仅向安装中的节点提供 preseed
+	node, err := h.repo.FindByMAC(c.Request.Context(), mac)
+	if err != nil {
+		if db.IsNodeNotFound(err) {
+			h.metrics.PreseedRequest("unknown", "not_found")
+		}
+		h.writeError(c, err, "failed to find node")
+		return
+	}
+	if node.Status != model.STATE_INSTALLING {
+		h.metrics.PreseedRequest(node.Status, "not_installing")
+		h.logger.Warn("preseed requested for node that is not installing",
+			zap.String("mac", node.MAC),
+			zap.String("status", node.Status),
+			zap.String("remote", c.RemoteIP()),
+		)
+		errorResponse(c, http.StatusForbidden, CodeNodeNotInstalling, "node is not installing")
+		return
+	}
+
+	// 先校验安装令牌，生成成功后再标记使用，生成失败时安装器仍可重试
+	if !h.verifyInstallToken(c, node.MAC, model.INSTALL_RESOURCE_PRESEED) {
+		h.metrics.PreseedRequest(node.Status, "denied")
+		return
+	}
+
 	// 获取查询参数（网络配置）
 	query := c.Request.URL.Query()
 
 	preseed, err := h.preseedGen.GenerateWithQuery(c.Request.Context(), mac, query)
 	if err != nil {
-		errorResponse(c, http.StatusNotFound, "node not found")
+		if db.IsNodeNotFound(err) {
+			h.metrics.PreseedRequest(node.Status, "not_found")
+		}
+		h.writeError(c, err, "failed to generate preseed")
 		return
 	}
+	if !h.consumeInstallToken(c, node.MAC, model.INSTALL_RESOURCE_PRESEED) {
+		h.metrics.PreseedRequest(node.Status, "denied")
+		return
+	}
+	h.metrics.PreseedRequest(node.Status, "served")
 
 	c.Header("Content-Type", "text/plain")
 	c.String(http.StatusOK, preseed)
 }
 
 // GetAgentBinary 获取 agent 二进制文件
+// 启用安装令牌时需要 ?mac=&token= 参数；令牌只校验不标记使用，下载中断后可通过 Range 续传
 func (h *Handler) GetAgentBinary(c *gin.Context) {
+	if !h.verifyInstallToken(c, c.Query("mac"), model.INSTALL_RESOURCE_AGENT) {
+		return
+	}
+
 	// 返回 ARM64 架构的 Agent 二进制文件
 	// 路径相对于项目根目录
 	agentPath := "bin/nodefoundry-agent-arm64"
 
-	// 检查文件是否存在
-	if _, err := os.Stat(agentPath); os.IsNotExist(err) {
+	file, err := os.Open(agentPath)
+	if os.IsNotExist(err) {
 		h.logger.Error("agent binary not found", zap.String("path", agentPath))
-		errorResponse(c, http.StatusNotFound, "agent binary not found")
+		errorResponse(c, http.StatusNotFound, CodeNotFound, "agent binary not found")
 		return
 	}
+	if err != nil {
+		h.logger.Error("failed to open agent binary", zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to read agent binary")
+		return
+	}
+	defer file.Close()
 
-	// 读取文件
-	data, err := os.ReadFile(agentPath)
+	info, err := file.Stat()
 	if err != nil {
-		h.logger.Error("failed to read agent binary", zap.Error(err))
-		errorResponse(c, http.StatusInternalServerError, "failed to read agent binary")
+		h.logger.Error("failed to stat agent binary", zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to read agent binary")
 		return
 	}
 
-	// 设置响应头
-	c.Header("Content-Type", "application/octet-stream")
+	// 直接从文件流式发送，不将整个二进制读入内存
+	c.Header("Content-Type", contentOctetStream)
 	c.Header("Content-Disposition", "attachment; filename=nodefoundry-agent")
-	c.Data(http.StatusOK, "application/octet-stream", data)
+	http.ServeContent(c.Writer, c.Request, "nodefoundry-agent", info.ModTime(), file)
 
-	h.logger.Debug("agent binary downloaded", zap.Int("size", len(data)))
+	h.logger.Debug("agent binary downloaded", zap.Int64("size", info.Size()))
 }
 
 // GetAgentServiceFile 获取 systemd 服务文件
+// 启用安装令牌时需要 ?mac=&token= 参数
 func (h *Handler) GetAgentServiceFile(c *gin.Context) {
+	if !h.consumeInstallToken(c, c.Query("mac"), model.INSTALL_RESOURCE_AGENT_SERVICE) {
+		return
+	}
+
 	serviceFile := `[Unit]
 Description=NodeFoundry Agent
 After=network-online.target
@@ -275,6 +633,7 @@ ExecStart=/usr/local/bin/nodefoundry-agent
 Restart=always
 RestartSec=10
 EnvironmentFile=/etc/default/nodefoundry-agent
+StateDirectory=nodefoundry-agent
 
 [Install]
 WantedBy=multi-user.target
@@ -284,13 +643,13 @@ WantedBy=multi-user.target
 	c.String(http.StatusOK, serviceFile)
 }
 
-// HealthResponse 健康检查响应
+// HealthResponse 健康检查和存活检查响应
 type HealthResponse struct {
 	Status string `json:"status"`
 	Uptime string `json:"uptime"`
 }
 
-// HealthCheck 健康检查
+// HealthCheck 健康检查（旧版本接口，保持原有响应，不执行组件检查）
 func (h *Handler) HealthCheck(c *gin.Context) {
 	uptime := time.Since(h.startTime)
 	c.JSON(http.StatusOK, HealthResponse{
@@ -298,3 +657,32 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 		Uptime: uptime.String(),
 	})
 }
+
+// SetHealthChecker 设置组件健康检查
+func (h *Handler) SetHealthChecker(checker *health.Checker) {
+	h.health = checker
+}
+
+// HealthLive 存活检查：进程能够处理请求即返回 ok
+func (h *Handler) HealthLive(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthResponse{
+		Status: health.STATUS_OK,
+		Uptime: time.Since(h.startTime).Round(time.Second).String(),
+	})
+}
+
+// HealthReady 就绪检查：执行各组件检查，关键组件 down 时返回 503
+func (h *Handler) HealthReady(c *gin.Context) {
+	if h.health == nil {
+		h.HealthLive(c)
+		return
+	}
+
+	report := h.health.Run(c.Request.Context())
+
+	code := http.StatusOK
+	if report.Status == health.STATUS_DOWN {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// newTestHandler 创建使用临时数据库的处理器和已注册路由的 gin 引擎
+func newTestHandler(t testing.TB) (*Handler, *gin.Engine, db.NodeRepository) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	bdb, err := db.InitializeDB(filepath.Join(t.TempDir(), "nodes.db"), zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitializeDB: %v", err)
+	}
+	t.Cleanup(func() { bdb.Close() })
+
+	repo := db.NewBoltNodeRepository(bdb, zap.NewNop())
+	h := NewHandler(repo, nil, nil, zap.NewNop())
+	r := gin.New()
+	h.RegisterRoutes(r)
+	return h, r, repo
+}
+
+// saveNode 保存节点，mutate 可在保存前修改字段
+func saveNode(t testing.TB, repo db.NodeRepository, mac string, mutate func(node *model.Node)) *model.Node {
+	t.Helper()
+
+	node, err := model.NewNode(mac, model.STATE_DISCOVERED)
+	if err != nil {
+		t.Fatalf("NewNode: %v", err)
+	}
+	if mutate != nil {
+		mutate(node)
+	}
+	if err := repo.Save(context.Background(), node); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return node
+}
+
+// serve 发送请求，headers 为成对的头名称和值
+func serve(r http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// responseCode 解析错误响应中的错误码
+func responseCode(t testing.TB, w *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid error response %q: %v", w.Body.String(), err)
+	}
+	return resp.Code
+}
+
+func TestNodeIfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		ifMatch    string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "put stale", method: http.MethodPut, body: `{"action":"install"}`, ifMatch: `"0"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: CodePreconditionFailed},
+		{name: "put current", method: http.MethodPut, body: `{"action":"install"}`, ifMatch: `"1"`,
+			wantStatus: http.StatusOK},
+		// If-Match 使用强比较，只有弱 ETag 匹配时前置条件失败
+		{name: "put weak and list", method: http.MethodPut, body: `{"action":"install"}`, ifMatch: `"7", W/"1"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: CodePreconditionFailed},
+		{name: "put strong in list", method: http.MethodPut, body: `{"action":"install"}`, ifMatch: `W/"1", "1"`,
+			wantStatus: http.StatusOK},
+		{name: "patch stale", method: http.MethodPatch, body: `{"notes":"x"}`, ifMatch: `"2"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: CodePreconditionFailed},
+		{name: "patch any", method: http.MethodPatch, body: `{"notes":"x"}`, ifMatch: `*`,
+			wantStatus: http.StatusOK},
+		{name: "delete stale", method: http.MethodDelete, ifMatch: `"2"`,
+			wantStatus: http.StatusPreconditionFailed, wantCode: CodePreconditionFailed},
+		{name: "delete current", method: http.MethodDelete, ifMatch: `"1"`,
+			wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, r, repo := newTestHandler(t)
+			saveNode(t, repo, "aabbccddeeff", nil)
+
+			w := serve(r, tt.method, "/api/v1/nodes/aabbccddeeff", tt.body, "If-Match", tt.ifMatch)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := responseCode(t, w); code != tt.wantCode {
+					t.Errorf("code = %s, want %s", code, tt.wantCode)
+				}
+			}
+
+			// 前置条件失败时节点不能被修改
+			if tt.wantStatus == http.StatusPreconditionFailed {
+				node, err := repo.FindByMAC(context.Background(), "aabbccddeeff")
+				if err != nil {
+					t.Fatalf("FindByMAC: %v", err)
+				}
+				if node.ResourceVersion != 1 {
+					t.Errorf("version = %d after failed precondition, want 1", node.ResourceVersion)
+				}
+			}
+		})
+	}
+}
+
+func TestNodeETag(t *testing.T) {
+	_, r, repo := newTestHandler(t)
+	saveNode(t, repo, "aabbccddeeff", nil)
+
+	w := serve(r, http.MethodGet, "/api/v1/nodes/aabbccddeeff", "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("GET status = %d etag = %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	w = serve(r, http.MethodGet, "/api/v1/nodes/aabbccddeeff", "", "If-None-Match", `"1"`)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match current: status = %d, want 304", w.Code)
+	}
+
+	// If-None-Match 使用弱比较
+	w = serve(r, http.MethodGet, "/api/v1/nodes/aabbccddeeff", "", "If-None-Match", `W/"1"`)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match weak: status = %d, want 304", w.Code)
+	}
+
+	w = serve(r, http.MethodPut, "/api/v1/nodes/aabbccddeeff", `{"action":"install"}`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("PUT status = %d etag = %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	w = serve(r, http.MethodGet, "/api/v1/nodes/aabbccddeeff", "", "If-None-Match", `"1"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("If-None-Match stale: status = %d, want 200", w.Code)
+	}
+}
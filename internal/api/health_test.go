@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/health"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	h, r, _ := newTestHandler(t)
+	checker := health.NewChecker(time.Now())
+	checker.Register("database", true, func(ctx context.Context) health.Result {
+		return health.Down("unavailable", nil)
+	})
+	h.SetHealthChecker(checker)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		// /health 保持旧版本响应，不受组件检查影响
+		{path: "/health", wantStatus: http.StatusOK, wantBody: health.STATUS_OK},
+		{path: "/health/live", wantStatus: http.StatusOK, wantBody: health.STATUS_OK},
+		{path: "/health/ready", wantStatus: http.StatusServiceUnavailable, wantBody: health.STATUS_DOWN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := serve(r, http.MethodGet, tt.path, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["status"] != tt.wantBody {
+				t.Errorf("status field = %v, want %s", body["status"], tt.wantBody)
+			}
+			if _, ok := body["uptime"]; !ok {
+				t.Errorf("uptime missing: %s", w.Body.String())
+			}
+		})
+	}
+}
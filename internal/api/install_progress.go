@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// ReportInstallProgress 安装器上报安装进度（preseed early_command/late_command、cloud-init bootcmd/phone_home）
+// 仅 installing 节点可上报；finished 将节点转换为 installed，failed 转换为 failed
+// 错误信息通过表单或查询参数 message 传递，安装令牌只校验不标记使用
+func (h *Handler) ReportInstallProgress(c *gin.Context) {
+	mac := c.Param("mac")
+	stage := path.Base(c.FullPath())
+	message := c.PostForm("message")
+	if message == "" {
+		message = c.Query("message")
+	}
+
+	node, err := h.repo.FindByMAC(c.Request.Context(), mac)
+	if err != nil {
+		if db.IsNodeNotFound(err) {
+			h.metrics.InstallProgress(stage, "not_found")
+		}
+		h.writeError(c, err, "failed to find node")
+		return
+	}
+	if node.Status != model.STATE_INSTALLING {
+		h.metrics.InstallProgress(stage, "not_installing")
+		h.logger.Warn("install progress reported for node that is not installing",
+			zap.String("mac", node.MAC),
+			zap.String("stage", stage),
+			zap.String("status", node.Status),
+			zap.String("remote", c.RemoteIP()),
+		)
+		errorResponse(c, http.StatusForbidden, CodeNodeNotInstalling, "node is not installing")
+		return
+	}
+
+	if !h.verifyInstallToken(c, node.MAC, model.INSTALL_RESOURCE_PROGRESS) {
+		h.metrics.InstallProgress(stage, "denied")
+		return
+	}
+
+	from := ""
+	node, err = db.UpdateWithRetry(c.Request.Context(), h.repo, node.MAC, func(node *model.Node) error {
+		from = node.Status
+		if err := node.ApplyInstallProgress(stage, message); err != nil {
+			// 读取节点后状态被并发修改（如 agent 已上报 installed）
+			return &apiError{status: http.StatusForbidden, code: CodeNodeNotInstalling, message: err.Error()}
+		}
+		return nil
+	})
+	if err != nil {
+		h.writeError(c, err, "failed to record install progress")
+		return
+	}
+	h.metrics.InstallProgress(stage, "recorded")
+
+	data := map[string]interface{}{"stage": stage}
+	if node.InstallError != "" && stage == model.INSTALL_STAGE_FAILED {
+		data["message"] = node.InstallError
+	}
+	h.events.Publish(events.NodeEvent(events.EVENT_NODE_INSTALL_PROGRESS, node, data))
+
+	logFields := []zap.Field{
+		zap.String("mac", node.MAC),
+		zap.String("stage", stage),
+		zap.String("remote", c.RemoteIP()),
+	}
+	if from != node.Status {
+		h.events.Publish(events.StatusChanged(node, from, "installer"))
+		// 安装结束，令牌不再需要
+		h.revokeInstallToken(node.MAC)
+		logFields = append(logFields, zap.String("status", node.Status))
+	}
+	if node.Status == model.STATE_FAILED {
+		h.logger.Warn("installer reported failure", append(logFields, zap.String("error", node.InstallError))...)
+	} else {
+		h.logger.Info("install progress reported", logFields...)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// installProgressRoutes 安装进度上报路由（每个进度一个路由，共用处理器）
+func (h *Handler) installProgressRoutes() []apiRoute {
+	summaries := map[string]string{
+		model.INSTALL_STAGE_STARTED:     "安装器上报：安装开始",
+		model.INSTALL_STAGE_PARTITIONED: "安装器上报：分区完成",
+		model.INSTALL_STAGE_FINISHED:    "安装器上报：安装完成，节点转换为 installed",
+		model.INSTALL_STAGE_FAILED:      "安装器上报：安装失败，节点转换为 failed",
+	}
+
+	routes := make([]apiRoute, 0, len(model.InstallStages))
+	for _, stage := range model.InstallStages {
+		routes = append(routes, apiRoute{
+			method: http.MethodPost, path: "/install/:mac/" + stage, group: groupNode, handler: h.ReportInstallProgress, plain: true,
+			tag: "provisioning", summary: summaries[stage] + "（仅 installing 节点）",
+			params: []apiParam{
+				{name: "token", in: "query", typ: "string", description: "安装令牌（启用安装令牌时必填，可重复使用）"},
+				{name: "message", in: "query", typ: "string", description: "错误信息（也可以通过表单字段传递）"},
+			},
+			status: http.StatusNoContent,
+			errors: []int{http.StatusForbidden, http.StatusNotFound},
+		})
+	}
+	return routes
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// SetInstallTokens 设置安装令牌存储，preseed 和 agent 下载需要携带一次性令牌
+func (h *Handler) SetInstallTokens(tokens db.InstallTokenRepository) {
+	h.installTokens = tokens
+}
+
+// consumeInstallToken 校验请求携带的安装令牌（?token=）并标记资源已使用
+// 校验失败时写入响应并返回 false
+func (h *Handler) consumeInstallToken(c *gin.Context, mac, resource string) bool {
+	if h.installTokens == nil {
+		return true
+	}
+
+	err := h.installTokens.Consume(c.Request.Context(), mac, resource, c.Query("token"), time.Now())
+	return h.installTokenAccepted(c, mac, resource, err)
+}
+
+// verifyInstallToken 校验请求携带的安装令牌（?token=），不标记使用
+// 校验失败时写入响应并返回 false
+func (h *Handler) verifyInstallToken(c *gin.Context, mac, resource string) bool {
+	if h.installTokens == nil {
+		return true
+	}
+
+	err := h.installTokens.Verify(c.Request.Context(), mac, resource, c.Query("token"), time.Now())
+	return h.installTokenAccepted(c, mac, resource, err)
+}
+
+// installTokenAccepted 处理令牌校验结果，失败时写入响应
+func (h *Handler) installTokenAccepted(c *gin.Context, mac, resource string, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	if db.IsInstallTokenRejected(err) {
+		h.logger.Warn("install token rejected",
+			zap.String("mac", model.NormalizeMAC(mac)),
+			zap.String("resource", resource),
+			zap.String("remote", c.RemoteIP()),
+			zap.Error(err),
+		)
+		errorResponse(c, http.StatusForbidden, CodeInstallTokenInvalid, "invalid or expired install token")
+		return false
+	}
+
+	h.logger.Error("failed to verify install token",
+		zap.String("mac", mac),
+		zap.String("resource", resource),
+		zap.Error(err),
+	)
+	errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to verify install token")
+	return false
+}
+
+// revokeInstallToken 删除节点的安装令牌（节点删除时调用）
+func (h *Handler) revokeInstallToken(mac string) {
+	if h.installTokens == nil {
+		return
+	}
+	if err := h.installTokens.Revoke(context.Background(), mac); err != nil {
+		h.logger.Warn("failed to revoke install token", zap.String("mac", mac), zap.Error(err))
+	}
+}
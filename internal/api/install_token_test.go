@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/auth"
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/ipxe"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// setTestInstallTokens 为处理器启用基于临时数据库的安装令牌
+func setTestInstallTokens(t *testing.T, h *Handler) *db.BoltInstallTokenRepository {
+	t.Helper()
+
+	bdb, err := db.InitializeDB(filepath.Join(t.TempDir(), "tokens.db"), zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitializeDB: %v", err)
+	}
+	t.Cleanup(func() { bdb.Close() })
+
+	tokens := db.NewBoltInstallTokenRepository(bdb, zap.NewNop())
+	h.SetInstallTokens(tokens)
+	return tokens
+}
+
+// issueTestInstallToken 启用安装令牌并为节点签发令牌
+func issueTestInstallToken(t *testing.T, h *Handler, mac string) string {
+	t.Helper()
+
+	token, err := auth.NewInstallToken(mac, time.Now(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setTestInstallTokens(t, h).Issue(context.Background(), token); err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	return token.Token
+}
+
+func TestGetAgentBinaryInstallToken(t *testing.T) {
+	h, r, _ := newTestHandler(t)
+	token := issueTestInstallToken(t, h, "aabbccddeeff")
+	url := "/agent/nodefoundry-agent?mac=aabbccddeeff&token=" + token
+
+	// agent 二进制相对工作目录读取
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	// 二进制缺失不消耗令牌
+	if w := serve(r, http.MethodGet, url, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("missing binary status = %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bin", "nodefoundry-agent-arm64"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(r, http.MethodGet, url, "")
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" {
+		t.Fatalf("download status = %d body = %q", w.Code, w.Body.String())
+	}
+
+	// 中断后续传，令牌仍然有效
+	for i := 0; i < 2; i++ {
+		w = serve(r, http.MethodGet, url, "", "Range", "bytes=4-")
+		if w.Code != http.StatusPartialContent || w.Body.String() != "456789" {
+			t.Fatalf("range status = %d body = %q", w.Code, w.Body.String())
+		}
+	}
+
+	w = serve(r, http.MethodGet, "/agent/nodefoundry-agent?mac=aabbccddeeff&token=nfi_wrong", "")
+	if w.Code != http.StatusForbidden || responseCode(t, w) != CodeInstallTokenInvalid {
+		t.Errorf("wrong token status = %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGetAgentServiceFileSingleUse(t *testing.T) {
+	h, r, _ := newTestHandler(t)
+	token := issueTestInstallToken(t, h, "aabbccddeeff")
+	url := "/agent/nodefoundry-agent.service?mac=aabbccddeeff&token=" + token
+
+	if w := serve(r, http.MethodGet, url, ""); w.Code != http.StatusOK {
+		t.Fatalf("first download status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(r, http.MethodGet, url, ""); w.Code != http.StatusForbidden {
+		t.Errorf("second download status = %d, want 403", w.Code)
+	}
+}
+
+// installTokenPattern iPXE 脚本中的安装令牌
+var installTokenPattern = regexp.MustCompile(`token=(nfi_[A-Za-z0-9_-]+)`)
+
+func TestBootScriptInstallToken(t *testing.T) {
+	_, nodeNet, _ := net.ParseCIDR("10.0.0.0/24")
+
+	_, _, repo := newTestHandler(t)
+	h := NewHandler(repo, ipxe.NewGenerator("10.0.0.1:8080", "", repo, zap.NewNop()), nil, zap.NewNop())
+	tokens := setTestInstallTokens(t, h)
+	h.ipxeGen.SetInstallTokens(tokens, time.Hour)
+	h.SetNodeAllowedNetworks([]*net.IPNet{nodeNet})
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	saveNode(t, repo, "aabbccddeeff", func(node *model.Node) {
+		node.Status = model.STATE_INSTALLING
+		node.InstallStartedAt = time.Now()
+	})
+
+	// fetch 从指定来源地址获取引导脚本，返回脚本中的安装令牌
+	fetch := func(remote string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/boot/aabbccddeeff/boot.ipxe", nil)
+		req.RemoteAddr = remote + ":40000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		match := installTokenPattern.FindStringSubmatch(w.Body.String())
+		if match == nil {
+			return w.Code, ""
+		}
+		return w.Code, match[1]
+	}
+	verify := func(token string) error {
+		return tokens.Verify(context.Background(), "aabbccddeeff", model.INSTALL_RESOURCE_PRESEED, token, time.Now())
+	}
+
+	// 允许网段之外的请求被拒绝，不签发令牌
+	if code, token := fetch("192.168.1.20"); code != http.StatusForbidden || token != "" {
+		t.Fatalf("outside request status = %d token = %q, want 403 without token", code, token)
+	}
+	if err := verify("nfi_any"); !db.IsInstallTokenRejected(err) {
+		t.Fatalf("token issued for rejected request: %v", err)
+	}
+
+	code, first := fetch("10.0.0.5")
+	if code != http.StatusOK || first == "" {
+		t.Fatalf("node request status = %d token = %q", code, first)
+	}
+	if err := verify(first); err != nil {
+		t.Fatalf("issued token rejected: %v", err)
+	}
+
+	// 再次获取脚本签发新令牌，之前的令牌失效
+	_, second := fetch("10.0.0.5")
+	if second == "" || second == first {
+		t.Fatalf("second token = %q, want a new token", second)
+	}
+	if err := verify(first); !db.IsInstallTokenRejected(err) {
+		t.Errorf("previous token still valid: %v", err)
+	}
+	if err := verify(second); err != nil {
+		t.Errorf("new token rejected: %v", err)
+	}
+}
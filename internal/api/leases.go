@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/dhcp"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// LeasePool DHCP 地址池（查看和释放租约）
+type LeasePool interface {
+	LeaseReleaser
+	Leases() []dhcp.Lease
+	Stats() dhcp.PoolStats
+	Subnet() string
+}
+
+// LeaseResponse DHCP 租约
+type LeaseResponse struct {
+	MAC       string    `json:"mac"`
+	IP        string    `json:"ip"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Expired 已过期但尚未释放（仍占用地址）
+	Expired bool `json:"expired"`
+}
+
+// LeaseListResponse 地址池及其租约
+type LeaseListResponse struct {
+	Subnet string          `json:"subnet"`
+	Stats  dhcp.PoolStats  `json:"stats"`
+	Leases []LeaseResponse `json:"leases"`
+}
+
+// SetLeasePool 设置 DHCP 地址池（同时用于删除节点时释放租约）
+func (h *Handler) SetLeasePool(pool LeasePool) {
+	h.leasePool = pool
+	h.leases = pool
+}
+
+// ListLeases 列出 DHCP 租约
+func (h *Handler) ListLeases(c *gin.Context) {
+	if !h.leasePoolEnabled(c) {
+		return
+	}
+
+	now := time.Now()
+	leases := h.leasePool.Leases()
+	resp := LeaseListResponse{
+		Subnet: h.leasePool.Subnet(),
+		Stats:  h.leasePool.Stats(),
+		Leases: make([]LeaseResponse, 0, len(leases)),
+	}
+	for _, lease := range leases {
+		resp.Leases = append(resp.Leases, LeaseResponse{
+			MAC:       model.NormalizeMAC(lease.MAC),
+			IP:        lease.IP.String(),
+			ExpiresAt: lease.ExpiresAt,
+			Expired:   now.After(lease.ExpiresAt),
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReleaseLease 释放节点持有的 DHCP 租约（节点下次请求时重新分配）
+func (h *Handler) ReleaseLease(c *gin.Context) {
+	if !h.leasePoolEnabled(c) {
+		return
+	}
+
+	mac := c.Param("mac")
+	if !model.IsValidMAC(mac) {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidMAC, "invalid MAC address format")
+		return
+	}
+
+	if err := h.leasePool.ReleaseByMAC(mac); err != nil {
+		if errors.Is(err, dhcp.ErrLeaseNotFound) {
+			errorResponse(c, http.StatusNotFound, CodeLeaseNotFound, "lease not found")
+			return
+		}
+		h.logger.Error("failed to release lease", zap.String("mac", mac), zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to release lease")
+		return
+	}
+
+	h.logger.Info("DHCP lease released", zap.String("mac", model.NormalizeMAC(mac)))
+	c.Status(http.StatusNoContent)
+}
+
+// leasePoolEnabled 检查是否配置了 DHCP 地址池
+func (h *Handler) leasePoolEnabled(c *gin.Context) bool {
+	if h.leasePool == nil {
+		errorResponse(c, http.StatusServiceUnavailable, CodeFeatureDisabled, "DHCP IP pool not configured")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 节点操作
+const (
+	ActionInstall   = "install"
+	ActionReinstall = "reinstall"
+	ActionReboot    = "reboot"
+	ActionLabel     = "label"
+	ActionDelete    = "delete"
+)
+
+// CommandPublisher 向节点 agent 下发命令
+type CommandPublisher interface {
+	PublishCommand(mac string, command string, args map[string]interface{}) error
+}
+
+// SetCommandPublisher 设置命令发布器（reboot、reinstall 需要）
+func (h *Handler) SetCommandPublisher(publisher CommandPublisher) {
+	h.commands = publisher
+}
+
+// installMutation 安装：discovered 节点和安装失败的节点可安装
+func installMutation(node *model.Node) error {
+	if node.Status != model.STATE_DISCOVERED && node.Status != model.STATE_FAILED {
+		return &apiError{
+			status: http.StatusConflict,
+			code:   CodeInvalidTransition,
+			message: fmt.Sprintf("cannot install node with status '%s', only 'discovered' or 'failed' nodes can be installed",
+				node.Status),
+			details: map[string]interface{}{"from": node.Status, "to": model.STATE_INSTALLING},
+		}
+	}
+	return node.StartInstall()
+}
+
+// reinstallMutation 重装：仅 installed 节点可重装
+func reinstallMutation(node *model.Node) error {
+	if node.Status != model.STATE_INSTALLED {
+		return &apiError{
+			status: http.StatusConflict,
+			code:   CodeInvalidTransition,
+			message: fmt.Sprintf("cannot reinstall node with status '%s', only 'installed' nodes can be reinstalled",
+				node.Status),
+			details: map[string]interface{}{"from": node.Status, "to": model.STATE_INSTALLING},
+		}
+	}
+	return node.StartInstall()
+}
+
+// requestReboot 通过 agent 重启节点，使其重新 PXE 引导
+func (h *Handler) requestReboot(mac string) error {
+	if h.commands == nil {
+		return &apiError{status: http.StatusServiceUnavailable, code: CodeFeatureDisabled, message: "command channel not available"}
+	}
+
+	if err := h.commands.PublishCommand(mac, "reboot", nil); err != nil {
+		h.logger.Error("failed to publish reboot command", zap.String("mac", mac), zap.Error(err))
+		return &apiError{status: http.StatusServiceUnavailable, code: CodeServiceUnavailable, message: "failed to send reboot command"}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 节点事件历史数量限制
+const (
+	defaultNodeEventLimit = 100
+	maxNodeEventLimit     = events.DefaultBufferSize
+)
+
+// BootScriptRecord 最近一次向节点提供的 iPXE 引导脚本
+type BootScriptRecord struct {
+	MAC string `json:"mac"`
+	// Status 提供脚本时的节点状态
+	Status   string    `json:"status"`
+	Script   string    `json:"script"`
+	ServedAt time.Time `json:"served_at"`
+	// RemoteAddr 请求来源地址
+	RemoteAddr string `json:"remote_addr"`
+}
+
+// recordBootScript 记录向节点提供的引导脚本（仅保存在内存中，每个节点保留最近一次）
+func (h *Handler) recordBootScript(c *gin.Context, node *model.Node, script string) {
+	h.bootScriptsMu.Lock()
+	defer h.bootScriptsMu.Unlock()
+
+	if h.bootScripts == nil {
+		h.bootScripts = make(map[string]*BootScriptRecord)
+	}
+	h.bootScripts[node.MAC] = &BootScriptRecord{
+		MAC:        node.MAC,
+		Status:     node.Status,
+		Script:     script,
+		ServedAt:   time.Now(),
+		RemoteAddr: c.RemoteIP(),
+	}
+}
+
+// forgetBootScript 删除节点的引导脚本记录
+func (h *Handler) forgetBootScript(mac string) {
+	h.bootScriptsMu.Lock()
+	defer h.bootScriptsMu.Unlock()
+	delete(h.bootScripts, mac)
+}
+
+// GetNodeBootScript 获取最近一次向节点提供的引导脚本（服务重启后清空）
+func (h *Handler) GetNodeBootScript(c *gin.Context) {
+	mac := c.Param("mac")
+	if !model.IsValidMAC(mac) {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidMAC, "invalid MAC address format")
+		return
+	}
+	mac = model.NormalizeMAC(mac)
+
+	h.bootScriptsMu.Lock()
+	record := h.bootScripts[mac]
+	h.bootScriptsMu.Unlock()
+
+	if record == nil {
+		errorResponse(c, http.StatusNotFound, CodeNotFound, "no boot script has been served to this node since server start")
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// ListNodeEvents 节点的最近事件（按时间升序，来自事件流缓冲区，服务重启后清空）
+// 已删除节点的事件仍可查询，直到被新事件挤出缓冲区
+func (h *Handler) ListNodeEvents(c *gin.Context) {
+	if h.events == nil {
+		errorResponse(c, http.StatusServiceUnavailable, CodeFeatureDisabled, "event stream not available")
+		return
+	}
+
+	mac := c.Param("mac")
+	if !model.IsValidMAC(mac) {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidMAC, "invalid MAC address format")
+		return
+	}
+
+	limit := defaultNodeEventLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxNodeEventLimit {
+			errorResponse(c, http.StatusBadRequest, CodeInvalidRequest,
+				fmt.Sprintf("limit must be between 1 and %d", maxNodeEventLimit))
+			return
+		}
+		limit = n
+	}
+
+	filter := &events.Filter{MACs: map[string]bool{model.NormalizeMAC(mac): true}}
+	recent := h.events.Recent(filter, limit)
+	if recent == nil {
+		recent = []events.Event{}
+	}
+	c.JSON(http.StatusOK, recent)
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// MaxNotesLength 备注最大长度
+const MaxNotesLength = 4096
+
+// nodePatch JSON Merge Patch（RFC 7386）中可编辑的节点字段
+// 字段值为 JSON null 表示清除该字段；labels 按键合并，键值为 null 表示删除该标签
+type nodePatch map[string]json.RawMessage
+
+// NodePatchRequest PATCH 请求体的结构，用于 OpenAPI 文档和请求校验
+// 处理器按 nodePatch 解析以区分省略的字段和 null
+type NodePatchRequest struct {
+	Hostname *string `json:"hostname,omitempty"`
+	IP       *string `json:"ip,omitempty"`
+	Netmask  *string `json:"netmask,omitempty"`
+	Gateway  *string `json:"gateway,omitempty"`
+	DNS      *string `json:"dns,omitempty"`
+	Notes    *string `json:"notes,omitempty"`
+	// Labels 按键合并，值为 null 表示删除该标签
+	Labels map[string]*string `json:"labels,omitempty"`
+}
+
+// 网络配置字段，修改任一字段后整体校验并固定为静态网络
+var networkFields = []string{"ip", "netmask", "gateway", "dns"}
+
+// 可通过 PATCH 修改的字段
+var patchableFields = map[string]bool{
+	"hostname": true,
+	"ip":       true,
+	"netmask":  true,
+	"gateway":  true,
+	"dns":      true,
+	"labels":   true,
+	"notes":    true,
+}
+
+// fields 返回 patch 修改的字段名（已排序）
+func (p nodePatch) fields() []string {
+	fields := make([]string, 0, len(p))
+	for field := range p {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// parseNodePatch 解析并校验 merge patch 请求体
+func parseNodePatch(body []byte) (nodePatch, error) {
+	var patch nodePatch
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		return nil, fmt.Errorf("request body must be a JSON object")
+	}
+
+	for field := range patch {
+		if !patchableFields[field] {
+			return nil, fmt.Errorf("field %q cannot be modified", field)
+		}
+	}
+
+	// 预先在空节点上应用一次，提前发现格式错误（网络配置的完整性在合并到节点后校验）
+	if err := patch.applyFields(&model.Node{}); err != nil {
+		return nil, err
+	}
+
+	return patch, nil
+}
+
+// apply 将 patch 应用到节点并校验结果
+// 修改网络配置时校验合并后的完整配置：设置 ip 后节点固定为静态网络（DHCP 不再覆盖），清除 ip 后恢复由 DHCP 分配
+func (p nodePatch) apply(node *model.Node) error {
+	if err := p.applyFields(node); err != nil {
+		return err
+	}
+
+	// 清除 ip 时一并清除未在 patch 中指定的其他网络字段，之后由 DHCP 重新分配
+	if _, ok := p["ip"]; ok && node.IP == "" {
+		for field, target := range map[string]*string{"netmask": &node.Netmask, "gateway": &node.Gateway, "dns": &node.DNS} {
+			if _, set := p[field]; !set {
+				*target = ""
+			}
+		}
+	}
+
+	// 只在修改网络配置时校验，不影响其他字段的编辑
+	if p.touchesNetwork() {
+		if err := model.ValidateNetworkConfig(node.IP, node.Netmask, node.Gateway, node.DNS); err != nil {
+			return err
+		}
+		node.StaticNetwork = node.IP != ""
+	}
+
+	return nil
+}
+
+// applyFields 逐个应用字段并校验字段格式
+func (p nodePatch) applyFields(node *model.Node) error {
+	for field, raw := range p {
+		var err error
+		switch field {
+		case "hostname":
+			err = patchString(raw, &node.Hostname, func(v string) error {
+				if !model.IsValidHostname(v) {
+					return fmt.Errorf("invalid hostname: %s", v)
+				}
+				return nil
+			})
+		case "ip", "gateway":
+			target := &node.IP
+			if field == "gateway" {
+				target = &node.Gateway
+			}
+			err = patchString(raw, target, func(v string) error {
+				if ip := net.ParseIP(v); ip == nil || ip.To4() == nil {
+					return fmt.Errorf("invalid %s: %s", field, v)
+				}
+				return nil
+			})
+		case "netmask":
+			err = patchString(raw, &node.Netmask, func(v string) error {
+				_, err := model.ParseNetmask(v)
+				return err
+			})
+		case "dns":
+			err = patchString(raw, &node.DNS, func(v string) error {
+				for _, server := range strings.Split(v, ",") {
+					if net.ParseIP(strings.TrimSpace(server)) == nil {
+						return fmt.Errorf("invalid dns server: %s", server)
+					}
+				}
+				return nil
+			})
+		case "notes":
+			err = patchString(raw, &node.Notes, func(v string) error {
+				if len(v) > MaxNotesLength {
+					return fmt.Errorf("notes must not exceed %d bytes", MaxNotesLength)
+				}
+				return nil
+			})
+		case "labels":
+			err = patchLabels(raw, node)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// touchesNetwork 判断 patch 是否修改网络配置
+func (p nodePatch) touchesNetwork() bool {
+	for _, field := range networkFields {
+		if _, ok := p[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// patchString 应用字符串字段，null 清除字段，非空值先经过 validate 校验
+func patchString(raw json.RawMessage, target *string, validate func(string) error) error {
+	if isJSONNull(raw) {
+		*target = ""
+		return nil
+	}
+
+	var v string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return fmt.Errorf("expected string value")
+	}
+
+	if v != "" {
+		if err := validate(v); err != nil {
+			return err
+		}
+	}
+
+	*target = v
+	return nil
+}
+
+// patchLabels 按 merge patch 语义合并标签
+func patchLabels(raw json.RawMessage, node *model.Node) error {
+	if isJSONNull(raw) {
+		node.Labels = nil
+		return nil
+	}
+
+	var changes map[string]*string
+	if err := json.Unmarshal(raw, &changes); err != nil {
+		return fmt.Errorf("labels must be an object of string values")
+	}
+
+	labels := make(map[string]string, len(node.Labels)+len(changes))
+	for k, v := range node.Labels {
+		labels[k] = v
+	}
+	for k, v := range changes {
+		if v == nil {
+			delete(labels, k)
+			continue
+		}
+		labels[k] = *v
+	}
+
+	if err := model.ValidateLabels(labels); err != nil {
+		return err
+	}
+
+	if len(labels) == 0 {
+		labels = nil
+	}
+	node.Labels = labels
+	return nil
+}
+
+// isJSONNull 判断 JSON 值是否为 null
+func isJSONNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// networkConfig 节点网络配置字段，便于整体比较
+type networkConfig struct {
+	IP, Netmask, Gateway, DNS string
+	Static                    bool
+}
+
+func TestPatchNodeNetwork(t *testing.T) {
+	// dhcp 为地址池分配的网络配置
+	dhcp := func(node *model.Node) {
+		node.IP = "10.0.0.100"
+		node.Netmask = "255.255.255.0"
+		node.Gateway = "10.0.0.1"
+		node.DNS = "10.0.0.53"
+	}
+
+	tests := []struct {
+		name       string
+		existing   func(node *model.Node)
+		patch      string
+		wantStatus int
+		want       networkConfig
+	}{
+		{name: "pin full config", patch: `{"ip":"192.168.1.50","netmask":"255.255.255.0","gateway":"192.168.1.1","dns":"8.8.8.8"}`,
+			wantStatus: http.StatusOK,
+			want:       networkConfig{"192.168.1.50", "255.255.255.0", "192.168.1.1", "8.8.8.8", true}},
+		{name: "change ip of dhcp node", existing: dhcp, patch: `{"ip":"10.0.0.20"}`,
+			wantStatus: http.StatusOK,
+			want:       networkConfig{"10.0.0.20", "255.255.255.0", "10.0.0.1", "10.0.0.53", true}},
+		{name: "clear ip returns to dhcp", existing: dhcp, patch: `{"ip":null}`,
+			wantStatus: http.StatusOK, want: networkConfig{}},
+		{name: "ip without netmask", patch: `{"ip":"192.168.1.50"}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "netmask without ip", patch: `{"netmask":"255.255.255.0"}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "gateway without ip", patch: `{"gateway":"192.168.1.1"}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "clear netmask keeps ip", existing: dhcp, patch: `{"netmask":null}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "gateway outside subnet", patch: `{"ip":"192.168.1.50","netmask":"255.255.255.0","gateway":"192.168.2.1"}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "gateway outside existing subnet", existing: dhcp, patch: `{"gateway":"10.0.1.1"}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "invalid netmask", patch: `{"ip":"192.168.1.50","netmask":"255.0.255.0"}`,
+			wantStatus: http.StatusBadRequest},
+		{name: "other fields skip network check", existing: func(node *model.Node) { node.IP = "10.0.0.9" },
+			patch: `{"notes":"legacy"}`, wantStatus: http.StatusOK,
+			want: networkConfig{IP: "10.0.0.9"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, r, repo := newTestHandler(t)
+			saveNode(t, repo, "aabbccddeeff", tt.existing)
+
+			w := serve(r, http.MethodPatch, "/api/v1/nodes/aabbccddeeff", tt.patch)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			node, _ := repo.FindByMAC(context.Background(), "aabbccddeeff")
+			got := networkConfig{node.IP, node.Netmask, node.Gateway, node.DNS, node.StaticNetwork}
+			if got != tt.want {
+				t.Errorf("network = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterNodeNetwork(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantStatic bool
+	}{
+		{name: "mac only", body: `{"mac":"aa:bb:cc:dd:ee:ff"}`, wantStatus: http.StatusCreated},
+		{name: "static", body: `{"mac":"aa:bb:cc:dd:ee:ff","ip":"10.0.0.5","netmask":"255.255.255.0","gateway":"10.0.0.1"}`,
+			wantStatus: http.StatusCreated, wantStatic: true},
+		{name: "ip without netmask", body: `{"mac":"aa:bb:cc:dd:ee:ff","ip":"10.0.0.5"}`, wantStatus: http.StatusBadRequest},
+		{name: "gateway without ip", body: `{"mac":"aa:bb:cc:dd:ee:ff","gateway":"10.0.0.1"}`, wantStatus: http.StatusBadRequest},
+		{name: "gateway outside subnet", body: `{"mac":"aa:bb:cc:dd:ee:ff","ip":"10.0.0.5","netmask":"255.255.255.0","gateway":"10.0.1.1"}`,
+			wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, r, repo := newTestHandler(t)
+
+			w := serve(r, http.MethodPost, "/api/v1/nodes", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code != http.StatusCreated {
+				if _, err := repo.FindByMAC(context.Background(), "aabbccddeeff"); err == nil {
+					t.Error("node created by rejected request")
+				}
+				return
+			}
+			node, _ := repo.FindByMAC(context.Background(), "aabbccddeeff")
+			if node.StaticNetwork != tt.wantStatic {
+				t.Errorf("StaticNetwork = %v, want %v", node.StaticNetwork, tt.wantStatic)
+			}
+		})
+	}
+}
@@ -0,0 +1,425 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 分页限制
+const (
+	// MaxListLimit 单页最大节点数
+	MaxListLimit = 1000
+)
+
+// sortKeyFuncs 可排序字段及其排序键（字符串按字典序比较即可得到正确顺序）
+var sortKeyFuncs = map[string]func(node *model.Node) string{
+	"mac":      func(n *model.Node) string { return n.MAC },
+	"hostname": func(n *model.Node) string { return n.Hostname },
+	"status":   func(n *model.Node) string { return n.Status },
+	"ip":       func(n *model.Node) string { return ipSortKey(n.IP) },
+	"created_at": func(n *model.Node) string {
+		return timeSortKey(n.CreatedAt)
+	},
+	"updated_at": func(n *model.Node) string {
+		return timeSortKey(n.UpdatedAt)
+	},
+	"last_heartbeat": func(n *model.Node) string {
+		return timeSortKey(n.LastHeartbeat)
+	},
+}
+
+// nodeFields 节点 JSON 字段名（fields 参数可选的字段）
+var nodeFields = jsonFieldNames(reflect.TypeOf(model.Node{}))
+
+// sortField 排序字段
+type sortField struct {
+	name string
+	desc bool
+}
+
+// nodeQuery 节点列表查询参数
+type nodeQuery struct {
+	statuses     map[string]bool
+	labels       *model.LabelSelector
+	ipNet        *net.IPNet
+	hostnameGlob string
+	maxAge       time.Duration
+	minAge       time.Duration
+	sort         []sortField
+	fields       []string
+	limit        int
+	cursor       *listCursor
+}
+
+// listCursor 分页游标：上一页最后一个节点的排序键
+type listCursor struct {
+	Keys []string `json:"k"`
+	MAC  string   `json:"m"`
+}
+
+// parseNodeQuery 解析 GET /api/v1/nodes 查询参数
+//
+//	status=installed,installing      按状态过滤
+//	label=rack=a1,!maintenance       标签选择器
+//	ip=192.168.1.10 / 10.0.0.0/24    按 IP 或网段过滤
+//	hostname=edge-*                  主机名通配符
+//	heartbeat_max_age=5m             最近 5 分钟内有心跳
+//	heartbeat_min_age=10m            超过 10 分钟无心跳（含从未上报）
+//	sort=-created_at,hostname        排序字段，- 表示降序
+//	fields=mac,status,ip             仅返回指定字段
+//	limit=50&cursor=...              游标分页
+func parseNodeQuery(query url.Values) (*nodeQuery, error) {
+	q := &nodeQuery{}
+
+	if v := query.Get("status"); v != "" {
+		q.statuses = make(map[string]bool)
+		for _, status := range splitList(v) {
+			if !model.IsValidStatus(status) {
+				return nil, fmt.Errorf("invalid status: %s", status)
+			}
+			q.statuses[status] = true
+		}
+	}
+
+	if v := query.Get("label"); v != "" {
+		selector, err := model.ParseLabelSelector(v)
+		if err != nil {
+			return nil, err
+		}
+		q.labels = selector
+	}
+
+	if v := query.Get("ip"); v != "" {
+		ipNet, err := parseIPOrCIDR(v)
+		if err != nil {
+			return nil, err
+		}
+		q.ipNet = ipNet
+	}
+
+	if v := query.Get("hostname"); v != "" {
+		if _, err := path.Match(v, ""); err != nil {
+			return nil, fmt.Errorf("invalid hostname pattern: %s", v)
+		}
+		q.hostnameGlob = v
+	}
+
+	var err error
+	if q.maxAge, err = parseDurationParam(query, "heartbeat_max_age"); err != nil {
+		return nil, err
+	}
+	if q.minAge, err = parseDurationParam(query, "heartbeat_min_age"); err != nil {
+		return nil, err
+	}
+
+	// 默认按 CreatedAt 降序（与 repository 保持一致）
+	q.sort = []sortField{{name: "created_at", desc: true}}
+	if v := query.Get("sort"); v != "" {
+		q.sort = nil
+		for _, name := range splitList(v) {
+			field := sortField{name: strings.TrimPrefix(name, "-"), desc: strings.HasPrefix(name, "-")}
+			if _, ok := sortKeyFuncs[field.name]; !ok {
+				return nil, fmt.Errorf("invalid sort field: %s", field.name)
+			}
+			q.sort = append(q.sort, field)
+		}
+	}
+
+	if v := query.Get("fields"); v != "" {
+		q.fields = splitList(v)
+		for _, field := range q.fields {
+			if !nodeFields[field] {
+				return nil, fmt.Errorf("invalid field: %s", field)
+			}
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxListLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", MaxListLimit)
+		}
+		q.limit = limit
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(cursor.Keys) != len(q.sort) {
+			return nil, errors.New("cursor does not match sort order")
+		}
+		if !model.IsValidMAC(cursor.MAC) {
+			return nil, errors.New("invalid cursor")
+		}
+		q.cursor = cursor
+	}
+
+	return q, nil
+}
+
+// matches 判断节点是否满足过滤条件
+func (q *nodeQuery) matches(node *model.Node, now time.Time) bool {
+	if q.statuses != nil && !q.statuses[node.Status] {
+		return false
+	}
+
+	if !q.labels.Matches(node.Labels) {
+		return false
+	}
+
+	if q.ipNet != nil {
+		ip := net.ParseIP(node.IP)
+		if ip == nil || !q.ipNet.Contains(ip) {
+			return false
+		}
+	}
+
+	if q.hostnameGlob != "" {
+		if ok, _ := path.Match(q.hostnameGlob, node.Hostname); !ok {
+			return false
+		}
+	}
+
+	if q.maxAge > 0 {
+		if node.LastHeartbeat.IsZero() || now.Sub(node.LastHeartbeat) > q.maxAge {
+			return false
+		}
+	}
+
+	if q.minAge > 0 {
+		if !node.LastHeartbeat.IsZero() && now.Sub(node.LastHeartbeat) < q.minAge {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sortKeys 计算节点的排序键
+func (q *nodeQuery) sortKeys(node *model.Node) []string {
+	keys := make([]string, len(q.sort))
+	for i, field := range q.sort {
+		keys[i] = sortKeyFuncs[field.name](node)
+	}
+	return keys
+}
+
+// less 比较两个排序位置，MAC 升序作为最终排序依据以保证顺序稳定
+func (q *nodeQuery) less(aKeys []string, aMAC string, bKeys []string, bMAC string) bool {
+	for i, field := range q.sort {
+		if aKeys[i] == bKeys[i] {
+			continue
+		}
+		if field.desc {
+			return aKeys[i] > bKeys[i]
+		}
+		return aKeys[i] < bKeys[i]
+	}
+	return aMAC < bMAC
+}
+
+// nodePage 查询结果页
+type nodePage struct {
+	nodes      []*model.Node
+	total      int
+	nextCursor string
+}
+
+// apply 对节点列表执行过滤、排序和分页
+func (q *nodeQuery) apply(nodes []*model.Node, now time.Time) (*nodePage, error) {
+	type entry struct {
+		node *model.Node
+		keys []string
+	}
+
+	entries := make([]entry, 0, len(nodes))
+	for _, node := range nodes {
+		if q.matches(node, now) {
+			entries = append(entries, entry{node: node, keys: q.sortKeys(node)})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return q.less(entries[i].keys, entries[i].node.MAC, entries[j].keys, entries[j].node.MAC)
+	})
+
+	page := &nodePage{total: len(entries)}
+
+	// 跳过游标之前（含游标）的节点
+	start := 0
+	if q.cursor != nil {
+		start = sort.Search(len(entries), func(i int) bool {
+			return q.less(q.cursor.Keys, q.cursor.MAC, entries[i].keys, entries[i].node.MAC)
+		})
+	}
+
+	end := len(entries)
+	if q.limit > 0 && start+q.limit < end {
+		end = start + q.limit
+		last := entries[end-1]
+		cursor, err := encodeCursor(&listCursor{Keys: last.keys, MAC: last.node.MAC})
+		if err != nil {
+			return nil, err
+		}
+		page.nextCursor = cursor
+	}
+
+	page.nodes = make([]*model.Node, 0, end-start)
+	for _, e := range entries[start:end] {
+		page.nodes = append(page.nodes, e.node)
+	}
+
+	return page, nil
+}
+
+// project 按 fields 参数裁剪节点字段（始终包含 mac）
+// 请求的字段为空值（被 omitempty 省略）时输出 null，保证每个对象都包含请求的字段
+func (q *nodeQuery) project(nodes []*model.Node) ([]map[string]json.RawMessage, error) {
+	result := make([]map[string]json.RawMessage, 0, len(nodes))
+	for _, node := range nodes {
+		data, err := json.Marshal(node)
+		if err != nil {
+			return nil, err
+		}
+
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		selected := map[string]json.RawMessage{"mac": all["mac"]}
+		for _, field := range q.fields {
+			if v, ok := all[field]; ok {
+				selected[field] = v
+			} else {
+				selected[field] = json.RawMessage("null")
+			}
+		}
+		result = append(result, selected)
+	}
+	return result, nil
+}
+
+// jsonFieldNames 结构体的 JSON 字段名（忽略 json:"-" 字段）
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// encodeCursor 编码分页游标
+func encodeCursor(cursor *listCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 解码分页游标
+func decodeCursor(s string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// parseIPOrCIDR 解析单个 IP 或 CIDR 网段
+func parseIPOrCIDR(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR: %s", s)
+		}
+		return ipNet, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", s)
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return &net.IPNet{IP: ipv4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// parseDurationParam 解析时长查询参数（如 5m、1h）
+func parseDurationParam(query url.Values, name string) (time.Duration, error) {
+	v := query.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s: %s", name, v)
+	}
+	return d, nil
+}
+
+// splitList 拆分逗号分隔的参数列表
+func splitList(s string) []string {
+	parts := strings.Split(s, ",")
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+// ipSortKey IP 排序键（IPv4 按数值排序，无 IP 的节点排在最后）
+func ipSortKey(s string) string {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return "~"
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return fmt.Sprintf("4%010d", binary.BigEndian.Uint32(ipv4))
+	}
+	return "6" + ip.String()
+}
+
+// timeSortKey 时间排序键（零值排在最前）
+func timeSortKey(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z")
+}
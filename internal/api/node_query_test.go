@@ -0,0 +1,222 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// newListTestHandler 创建包含以下节点的处理器：
+//
+//	aabbccddee00 edge-0 10.0.0.10 installed  心跳 1 分钟前
+//	aabbccddee01 edge-1 10.0.0.11 installed  心跳 20 分钟前
+//	aabbccddee02 edge-2 10.0.1.12 discovered 无心跳
+//	aabbccddee03 core-3 10.0.1.13 discovered 无心跳
+//	aabbccddee04 core-4 (无 IP)   discovered 无心跳
+func newListTestHandler(t *testing.T) (*gin.Engine, db.NodeRepository) {
+	t.Helper()
+
+	_, r, repo := newTestHandler(t)
+	now := time.Now()
+	nodes := []struct {
+		hostname  string
+		ip        string
+		status    string
+		heartbeat time.Duration
+	}{
+		{"edge-0", "10.0.0.10", model.STATE_INSTALLED, time.Minute},
+		{"edge-1", "10.0.0.11", model.STATE_INSTALLED, 20 * time.Minute},
+		{"edge-2", "10.0.1.12", model.STATE_DISCOVERED, 0},
+		{"core-3", "10.0.1.13", model.STATE_DISCOVERED, 0},
+		{"core-4", "", model.STATE_DISCOVERED, 0},
+	}
+	for i, n := range nodes {
+		saveNode(t, repo, fmt.Sprintf("aabbccddee%02x", i), func(node *model.Node) {
+			node.Hostname = n.hostname
+			node.IP = n.ip
+			node.Status = n.status
+			if n.heartbeat > 0 {
+				node.LastHeartbeat = now.Add(-n.heartbeat)
+			}
+		})
+	}
+	return r, repo
+}
+
+// listMACs 解析节点列表响应中的 MAC
+func listMACs(t *testing.T, body []byte) []string {
+	t.Helper()
+
+	var nodes []map[string]interface{}
+	if err := json.Unmarshal(body, &nodes); err != nil {
+		t.Fatalf("invalid list response %q: %v", body, err)
+	}
+	macs := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		macs = append(macs, node["mac"].(string))
+	}
+	return macs
+}
+
+func TestListNodesFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "cidr", query: "ip=10.0.1.0/24&sort=mac", want: []string{"aabbccddee02", "aabbccddee03"}},
+		{name: "single ip", query: "ip=10.0.0.11", want: []string{"aabbccddee01"}},
+		{name: "hostname glob", query: "hostname=core-*&sort=mac", want: []string{"aabbccddee03", "aabbccddee04"}},
+		{name: "hostname class", query: "hostname=edge-[02]&sort=mac", want: []string{"aabbccddee00", "aabbccddee02"}},
+		{name: "heartbeat max age", query: "heartbeat_max_age=5m", want: []string{"aabbccddee00"}},
+		{name: "heartbeat max age wide", query: "heartbeat_max_age=30m&sort=mac", want: []string{"aabbccddee00", "aabbccddee01"}},
+		{name: "heartbeat min age includes never", query: "heartbeat_min_age=10m&sort=mac",
+			want: []string{"aabbccddee01", "aabbccddee02", "aabbccddee03", "aabbccddee04"}},
+		{name: "status and cidr", query: "status=installed&ip=10.0.0.0/16&sort=-mac", want: []string{"aabbccddee01", "aabbccddee00"}},
+		{name: "sort descending with limit", query: "sort=-hostname&ip=10.0.0.0/8&limit=2", want: []string{"aabbccddee02", "aabbccddee01"}},
+		{name: "no match", query: "hostname=gpu-*", want: []string{}},
+	}
+
+	r, _ := newListTestHandler(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodGet, "/api/v1/nodes?"+tt.query, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if got := listMACs(t, w.Body.Bytes()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nodes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListNodesTotalCount(t *testing.T) {
+	r, _ := newListTestHandler(t)
+
+	w := serve(r, http.MethodGet, "/api/v1/nodes?status=discovered&limit=1", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "3" {
+		t.Errorf("X-Total-Count = %q, want 3", got)
+	}
+	if len(listMACs(t, w.Body.Bytes())) != 1 {
+		t.Errorf("page size != 1: %s", w.Body.String())
+	}
+	if w.Header().Get("X-Next-Cursor") == "" || !strings.Contains(w.Header().Get("Link"), `rel="next"`) {
+		t.Errorf("missing next page headers: %v", w.Header())
+	}
+}
+
+func TestListNodesCursorStable(t *testing.T) {
+	r, repo := newListTestHandler(t)
+	// 追加更多同状态节点，使排序键大量相等
+	for i := 5; i < 12; i++ {
+		saveNode(t, repo, fmt.Sprintf("aabbccddee%02x", i), nil)
+	}
+
+	var pages [][]string
+	var all []string
+	query := "/api/v1/nodes?sort=status&status=discovered&limit=3"
+	next := query
+	for len(pages) < 10 {
+		w := serve(r, http.MethodGet, next, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		page := listMACs(t, w.Body.Bytes())
+		pages = append(pages, page)
+		all = append(all, page...)
+
+		cursor := w.Header().Get("X-Next-Cursor")
+		if cursor == "" {
+			break
+		}
+		next = query + "&cursor=" + cursor
+	}
+
+	want := []string{"aabbccddee02", "aabbccddee03", "aabbccddee04"}
+	for i := 5; i < 12; i++ {
+		want = append(want, fmt.Sprintf("aabbccddee%02x", i))
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("paged nodes = %v, want %v", all, want)
+	}
+	if len(pages) != 4 || len(pages[3]) != 1 {
+		t.Errorf("pages = %v, want 3+3+3+1", pages)
+	}
+}
+
+func TestListNodesFields(t *testing.T) {
+	r, _ := newListTestHandler(t)
+
+	w := serve(r, http.MethodGet, "/api/v1/nodes?sort=mac&hostname=core-*&fields=ip,hostname", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var nodes []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &nodes); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{
+		{"mac": "aabbccddee03", "hostname": "core-3", "ip": "10.0.1.13"},
+		// 未分配 IP 的节点保留请求的字段
+		{"mac": "aabbccddee04", "hostname": "core-4", "ip": nil},
+	}
+	if !reflect.DeepEqual(nodes, want) {
+		t.Errorf("nodes = %v, want %v", nodes, want)
+	}
+}
+
+func TestListNodesInvalidQuery(t *testing.T) {
+	otherSort, _ := encodeCursor(&listCursor{Keys: []string{"a", "b"}, MAC: "aabbccddee00"})
+	badMAC, _ := encodeCursor(&listCursor{Keys: []string{""}, MAC: "zz"})
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{name: "limit above max", query: fmt.Sprintf("limit=%d", MaxListLimit+1)},
+		{name: "limit zero", query: "limit=0"},
+		{name: "limit not a number", query: "limit=ten", code: CodeValidationFailed},
+		{name: "cursor not base64", query: "cursor=%21%21%21"},
+		{name: "cursor not json", query: "cursor=" + base64.RawURLEncoding.EncodeToString([]byte("tampered"))},
+		{name: "cursor for other sort", query: "cursor=" + otherSort},
+		{name: "cursor with invalid mac", query: "cursor=" + badMAC},
+		{name: "unknown sort", query: "sort=uptime"},
+		{name: "unknown field", query: "fields=mac,hostnmae"},
+		{name: "invalid cidr", query: "ip=10.0.0.0/40"},
+		{name: "invalid glob", query: "hostname=edge-["},
+		{name: "invalid age", query: "heartbeat_max_age=-5m"},
+		{name: "invalid status", query: "status=running"},
+	}
+
+	r, _ := newListTestHandler(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodGet, "/api/v1/nodes?"+tt.query, "")
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+			want := CodeInvalidRequest
+			if tt.code != "" {
+				want = tt.code
+			}
+			if code := responseCode(t, w); code != want {
+				t.Errorf("code = %s, want %s", code, want)
+			}
+		})
+	}
+}
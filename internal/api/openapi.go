@@ -0,0 +1,564 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/health"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 路由分组：决定挂载的中间件
+const (
+	// groupAPI /api/v1 下的管理 API（token 认证）
+	groupAPI = "api"
+	// groupNode 节点在 PXE/安装阶段访问的端点（按来源网段限制）
+	groupNode = "node"
+	// groupMetrics Prometheus 指标（与 API 相同的 token 认证，可配置为公开）
+	groupMetrics = "metrics"
+	// groupPublic 无需认证的端点
+	groupPublic = "public"
+)
+
+// 响应内容类型
+const (
+	contentJSON        = "application/json"
+	contentText        = "text/plain"
+	contentEventStream = "text/event-stream"
+	contentOctetStream = "application/octet-stream"
+	contentPEM         = "application/x-pem-file"
+	contentNDJSON      = "application/x-ndjson"
+)
+
+// apiParam 查询参数或请求头
+type apiParam struct {
+	name        string
+	in          string // query 或 header
+	typ         string // string、integer
+	description string
+}
+
+// apiRoute 路由定义：同时用于注册 gin 路由、生成 OpenAPI 文档和校验请求
+type apiRoute struct {
+	method string
+	// path gin 路由路径（相对于分组）
+	path string
+	// docPath 文档中的路径，为空时由 path 转换（:mac -> {mac}）
+	docPath string
+	group   string
+	handler gin.HandlerFunc
+	// role 额外要求的角色（默认按请求方法要求 viewer/operator）
+	role    string
+	tag     string
+	summary string
+	params  []apiParam
+	// request 请求体类型的零值，nil 表示无请求体
+	request interface{}
+	// status 成功状态码及响应体（response 为 nil 表示无响应体）
+	status      int
+	response    interface{}
+	contentType string
+	// accepted 请求转为后台执行时 202 的响应体，nil 表示不会返回 202
+	accepted interface{}
+	// errors 可能返回的错误状态码（响应体为 ErrorResponse）
+	errors []int
+	// plain 启用 TLS 后仍通过 HTTP 提供
+	plain bool
+}
+
+// 常用参数
+var (
+	paramLimit   = apiParam{name: "limit", in: "query", typ: "integer", description: "最大返回数量"}
+	paramIfMatch = apiParam{name: "If-Match", in: "header", typ: "string",
+		description: "仅在节点 ETag（资源版本）匹配时执行，否则返回 412"}
+	paramInstallMAC   = apiParam{name: "mac", in: "query", typ: "string", description: "节点 MAC（启用安装令牌时必填）"}
+	paramInstallToken = apiParam{name: "token", in: "query", typ: "string", description: "一次性安装令牌（启用安装令牌时必填）"}
+	// auditParams 审计记录查询条件
+	auditParams = []apiParam{
+		{name: "actor", in: "query", typ: "string", description: "操作者（token 名称或 ID）"},
+		{name: "mac", in: "query", typ: "string", description: "目标节点 MAC"},
+		{name: "since", in: "query", typ: "string", description: "起始时间（RFC 3339，包含）"},
+		{name: "until", in: "query", typ: "string", description: "结束时间（RFC 3339，不包含）"},
+	}
+)
+
+// routes 所有 HTTP 路由
+func (h *Handler) routes() []apiRoute {
+	routes := []apiRoute{
+		{method: http.MethodGet, path: "/openapi.json", group: groupAPI, handler: h.GetOpenAPI,
+			tag: "meta", summary: "OpenAPI 文档",
+			status: http.StatusOK, response: map[string]interface{}{}},
+
+		// 节点事件流
+		{method: http.MethodGet, path: "/events", group: groupAPI, handler: h.StreamEvents,
+			tag: "events", summary: "订阅节点事件（SSE，请求 WebSocket 升级时使用 WebSocket）",
+			params: []apiParam{
+				{name: "mac", in: "query", typ: "string", description: "节点 MAC（逗号分隔）"},
+				{name: "type", in: "query", typ: "string", description: "事件类型（逗号分隔，支持 node.* 形式）"},
+				{name: "labels", in: "query", typ: "string", description: "节点标签选择器"},
+				{name: "last_event_id", in: "query", typ: "integer", description: "从该事件之后续传"},
+				{name: "Last-Event-ID", in: "header", typ: "integer", description: "从该事件之后续传（SSE 重连）"},
+			},
+			status: http.StatusOK, response: events.Event{}, contentType: contentEventStream,
+			errors: []int{http.StatusBadRequest, http.StatusServiceUnavailable}},
+
+		// 节点
+		{method: http.MethodGet, path: "/nodes", group: groupAPI, handler: h.ListNodes,
+			tag: "nodes", summary: "列出节点（X-Total-Count 为总数，X-Next-Cursor 为下一页游标）",
+			params: []apiParam{
+				{name: "status", in: "query", typ: "string", description: "节点状态（逗号分隔）"},
+				{name: "label", in: "query", typ: "string", description: "标签选择器，如 rack=a1,!maintenance"},
+				{name: "ip", in: "query", typ: "string", description: "IP 地址或 CIDR"},
+				{name: "hostname", in: "query", typ: "string", description: "主机名通配符"},
+				{name: "heartbeat_max_age", in: "query", typ: "string", description: "最近心跳不早于该时长（如 5m）"},
+				{name: "heartbeat_min_age", in: "query", typ: "string", description: "超过该时长无心跳（含从未上报）"},
+				{name: "sort", in: "query", typ: "string", description: "排序字段（逗号分隔，- 前缀表示降序）"},
+				{name: "fields", in: "query", typ: "string", description: "仅返回指定字段（逗号分隔的节点 JSON 字段名，始终包含 mac；空值输出 null）"},
+				{name: "limit", in: "query", typ: "integer", description: "单页数量（最大 1000）"},
+				{name: "cursor", in: "query", typ: "string", description: "分页游标"},
+			},
+			status: http.StatusOK, response: []model.Node{},
+			errors: []int{http.StatusBadRequest}},
+		{method: http.MethodGet, path: "/nodes/:mac", group: groupAPI, handler: h.GetNode,
+			tag: "nodes", summary: "获取节点（响应 ETag 为资源版本）",
+			params: []apiParam{{name: "If-None-Match", in: "header", typ: "string", description: "ETag 匹配时返回 304"}},
+			status: http.StatusOK, response: model.Node{},
+			errors: []int{http.StatusNotFound}},
+		{method: http.MethodPost, path: "/nodes", group: groupAPI, handler: h.RegisterNode,
+			tag: "nodes", summary: "手动注册节点",
+			request: RegisterNodeRequest{}, status: http.StatusCreated, response: model.Node{},
+			errors: []int{http.StatusBadRequest, http.StatusConflict}},
+		{method: http.MethodPut, path: "/nodes/:mac", group: groupAPI, handler: h.UpdateNode,
+			tag: "nodes", summary: "执行节点操作（install、reinstall）",
+			params: []apiParam{paramIfMatch}, request: UpdateNodeRequest{},
+			status: http.StatusOK, response: model.Node{},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed}},
+		{method: http.MethodPatch, path: "/nodes/:mac", group: groupAPI, handler: h.PatchNode,
+			tag: "nodes", summary: "编辑节点属性（JSON Merge Patch）",
+			params: []apiParam{paramIfMatch}, request: NodePatchRequest{},
+			status: http.StatusOK, response: model.Node{},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed}},
+		{method: http.MethodDelete, path: "/nodes/:mac", group: groupAPI, handler: h.DeleteNode,
+			tag: "nodes", summary: "删除节点并释放 DHCP 租约",
+			params: []apiParam{paramIfMatch}, status: http.StatusNoContent,
+			errors: []int{http.StatusNotFound, http.StatusPreconditionFailed}},
+		// gin 不支持转义路由中的冒号，:verb 由 nodeCollectionVerb 分发
+		{method: http.MethodPost, path: "/nodes:verb", docPath: "/api/v1/nodes:bulk", group: groupAPI,
+			handler: h.nodeCollectionVerb, tag: "nodes", summary: "按选择器批量操作节点（批次之间需要等待时在后台执行并返回 202）",
+			request: BulkRequest{}, status: http.StatusOK, response: BulkResponse{}, accepted: BulkOperation{},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{method: http.MethodGet, path: "/operations/:id", group: groupAPI, handler: h.GetBulkOperation,
+			tag: "nodes", summary: "后台批量操作的进度和结果（服务重启后清空）",
+			status: http.StatusOK, response: BulkOperation{},
+			errors: []int{http.StatusNotFound}},
+
+		// 远程命令
+		{method: http.MethodPost, path: "/nodes/:mac/commands", group: groupAPI, handler: h.CreateNodeCommand,
+			tag: "commands", summary: "向节点 agent 下发命令（幂等键重复时返回 200 和已有命令）",
+			params:  []apiParam{{name: "Idempotency-Key", in: "header", typ: "string", description: "幂等键（最长 128 字符）"}},
+			request: CommandRequest{}, status: http.StatusAccepted, response: model.CommandExecution{},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusServiceUnavailable}},
+		{method: http.MethodGet, path: "/nodes/:mac/commands", group: groupAPI, handler: h.ListNodeCommands,
+			tag: "commands", summary: "节点的命令记录（最新的在前）",
+			params: []apiParam{{name: "status", in: "query", typ: "string", description: "命令状态"}, paramLimit},
+			status: http.StatusOK, response: []model.CommandExecution{},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{method: http.MethodGet, path: "/nodes/:mac/events", group: groupAPI, handler: h.ListNodeEvents,
+			tag: "nodes", summary: "节点的最近事件（来自事件流缓冲区，按时间升序）",
+			params: []apiParam{paramLimit}, status: http.StatusOK, response: []events.Event{},
+			errors: []int{http.StatusBadRequest, http.StatusServiceUnavailable}},
+		{method: http.MethodGet, path: "/nodes/:mac/boot-script", group: groupAPI, handler: h.GetNodeBootScript,
+			tag: "nodes", summary: "最近一次向节点提供的 iPXE 引导脚本（服务重启后清空）",
+			status: http.StatusOK, response: BootScriptRecord{},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{method: http.MethodGet, path: "/nodes/:mac/commands/:id", group: groupAPI, handler: h.GetNodeCommand,
+			tag: "commands", summary: "获取命令记录",
+			status: http.StatusOK, response: model.CommandExecution{},
+			errors: []int{http.StatusNotFound}},
+		{method: http.MethodGet, path: "/commands", group: groupAPI, handler: h.ListCommands,
+			tag: "commands", summary: "所有节点的命令记录（最新的在前）",
+			params: []apiParam{
+				{name: "mac", in: "query", typ: "string", description: "节点 MAC"},
+				{name: "status", in: "query", typ: "string", description: "命令状态"},
+				paramLimit,
+			},
+			status: http.StatusOK, response: []model.CommandExecution{},
+			errors: []int{http.StatusBadRequest}},
+
+		// DHCP 租约
+		{method: http.MethodGet, path: "/leases", group: groupAPI, handler: h.ListLeases,
+			tag: "leases", summary: "地址池统计和所有租约",
+			status: http.StatusOK, response: LeaseListResponse{},
+			errors: []int{http.StatusServiceUnavailable}},
+		{method: http.MethodDelete, path: "/leases/:mac", group: groupAPI, handler: h.ReleaseLease,
+			tag: "leases", summary: "释放节点的租约",
+			status: http.StatusNoContent,
+			errors: []int{http.StatusNotFound, http.StatusServiceUnavailable}},
+
+		// API token 管理
+		{method: http.MethodGet, path: "/tokens", group: groupAPI, handler: h.ListTokens, role: model.ROLE_ADMIN,
+			tag: "tokens", summary: "列出 API token",
+			status: http.StatusOK, response: []TokenResponse{},
+			errors: []int{http.StatusNotFound}},
+		{method: http.MethodPost, path: "/tokens", group: groupAPI, handler: h.CreateToken, role: model.ROLE_ADMIN,
+			tag: "tokens", summary: "创建 API token（明文仅返回一次）",
+			request: CreateTokenRequest{}, status: http.StatusCreated, response: TokenResponse{},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{method: http.MethodDelete, path: "/tokens/:id", group: groupAPI, handler: h.RevokeToken, role: model.ROLE_ADMIN,
+			tag: "tokens", summary: "吊销 API token",
+			status: http.StatusNoContent,
+			errors: []int{http.StatusNotFound}},
+
+		// webhook 管理
+		{method: http.MethodGet, path: "/webhooks", group: groupAPI, handler: h.ListWebhooks, role: model.ROLE_ADMIN,
+			tag: "webhooks", summary: "列出 webhook",
+			status: http.StatusOK, response: []WebhookResponse{},
+			errors: []int{http.StatusServiceUnavailable}},
+		{method: http.MethodPost, path: "/webhooks", group: groupAPI, handler: h.CreateWebhook, role: model.ROLE_ADMIN,
+			tag: "webhooks", summary: "创建 webhook（签名密钥仅返回一次）",
+			request: WebhookRequest{}, status: http.StatusCreated, response: WebhookResponse{},
+			errors: []int{http.StatusBadRequest, http.StatusServiceUnavailable}},
+		{method: http.MethodGet, path: "/webhooks/:id", group: groupAPI, handler: h.GetWebhook, role: model.ROLE_ADMIN,
+			tag: "webhooks", summary: "获取 webhook",
+			status: http.StatusOK, response: WebhookResponse{},
+			errors: []int{http.StatusNotFound, http.StatusServiceUnavailable}},
+		{method: http.MethodPatch, path: "/webhooks/:id", group: groupAPI, handler: h.UpdateWebhook, role: model.ROLE_ADMIN,
+			tag: "webhooks", summary: "修改 webhook（省略的字段保持不变）",
+			request: WebhookRequest{}, status: http.StatusOK, response: WebhookResponse{},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusServiceUnavailable}},
+		{method: http.MethodDelete, path: "/webhooks/:id", group: groupAPI, handler: h.DeleteWebhook, role: model.ROLE_ADMIN,
+			tag: "webhooks", summary: "删除 webhook",
+			status: http.StatusNoContent,
+			errors: []int{http.StatusNotFound, http.StatusServiceUnavailable}},
+		{method: http.MethodGet, path: "/webhooks/:id/deliveries", group: groupAPI, handler: h.ListWebhookDeliveries,
+			role: model.ROLE_ADMIN, tag: "webhooks", summary: "webhook 投递历史（最新的在前）",
+			params: []apiParam{paramLimit}, status: http.StatusOK, response: []model.WebhookDelivery{},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusServiceUnavailable}},
+		{method: http.MethodPost, path: "/webhooks/:id/test", group: groupAPI, handler: h.TestWebhook, role: model.ROLE_ADMIN,
+			tag: "webhooks", summary: "同步发送测试事件",
+			status: http.StatusOK, response: model.WebhookDelivery{},
+			errors: []int{http.StatusNotFound, http.StatusServiceUnavailable}},
+
+		// 安装配置
+		{method: http.MethodGet, path: "/profiles", group: groupAPI, handler: h.ListProfiles,
+			tag: "profiles", summary: "列出安装配置",
+			status: http.StatusOK, response: []model.InstallProfile{},
+			errors: []int{http.StatusServiceUnavailable}},
+		{method: http.MethodPost, path: "/profiles", group: groupAPI, handler: h.CreateProfile, role: model.ROLE_ADMIN,
+			tag: "profiles", summary: "创建安装配置（校验其中的模板）",
+			request: ProfileRequest{}, status: http.StatusCreated, response: model.InstallProfile{},
+			errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusServiceUnavailable}},
+		{method: http.MethodGet, path: "/profiles/:name", group: groupAPI, handler: h.GetProfile,
+			tag: "profiles", summary: "获取安装配置",
+			status: http.StatusOK, response: model.InstallProfile{},
+			errors: []int{http.StatusNotFound, http.StatusServiceUnavailable}},
+		{method: http.MethodPatch, path: "/profiles/:name", group: groupAPI, handler: h.UpdateProfile, role: model.ROLE_ADMIN,
+			tag: "profiles", summary: "修改安装配置（省略的字段保持不变）",
+			request: ProfileRequest{}, status: http.StatusOK, response: model.InstallProfile{},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusServiceUnavailable}},
+		{method: http.MethodDelete, path: "/profiles/:name", group: groupAPI, handler: h.DeleteProfile, role: model.ROLE_ADMIN,
+			tag: "profiles", summary: "删除安装配置",
+			status: http.StatusNoContent,
+			errors: []int{http.StatusNotFound, http.StatusServiceUnavailable}},
+
+		// 审计记录
+		{method: http.MethodGet, path: "/audit", group: groupAPI, handler: h.ListAudit, role: model.ROLE_ADMIN,
+			tag: "audit", summary: "查询修改类请求的审计记录（最新的在前，X-Next-Cursor 为下一页游标）",
+			params: append(auditParams,
+				apiParam{name: "limit", in: "query", typ: "integer", description: "单页数量（最大 1000）"},
+				apiParam{name: "cursor", in: "query", typ: "integer", description: "分页游标"},
+			),
+			status: http.StatusOK, response: []model.AuditEntry{},
+			errors: []int{http.StatusBadRequest, http.StatusServiceUnavailable}},
+		{method: http.MethodGet, path: "/audit/export", group: groupAPI, handler: h.ExportAudit, role: model.ROLE_ADMIN,
+			tag: "audit", summary: "以 JSON Lines 格式导出审计记录（按时间升序）",
+			params: auditParams, status: http.StatusOK, response: model.AuditEntry{}, contentType: contentNDJSON,
+			errors: []int{http.StatusBadRequest, http.StatusServiceUnavailable}},
+
+		// 节点端点
+		{method: http.MethodGet, path: "/boot/:mac/boot.ipxe", group: groupNode, handler: h.GetBootScript, plain: true,
+			tag: "provisioning", summary: "iPXE 引导脚本",
+			status: http.StatusOK, response: "", contentType: contentText,
+			errors: []int{http.StatusForbidden, http.StatusNotFound}},
+		{method: http.MethodGet, path: "/preseed/:mac/preseed.cfg", group: groupNode, handler: h.GetPreseed, plain: true,
+			tag: "provisioning", summary: "应答文件：安装配置中的模板或内置 preseed（仅 installing 节点）",
+			params: []apiParam{
+				paramInstallToken,
+				{name: "ip", in: "query", typ: "string", description: "静态 IP"},
+				{name: "netmask", in: "query", typ: "string", description: "子网掩码"},
+				{name: "gateway", in: "query", typ: "string", description: "网关"},
+				{name: "dns", in: "query", typ: "string", description: "DNS 服务器"},
+			},
+			status: http.StatusOK, response: "", contentType: contentText,
+			errors: []int{http.StatusForbidden, http.StatusNotFound}},
+		{method: http.MethodGet, path: "/agent/nodefoundry-agent", group: groupNode, handler: h.GetAgentBinary,
+			tag: "provisioning", summary: "agent 二进制文件",
+			params: []apiParam{paramInstallMAC, paramInstallToken},
+			status: http.StatusOK, response: "", contentType: contentOctetStream,
+			errors: []int{http.StatusForbidden, http.StatusNotFound}},
+		{method: http.MethodGet, path: "/agent/nodefoundry-agent.service", group: groupNode, handler: h.GetAgentServiceFile,
+			tag: "provisioning", summary: "agent systemd 服务文件",
+			params: []apiParam{paramInstallMAC, paramInstallToken},
+			status: http.StatusOK, response: "", contentType: contentText,
+			errors: []int{http.StatusForbidden}},
+
+		// Prometheus 指标
+		{method: http.MethodGet, path: "/metrics", group: groupMetrics, handler: h.GetMetrics,
+			tag: "meta", summary: "Prometheus 指标（启用认证时需要 viewer token，NF_METRICS_PUBLIC=true 时无需认证）",
+			status: http.StatusOK, response: "", contentType: contentText,
+			errors: []int{http.StatusServiceUnavailable}},
+
+		// 健康检查（/health 保持旧版本的响应，不执行组件检查）
+		{method: http.MethodGet, path: "/health", group: groupPublic, handler: h.HealthCheck,
+			tag: "health", summary: "健康检查（兼容旧版本，始终返回 200；组件检查见 /health/ready）",
+			status: http.StatusOK, response: HealthResponse{}},
+		{method: http.MethodGet, path: "/health/live", group: groupPublic, handler: h.HealthLive,
+			tag: "health", summary: "存活检查",
+			status: http.StatusOK, response: HealthResponse{}},
+		{method: http.MethodGet, path: "/health/ready", group: groupPublic, handler: h.HealthReady,
+			tag: "health", summary: "就绪检查（关键组件 down 时返回 503）",
+			status: http.StatusOK, response: health.Report{},
+			errors: []int{http.StatusServiceUnavailable}},
+	}
+
+	routes = append(routes, h.cloudInitRoutes()...)
+	routes = append(routes, h.installProgressRoutes()...)
+
+	if h.caPEM != nil {
+		routes = append(routes, apiRoute{
+			method: http.MethodGet, path: "/ca.crt", group: groupPublic, handler: h.GetCACertificate, plain: true,
+			tag: "provisioning", summary: "服务器 CA 证书",
+			status: http.StatusOK, response: "", contentType: contentPEM,
+		})
+	}
+	return routes
+}
+
+// fullPath 路由的完整 gin 路径
+func (r *apiRoute) fullPath() string {
+	if r.group == groupAPI {
+		return "/api/v1" + r.path
+	}
+	return r.path
+}
+
+// pathParamPattern gin 路径参数
+var pathParamPattern = regexp.MustCompile(`:([a-z_]+)`)
+
+// openAPIPath 文档中的路径（{param} 形式）
+func (r *apiRoute) openAPIPath() string {
+	if r.docPath != "" {
+		return r.docPath
+	}
+	return pathParamPattern.ReplaceAllString(r.fullPath(), "{$1}")
+}
+
+// openAPI 生成的 OpenAPI 文档（路由固定，只生成一次）
+type openAPI struct {
+	once     sync.Once
+	document map[string]interface{}
+	// schemas 与文档共享的 schema，用于请求校验
+	schemas *schemaRegistry
+	// requests 各路由请求体的 schema（键为 方法+完整路径）
+	requests map[string]*schema
+}
+
+// build 根据路由表生成文档
+func (o *openAPI) build(routes []apiRoute) {
+	o.once.Do(func() {
+		o.schemas = newSchemaRegistry()
+		o.requests = make(map[string]*schema)
+		errorSchema := o.schemas.schemaFor(ErrorResponse{})
+
+		paths := make(map[string]map[string]interface{})
+		tags := make(map[string]bool)
+		for i := range routes {
+			route := &routes[i]
+			op := map[string]interface{}{
+				"operationId": operationID(route),
+				"summary":     route.summary,
+				"tags":        []string{route.tag},
+			}
+			tags[route.tag] = true
+
+			var params []map[string]interface{}
+			for _, match := range pathParamPattern.FindAllStringSubmatch(route.path, -1) {
+				params = append(params, map[string]interface{}{
+					"name": match[1], "in": "path", "required": true, "schema": &schema{Type: "string"},
+				})
+			}
+			for _, p := range route.params {
+				params = append(params, map[string]interface{}{
+					"name": p.name, "in": p.in, "description": p.description, "schema": &schema{Type: p.typ},
+				})
+			}
+			if len(params) > 0 {
+				op["parameters"] = params
+			}
+
+			if route.request != nil {
+				s := o.schemas.schemaFor(route.request)
+				o.requests[route.method+" "+route.fullPath()] = s
+				op["requestBody"] = map[string]interface{}{
+					"required": true,
+					"content":  map[string]interface{}{contentJSON: map[string]interface{}{"schema": s}},
+				}
+			}
+
+			responses := make(map[string]interface{})
+			success := map[string]interface{}{"description": http.StatusText(route.status)}
+			if route.response != nil {
+				contentType := route.contentType
+				if contentType == "" {
+					contentType = contentJSON
+				}
+				s := o.schemas.schemaFor(route.response)
+				if contentType == contentOctetStream {
+					s.Format = "binary"
+				}
+				success["content"] = map[string]interface{}{contentType: map[string]interface{}{"schema": s}}
+			}
+			responses[strconv.Itoa(route.status)] = success
+			if route.accepted != nil {
+				responses[strconv.Itoa(http.StatusAccepted)] = map[string]interface{}{
+					"description": http.StatusText(http.StatusAccepted),
+					"content": map[string]interface{}{contentJSON: map[string]interface{}{
+						"schema": o.schemas.schemaFor(route.accepted)}},
+				}
+			}
+
+			codes := route.errors
+			switch route.group {
+			case groupAPI:
+				codes = append(append([]int{}, codes...), http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests)
+			case groupNode:
+				// 启用限速时可能返回 429（响应头 Retry-After）
+				codes = append(append([]int{}, codes...), http.StatusTooManyRequests)
+			case groupMetrics:
+				codes = append(append([]int{}, codes...), http.StatusUnauthorized, http.StatusForbidden)
+			}
+			for _, code := range codes {
+				responses[strconv.Itoa(code)] = map[string]interface{}{
+					"description": http.StatusText(code),
+					"content":     map[string]interface{}{contentJSON: map[string]interface{}{"schema": errorSchema}},
+				}
+			}
+			op["responses"] = responses
+
+			if route.group == groupAPI || route.group == groupMetrics {
+				op["security"] = []map[string][]string{{"bearerAuth": {}}}
+			}
+
+			path := route.openAPIPath()
+			if paths[path] == nil {
+				paths[path] = make(map[string]interface{})
+			}
+			paths[path][strings.ToLower(route.method)] = op
+		}
+
+		tagList := make([]map[string]string, 0, len(tags))
+		for tag := range tags {
+			tagList = append(tagList, map[string]string{"name": tag})
+		}
+		sort.Slice(tagList, func(i, j int) bool { return tagList[i]["name"] < tagList[j]["name"] })
+
+		o.document = map[string]interface{}{
+			"openapi": "3.0.3",
+			"info": map[string]string{
+				"title":       "NodeFoundry API",
+				"version":     "v1",
+				"description": "边缘 AI 节点管理 API。未启用认证时无需 token。",
+			},
+			"tags":  tagList,
+			"paths": paths,
+			"components": map[string]interface{}{
+				"schemas": o.schemas.schemas,
+				"securitySchemes": map[string]interface{}{
+					"bearerAuth": map[string]string{"type": "http", "scheme": "bearer"},
+				},
+			},
+		}
+	})
+}
+
+// operationID 由处理器对应的方法和路径生成唯一的操作 ID，如 get_nodes_mac_commands
+func operationID(route *apiRoute) string {
+	path := strings.NewReplacer("/api/v1/", "", ":", "", "{", "", "}", "", ".", "_").Replace(route.openAPIPath())
+	path = strings.Trim(strings.ReplaceAll(path, "/", "_"), "_")
+	return strings.ToLower(route.method) + "_" + path
+}
+
+// GetOpenAPI 返回由路由表生成的 OpenAPI 3 文档
+func (h *Handler) GetOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, h.openAPI.document)
+}
+
+// validateRequest 按 OpenAPI 文档校验请求：整数查询参数和 JSON 请求体
+// 路由无需校验时返回 nil；处理器仍负责字段取值范围等业务校验
+func (h *Handler) validateRequest(route *apiRoute) gin.HandlerFunc {
+	var integerParams []string
+	for _, p := range route.params {
+		if p.in == "query" && p.typ == "integer" {
+			integerParams = append(integerParams, p.name)
+		}
+	}
+	body := h.openAPI.requests[route.method+" "+route.fullPath()]
+	if body == nil && len(integerParams) == 0 {
+		return nil
+	}
+
+	return func(c *gin.Context) {
+		for _, name := range integerParams {
+			if value := c.Query(name); value != "" {
+				if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+					errorDetails(c, http.StatusBadRequest, CodeValidationFailed,
+						"invalid query parameter "+name+": must be an integer",
+						map[string]interface{}{"field": "query." + name, "reason": "must be an integer"})
+					c.Abort()
+					return
+				}
+			}
+		}
+
+		if body == nil {
+			c.Next()
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "failed to read request body")
+			c.Abort()
+			return
+		}
+		// 处理器重新解析请求体
+		c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body: "+err.Error())
+			c.Abort()
+			return
+		}
+		if err := h.openAPI.schemas.validate(body, value, "body"); err != nil {
+			errorDetails(c, http.StatusBadRequest, CodeValidationFailed, "invalid request body: "+err.Error(),
+				map[string]interface{}{"field": err.field, "reason": err.reason})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// updateGolden 重新生成 testdata 中的 OpenAPI 文档：go test ./internal/api -run OpenAPI -update
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// goldenOpenAPI 提交到仓库的 OpenAPI 文档，API 变更需要同步更新
+var goldenOpenAPI = filepath.Join("testdata", "openapi.json")
+
+// registeredOperations 返回引擎中注册的路由（方法 + 文档路径）
+// 路由表中的路由使用其文档路径（如 /api/v1/nodes:bulk），其他路由按 :param -> {param} 转换
+func registeredOperations(h *Handler, r *gin.Engine) []string {
+	docPaths := make(map[string]string)
+	for _, route := range h.routes() {
+		docPaths[route.method+" "+route.fullPath()] = route.openAPIPath()
+	}
+
+	var ops []string
+	for _, info := range r.Routes() {
+		path, ok := docPaths[info.Method+" "+info.Path]
+		if !ok {
+			path = pathParamPattern.ReplaceAllString(info.Path, "{$1}")
+		}
+		ops = append(ops, info.Method+" "+path)
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// documentedOperations 返回 OpenAPI 文档中的操作（方法 + 路径）
+func documentedOperations(t *testing.T, document map[string]interface{}) map[string]bool {
+	t.Helper()
+
+	paths, ok := document["paths"].(map[string]map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected paths type %T", document["paths"])
+	}
+	ops := make(map[string]bool)
+	for path, methods := range paths {
+		for method := range methods {
+			ops[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	return ops
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	h, r, _ := newTestHandler(t)
+	documented := documentedOperations(t, h.openAPI.document)
+
+	registered := make(map[string]bool)
+	for _, op := range registeredOperations(h, r) {
+		registered[op] = true
+		if !documented[op] {
+			t.Errorf("route %s is not documented", op)
+		}
+	}
+	for op := range documented {
+		if !registered[op] {
+			t.Errorf("documented operation %s is not registered", op)
+		}
+	}
+
+	// 明文 HTTP 路由是完整路由的子集
+	plain := gin.New()
+	h.RegisterPlainRoutes(plain)
+	for _, op := range registeredOperations(h, plain) {
+		if !documented[op] {
+			t.Errorf("plain route %s is not documented", op)
+		}
+	}
+}
+
+func TestOpenAPIGolden(t *testing.T) {
+	_, r, _ := newTestHandler(t)
+
+	w := serve(r, http.MethodGet, "/api/v1/openapi.json", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var got bytes.Buffer
+	if err := json.Indent(&got, w.Body.Bytes(), "", "  "); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	got.WriteByte('\n')
+
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(goldenOpenAPI), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(goldenOpenAPI, got.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenOpenAPI)
+	if err != nil {
+		t.Fatalf("read golden file (run with -update to create): %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("OpenAPI document differs from %s; review the API change and run go test ./internal/api -run OpenAPIGolden -update", goldenOpenAPI)
+	}
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/ipxe"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// ProfileRequest 创建或修改安装配置请求（修改时省略的字段保持不变，名称不可修改）
+type ProfileRequest struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Distro      *string   `json:"distro"`
+	Release     *string   `json:"release"`
+	Arch        *string   `json:"arch"`
+	KernelURL   *string   `json:"kernel_url"`
+	InitrdURL   *string   `json:"initrd_url"`
+	KernelArgs  *string   `json:"kernel_args"`
+	AnswerFile  *string   `json:"answer_file"`
+	Groups      *[]string `json:"groups"`
+}
+
+// SetProfiles 设置安装配置存储
+func (h *Handler) SetProfiles(repo db.ProfileRepository) {
+	h.profiles = repo
+}
+
+// apply 将请求中的字段写入安装配置并校验模板
+func (req *ProfileRequest) apply(profile *model.InstallProfile) error {
+	for _, f := range []struct {
+		value  *string
+		target *string
+	}{
+		{req.Description, &profile.Description},
+		{req.Distro, &profile.Distro},
+		{req.Release, &profile.Release},
+		{req.Arch, &profile.Arch},
+		{req.KernelURL, &profile.KernelURL},
+		{req.InitrdURL, &profile.InitrdURL},
+		{req.KernelArgs, &profile.KernelArgs},
+		{req.AnswerFile, &profile.AnswerFile},
+	} {
+		if f.value != nil {
+			*f.target = *f.value
+		}
+	}
+	if req.Groups != nil {
+		groups := make([]string, 0, len(*req.Groups))
+		seen := make(map[string]bool)
+		for _, group := range *req.Groups {
+			if !seen[group] {
+				seen[group] = true
+				groups = append(groups, group)
+			}
+		}
+		profile.Groups = groups
+	}
+	return ipxe.ValidateProfile(profile)
+}
+
+// profilesEnabled 检查是否设置了安装配置存储，未设置时返回 503
+func (h *Handler) profilesEnabled(c *gin.Context) bool {
+	if h.profiles == nil {
+		errorResponse(c, http.StatusServiceUnavailable, CodeFeatureDisabled, "install profiles not available")
+		return false
+	}
+	return true
+}
+
+// ListProfiles 列出安装配置（按名称排序）
+func (h *Handler) ListProfiles(c *gin.Context) {
+	if !h.profilesEnabled(c) {
+		return
+	}
+
+	profiles, err := h.profiles.List(c.Request.Context())
+	if err != nil {
+		h.writeError(c, err, "failed to list install profiles")
+		return
+	}
+	if profiles == nil {
+		profiles = []*model.InstallProfile{}
+	}
+	c.JSON(http.StatusOK, profiles)
+}
+
+// CreateProfile 创建安装配置
+func (h *Handler) CreateProfile(c *gin.Context) {
+	if !h.profilesEnabled(c) {
+		return
+	}
+
+	var req ProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.Name == nil {
+		errorDetails(c, http.StatusBadRequest, CodeValidationFailed, "name is required",
+			map[string]interface{}{"field": "body.name", "reason": "is required"})
+		return
+	}
+
+	now := time.Now()
+	profile := &model.InstallProfile{
+		Name:      *req.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := req.apply(profile); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if err := h.profiles.Create(c.Request.Context(), profile); err != nil {
+		h.writeError(c, err, "failed to create install profile")
+		return
+	}
+
+	h.logger.Info("install profile created",
+		zap.String("profile", profile.Name),
+		zap.String("distro", profile.Distro),
+		zap.String("release", profile.Release),
+		zap.Strings("groups", profile.Groups),
+	)
+
+	c.Header("Location", "/api/v1/profiles/"+profile.Name)
+	c.JSON(http.StatusCreated, profile)
+}
+
+// GetProfile 获取安装配置
+func (h *Handler) GetProfile(c *gin.Context) {
+	if !h.profilesEnabled(c) {
+		return
+	}
+
+	profile, err := h.profiles.FindByName(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.writeError(c, err, "failed to get install profile")
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateProfile 修改安装配置（省略的字段保持不变）
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	if !h.profilesEnabled(c) {
+		return
+	}
+
+	var req ProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	name := c.Param("name")
+	if req.Name != nil && *req.Name != name {
+		errorDetails(c, http.StatusBadRequest, CodeValidationFailed, "profile name cannot be changed",
+			map[string]interface{}{"field": "body.name", "reason": "cannot be changed"})
+		return
+	}
+
+	profile, err := h.profiles.Update(c.Request.Context(), name, func(profile *model.InstallProfile) error {
+		if err := req.apply(profile); err != nil {
+			return &apiError{status: http.StatusBadRequest, code: CodeValidationFailed, message: err.Error()}
+		}
+		return nil
+	})
+	if err != nil {
+		h.writeError(c, err, "failed to update install profile")
+		return
+	}
+
+	h.logger.Info("install profile updated", zap.String("profile", name))
+	c.JSON(http.StatusOK, profile)
+}
+
+// DeleteProfile 删除安装配置
+// 仍通过 install-profile 标签引用该配置的节点在安装时会引导失败，需要先修改标签
+func (h *Handler) DeleteProfile(c *gin.Context) {
+	if !h.profilesEnabled(c) {
+		return
+	}
+
+	name := c.Param("name")
+	if err := h.profiles.Delete(c.Request.Context(), name); err != nil {
+		h.writeError(c, err, "failed to delete install profile")
+		return
+	}
+
+	h.logger.Info("install profile deleted", zap.String("profile", name))
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+	"github.com/lucheng0127/nodefoundry/internal/ratelimit"
+)
+
+// 限速范围
+const (
+	rateLimitScopeIP  = "ip"
+	rateLimitScopeMAC = "mac"
+)
+
+// groupLimiters 路由分组的限速器，nil 表示该范围不限速
+type groupLimiters struct {
+	perIP  *ratelimit.Limiter
+	perMAC *ratelimit.Limiter
+}
+
+// SetNodeRateLimits 设置节点端点（/boot、/preseed、/agent）按来源 IP 和节点 MAC 的限速
+// 异常的 PXE 循环会不断请求这些端点，每次请求都会访问数据库
+func (h *Handler) SetNodeRateLimits(perIP, perMAC ratelimit.Limit) {
+	h.setRateLimits(groupNode, &groupLimiters{perIP: newLimiter(perIP), perMAC: newLimiter(perMAC)})
+}
+
+// SetAPIRateLimit 设置 /api/v1 按来源 IP 的限速
+func (h *Handler) SetAPIRateLimit(perIP ratelimit.Limit) {
+	h.setRateLimits(groupAPI, &groupLimiters{perIP: newLimiter(perIP)})
+}
+
+// setRateLimits 设置分组的限速器
+func (h *Handler) setRateLimits(group string, limiters *groupLimiters) {
+	if h.rateLimits == nil {
+		h.rateLimits = make(map[string]*groupLimiters)
+	}
+	h.rateLimits[group] = limiters
+}
+
+// newLimiter 创建限速器，未启用时返回 nil
+func newLimiter(limit ratelimit.Limit) *ratelimit.Limiter {
+	if !limit.Enabled() {
+		return nil
+	}
+	return ratelimit.New(limit)
+}
+
+// rateLimit 路由分组的限速中间件：超出限制时返回 429 和 Retry-After
+// 按连接对端地址限速，不信任 X-Forwarded-For；节点端点同时按路径或查询参数中的 MAC 限速
+func (h *Handler) rateLimit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiters := h.rateLimits[group]
+		if limiters == nil {
+			c.Next()
+			return
+		}
+
+		if limiters.perIP != nil && !h.allowRequest(c, group, rateLimitScopeIP, c.RemoteIP(), limiters.perIP) {
+			return
+		}
+		if limiters.perMAC != nil {
+			mac := c.Param("mac")
+			if mac == "" {
+				mac = c.Query("mac")
+			}
+			// 无效的 MAC 不单独计数，避免随意构造的键占用内存（仍受来源 IP 限速）
+			if model.IsValidMAC(mac) &&
+				!h.allowRequest(c, group, rateLimitScopeMAC, model.NormalizeMAC(mac), limiters.perMAC) {
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// allowRequest 消耗 key 的令牌，被拒绝时写入 429 响应、记录指标和日志（每个客户端每分钟最多一条）
+func (h *Handler) allowRequest(c *gin.Context, group, scope, key string, limiter *ratelimit.Limiter) bool {
+	result := limiter.Allow(key)
+	if result.Allowed {
+		return true
+	}
+
+	h.metrics.RateLimited(group, scope)
+	if result.Rejected > 0 {
+		h.logger.Warn("client rate limited",
+			zap.String("group", group),
+			zap.String("scope", scope),
+			zap.String("key", key),
+			zap.String("remote", c.RemoteIP()),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("rejected", result.Rejected),
+			zap.String("limit", limiter.Limit().String()),
+		)
+	}
+
+	retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	errorDetails(c, http.StatusTooManyRequests, CodeRateLimited, "too many requests, retry later",
+		map[string]interface{}{"scope": scope, "retry_after_seconds": retryAfter})
+	c.Abort()
+	return false
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/ratelimit"
+)
+
+func TestAPIRateLimit(t *testing.T) {
+	h, r, _ := newTestHandler(t)
+	h.SetAPIRateLimit(ratelimit.Limit{Rate: 1, Burst: 2})
+
+	for i := 0; i < 2; i++ {
+		if w := serve(r, http.MethodGet, "/api/v1/nodes", ""); w.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d", i, w.Code)
+		}
+	}
+
+	w := serve(r, http.MethodGet, "/api/v1/nodes", "")
+	if w.Code != http.StatusTooManyRequests || responseCode(t, w) != CodeRateLimited {
+		t.Fatalf("over burst status = %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Details["scope"] != rateLimitScopeIP {
+		t.Errorf("details = %v, want scope %s", resp.Details, rateLimitScopeIP)
+	}
+}
+
+func TestNodeRateLimitPerMAC(t *testing.T) {
+	h, r, _ := newTestHandler(t)
+	h.SetNodeRateLimits(ratelimit.Limit{}, ratelimit.Limit{Rate: 20, Burst: 1})
+	path := func(mac string) string { return "/agent/nodefoundry-agent.service?mac=" + mac }
+
+	if w := serve(r, http.MethodGet, path("aabbccddeeff"), ""); w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d: %s", w.Code, w.Body.String())
+	}
+	// 同一节点的不同 MAC 写法共用令牌桶
+	w := serve(r, http.MethodGet, path("aa:bb:cc:dd:ee:ff"), "")
+	if w.Code != http.StatusTooManyRequests || responseCode(t, w) != CodeRateLimited {
+		t.Fatalf("over burst status = %d: %s", w.Code, w.Body.String())
+	}
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 {
+		t.Errorf("Retry-After = %q, want >= 1", w.Header().Get("Retry-After"))
+	}
+
+	// 其他节点不受影响
+	if w := serve(r, http.MethodGet, path("001122334455"), ""); w.Code != http.StatusOK {
+		t.Errorf("other node status = %d", w.Code)
+	}
+
+	// 每秒补充 20 个令牌
+	time.Sleep(60 * time.Millisecond)
+	if w := serve(r, http.MethodGet, path("aabbccddeeff"), ""); w.Code != http.StatusOK {
+		t.Errorf("after refill status = %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// schema OpenAPI 3.0 schema（只包含 API 类型用到的部分）
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaRegistry 由 Go 类型生成的命名 schema（components/schemas）
+type schemaRegistry struct {
+	schemas map[string]*schema
+	// types 已注册的类型，检测不同包中的同名类型
+	types map[string]reflect.Type
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*schema),
+		types:   make(map[string]reflect.Type),
+	}
+}
+
+// schemaFor 返回 v 的类型对应的 schema，命名结构体注册到 components 并返回引用
+// 字段规则：json 标签决定名称，"-" 跳过；binding:"required" 或无 omitempty 的非指针字段为必填；
+// 指针字段可为 null；enum 标签列出允许的取值（逗号分隔）
+func (r *schemaRegistry) schemaFor(v interface{}) *schema {
+	return r.typeSchema(reflect.TypeOf(v))
+}
+
+func (r *schemaRegistry) typeSchema(t reflect.Type) *schema {
+	switch t {
+	case timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := r.typeSchema(t.Elem())
+		if s.Ref != "" {
+			// $ref 不能与其他关键字并列，引用类型的可空性不单独描述
+			return s
+		}
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: "integer", Format: integerFormat(t)}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: r.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: r.typeSchema(t.Elem())}
+	case reflect.Interface:
+		return &schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		name := t.Name()
+		if existing, ok := r.types[name]; ok {
+			if existing != t {
+				panic(fmt.Sprintf("openapi: schema name %s used by both %s and %s", name, existing, t))
+			}
+			return &schema{Ref: "#/components/schemas/" + name}
+		}
+		r.types[name] = t
+		// 先占位，支持自引用类型
+		r.schemas[name] = &schema{}
+		*r.schemas[name] = *r.structSchema(t)
+		return &schema{Ref: "#/components/schemas/" + name}
+	}
+	panic(fmt.Sprintf("openapi: unsupported type %s", t))
+}
+
+// structSchema 生成结构体的 object schema（嵌入字段展开）
+func (r *schemaRegistry) structSchema(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := r.structSchema(field.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fs := r.typeSchema(field.Type)
+		if enum := field.Tag.Get("enum"); enum != "" {
+			fs.Enum = strings.Split(enum, ",")
+		}
+		s.Properties[name] = fs
+
+		required := strings.Contains(field.Tag.Get("binding"), "required") ||
+			(!strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr)
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// integerFormat 整数的 format
+func integerFormat(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int32, reflect.Uint32:
+		return "int32"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return "int64"
+	}
+	return ""
+}
+
+// resolve 解析 $ref
+func (r *schemaRegistry) resolve(s *schema) *schema {
+	if s.Ref != "" {
+		return r.schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+// validate 按 schema 校验 JSON 值（由 json.Decoder.UseNumber 解码），返回第一个错误
+func (r *schemaRegistry) validate(s *schema, value interface{}, path string) *validationError {
+	s = r.resolve(s)
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return &validationError{field: path, reason: "must not be null"}
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return &validationError{field: path, reason: "must be an object"}
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return &validationError{field: joinPath(path, name), reason: "is required"}
+			}
+		}
+		// 按键排序，保证错误信息稳定
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fs := s.Properties[k]
+			if fs == nil {
+				fs = s.AdditionalProperties
+			}
+			// 未声明的字段交给处理器决定是否接受
+			if fs == nil {
+				continue
+			}
+			if err := r.validate(fs, obj[k], joinPath(path, k)); err != nil {
+				return err
+			}
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return &validationError{field: path, reason: "must be an array"}
+		}
+		for i, item := range items {
+			if err := r.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return &validationError{field: path, reason: "must be a string"}
+		}
+		if len(s.Enum) > 0 && !containsString(s.Enum, str) {
+			return &validationError{field: path, reason: "must be one of: " + strings.Join(s.Enum, ", ")}
+		}
+
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return &validationError{field: path, reason: "must be an integer"}
+		}
+		if _, err := n.Int64(); err != nil {
+			return &validationError{field: path, reason: "must be an integer"}
+		}
+
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return &validationError{field: path, reason: "must be a number"}
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return &validationError{field: path, reason: "must be a boolean"}
+		}
+	}
+	return nil
+}
+
+// validationError 请求体不符合 schema
+type validationError struct {
+	// field 字段路径，如 body.labels.rack
+	field  string
+	reason string
+}
+
+func (e *validationError) Error() string {
+	return e.field + " " + e.reason
+}
+
+// joinPath 拼接字段路径
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// containsString 判断切片是否包含字符串
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
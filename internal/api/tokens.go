@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/auth"
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// CreateTokenRequest 创建 API token 请求
+type CreateTokenRequest struct {
+	Name string `json:"name" binding:"required"`
+	Role string `json:"role" binding:"required" enum:"viewer,operator,admin"`
+	// ExpiresIn 有效期（如 720h），为空表示永不过期
+	ExpiresIn string `json:"expires_in,omitempty"`
+}
+
+// TokenResponse API token 信息（不含摘要）
+type TokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// Token 明文，仅在创建时返回一次
+	Token string `json:"token,omitempty"`
+}
+
+// newTokenResponse 转换 token 为响应结构
+func newTokenResponse(token *model.APIToken) TokenResponse {
+	resp := TokenResponse{
+		ID:        token.ID,
+		Name:      token.Name,
+		Role:      token.Role,
+		CreatedAt: token.CreatedAt,
+	}
+	if !token.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &token.ExpiresAt
+	}
+	if !token.LastUsedAt.IsZero() {
+		resp.LastUsedAt = &token.LastUsedAt
+	}
+	if !token.RevokedAt.IsZero() {
+		resp.RevokedAt = &token.RevokedAt
+	}
+	return resp
+}
+
+// ListTokens 列出 API token
+func (h *Handler) ListTokens(c *gin.Context) {
+	if h.tokens == nil {
+		errorResponse(c, http.StatusNotFound, CodeFeatureDisabled, "authentication is not enabled")
+		return
+	}
+
+	tokens, err := h.tokens.List(c.Request.Context())
+	if err != nil {
+		h.logger.Error("failed to list tokens", zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to list tokens")
+		return
+	}
+
+	resp := make([]TokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		resp = append(resp, newTokenResponse(token))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateToken 创建 API token
+func (h *Handler) CreateToken(c *gin.Context) {
+	if h.tokens == nil {
+		errorResponse(c, http.StatusNotFound, CodeFeatureDisabled, "authentication is not enabled")
+		return
+	}
+
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	var ttl time.Duration
+	if req.ExpiresIn != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.ExpiresIn)
+		if err != nil || ttl <= 0 {
+			errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid expires_in")
+			return
+		}
+	}
+
+	token, plaintext, err := auth.NewAPIToken(req.Name, req.Role, ttl)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if err := h.tokens.Create(c.Request.Context(), token); err != nil {
+		h.logger.Error("failed to create token", zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to create token")
+		return
+	}
+
+	h.logger.Info("API token created",
+		zap.String("token_id", token.ID),
+		zap.String("name", token.Name),
+		zap.String("role", token.Role),
+	)
+
+	auditDetail(c, "token_id", token.ID)
+	auditDetail(c, "name", token.Name)
+	auditDetail(c, "role", token.Role)
+
+	resp := newTokenResponse(token)
+	resp.Token = plaintext
+	c.Header("Location", "/api/v1/tokens/"+token.ID)
+	c.JSON(http.StatusCreated, resp)
+}
+
+// RevokeToken 吊销 API token
+func (h *Handler) RevokeToken(c *gin.Context) {
+	if h.tokens == nil {
+		errorResponse(c, http.StatusNotFound, CodeFeatureDisabled, "authentication is not enabled")
+		return
+	}
+
+	id := c.Param("id")
+	if err := h.tokens.Revoke(c.Request.Context(), id); err != nil {
+		if db.IsTokenNotFound(err) {
+			errorResponse(c, http.StatusNotFound, CodeTokenNotFound, "token not found")
+			return
+		}
+		h.logger.Error("failed to revoke token", zap.String("token_id", id), zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to revoke token")
+		return
+	}
+
+	h.logger.Info("API token revoked", zap.String("token_id", id))
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/auth"
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// WebhookSecretPrefix 自动生成的 webhook 签名密钥前缀
+const WebhookSecretPrefix = "whsec_"
+
+// 投递历史查询数量
+const (
+	defaultDeliveryLimit = 20
+	maxDeliveryLimit     = db.WebhookDeliveryHistoryLimit
+)
+
+// WebhookTester 向 webhook 发送测试事件
+type WebhookTester interface {
+	Test(ctx context.Context, webhook *model.Webhook) (*model.WebhookDelivery, error)
+}
+
+// WebhookRequest 创建或修改 webhook 请求（修改时省略的字段保持不变）
+type WebhookRequest struct {
+	URL         *string   `json:"url"`
+	Description *string   `json:"description"`
+	Events      *[]string `json:"events"`
+	Labels      *string   `json:"labels"`
+	// Secret 签名密钥，创建时为空则自动生成
+	Secret  *string `json:"secret"`
+	Enabled *bool   `json:"enabled"`
+}
+
+// WebhookResponse webhook 信息（不含签名密钥）
+type WebhookResponse struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Events      []string  `json:"events"`
+	Labels      string    `json:"labels,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Secret 签名密钥，仅在创建时返回一次
+	Secret string `json:"secret,omitempty"`
+}
+
+// newWebhookResponse 转换 webhook 为响应结构
+func newWebhookResponse(webhook *model.Webhook) WebhookResponse {
+	resp := WebhookResponse{
+		ID:          webhook.ID,
+		URL:         webhook.URL,
+		Description: webhook.Description,
+		Events:      webhook.Events,
+		Labels:      webhook.Labels,
+		Enabled:     webhook.Enabled,
+		CreatedAt:   webhook.CreatedAt,
+		UpdatedAt:   webhook.UpdatedAt,
+	}
+	if resp.Events == nil {
+		resp.Events = []string{}
+	}
+	return resp
+}
+
+// SetWebhooks 设置 webhook 存储和测试投递器
+func (h *Handler) SetWebhooks(repo db.WebhookRepository, tester WebhookTester) {
+	h.webhooks = repo
+	h.webhookTester = tester
+}
+
+// apply 将请求中的字段写入 webhook 并校验
+func (req *WebhookRequest) apply(webhook *model.Webhook) error {
+	if req.URL != nil {
+		if err := model.ValidateWebhookURL(*req.URL); err != nil {
+			return err
+		}
+		webhook.URL = *req.URL
+	}
+	if req.Description != nil {
+		webhook.Description = *req.Description
+	}
+	if req.Events != nil {
+		types := make([]string, 0, len(*req.Events))
+		seen := make(map[string]bool)
+		for _, t := range *req.Events {
+			if !events.IsValidTypePattern(t) {
+				return fmt.Errorf("invalid event type: %s", t)
+			}
+			if !seen[t] {
+				seen[t] = true
+				types = append(types, t)
+			}
+		}
+		webhook.Events = types
+	}
+	if req.Labels != nil {
+		selector, err := model.ParseLabelSelector(*req.Labels)
+		if err != nil {
+			return err
+		}
+		webhook.Labels = selector.String()
+	}
+	if req.Secret != nil {
+		if len(*req.Secret) < 16 {
+			return errors.New("secret must be at least 16 characters")
+		}
+		webhook.Secret = *req.Secret
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// ListWebhooks 列出 webhook
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+
+	webhooks, err := h.webhooks.List(c.Request.Context())
+	if err != nil {
+		h.logger.Error("failed to list webhooks", zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to list webhooks")
+		return
+	}
+
+	resp := make([]WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		resp = append(resp, newWebhookResponse(webhook))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateWebhook 创建 webhook
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.URL == nil {
+		errorDetails(c, http.StatusBadRequest, CodeValidationFailed, "url is required",
+			map[string]interface{}{"field": "body.url", "reason": "is required"})
+		return
+	}
+
+	id, err := auth.GenerateID()
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to create webhook")
+		return
+	}
+
+	now := time.Now()
+	webhook := &model.Webhook{
+		ID:        id,
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := req.apply(webhook); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if webhook.Secret == "" {
+		webhook.Secret, err = auth.GenerateToken(WebhookSecretPrefix)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to create webhook")
+			return
+		}
+	}
+
+	if err := h.webhooks.Create(c.Request.Context(), webhook); err != nil {
+		h.logger.Error("failed to create webhook", zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to create webhook")
+		return
+	}
+
+	h.logger.Info("webhook created",
+		zap.String("webhook_id", webhook.ID),
+		zap.String("url", webhook.URL),
+		zap.Strings("events", webhook.Events),
+	)
+
+	auditDetail(c, "webhook_id", webhook.ID)
+
+	resp := newWebhookResponse(webhook)
+	resp.Secret = webhook.Secret
+	c.Header("Location", "/api/v1/webhooks/"+webhook.ID)
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetWebhook 获取 webhook
+func (h *Handler) GetWebhook(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+
+	webhook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookResponse(webhook))
+}
+
+// UpdateWebhook 修改 webhook（省略的字段保持不变）
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	id := c.Param("id")
+	webhook, err := h.webhooks.Update(c.Request.Context(), id, func(webhook *model.Webhook) error {
+		if err := req.apply(webhook); err != nil {
+			return &apiError{status: http.StatusBadRequest, code: CodeValidationFailed, message: err.Error()}
+		}
+		return nil
+	})
+	if err != nil {
+		if db.IsWebhookNotFound(err) {
+			errorResponse(c, http.StatusNotFound, CodeWebhookNotFound, "webhook not found")
+			return
+		}
+		h.writeError(c, err, "failed to update webhook")
+		return
+	}
+
+	h.logger.Info("webhook updated", zap.String("webhook_id", id))
+	c.JSON(http.StatusOK, newWebhookResponse(webhook))
+}
+
+// DeleteWebhook 删除 webhook 及其投递记录
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+
+	id := c.Param("id")
+	if err := h.webhooks.Delete(c.Request.Context(), id); err != nil {
+		if db.IsWebhookNotFound(err) {
+			errorResponse(c, http.StatusNotFound, CodeWebhookNotFound, "webhook not found")
+			return
+		}
+		h.logger.Error("failed to delete webhook", zap.String("webhook_id", id), zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to delete webhook")
+		return
+	}
+
+	h.logger.Info("webhook deleted", zap.String("webhook_id", id))
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries 列出 webhook 投递历史（最新的在前），limit 默认 20
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxDeliveryLimit {
+			errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", maxDeliveryLimit))
+			return
+		}
+		limit = n
+	}
+
+	id := c.Param("id")
+	deliveries, err := h.webhooks.ListDeliveries(c.Request.Context(), id, limit)
+	if err != nil {
+		if db.IsWebhookNotFound(err) {
+			errorResponse(c, http.StatusNotFound, CodeWebhookNotFound, "webhook not found")
+			return
+		}
+		h.logger.Error("failed to list webhook deliveries", zap.String("webhook_id", id), zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to list webhook deliveries")
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []*model.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// TestWebhook 同步发送一次测试事件并返回投递结果
+func (h *Handler) TestWebhook(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+
+	webhook, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookTester.Test(c.Request.Context(), webhook)
+	if err != nil {
+		h.logger.Error("failed to test webhook", zap.String("webhook_id", webhook.ID), zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to test webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// webhooksEnabled 检查 webhook 功能是否可用
+func (h *Handler) webhooksEnabled(c *gin.Context) bool {
+	if h.webhooks == nil || h.webhookTester == nil {
+		errorResponse(c, http.StatusServiceUnavailable, CodeFeatureDisabled, "webhooks not available")
+		return false
+	}
+	return true
+}
+
+// findWebhook 读取路径参数指定的 webhook，不存在时写入错误响应
+func (h *Handler) findWebhook(c *gin.Context) (*model.Webhook, bool) {
+	id := c.Param("id")
+	webhook, err := h.webhooks.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if db.IsWebhookNotFound(err) {
+			errorResponse(c, http.StatusNotFound, CodeWebhookNotFound, "webhook not found")
+			return nil, false
+		}
+		h.logger.Error("failed to find webhook", zap.String("webhook_id", id), zap.Error(err))
+		errorResponse(c, http.StatusInternalServerError, CodeInternal, "failed to find webhook")
+		return nil, false
+	}
+	return webhook, true
+}
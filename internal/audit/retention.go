@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+)
+
+// pruneInterval 保留策略检查间隔
+const pruneInterval = time.Hour
+
+// Retention 审计记录保留策略：定期删除超过保留期限或超出数量上限的最旧记录
+type Retention struct {
+	repo db.AuditRepository
+	// maxAge 保留期限，0 表示不按时间删除
+	maxAge time.Duration
+	// maxEntries 最多保留的记录数，0 表示不限制
+	maxEntries int
+	logger     *zap.Logger
+}
+
+// NewRetention 创建保留策略
+func NewRetention(repo db.AuditRepository, maxAge time.Duration, maxEntries int, logger *zap.Logger) *Retention {
+	return &Retention{
+		repo:       repo,
+		maxAge:     maxAge,
+		maxEntries: maxEntries,
+		logger:     logger,
+	}
+}
+
+// Run 启动时及此后每小时执行一次清理
+func (r *Retention) Run(ctx context.Context) error {
+	if r.maxAge <= 0 && r.maxEntries <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		r.Prune(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune 执行一次清理
+func (r *Retention) Prune(ctx context.Context) {
+	var before time.Time
+	if r.maxAge > 0 {
+		before = time.Now().Add(-r.maxAge)
+	}
+
+	deleted, err := r.repo.Prune(ctx, before, r.maxEntries)
+	if err != nil {
+		r.logger.Error("failed to prune audit log", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		r.logger.Info("pruned audit log", zap.Int("deleted", deleted))
+	}
+}
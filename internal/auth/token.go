@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// token 前缀，便于在日志和密钥扫描中识别
+const (
+	TokenPrefix        = "nf_"  // API token
+	InstallTokenPrefix = "nfi_" // 节点安装令牌
+)
+
+// GenerateToken 生成随机 token（32 字节随机数，base64url 编码）
+func GenerateToken(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// GenerateID 生成短随机 ID（16 位十六进制）
+func GenerateID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HashToken 计算 token 的 SHA-256 摘要（token 为高熵随机数，无需加盐慢哈希）
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// EqualHash 以常量时间比较两个摘要
+func EqualHash(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// BearerToken 从 Authorization 头中提取 Bearer token
+func BearerToken(header string) string {
+	const scheme = "bearer "
+	if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(scheme):])
+}
+
+// NewAPIToken 生成新的 API token，返回 token 记录和仅展示一次的明文
+// ttl 为 0 表示永不过期
+func NewAPIToken(name, role string, ttl time.Duration) (*model.APIToken, string, error) {
+	if !model.IsValidRole(role) {
+		return nil, "", fmt.Errorf("invalid role: %s", role)
+	}
+
+	id, err := GenerateID()
+	if err != nil {
+		return nil, "", err
+	}
+
+	plaintext, err := GenerateToken(TokenPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	token := &model.APIToken{
+		ID:        id,
+		Name:      name,
+		Role:      role,
+		Hash:      HashToken(plaintext),
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		token.ExpiresAt = now.Add(ttl)
+	}
+
+	if err := token.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	return token, plaintext, nil
+}
+
+// NewInstallToken 为处于安装周期的节点生成安装令牌
+func NewInstallToken(mac string, installStartedAt time.Time, ttl time.Duration) (*model.InstallToken, error) {
+	plaintext, err := GenerateToken(InstallTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &model.InstallToken{
+		MAC:              model.NormalizeMAC(mac),
+		Token:            plaintext,
+		InstallStartedAt: installStartedAt,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(ttl),
+	}, nil
+}
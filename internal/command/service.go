@@ -0,0 +1,312 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+	"github.com/lucheng0127/nodefoundry/internal/mqtt"
+)
+
+// 命令参数
+const (
+	// DefaultTimeout 节点操作（如 reboot）下发命令的截止时间
+	DefaultTimeout = 5 * time.Minute
+	// sweepInterval 检查超时命令的间隔
+	sweepInterval = 5 * time.Second
+	// outputExcerpt 保存的 stdout/stderr 最大长度
+	outputExcerpt = 4096
+)
+
+// ErrPublishFailed 命令已记录但未能下发到 MQTT（记录状态为 failed）
+var ErrPublishFailed = errors.New("failed to publish command")
+
+// errUnchanged 命令状态无需更新
+var errUnchanged = errors.New("command unchanged")
+
+// Publisher 向节点 agent 发布命令消息
+type Publisher interface {
+	PublishCommand(mac string, msg mqtt.CommandMessage) error
+}
+
+// Service 下发节点命令，并根据 agent 上报的结果和截止时间跟踪执行状态
+type Service struct {
+	repo      db.CommandRepository
+	nodes     db.NodeRepository
+	publisher Publisher
+	events    *events.Bus
+	logger    *zap.Logger
+}
+
+// NewService 创建命令服务
+func NewService(repo db.CommandRepository, nodes db.NodeRepository, publisher Publisher, bus *events.Bus, logger *zap.Logger) *Service {
+	return &Service{
+		repo:      repo,
+		nodes:     nodes,
+		publisher: publisher,
+		events:    bus,
+		logger:    logger,
+	}
+}
+
+// Send 记录并下发命令（execution 由 model.NewCommandExecution 创建）
+// 同一节点已有相同幂等键的命令时不再下发，直接返回已有命令；
+// 下发失败时命令记录为 failed，返回该记录和 ErrPublishFailed
+func (s *Service) Send(ctx context.Context, execution *model.CommandExecution) (*model.CommandExecution, error) {
+	if err := s.repo.Create(ctx, execution); err != nil {
+		var duplicate *db.ErrDuplicateCommand
+		if errors.As(err, &duplicate) {
+			return s.repo.FindByID(ctx, duplicate.ID)
+		}
+		return nil, err
+	}
+
+	err := s.publisher.PublishCommand(execution.MAC, mqtt.CommandMessage{
+		ID:             execution.ID,
+		Command:        execution.Command,
+		Args:           execution.Args,
+		Deadline:       execution.Deadline,
+		IdempotencyKey: execution.IdempotencyKey,
+	})
+	if err != nil {
+		s.logger.Error("failed to publish command",
+			zap.String("mac", execution.MAC),
+			zap.String("id", execution.ID),
+			zap.String("command", execution.Command),
+			zap.Error(err),
+		)
+		failed, updateErr := s.finish(ctx, execution.ID, model.COMMAND_FAILED, func(execution *model.CommandExecution) {
+			execution.Error = err.Error()
+		})
+		if updateErr == nil {
+			execution = failed
+		}
+		return execution, fmt.Errorf("%w: %v", ErrPublishFailed, err)
+	}
+
+	s.logger.Info("command sent",
+		zap.String("mac", execution.MAC),
+		zap.String("id", execution.ID),
+		zap.String("command", execution.Command),
+		zap.Time("deadline", execution.Deadline),
+	)
+	return execution, nil
+}
+
+// PublishCommand 以默认超时下发并跟踪命令（供 reboot 等节点操作使用）
+func (s *Service) PublishCommand(mac string, command string, args map[string]interface{}) error {
+	_, err := s.Send(context.Background(), model.NewCommandExecution(mac, command, args, DefaultTimeout, time.Now()))
+	return err
+}
+
+// HandleResult 根据 agent 上报更新命令状态
+// 未携带 ID 或 ID 未知的结果（旧版 agent 或其他来源）直接转发为事件；
+// 已结束命令的重复或迟到结果被忽略
+func (s *Service) HandleResult(ctx context.Context, node *model.Node, result *mqtt.CommandResultMessage) {
+	if result.ID == "" {
+		s.publishUntracked(node, result)
+		return
+	}
+
+	status := result.Status
+	if status != model.COMMAND_RUNNING && status != model.COMMAND_SUCCEEDED && status != model.COMMAND_FAILED {
+		s.logger.Warn("invalid command result status",
+			zap.String("mac", node.MAC),
+			zap.String("id", result.ID),
+			zap.String("status", status),
+		)
+		return
+	}
+
+	execution, err := s.repo.FindByID(ctx, result.ID)
+	if err != nil {
+		if db.IsCommandNotFound(err) {
+			s.publishUntracked(node, result)
+			return
+		}
+		s.logger.Error("failed to find command", zap.String("id", result.ID), zap.Error(err))
+		return
+	}
+	if execution.MAC != node.MAC {
+		s.logger.Warn("command result reported by another node",
+			zap.String("mac", node.MAC),
+			zap.String("id", result.ID),
+			zap.String("command_mac", execution.MAC),
+		)
+		return
+	}
+
+	now := time.Now()
+	execution, err = s.repo.Update(ctx, result.ID, func(execution *model.CommandExecution) error {
+		if execution.Status == status {
+			// 执行中的进度更新
+			if status != model.COMMAND_RUNNING || result.Progress == "" || result.Progress == execution.Progress {
+				return errUnchanged
+			}
+			execution.Progress = result.Progress
+			return nil
+		}
+		if err := execution.CanTransitionTo(status); err != nil {
+			return errUnchanged
+		}
+
+		execution.Status = status
+		if result.Progress != "" {
+			execution.Progress = result.Progress
+		}
+		if execution.StartedAt.IsZero() {
+			execution.StartedAt = now
+		}
+		if execution.Finished() {
+			execution.FinishedAt = now
+			execution.ExitCode = result.ExitCode
+			execution.Stdout = excerpt(result.Stdout)
+			execution.Stderr = excerpt(result.Stderr)
+			execution.Error = result.Error
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errUnchanged) {
+			s.logger.Debug("ignoring command result",
+				zap.String("mac", node.MAC),
+				zap.String("id", result.ID),
+				zap.String("status", status),
+			)
+			return
+		}
+		s.logger.Error("failed to update command", zap.String("id", result.ID), zap.Error(err))
+		return
+	}
+
+	s.logger.Info("command status updated",
+		zap.String("mac", node.MAC),
+		zap.String("id", execution.ID),
+		zap.String("command", execution.Command),
+		zap.String("status", execution.Status),
+		zap.String("progress", execution.Progress),
+	)
+	if execution.Finished() {
+		s.events.Publish(resultEvent(node, execution))
+	}
+}
+
+// Run 周期性将超过截止时间的命令标记为 timed_out，直到 ctx 取消
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// sweep 标记超时命令
+func (s *Service) sweep(ctx context.Context) {
+	active, err := s.repo.Active(ctx)
+	if err != nil {
+		s.logger.Error("failed to list active commands", zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	for _, execution := range active {
+		if now.Before(execution.Deadline) {
+			continue
+		}
+
+		timedOut, err := s.finish(ctx, execution.ID, model.COMMAND_TIMED_OUT, func(execution *model.CommandExecution) {
+			execution.Error = "no result reported before deadline"
+		})
+		if err != nil {
+			if !errors.Is(err, errUnchanged) && !db.IsCommandNotFound(err) {
+				s.logger.Error("failed to time out command", zap.String("id", execution.ID), zap.Error(err))
+			}
+			continue
+		}
+
+		s.logger.Warn("command timed out",
+			zap.String("mac", timedOut.MAC),
+			zap.String("id", timedOut.ID),
+			zap.String("command", timedOut.Command),
+		)
+	}
+}
+
+// finish 将命令转换到结束状态并发布结果事件
+func (s *Service) finish(ctx context.Context, id, status string, mutate func(execution *model.CommandExecution)) (*model.CommandExecution, error) {
+	execution, err := s.repo.Update(ctx, id, func(execution *model.CommandExecution) error {
+		if err := execution.CanTransitionTo(status); err != nil {
+			return errUnchanged
+		}
+		execution.Status = status
+		execution.FinishedAt = time.Now()
+		mutate(execution)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := s.nodes.FindByMAC(ctx, execution.MAC)
+	if err != nil {
+		node = &model.Node{MAC: execution.MAC}
+	}
+	s.events.Publish(resultEvent(node, execution))
+	return execution, nil
+}
+
+// publishUntracked 转发未跟踪命令的结果事件
+func (s *Service) publishUntracked(node *model.Node, result *mqtt.CommandResultMessage) {
+	data := map[string]interface{}{
+		"command": result.Command,
+		"status":  result.Status,
+	}
+	if result.ID != "" {
+		data["id"] = result.ID
+	}
+	if result.ExitCode != nil {
+		data["exit_code"] = *result.ExitCode
+	}
+	if result.Error != "" {
+		data["error"] = result.Error
+	}
+	s.events.Publish(events.NodeEvent(events.EVENT_COMMAND_RESULT, node, data))
+}
+
+// resultEvent 构造命令结束事件
+func resultEvent(node *model.Node, execution *model.CommandExecution) events.Event {
+	data := map[string]interface{}{
+		"id":      execution.ID,
+		"command": execution.Command,
+		"status":  execution.Status,
+	}
+	if execution.ExitCode != nil {
+		data["exit_code"] = *execution.ExitCode
+	}
+	if execution.Error != "" {
+		data["error"] = execution.Error
+	}
+	return events.NodeEvent(events.EVENT_COMMAND_RESULT, node, data)
+}
+
+// excerpt 截取输出开头部分
+func excerpt(s string) string {
+	if len(s) <= outputExcerpt {
+		return s
+	}
+	// 截断处可能位于多字节字符中间
+	return strings.ToValidUTF8(s[:outputExcerpt], "")
+}
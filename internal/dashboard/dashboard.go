@@ -0,0 +1,80 @@
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PathPrefix 控制台路径前缀
+const PathPrefix = "/ui"
+
+//go:embed static
+var staticFiles embed.FS
+
+// Config 控制台配置（通过 /ui/config.json 提供给页面）
+type Config struct {
+	// HeartbeatTimeout 超过该时间未上报心跳的节点显示为离线
+	HeartbeatTimeout time.Duration
+	// AuthEnabled API 是否需要 token，启用时页面提示输入 token
+	AuthEnabled bool
+}
+
+// configResponse 页面配置
+type configResponse struct {
+	HeartbeatTimeoutSeconds int  `json:"heartbeat_timeout_seconds"`
+	AuthEnabled             bool `json:"auth_enabled"`
+}
+
+// Dashboard 内嵌的 Web 控制台
+// 静态页面无需认证，页面通过 /api/v1 接口读取和操作节点（启用认证时使用用户输入的 token）
+type Dashboard struct {
+	config Config
+	files  http.FileSystem
+}
+
+// New 创建控制台
+func New(config Config) *Dashboard {
+	static, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// 目录在编译时嵌入，不会失败
+		panic(err)
+	}
+	return &Dashboard{
+		config: config,
+		files:  http.FS(static),
+	}
+}
+
+// RegisterRoutes 注册控制台路由：/ 重定向到 /ui/，/ui/ 下为静态页面
+func (d *Dashboard) RegisterRoutes(r *gin.Engine) {
+	r.GET("/", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, PathPrefix+"/")
+	})
+	r.GET(PathPrefix, func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, PathPrefix+"/")
+	})
+	r.GET(PathPrefix+"/*filepath", d.serve)
+}
+
+// serve 提供页面配置和静态文件
+func (d *Dashboard) serve(c *gin.Context) {
+	path := c.Param("filepath")
+	if path == "/config.json" {
+		c.JSON(http.StatusOK, configResponse{
+			HeartbeatTimeoutSeconds: int(d.config.HeartbeatTimeout.Seconds()),
+			AuthEnabled:             d.config.AuthEnabled,
+		})
+		return
+	}
+
+	// 页面为单页应用，index.html 不缓存以便升级后立即生效
+	if path == "/" || strings.HasSuffix(path, ".html") {
+		c.Header("Cache-Control", "no-cache")
+	}
+	c.FileFromFS(path, d.files)
+}
@@ -0,0 +1,567 @@
+// NodeFoundry 控制台：节点列表、节点详情和操作，通过 /api/v1 接口读写，事件流实时刷新
+(function () {
+  'use strict';
+
+  var TOKEN_KEY = 'nodefoundry.token';
+  // 事件流不包含心跳，定期刷新以更新在线状态
+  var POLL_INTERVAL = 30000;
+  var EVENT_TYPES = [
+    'node.discovered', 'node.status_changed', 'node.updated', 'node.deleted',
+    'node.install_progress', 'node.heartbeat_lost', 'node.heartbeat_restored', 'dhcp.lease_allocated',
+    'command.result', 'stream.gap'
+  ];
+  var STATUSES = ['discovered', 'installing', 'installed', 'failed'];
+
+  var config = { heartbeat_timeout_seconds: 90, auth_enabled: false };
+  var token = localStorage.getItem(TOKEN_KEY) || '';
+  var nodes = [];
+  var pool = null;
+  var filter = { text: '', status: '' };
+  var source = null;
+  var refreshTimer = null;
+
+  var view = document.getElementById('view');
+
+  // ---- 工具函数 ----
+
+  // el 创建元素：attrs 中 on* 为事件，class 为类名，其余为属性；子节点为字符串时作为文本插入
+  function el(tag, attrs) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (key) {
+      var value = attrs[key];
+      if (value === undefined || value === null || value === false) {
+        return;
+      }
+      if (key.indexOf('on') === 0) {
+        node.addEventListener(key.slice(2), value);
+      } else if (key === 'class') {
+        node.className = value;
+      } else {
+        node.setAttribute(key, value === true ? '' : value);
+      }
+    });
+    for (var i = 2; i < arguments.length; i++) {
+      append(node, arguments[i]);
+    }
+    return node;
+  }
+
+  function append(parent, child) {
+    if (child === undefined || child === null || child === false) {
+      return;
+    }
+    if (Array.isArray(child)) {
+      child.forEach(function (c) { append(parent, c); });
+      return;
+    }
+    parent.appendChild(typeof child === 'object' ? child : document.createTextNode(String(child)));
+  }
+
+  function isZeroTime(t) {
+    return !t || t.indexOf('0001-01-01') === 0;
+  }
+
+  function formatTime(t) {
+    return isZeroTime(t) ? '—' : new Date(t).toLocaleString();
+  }
+
+  function formatAge(t) {
+    if (isZeroTime(t)) {
+      return '从未';
+    }
+    var seconds = Math.max(0, Math.round((Date.now() - new Date(t).getTime()) / 1000));
+    if (seconds < 60) { return seconds + ' 秒前'; }
+    if (seconds < 3600) { return Math.floor(seconds / 60) + ' 分钟前'; }
+    if (seconds < 86400) { return Math.floor(seconds / 3600) + ' 小时前'; }
+    return Math.floor(seconds / 86400) + ' 天前';
+  }
+
+  // liveness 按最近心跳判断节点是否在线（installed 之外的节点没有运行 agent）
+  function liveness(node) {
+    if (node.status !== 'installed') {
+      return null;
+    }
+    if (isZeroTime(node.last_heartbeat)) {
+      return 'offline';
+    }
+    var age = Date.now() - new Date(node.last_heartbeat).getTime();
+    return age <= config.heartbeat_timeout_seconds * 1000 ? 'online' : 'offline';
+  }
+
+  function badge(value, text) {
+    return el('span', { class: 'badge ' + value }, text || value);
+  }
+
+  function livenessBadge(node) {
+    var state = liveness(node);
+    if (!state) {
+      return el('span', { class: 'muted' }, '—');
+    }
+    return badge(state, state === 'online' ? '在线' : '离线');
+  }
+
+  function labelTags(labels) {
+    var keys = Object.keys(labels || {}).sort();
+    if (keys.length === 0) {
+      return el('span', { class: 'muted' }, '—');
+    }
+    return keys.map(function (k) {
+      return el('span', { class: 'label-tag' }, labels[k] ? k + '=' + labels[k] : k);
+    });
+  }
+
+  function showError(message) {
+    var banner = document.getElementById('error');
+    banner.textContent = message;
+    banner.hidden = !message;
+  }
+
+  // ---- API ----
+
+  // request 调用 API，返回 { data, headers }；非 2xx 响应转换为带 status、code、requestID 的错误
+  function request(method, path, body) {
+    var headers = { 'Accept': 'application/json' };
+    if (token) {
+      headers['Authorization'] = 'Bearer ' + token;
+    }
+    if (body !== undefined) {
+      headers['Content-Type'] = 'application/json';
+    }
+    return fetch('/api/v1' + path, {
+      method: method,
+      headers: headers,
+      body: body === undefined ? undefined : JSON.stringify(body)
+    }).then(function (resp) {
+      if (resp.status === 204) {
+        return { data: null, headers: resp.headers };
+      }
+      return resp.json().catch(function () { return null; }).then(function (data) {
+        if (resp.ok) {
+          return { data: data, headers: resp.headers };
+        }
+        if (resp.status === 401) {
+          logout();
+        }
+        var err = new Error((data && data.error) || resp.statusText);
+        err.status = resp.status;
+        err.code = data && data.code;
+        err.requestID = (data && data.request_id) || resp.headers.get('X-Request-ID');
+        throw err;
+      });
+    });
+  }
+
+  function api(method, path, body) {
+    return request(method, path, body).then(function (result) { return result.data; });
+  }
+
+  function describeError(err) {
+    var text = err.message;
+    if (err.code) {
+      text += ' [' + err.code + ']';
+    }
+    if (err.requestID) {
+      text += '（请求 ID ' + err.requestID + '）';
+    }
+    return text;
+  }
+
+  // ---- 登录 ----
+
+  function showLogin() {
+    view.replaceChildren();
+    document.getElementById('login').hidden = false;
+    document.getElementById('logout').hidden = true;
+    document.getElementById('token').focus();
+  }
+
+  function logout() {
+    token = '';
+    localStorage.removeItem(TOKEN_KEY);
+    stopLive();
+    showLogin();
+  }
+
+  document.getElementById('login-form').addEventListener('submit', function (e) {
+    e.preventDefault();
+    token = document.getElementById('token').value.trim();
+    api('GET', '/nodes?limit=1').then(function () {
+      localStorage.setItem(TOKEN_KEY, token);
+      document.getElementById('token').value = '';
+      start();
+    }).catch(function (err) {
+      showError('登录失败：' + describeError(err));
+    });
+  });
+
+  document.getElementById('logout').addEventListener('click', logout);
+
+  // ---- 实时更新 ----
+
+  function startLive() {
+    stopLive();
+    if (!window.EventSource) {
+      return;
+    }
+    var url = '/api/v1/events' + (token ? '?access_token=' + encodeURIComponent(token) : '');
+    source = new EventSource(url);
+    var indicator = document.getElementById('live');
+    source.onopen = function () { indicator.classList.add('on'); };
+    source.onerror = function () { indicator.classList.remove('on'); };
+    EVENT_TYPES.forEach(function (type) {
+      source.addEventListener(type, scheduleRefresh);
+    });
+  }
+
+  function stopLive() {
+    if (source) {
+      source.close();
+      source = null;
+    }
+    document.getElementById('live').classList.remove('on');
+  }
+
+  // scheduleRefresh 合并短时间内的多个事件，只刷新一次当前页面
+  function scheduleRefresh() {
+    clearTimeout(refreshTimer);
+    refreshTimer = setTimeout(render, 300);
+  }
+
+  // ---- 节点列表 ----
+
+  // loadNodes 读取全部节点（跟随 X-Next-Cursor 翻页）
+  function loadNodes() {
+    var all = [];
+    function page(cursor) {
+      var path = '/nodes?limit=1000&sort=status,hostname,mac' + (cursor ? '&cursor=' + encodeURIComponent(cursor) : '');
+      return request('GET', path).then(function (result) {
+        all = all.concat(result.data || []);
+        var next = result.headers.get('X-Next-Cursor');
+        return next ? page(next) : all;
+      });
+    }
+    return page('');
+  }
+
+  function loadPool() {
+    return api('GET', '/leases').catch(function (err) {
+      // 未配置地址池（ProxyDHCP 模式）时不显示
+      if (err.status === 503) {
+        return null;
+      }
+      throw err;
+    });
+  }
+
+  function renderList() {
+    return Promise.all([loadNodes(), loadPool()]).then(function (results) {
+      nodes = results[0];
+      pool = results[1];
+      // 刷新时保留过滤输入框，避免输入中失去焦点
+      var cards = view.querySelector('.cards');
+      if (cards && view.querySelector('.toolbar')) {
+        cards.replaceWith(summaryCards());
+        replaceTable();
+        return;
+      }
+      view.replaceChildren(summaryCards(), toolbar(), nodeTable());
+    });
+  }
+
+  function summaryCards() {
+    var counts = {};
+    var online = 0;
+    var installed = 0;
+    nodes.forEach(function (n) {
+      counts[n.status] = (counts[n.status] || 0) + 1;
+      if (n.status === 'installed') {
+        installed++;
+        if (liveness(n) === 'online') {
+          online++;
+        }
+      }
+    });
+
+    var cards = [
+      card('节点总数', nodes.length),
+      card('待安装', counts.discovered || 0),
+      card('安装中', counts.installing || 0),
+      card('安装失败', counts.failed || 0),
+      card('在线 / 已安装', online + ' / ' + installed)
+    ];
+    if (pool) {
+      var used = pool.stats.allocated;
+      var percent = pool.stats.size ? Math.round(used * 100 / pool.stats.size) : 0;
+      var level = percent >= 95 ? 'full' : percent >= 80 ? 'warn' : '';
+      cards.push(el('div', { class: 'card' },
+        el('div', { class: 'label' }, 'DHCP 地址池 ' + pool.subnet),
+        el('div', { class: 'value' }, used + ' / ' + pool.stats.size),
+        el('div', { class: 'bar' }, el('div', { class: level, style: 'width:' + percent + '%' })),
+        pool.stats.expired ? el('div', { class: 'label' }, pool.stats.expired + ' 个过期租约未释放') : null
+      ));
+    }
+    return el('div', { class: 'cards' }, cards);
+  }
+
+  function card(label, value) {
+    return el('div', { class: 'card' },
+      el('div', { class: 'label' }, label),
+      el('div', { class: 'value' }, value));
+  }
+
+  function toolbar() {
+    var input = el('input', {
+      type: 'search',
+      placeholder: '按 MAC、主机名、IP 或标签过滤',
+      value: filter.text,
+      oninput: function (e) {
+        filter.text = e.target.value;
+        replaceTable();
+      }
+    });
+    var select = el('select', {
+      onchange: function (e) {
+        filter.status = e.target.value;
+        replaceTable();
+      }
+    }, el('option', { value: '' }, '全部状态'), STATUSES.map(function (s) {
+      return el('option', { value: s, selected: filter.status === s }, s);
+    }));
+    return el('div', { class: 'toolbar' }, input, select);
+  }
+
+  function replaceTable() {
+    var table = view.querySelector('table');
+    if (table) {
+      table.replaceWith(nodeTable());
+    }
+  }
+
+  function matchesFilter(node) {
+    if (filter.status && node.status !== filter.status) {
+      return false;
+    }
+    var text = filter.text.trim().toLowerCase();
+    if (!text) {
+      return true;
+    }
+    var haystack = [node.mac, node.hostname, node.ip, node.notes].concat(
+      Object.keys(node.labels || {}).map(function (k) { return k + '=' + node.labels[k]; })
+    ).join(' ').toLowerCase();
+    return haystack.indexOf(text) >= 0;
+  }
+
+  function nodeTable() {
+    var rows = nodes.filter(matchesFilter).map(function (n) {
+      return el('tr', {
+        class: 'clickable',
+        onclick: function () { location.hash = '#/nodes/' + n.mac; }
+      },
+      el('td', { class: 'mono' }, n.mac),
+      el('td', {}, n.hostname || el('span', { class: 'muted' }, '—')),
+      el('td', { class: 'mono' }, n.ip || el('span', { class: 'muted' }, '—')),
+      el('td', {}, badge(n.status)),
+      el('td', {}, livenessBadge(n)),
+      el('td', {}, formatAge(n.last_heartbeat)),
+      el('td', {}, labelTags(n.labels)));
+    });
+    if (rows.length === 0) {
+      rows = [el('tr', {}, el('td', { colspan: 7, class: 'muted' }, nodes.length ? '没有匹配的节点' : '尚未发现节点'))];
+    }
+    return el('table', {},
+      el('thead', {}, el('tr', {},
+        ['MAC', '主机名', 'IP', '状态', '在线', '最近心跳', '标签'].map(function (h) { return el('th', {}, h); }))),
+      el('tbody', {}, rows));
+  }
+
+  // ---- 节点详情 ----
+
+  function renderDetail(mac) {
+    var path = '/nodes/' + encodeURIComponent(mac);
+    return Promise.all([
+      api('GET', path),
+      api('GET', path + '/events?limit=50').catch(function () { return []; }),
+      api('GET', path + '/commands?limit=20').catch(function () { return []; }),
+      api('GET', path + '/boot-script').catch(function () { return null; })
+    ]).then(function (results) {
+      var node = results[0];
+      view.replaceChildren(
+        el('p', {}, el('a', { href: '#/' }, '← 返回节点列表')),
+        el('h2', {}, (node.hostname || node.mac) + ' ', badge(node.status), ' ', livenessBadge(node)),
+        actions(node),
+        el('div', { class: 'grid' },
+          el('div', {},
+            el('h3', {}, '基本信息'),
+            details([
+              ['MAC', node.mac],
+              ['状态', node.status],
+              ['最近心跳', formatTime(node.last_heartbeat) + '（' + formatAge(node.last_heartbeat) + '）'],
+              ['创建时间', formatTime(node.created_at)],
+              ['更新时间', formatTime(node.updated_at)],
+              ['最近安装开始', formatTime(node.install_started_at)],
+              ['安装进度', node.install_stage || '—'],
+              ['安装错误', node.install_error || '—'],
+              ['标签', labelTags(node.labels)],
+              ['备注', node.notes || '—']
+            ])),
+          el('div', {},
+            el('h3', {}, '网络配置'),
+            details([
+              ['主机名', node.hostname || '—'],
+              ['配置来源', node.static_network ? '静态（手动指定）' : 'DHCP'],
+              ['IP', node.ip || '—'],
+              ['子网掩码', node.netmask || '—'],
+              ['网关', node.gateway || '—'],
+              ['DNS', node.dns || '—']
+            ]),
+            el('h3', {}, '硬件与系统信息'),
+            node.extra ? el('pre', {}, JSON.stringify(node.extra, null, 2))
+              : el('p', { class: 'muted' }, 'agent 尚未上报')),
+          el('div', {},
+            el('h3', {}, '历史事件'),
+            eventTable(results[1])),
+          el('div', {},
+            el('h3', {}, '远程命令'),
+            commandTable(results[2]))),
+        el('h3', {}, '最近提供的引导脚本'),
+        bootScript(results[3]));
+    });
+  }
+
+  function details(rows) {
+    return el('dl', {}, rows.map(function (r) {
+      return [el('dt', {}, r[0]), el('dd', {}, r[1])];
+    }));
+  }
+
+  function actions(node) {
+    return el('div', { class: 'actions' },
+      el('button', {
+        class: 'primary',
+        disabled: node.status !== 'discovered' && node.status !== 'failed',
+        title: '仅 discovered 或 failed 节点可安装',
+        onclick: function () { nodeAction(node, 'install', '安装'); }
+      }, '安装'),
+      el('button', {
+        disabled: node.status !== 'installed',
+        title: '仅 installed 节点可重装，节点将重启并重新安装系统',
+        onclick: function () { nodeAction(node, 'reinstall', '重装'); }
+      }, '重装'),
+      el('button', {
+        class: 'danger',
+        disabled: node.status !== 'installed',
+        title: '通过 agent 重启节点',
+        onclick: function () { reboot(node); }
+      }, '重启'));
+  }
+
+  function nodeAction(node, action, text) {
+    if (!confirm('确定' + text + '节点 ' + (node.hostname || node.mac) + '？')) {
+      return;
+    }
+    api('PUT', '/nodes/' + encodeURIComponent(node.mac), { action: action })
+      .then(function () { showError(''); render(); })
+      .catch(function (err) { showError(text + '失败：' + describeError(err)); });
+  }
+
+  function reboot(node) {
+    if (!confirm('确定重启节点 ' + (node.hostname || node.mac) + '？')) {
+      return;
+    }
+    api('POST', '/nodes/' + encodeURIComponent(node.mac) + '/commands', { command: 'reboot' })
+      .then(function () { showError(''); render(); })
+      .catch(function (err) { showError('重启失败：' + describeError(err)); });
+  }
+
+  function eventTable(items) {
+    if (!items || items.length === 0) {
+      return el('p', { class: 'muted' }, '服务启动以来没有该节点的事件');
+    }
+    return el('table', {},
+      el('thead', {}, el('tr', {}, el('th', {}, '时间'), el('th', {}, '事件'), el('th', {}, '详情'))),
+      el('tbody', {}, items.slice().reverse().map(function (e) {
+        return el('tr', {},
+          el('td', {}, formatTime(e.time)),
+          el('td', { class: 'mono' }, e.type),
+          el('td', { class: 'mono' }, e.data ? Object.keys(e.data).sort().map(function (k) {
+            var v = e.data[k];
+            return k + '=' + (typeof v === 'string' ? v : JSON.stringify(v));
+          }).join(' ') : ''));
+      })));
+  }
+
+  function commandTable(items) {
+    if (!items || items.length === 0) {
+      return el('p', { class: 'muted' }, '没有命令记录');
+    }
+    return el('table', {},
+      el('thead', {}, el('tr', {},
+        ['时间', '命令', '状态', '退出码', '进度/错误'].map(function (h) { return el('th', {}, h); }))),
+      el('tbody', {}, items.map(function (c) {
+        return el('tr', {},
+          el('td', {}, formatTime(c.created_at)),
+          el('td', { class: 'mono' }, c.command),
+          el('td', {}, badge(c.status)),
+          el('td', {}, c.exit_code === undefined || c.exit_code === null ? '—' : String(c.exit_code)),
+          el('td', {}, c.error || c.progress || ''));
+      })));
+  }
+
+  function bootScript(record) {
+    if (!record) {
+      return el('p', { class: 'muted' }, '服务启动以来未向该节点提供引导脚本');
+    }
+    return el('div', {},
+      el('p', { class: 'muted' }, formatTime(record.served_at) + '，节点状态 ' + record.status + '，来源 ' + record.remote_addr),
+      el('pre', {}, record.script));
+  }
+
+  // ---- 路由 ----
+
+  function render() {
+    if (config.auth_enabled && !token) {
+      showLogin();
+      return;
+    }
+    var match = location.hash.match(/^#\/nodes\/([^/]+)$/);
+    var rendering = match ? renderDetail(decodeURIComponent(match[1])) : renderList();
+    rendering.then(function () {
+      showError('');
+    }).catch(function (err) {
+      if (err.status === 401) {
+        return;
+      }
+      if (match && err.status === 404) {
+        view.replaceChildren(el('p', {}, '节点不存在或已删除。 ', el('a', { href: '#/' }, '返回节点列表')));
+        return;
+      }
+      showError('加载失败：' + describeError(err));
+    });
+  }
+
+  function start() {
+    document.getElementById('login').hidden = true;
+    document.getElementById('logout').hidden = !config.auth_enabled;
+    startLive();
+    render();
+  }
+
+  window.addEventListener('hashchange', render);
+  setInterval(function () {
+    if (!config.auth_enabled || token) {
+      render();
+    }
+  }, POLL_INTERVAL);
+
+  fetch('config.json').then(function (resp) { return resp.json(); }).then(function (c) {
+    config = c;
+  }).catch(function () {
+    // 使用默认配置
+  }).then(function () {
+    if (config.auth_enabled && !token) {
+      showLogin();
+      return;
+    }
+    start();
+  });
+})();
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// AuditFilter 审计记录查询条件（零值表示不限制）
+type AuditFilter struct {
+	// Actor 操作者名称或 token ID
+	Actor string
+	// MAC 目标节点
+	MAC string
+	// Since/Until 时间范围 [Since, Until)
+	Since time.Time
+	Until time.Time
+	// Cursor 分页位置：降序时返回 ID 小于 Cursor 的记录，升序时返回 ID 大于 Cursor 的记录
+	Cursor uint64
+	// Ascending 按写入顺序（从旧到新）返回，默认最新的在前
+	Ascending bool
+}
+
+// Match 判断记录是否满足查询条件（不含 Cursor）
+func (f *AuditFilter) Match(entry *model.AuditEntry) bool {
+	if f.Actor != "" && entry.Actor != f.Actor && entry.TokenID != f.Actor {
+		return false
+	}
+	if f.MAC != "" && entry.MAC != model.NormalizeMAC(f.MAC) {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// AuditRepository 定义审计记录存储接口（只追加，仅按保留策略删除）
+type AuditRepository interface {
+	// Append 追加记录并分配 ID
+	Append(ctx context.Context, entry *model.AuditEntry) error
+
+	// List 查询记录，limit <= 0 表示不限制
+	List(ctx context.Context, filter AuditFilter, limit int) ([]*model.AuditEntry, error)
+
+	// Prune 删除早于 before 的记录，并在超过 maxEntries 条时删除最旧的记录（零值表示不限制），返回删除数量
+	Prune(ctx context.Context, before time.Time, maxEntries int) (int, error)
+}
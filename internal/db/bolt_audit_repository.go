@@ -0,0 +1,175 @@
+package db
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// BUCKET_AUDIT 审计记录：ID（大端序 uint64，按写入顺序）→ 记录
+const BUCKET_AUDIT = "audit"
+
+// BoltAuditRepository bbolt 实现的 AuditRepository
+type BoltAuditRepository struct {
+	db     *bbolt.DB
+	logger *zap.Logger
+}
+
+// NewBoltAuditRepository 创建 BoltAuditRepository
+func NewBoltAuditRepository(db *bbolt.DB, logger *zap.Logger) *BoltAuditRepository {
+	repo := &BoltAuditRepository{
+		db:     db,
+		logger: logger,
+	}
+
+	// 初始化 bucket
+	if err := repo.initBucket(); err != nil {
+		logger.Error("failed to initialize audit bucket", zap.Error(err))
+	}
+
+	return repo
+}
+
+// initBucket 初始化 bucket
+func (r *BoltAuditRepository) initBucket() error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(BUCKET_AUDIT))
+		return err
+	})
+}
+
+// Append 追加记录并分配 ID
+func (r *BoltAuditRepository) Append(ctx context.Context, entry *model.AuditEntry) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_AUDIT))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		entry.ID = id
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return b.Put(auditKey(id), data)
+	})
+}
+
+// List 查询记录
+func (r *BoltAuditRepository) List(ctx context.Context, filter AuditFilter, limit int) ([]*model.AuditEntry, error) {
+	var result []*model.AuditEntry
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_AUDIT))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		c := b.Cursor()
+		var k, v []byte
+		var next func() ([]byte, []byte)
+		if filter.Ascending {
+			next = c.Next
+			k, v = c.Seek(auditKey(filter.Cursor + 1))
+		} else {
+			next = c.Prev
+			if filter.Cursor == 0 {
+				k, v = c.Last()
+			} else {
+				// 定位到第一个不小于 Cursor 的记录后回退一条
+				k, v = c.Seek(auditKey(filter.Cursor))
+				if k == nil {
+					k, v = c.Last()
+				}
+				if k != nil && binary.BigEndian.Uint64(k) >= filter.Cursor {
+					k, v = c.Prev()
+				}
+			}
+		}
+
+		for ; k != nil; k, v = next() {
+			var entry model.AuditEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			if !filter.Match(&entry) {
+				continue
+			}
+			result = append(result, &entry)
+			if limit > 0 && len(result) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Prune 按保留策略删除最旧的记录
+// 记录按写入顺序存储，从最旧的记录开始删除，遇到不早于 before 的记录即停止
+func (r *BoltAuditRepository) Prune(ctx context.Context, before time.Time, maxEntries int) (int, error) {
+	deleted := 0
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_AUDIT))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		excess := 0
+		if maxEntries > 0 {
+			excess = b.Stats().KeyN - maxEntries
+		}
+
+		var expired [][]byte
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if len(expired) >= excess {
+				if before.IsZero() {
+					break
+				}
+				var entry model.AuditEntry
+				if err := json.Unmarshal(v, &entry); err != nil {
+					return err
+				}
+				if !entry.Time.Before(before) {
+					break
+				}
+			}
+			expired = append(expired, append([]byte(nil), k...))
+		}
+
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		deleted = len(expired)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// auditKey 审计记录的键（大端序保证按 ID 排序）
+func auditKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
@@ -0,0 +1,358 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// Bucket 名称
+const (
+	// 每个节点一个子 bucket：命令 ID → 命令
+	BUCKET_COMMANDS = "commands"
+	// 命令 ID → 节点 MAC
+	BUCKET_COMMAND_INDEX = "command_index"
+	// 未结束的命令：命令 ID → 节点 MAC
+	BUCKET_COMMANDS_ACTIVE = "commands_active"
+)
+
+// BoltCommandRepository bbolt 实现的 CommandRepository
+type BoltCommandRepository struct {
+	db     *bbolt.DB
+	logger *zap.Logger
+}
+
+// NewBoltCommandRepository 创建 BoltCommandRepository
+func NewBoltCommandRepository(db *bbolt.DB, logger *zap.Logger) *BoltCommandRepository {
+	repo := &BoltCommandRepository{
+		db:     db,
+		logger: logger,
+	}
+
+	// 初始化 bucket
+	if err := repo.initBucket(); err != nil {
+		logger.Error("failed to initialize command buckets", zap.Error(err))
+	}
+
+	return repo
+}
+
+// initBucket 初始化 bucket
+func (r *BoltCommandRepository) initBucket() error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range []string{BUCKET_COMMANDS, BUCKET_COMMAND_INDEX, BUCKET_COMMANDS_ACTIVE} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Create 保存新命令
+func (r *BoltCommandRepository) Create(ctx context.Context, execution *model.CommandExecution) error {
+	if err := execution.Validate(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		index := tx.Bucket([]byte(BUCKET_COMMAND_INDEX))
+		if index == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		if index.Get([]byte(execution.ID)) != nil {
+			return fmt.Errorf("command id already exists: %s", execution.ID)
+		}
+		if execution.IdempotencyKey != "" {
+			if id, err := findByIdempotencyKey(tx, execution.MAC, execution.IdempotencyKey); err != nil {
+				return err
+			} else if id != "" {
+				return &ErrDuplicateCommand{ID: id}
+			}
+		}
+		if err := index.Put([]byte(execution.ID), []byte(execution.MAC)); err != nil {
+			return err
+		}
+
+		return putCommand(tx, execution)
+	})
+}
+
+// FindByID 根据 ID 查找命令
+func (r *BoltCommandRepository) FindByID(ctx context.Context, id string) (*model.CommandExecution, error) {
+	var execution *model.CommandExecution
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		execution, err = getCommand(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return execution, nil
+}
+
+// List 列出命令（最新的在前）
+func (r *BoltCommandRepository) List(ctx context.Context, mac, status string, limit int) ([]*model.CommandExecution, error) {
+	var result []*model.CommandExecution
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		parent := tx.Bucket([]byte(BUCKET_COMMANDS))
+		if parent == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		collect := func(commands *bbolt.Bucket) error {
+			c := commands.Cursor()
+			for k, v := c.Last(); k != nil; k, v = c.Prev() {
+				var execution model.CommandExecution
+				if err := json.Unmarshal(v, &execution); err != nil {
+					return err
+				}
+				if status != "" && execution.Status != status {
+					continue
+				}
+				result = append(result, &execution)
+				// 单个节点时可以提前结束，多个节点需合并后再截取
+				if mac != "" && limit > 0 && len(result) >= limit {
+					break
+				}
+			}
+			return nil
+		}
+
+		if mac != "" {
+			commands := parent.Bucket([]byte(model.NormalizeMAC(mac)))
+			if commands == nil {
+				return nil
+			}
+			return collect(commands)
+		}
+
+		return parent.ForEach(func(k, v []byte) error {
+			if commands := parent.Bucket(k); commands != nil {
+				return collect(commands)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if mac == "" {
+		sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+		if limit > 0 && len(result) > limit {
+			result = result[:limit]
+		}
+	}
+	return result, nil
+}
+
+// Update 读-改-写命令
+func (r *BoltCommandRepository) Update(ctx context.Context, id string, mutate func(execution *model.CommandExecution) error) (*model.CommandExecution, error) {
+	var execution *model.CommandExecution
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		execution, err = getCommand(tx, id)
+		if err != nil {
+			return err
+		}
+
+		mac := execution.MAC
+		if err := mutate(execution); err != nil {
+			return err
+		}
+		execution.ID = id
+		execution.MAC = mac
+		if err := execution.Validate(); err != nil {
+			return err
+		}
+
+		if err := putCommand(tx, execution); err != nil {
+			return err
+		}
+		if execution.Finished() {
+			return pruneCommands(tx.Bucket([]byte(BUCKET_COMMANDS)).Bucket([]byte(mac)), CommandHistoryLimit)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return execution, nil
+}
+
+// Active 列出所有未结束的命令
+func (r *BoltCommandRepository) Active(ctx context.Context) ([]*model.CommandExecution, error) {
+	var result []*model.CommandExecution
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		active := tx.Bucket([]byte(BUCKET_COMMANDS_ACTIVE))
+		parent := tx.Bucket([]byte(BUCKET_COMMANDS))
+		if active == nil || parent == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		return active.ForEach(func(k, v []byte) error {
+			commands := parent.Bucket(v)
+			if commands == nil {
+				return nil
+			}
+			data := commands.Get(k)
+			if data == nil {
+				return nil
+			}
+
+			var execution model.CommandExecution
+			if err := json.Unmarshal(data, &execution); err != nil {
+				return err
+			}
+			result = append(result, &execution)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteByNode 删除节点的全部命令
+func (r *BoltCommandRepository) DeleteByNode(ctx context.Context, mac string) error {
+	mac = model.NormalizeMAC(mac)
+
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		parent := tx.Bucket([]byte(BUCKET_COMMANDS))
+		if parent == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		commands := parent.Bucket([]byte(mac))
+		if commands == nil {
+			return nil
+		}
+
+		index := tx.Bucket([]byte(BUCKET_COMMAND_INDEX))
+		active := tx.Bucket([]byte(BUCKET_COMMANDS_ACTIVE))
+		err := commands.ForEach(func(k, v []byte) error {
+			if err := index.Delete(k); err != nil {
+				return err
+			}
+			return active.Delete(k)
+		})
+		if err != nil {
+			return err
+		}
+
+		return parent.DeleteBucket([]byte(mac))
+	})
+}
+
+// pruneCommands 已结束的命令超过 limit 时删除最旧的（未结束的命令不受影响）
+func pruneCommands(commands *bbolt.Bucket, limit int) error {
+	var finished [][]byte
+	err := commands.ForEach(func(k, v []byte) error {
+		var execution model.CommandExecution
+		if err := json.Unmarshal(v, &execution); err != nil {
+			return err
+		}
+		if execution.Finished() {
+			finished = append(finished, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	index := commands.Tx().Bucket([]byte(BUCKET_COMMAND_INDEX))
+	for i := 0; i < len(finished)-limit; i++ {
+		if err := commands.Delete(finished[i]); err != nil {
+			return err
+		}
+		if err := index.Delete(finished[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findByIdempotencyKey 在事务中查找节点上使用该幂等键的命令 ID
+// 幂等键只在保留的历史记录范围内有效
+func findByIdempotencyKey(tx *bbolt.Tx, mac, key string) (string, error) {
+	commands := tx.Bucket([]byte(BUCKET_COMMANDS)).Bucket([]byte(mac))
+	if commands == nil {
+		return "", nil
+	}
+
+	var id string
+	err := commands.ForEach(func(k, v []byte) error {
+		var execution model.CommandExecution
+		if err := json.Unmarshal(v, &execution); err != nil {
+			return err
+		}
+		if execution.IdempotencyKey == key {
+			id = execution.ID
+		}
+		return nil
+	})
+	return id, err
+}
+
+// putCommand 在事务中写入命令，并维护未结束命令索引
+func putCommand(tx *bbolt.Tx, execution *model.CommandExecution) error {
+	commands, err := tx.Bucket([]byte(BUCKET_COMMANDS)).CreateBucketIfNotExists([]byte(execution.MAC))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(execution)
+	if err != nil {
+		return err
+	}
+	if err := commands.Put([]byte(execution.ID), data); err != nil {
+		return err
+	}
+
+	active := tx.Bucket([]byte(BUCKET_COMMANDS_ACTIVE))
+	if execution.Finished() {
+		return active.Delete([]byte(execution.ID))
+	}
+	return active.Put([]byte(execution.ID), []byte(execution.MAC))
+}
+
+// getCommand 在事务中读取命令
+func getCommand(tx *bbolt.Tx, id string) (*model.CommandExecution, error) {
+	index := tx.Bucket([]byte(BUCKET_COMMAND_INDEX))
+	if index == nil {
+		return nil, fmt.Errorf("bucket not found")
+	}
+
+	mac := index.Get([]byte(id))
+	if mac == nil {
+		return nil, &ErrCommandNotFound{ID: id}
+	}
+
+	commands := tx.Bucket([]byte(BUCKET_COMMANDS)).Bucket(mac)
+	if commands == nil {
+		return nil, &ErrCommandNotFound{ID: id}
+	}
+	data := commands.Get([]byte(id))
+	if data == nil {
+		return nil, &ErrCommandNotFound{ID: id}
+	}
+
+	var execution model.CommandExecution
+	if err := json.Unmarshal(data, &execution); err != nil {
+		return nil, err
+	}
+	return &execution, nil
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// BUCKET_INSTALL_TOKENS 安装令牌 bucket（MAC → 令牌）
+const BUCKET_INSTALL_TOKENS = "install_tokens"
+
+// BoltInstallTokenRepository bbolt 实现的 InstallTokenRepository
+type BoltInstallTokenRepository struct {
+	db     *bbolt.DB
+	logger *zap.Logger
+}
+
+// NewBoltInstallTokenRepository 创建 BoltInstallTokenRepository
+func NewBoltInstallTokenRepository(db *bbolt.DB, logger *zap.Logger) *BoltInstallTokenRepository {
+	repo := &BoltInstallTokenRepository{
+		db:     db,
+		logger: logger,
+	}
+
+	// 初始化 bucket
+	if err := repo.initBucket(); err != nil {
+		logger.Error("failed to initialize install token bucket", zap.Error(err))
+	}
+
+	return repo
+}
+
+// initBucket 初始化 bucket
+func (r *BoltInstallTokenRepository) initBucket() error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(BUCKET_INSTALL_TOKENS))
+		return err
+	})
+}
+
+// Issue 为节点签发安装令牌，替换节点已有的令牌
+func (r *BoltInstallTokenRepository) Issue(ctx context.Context, token *model.InstallToken) error {
+	token.MAC = model.NormalizeMAC(token.MAC)
+
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_INSTALL_TOKENS))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+		return putInstallToken(b, token)
+	})
+}
+
+// Consume 校验令牌并将其标记为已用于指定资源
+func (r *BoltInstallTokenRepository) Consume(ctx context.Context, mac, resource, token string, now time.Time) error {
+	mac = model.NormalizeMAC(mac)
+	if token == "" {
+		return &ErrInstallTokenRejected{MAC: mac, Resource: resource, Reason: INSTALL_TOKEN_MISSING}
+	}
+
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_INSTALL_TOKENS))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		existing, err := checkInstallToken(b, mac, resource, token, now)
+		if err != nil {
+			return err
+		}
+		if _, used := existing.Used[resource]; used {
+			return &ErrInstallTokenRejected{MAC: mac, Resource: resource, Reason: INSTALL_TOKEN_REUSED}
+		}
+
+		if existing.Used == nil {
+			existing.Used = make(map[string]time.Time)
+		}
+		existing.Used[resource] = now
+		return putInstallToken(b, existing)
+	})
+}
+
+// Verify 校验令牌但不标记使用
+func (r *BoltInstallTokenRepository) Verify(ctx context.Context, mac, resource, token string, now time.Time) error {
+	mac = model.NormalizeMAC(mac)
+	if token == "" {
+		return &ErrInstallTokenRejected{MAC: mac, Resource: resource, Reason: INSTALL_TOKEN_MISSING}
+	}
+
+	return r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_INSTALL_TOKENS))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		_, err := checkInstallToken(b, mac, resource, token, now)
+		return err
+	})
+}
+
+// checkInstallToken 读取节点的安装令牌，校验令牌是否匹配且未过期
+func checkInstallToken(b *bbolt.Bucket, mac, resource, token string, now time.Time) (*model.InstallToken, error) {
+	reject := func(reason string) error {
+		return &ErrInstallTokenRejected{MAC: mac, Resource: resource, Reason: reason}
+	}
+
+	existing, err := getInstallToken(b, mac)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, reject(INSTALL_TOKEN_NOT_ISSUED)
+	}
+	if subtle.ConstantTimeCompare([]byte(existing.Token), []byte(token)) != 1 {
+		return nil, reject(INSTALL_TOKEN_MISMATCH)
+	}
+	if existing.Expired(now) {
+		return nil, reject(INSTALL_TOKEN_EXPIRED)
+	}
+	return existing, nil
+}
+
+// Revoke 删除节点的安装令牌
+func (r *BoltInstallTokenRepository) Revoke(ctx context.Context, mac string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_INSTALL_TOKENS))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+		return b.Delete([]byte(model.NormalizeMAC(mac)))
+	})
+}
+
+// getInstallToken 读取节点的安装令牌，不存在时返回 nil
+func getInstallToken(b *bbolt.Bucket, mac string) (*model.InstallToken, error) {
+	data := b.Get([]byte(mac))
+	if data == nil {
+		return nil, nil
+	}
+
+	var token model.InstallToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// putInstallToken 保存安装令牌
+func putInstallToken(b *bbolt.Bucket, token *model.InstallToken) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(token.MAC), data)
+}
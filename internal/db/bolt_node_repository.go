@@ -47,6 +47,8 @@ func (r *BoltNodeRepository) initBucket() error {
 }
 
 // Save 保存或更新节点
+// 采用比较并交换语义：node.ResourceVersion 必须等于存储中的当前版本（新节点为 0），
+// 否则返回 ErrVersionConflict；写入成功后 node.ResourceVersion 更新为新版本
 func (r *BoltNodeRepository) Save(ctx context.Context, node *model.Node) error {
 	if err := node.Validate(); err != nil {
 		return err
@@ -64,18 +66,30 @@ func (r *BoltNodeRepository) Save(ctx context.Context, node *model.Node) error {
 		existing := b.Get([]byte(mac))
 		now := time.Now()
 
+		var currentVersion uint64
 		if existing != nil {
 			// 更新现有节点，保持 CreatedAt 不变
 			var existingNode model.Node
 			if err := json.Unmarshal(existing, &existingNode); err != nil {
 				return err
 			}
+			currentVersion = existingNode.ResourceVersion
 			node.CreatedAt = existingNode.CreatedAt
+			// 心跳可能已由批量刷新写入，不回退到调用方持有的旧值
+			if existingNode.LastHeartbeat.After(node.LastHeartbeat) {
+				node.LastHeartbeat = existingNode.LastHeartbeat
+			}
 		} else {
 			// 新节点
 			node.CreatedAt = now
 		}
 
+		// 比较并交换：调用方持有的版本必须与存储中的版本一致
+		if node.ResourceVersion != currentVersion {
+			return &ErrVersionConflict{MAC: mac, Expected: node.ResourceVersion, Actual: currentVersion}
+		}
+
+		node.ResourceVersion = currentVersion + 1
 		node.UpdatedAt = now
 		node.MAC = mac
 
@@ -194,6 +208,7 @@ func (r *BoltNodeRepository) UpdateStatus(ctx context.Context, mac string, statu
 
 		node.Status = status
 		node.UpdatedAt = time.Now()
+		node.ResourceVersion++
 
 		updatedData, err := json.Marshal(node)
 		if err != nil {
@@ -204,6 +219,50 @@ func (r *BoltNodeRepository) UpdateStatus(ctx context.Context, mac string, statu
 	})
 }
 
+// UpdateHeartbeats 在单个事务中批量更新节点心跳时间
+// 心跳属于存活信息而非节点资源状态，不递增 ResourceVersion；
+// 不存在的节点会被跳过，只会把心跳向前推进
+func (r *BoltNodeRepository) UpdateHeartbeats(ctx context.Context, heartbeats map[string]time.Time) error {
+	if len(heartbeats) == 0 {
+		return nil
+	}
+
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_NODES))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		for mac, beat := range heartbeats {
+			mac = model.NormalizeMAC(mac)
+			data := b.Get([]byte(mac))
+			if data == nil {
+				continue
+			}
+
+			var node model.Node
+			if err := json.Unmarshal(data, &node); err != nil {
+				return err
+			}
+
+			if !beat.After(node.LastHeartbeat) {
+				continue
+			}
+			node.LastHeartbeat = beat
+
+			updatedData, err := json.Marshal(node)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(mac), updatedData); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
 // Delete 删除节点
 func (r *BoltNodeRepository) Delete(ctx context.Context, mac string) error {
 	mac = model.NormalizeMAC(mac)
@@ -257,3 +316,17 @@ func InitializeDB(dbPath string, logger *zap.Logger) (*bbolt.DB, error) {
 	logger.Info("database initialized", zap.String("path", dbPath))
 	return db, nil
 }
+
+// CheckDB 通过只读事务检查数据库可用，返回节点数量
+func CheckDB(db *bbolt.DB) (int, error) {
+	count := 0
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_NODES))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+		count = b.Stats().KeyN
+		return nil
+	})
+	return count, err
+}
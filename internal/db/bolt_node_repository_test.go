@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// newTestDB 在临时目录中创建数据库
+func newTestDB(t testing.TB) *bbolt.DB {
+	t.Helper()
+
+	bdb, err := InitializeDB(filepath.Join(t.TempDir(), "nodes.db"), zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitializeDB: %v", err)
+	}
+	t.Cleanup(func() { bdb.Close() })
+	return bdb
+}
+
+// saveTestNode 保存一个 discovered 节点
+func saveTestNode(t testing.TB, repo NodeRepository, mac string) *model.Node {
+	t.Helper()
+
+	node, err := model.NewNode(mac, model.STATE_DISCOVERED)
+	if err != nil {
+		t.Fatalf("NewNode: %v", err)
+	}
+	if err := repo.Save(context.Background(), node); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return node
+}
+
+func TestBoltNodeRepositorySaveCompareAndSwap(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     func(current uint64) uint64
+		wantErr     bool
+		wantVersion uint64
+	}{
+		{name: "current version", version: func(current uint64) uint64 { return current }, wantVersion: 2},
+		{name: "stale version", version: func(current uint64) uint64 { return current - 1 }, wantErr: true, wantVersion: 1},
+		{name: "future version", version: func(current uint64) uint64 { return current + 1 }, wantErr: true, wantVersion: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewBoltNodeRepository(newTestDB(t), zap.NewNop())
+			saved := saveTestNode(t, repo, "aabbccddeeff")
+			if saved.ResourceVersion != 1 {
+				t.Fatalf("new node version = %d, want 1", saved.ResourceVersion)
+			}
+
+			node, err := repo.FindByMAC(ctx, "aabbccddeeff")
+			if err != nil {
+				t.Fatalf("FindByMAC: %v", err)
+			}
+			node.ResourceVersion = tt.version(node.ResourceVersion)
+			node.Notes = "updated"
+
+			err = repo.Save(ctx, node)
+			if tt.wantErr {
+				if !IsVersionConflict(err) {
+					t.Fatalf("Save error = %v, want version conflict", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+
+			stored, err := repo.FindByMAC(ctx, "aabbccddeeff")
+			if err != nil {
+				t.Fatalf("FindByMAC: %v", err)
+			}
+			if stored.ResourceVersion != tt.wantVersion {
+				t.Errorf("stored version = %d, want %d", stored.ResourceVersion, tt.wantVersion)
+			}
+			if tt.wantErr && stored.Notes != "" {
+				t.Errorf("conflicting save was written: notes = %q", stored.Notes)
+			}
+		})
+	}
+}
+
+func TestBoltNodeRepositorySaveNewNodeWithVersion(t *testing.T) {
+	repo := NewBoltNodeRepository(newTestDB(t), zap.NewNop())
+
+	node, _ := model.NewNode("aabbccddeeff", model.STATE_DISCOVERED)
+	node.ResourceVersion = 3
+	if err := repo.Save(context.Background(), node); !IsVersionConflict(err) {
+		t.Fatalf("Save error = %v, want version conflict", err)
+	}
+}
+
+// conflictingRepo 在前 conflicts 次 Save 之前模拟并发写入，使调用方持有的版本过期
+type conflictingRepo struct {
+	*BoltNodeRepository
+	conflicts int
+	saves     int
+}
+
+func (r *conflictingRepo) Save(ctx context.Context, node *model.Node) error {
+	r.saves++
+	if r.conflicts > 0 {
+		r.conflicts--
+		other, err := r.BoltNodeRepository.FindByMAC(ctx, node.MAC)
+		if err != nil {
+			return err
+		}
+		other.Hostname = "concurrent"
+		if err := r.BoltNodeRepository.Save(ctx, other); err != nil {
+			return err
+		}
+	}
+	return r.BoltNodeRepository.Save(ctx, node)
+}
+
+func TestUpdateWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		conflicts int
+		wantErr   bool
+		wantSaves int
+	}{
+		{name: "no conflict", conflicts: 0, wantSaves: 1},
+		{name: "succeeds after conflict", conflicts: 2, wantSaves: 3},
+		{name: "succeeds on last retry", conflicts: MaxConflictRetries, wantSaves: MaxConflictRetries + 1},
+		{name: "gives up", conflicts: MaxConflictRetries + 1, wantErr: true, wantSaves: MaxConflictRetries + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &conflictingRepo{BoltNodeRepository: NewBoltNodeRepository(newTestDB(t), zap.NewNop())}
+			saveTestNode(t, repo.BoltNodeRepository, "aabbccddeeff")
+			repo.conflicts = tt.conflicts
+
+			calls := 0
+			node, err := UpdateWithRetry(ctx, repo, "aabbccddeeff", func(node *model.Node) error {
+				calls++
+				node.Notes = "updated"
+				return nil
+			})
+			if repo.saves != tt.wantSaves {
+				t.Errorf("saves = %d, want %d", repo.saves, tt.wantSaves)
+			}
+			if calls != tt.wantSaves {
+				t.Errorf("mutate calls = %d, want %d", calls, tt.wantSaves)
+			}
+
+			if tt.wantErr {
+				if !IsVersionConflict(err) {
+					t.Fatalf("UpdateWithRetry error = %v, want version conflict", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateWithRetry: %v", err)
+			}
+
+			stored, _ := repo.FindByMAC(ctx, "aabbccddeeff")
+			if stored.Notes != "updated" || stored.ResourceVersion != node.ResourceVersion {
+				t.Errorf("stored notes = %q version = %d, want %q version %d",
+					stored.Notes, stored.ResourceVersion, "updated", node.ResourceVersion)
+			}
+			// 重试基于最新节点，不覆盖并发写入
+			if tt.conflicts > 0 && stored.Hostname != "concurrent" {
+				t.Errorf("concurrent write lost: hostname = %q", stored.Hostname)
+			}
+		})
+	}
+}
+
+func TestUpdateWithRetryNotFound(t *testing.T) {
+	repo := NewBoltNodeRepository(newTestDB(t), zap.NewNop())
+
+	_, err := UpdateWithRetry(context.Background(), repo, "aabbccddeeff", func(*model.Node) error { return nil })
+	if !IsNodeNotFound(err) {
+		t.Fatalf("UpdateWithRetry error = %v, want node not found", err)
+	}
+}
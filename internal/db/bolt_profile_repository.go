@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// BUCKET_PROFILES 安装配置：名称 → 配置
+const BUCKET_PROFILES = "profiles"
+
+// BoltProfileRepository bbolt 实现的 ProfileRepository
+type BoltProfileRepository struct {
+	db     *bbolt.DB
+	logger *zap.Logger
+}
+
+// NewBoltProfileRepository 创建 BoltProfileRepository
+func NewBoltProfileRepository(db *bbolt.DB, logger *zap.Logger) *BoltProfileRepository {
+	repo := &BoltProfileRepository{
+		db:     db,
+		logger: logger,
+	}
+
+	// 初始化 bucket
+	if err := repo.initBucket(); err != nil {
+		logger.Error("failed to initialize profile bucket", zap.Error(err))
+	}
+
+	return repo
+}
+
+// initBucket 初始化 bucket
+func (r *BoltProfileRepository) initBucket() error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(BUCKET_PROFILES))
+		return err
+	})
+}
+
+// Create 保存新配置
+func (r *BoltProfileRepository) Create(ctx context.Context, profile *model.InstallProfile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_PROFILES))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		if b.Get([]byte(profile.Name)) != nil {
+			return &ErrProfileAlreadyExists{Name: profile.Name}
+		}
+
+		return putProfile(tx, profile)
+	})
+}
+
+// FindByName 根据名称查找配置
+func (r *BoltProfileRepository) FindByName(ctx context.Context, name string) (*model.InstallProfile, error) {
+	var profile *model.InstallProfile
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		profile, err = getProfile(tx, name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// FindByGroup 查找分配给节点分组的配置
+func (r *BoltProfileRepository) FindByGroup(ctx context.Context, group string) (*model.InstallProfile, error) {
+	var found *model.InstallProfile
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		found, err = findProfileByGroup(tx, group)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, &ErrProfileNotFound{Group: group}
+	}
+	return found, nil
+}
+
+// List 列出所有配置（按名称排序）
+func (r *BoltProfileRepository) List(ctx context.Context) ([]*model.InstallProfile, error) {
+	var profiles []*model.InstallProfile
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_PROFILES))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var profile model.InstallProfile
+			if err := json.Unmarshal(v, &profile); err != nil {
+				return err
+			}
+			profiles = append(profiles, &profile)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// Update 读-改-写配置（名称不可修改）
+func (r *BoltProfileRepository) Update(ctx context.Context, name string, mutate func(profile *model.InstallProfile) error) (*model.InstallProfile, error) {
+	var profile *model.InstallProfile
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		profile, err = getProfile(tx, name)
+		if err != nil {
+			return err
+		}
+
+		if err := mutate(profile); err != nil {
+			return err
+		}
+		profile.Name = name
+		profile.UpdatedAt = time.Now()
+		if err := profile.Validate(); err != nil {
+			return err
+		}
+
+		return putProfile(tx, profile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Delete 删除配置
+func (r *BoltProfileRepository) Delete(ctx context.Context, name string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		if _, err := getProfile(tx, name); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(BUCKET_PROFILES)).Delete([]byte(name))
+	})
+}
+
+// putProfile 在事务中写入配置，分组已分配给其他配置时返回 ErrProfileGroupConflict
+func putProfile(tx *bbolt.Tx, profile *model.InstallProfile) error {
+	for _, group := range profile.Groups {
+		other, err := findProfileByGroup(tx, group)
+		if err != nil {
+			return err
+		}
+		if other != nil && other.Name != profile.Name {
+			return &ErrProfileGroupConflict{Group: group, Profile: other.Name}
+		}
+	}
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket([]byte(BUCKET_PROFILES)).Put([]byte(profile.Name), data)
+}
+
+// getProfile 在事务中读取配置
+func getProfile(tx *bbolt.Tx, name string) (*model.InstallProfile, error) {
+	b := tx.Bucket([]byte(BUCKET_PROFILES))
+	if b == nil {
+		return nil, fmt.Errorf("bucket not found")
+	}
+
+	data := b.Get([]byte(name))
+	if data == nil {
+		return nil, &ErrProfileNotFound{Name: name}
+	}
+
+	var profile model.InstallProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// findProfileByGroup 在事务中查找分配给分组的配置，不存在时返回 nil
+func findProfileByGroup(tx *bbolt.Tx, group string) (*model.InstallProfile, error) {
+	b := tx.Bucket([]byte(BUCKET_PROFILES))
+	if b == nil {
+		return nil, fmt.Errorf("bucket not found")
+	}
+
+	var found *model.InstallProfile
+	err := b.ForEach(func(k, v []byte) error {
+		if found != nil {
+			return nil
+		}
+		var profile model.InstallProfile
+		if err := json.Unmarshal(v, &profile); err != nil {
+			return err
+		}
+		if profile.HasGroup(group) {
+			found = &profile
+		}
+		return nil
+	})
+	return found, err
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// Bucket 名称
+const (
+	BUCKET_API_TOKENS       = "api_tokens"
+	BUCKET_API_TOKEN_HASHES = "api_token_hashes" // 摘要 → ID 索引
+)
+
+// BoltTokenRepository bbolt 实现的 TokenRepository
+type BoltTokenRepository struct {
+	db     *bbolt.DB
+	logger *zap.Logger
+}
+
+// NewBoltTokenRepository 创建 BoltTokenRepository
+func NewBoltTokenRepository(db *bbolt.DB, logger *zap.Logger) *BoltTokenRepository {
+	repo := &BoltTokenRepository{
+		db:     db,
+		logger: logger,
+	}
+
+	// 初始化 bucket
+	if err := repo.initBucket(); err != nil {
+		logger.Error("failed to initialize token buckets", zap.Error(err))
+	}
+
+	return repo
+}
+
+// initBucket 初始化 bucket
+func (r *BoltTokenRepository) initBucket() error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists([]byte(BUCKET_API_TOKENS)); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists([]byte(BUCKET_API_TOKEN_HASHES))
+		return err
+	})
+}
+
+// Create 保存新 token
+func (r *BoltTokenRepository) Create(ctx context.Context, token *model.APIToken) error {
+	if err := token.Validate(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_API_TOKENS))
+		idx := tx.Bucket([]byte(BUCKET_API_TOKEN_HASHES))
+		if b == nil || idx == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		if b.Get([]byte(token.ID)) != nil {
+			return fmt.Errorf("token id already exists: %s", token.ID)
+		}
+
+		data, err := json.Marshal(token)
+		if err != nil {
+			return err
+		}
+
+		if err := b.Put([]byte(token.ID), data); err != nil {
+			return err
+		}
+		return idx.Put([]byte(token.Hash), []byte(token.ID))
+	})
+}
+
+// FindByID 根据 ID 查找 token
+func (r *BoltTokenRepository) FindByID(ctx context.Context, id string) (*model.APIToken, error) {
+	var token *model.APIToken
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		token, err = getToken(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// FindByHash 根据 token 摘要查找 token
+func (r *BoltTokenRepository) FindByHash(ctx context.Context, hash string) (*model.APIToken, error) {
+	var token *model.APIToken
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		idx := tx.Bucket([]byte(BUCKET_API_TOKEN_HASHES))
+		if idx == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		id := idx.Get([]byte(hash))
+		if id == nil {
+			return &ErrTokenNotFound{}
+		}
+
+		var err error
+		token, err = getToken(tx, string(id))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// List 列出所有 token（按创建时间升序）
+func (r *BoltTokenRepository) List(ctx context.Context) ([]*model.APIToken, error) {
+	var tokens []*model.APIToken
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_API_TOKENS))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var token model.APIToken
+			if err := json.Unmarshal(v, &token); err != nil {
+				return err
+			}
+			tokens = append(tokens, &token)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+// Revoke 吊销 token（保留记录以便审计）
+func (r *BoltTokenRepository) Revoke(ctx context.Context, id string) error {
+	return r.updateToken(id, func(token *model.APIToken) {
+		if token.RevokedAt.IsZero() {
+			token.RevokedAt = time.Now()
+		}
+	})
+}
+
+// Touch 记录 token 最近使用时间
+func (r *BoltTokenRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	return r.updateToken(id, func(token *model.APIToken) {
+		token.LastUsedAt = at
+	})
+}
+
+// updateToken 读-改-写单个 token
+func (r *BoltTokenRepository) updateToken(id string, mutate func(token *model.APIToken)) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		token, err := getToken(tx, id)
+		if err != nil {
+			return err
+		}
+
+		mutate(token)
+
+		data, err := json.Marshal(token)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(BUCKET_API_TOKENS)).Put([]byte(id), data)
+	})
+}
+
+// getToken 在事务中读取 token
+func getToken(tx *bbolt.Tx, id string) (*model.APIToken, error) {
+	b := tx.Bucket([]byte(BUCKET_API_TOKENS))
+	if b == nil {
+		return nil, fmt.Errorf("bucket not found")
+	}
+
+	data := b.Get([]byte(id))
+	if data == nil {
+		return nil, &ErrTokenNotFound{ID: id}
+	}
+
+	var token model.APIToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
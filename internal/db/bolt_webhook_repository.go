@@ -0,0 +1,356 @@
+package db
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// Bucket 名称
+const (
+	BUCKET_WEBHOOKS = "webhooks"
+	// 每个 webhook 一个子 bucket：投递 ID → 投递记录
+	BUCKET_WEBHOOK_DELIVERIES = "webhook_deliveries"
+	// 待投递队列：下次尝试时间（8 字节大端纳秒）+ 投递 ID → webhook ID
+	BUCKET_WEBHOOK_QUEUE = "webhook_queue"
+)
+
+// BoltWebhookRepository bbolt 实现的 WebhookRepository
+type BoltWebhookRepository struct {
+	db     *bbolt.DB
+	logger *zap.Logger
+}
+
+// NewBoltWebhookRepository 创建 BoltWebhookRepository
+func NewBoltWebhookRepository(db *bbolt.DB, logger *zap.Logger) *BoltWebhookRepository {
+	repo := &BoltWebhookRepository{
+		db:     db,
+		logger: logger,
+	}
+
+	// 初始化 bucket
+	if err := repo.initBucket(); err != nil {
+		logger.Error("failed to initialize webhook buckets", zap.Error(err))
+	}
+
+	return repo
+}
+
+// initBucket 初始化 bucket
+func (r *BoltWebhookRepository) initBucket() error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range []string{BUCKET_WEBHOOKS, BUCKET_WEBHOOK_DELIVERIES, BUCKET_WEBHOOK_QUEUE} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Create 保存新 webhook
+func (r *BoltWebhookRepository) Create(ctx context.Context, webhook *model.Webhook) error {
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_WEBHOOKS))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		if b.Get([]byte(webhook.ID)) != nil {
+			return fmt.Errorf("webhook id already exists: %s", webhook.ID)
+		}
+
+		return putWebhook(tx, webhook)
+	})
+}
+
+// FindByID 根据 ID 查找 webhook
+func (r *BoltWebhookRepository) FindByID(ctx context.Context, id string) (*model.Webhook, error) {
+	var webhook *model.Webhook
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		webhook, err = getWebhook(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// List 列出所有 webhook（按创建时间升序）
+func (r *BoltWebhookRepository) List(ctx context.Context) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BUCKET_WEBHOOKS))
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var webhook model.Webhook
+			if err := json.Unmarshal(v, &webhook); err != nil {
+				return err
+			}
+			webhooks = append(webhooks, &webhook)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt) })
+	return webhooks, nil
+}
+
+// Update 读-改-写 webhook
+func (r *BoltWebhookRepository) Update(ctx context.Context, id string, mutate func(webhook *model.Webhook) error) (*model.Webhook, error) {
+	var webhook *model.Webhook
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		webhook, err = getWebhook(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := mutate(webhook); err != nil {
+			return err
+		}
+		webhook.ID = id
+		webhook.UpdatedAt = time.Now()
+		if err := webhook.Validate(); err != nil {
+			return err
+		}
+
+		return putWebhook(tx, webhook)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// Delete 删除 webhook 及其全部投递记录
+func (r *BoltWebhookRepository) Delete(ctx context.Context, id string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		if _, err := getWebhook(tx, id); err != nil {
+			return err
+		}
+
+		if err := tx.Bucket([]byte(BUCKET_WEBHOOKS)).Delete([]byte(id)); err != nil {
+			return err
+		}
+
+		parent := tx.Bucket([]byte(BUCKET_WEBHOOK_DELIVERIES))
+		deliveries := parent.Bucket([]byte(id))
+		if deliveries == nil {
+			return nil
+		}
+
+		// 移除仍在队列中的投递
+		queue := tx.Bucket([]byte(BUCKET_WEBHOOK_QUEUE))
+		err := deliveries.ForEach(func(k, v []byte) error {
+			var delivery model.WebhookDelivery
+			if err := json.Unmarshal(v, &delivery); err != nil {
+				return err
+			}
+			if delivery.Pending() {
+				return queue.Delete(queueKey(&delivery))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return parent.DeleteBucket([]byte(id))
+	})
+}
+
+// SaveDelivery 创建或更新投递记录
+// 待投递的记录按下次尝试时间进入队列；已完成的记录超过历史上限时删除最旧的
+func (r *BoltWebhookRepository) SaveDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		// webhook 已删除时不再写入记录
+		if _, err := getWebhook(tx, delivery.WebhookID); err != nil {
+			return err
+		}
+
+		deliveries, err := tx.Bucket([]byte(BUCKET_WEBHOOK_DELIVERIES)).CreateBucketIfNotExists([]byte(delivery.WebhookID))
+		if err != nil {
+			return err
+		}
+		queue := tx.Bucket([]byte(BUCKET_WEBHOOK_QUEUE))
+
+		// 移除旧的队列位置
+		if data := deliveries.Get([]byte(delivery.ID)); data != nil {
+			var old model.WebhookDelivery
+			if err := json.Unmarshal(data, &old); err != nil {
+				return err
+			}
+			if old.Pending() {
+				if err := queue.Delete(queueKey(&old)); err != nil {
+					return err
+				}
+			}
+		}
+
+		data, err := json.Marshal(delivery)
+		if err != nil {
+			return err
+		}
+		if err := deliveries.Put([]byte(delivery.ID), data); err != nil {
+			return err
+		}
+
+		if delivery.Pending() {
+			return queue.Put(queueKey(delivery), []byte(delivery.WebhookID))
+		}
+		return pruneDeliveries(deliveries, WebhookDeliveryHistoryLimit)
+	})
+}
+
+// ListDeliveries 列出 webhook 的投递记录（最新的在前）
+func (r *BoltWebhookRepository) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*model.WebhookDelivery, error) {
+	var result []*model.WebhookDelivery
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		if _, err := getWebhook(tx, webhookID); err != nil {
+			return err
+		}
+
+		deliveries := tx.Bucket([]byte(BUCKET_WEBHOOK_DELIVERIES)).Bucket([]byte(webhookID))
+		if deliveries == nil {
+			return nil
+		}
+
+		c := deliveries.Cursor()
+		for k, v := c.Last(); k != nil && (limit <= 0 || len(result) < limit); k, v = c.Prev() {
+			var delivery model.WebhookDelivery
+			if err := json.Unmarshal(v, &delivery); err != nil {
+				return err
+			}
+			result = append(result, &delivery)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DueDeliveries 返回到期的待投递记录（最早的在前）
+func (r *BoltWebhookRepository) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var result []*model.WebhookDelivery
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		queue := tx.Bucket([]byte(BUCKET_WEBHOOK_QUEUE))
+		parent := tx.Bucket([]byte(BUCKET_WEBHOOK_DELIVERIES))
+		if queue == nil || parent == nil {
+			return fmt.Errorf("bucket not found")
+		}
+
+		deadline := uint64(now.UnixNano())
+		c := queue.Cursor()
+		for k, v := c.First(); k != nil && (limit <= 0 || len(result) < limit); k, v = c.Next() {
+			if binary.BigEndian.Uint64(k[:8]) > deadline {
+				break
+			}
+
+			deliveries := parent.Bucket(v)
+			if deliveries == nil {
+				continue
+			}
+			data := deliveries.Get(k[8:])
+			if data == nil {
+				continue
+			}
+
+			var delivery model.WebhookDelivery
+			if err := json.Unmarshal(data, &delivery); err != nil {
+				return err
+			}
+			result = append(result, &delivery)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pruneDeliveries 已完成的投递记录超过 limit 时删除最旧的（待投递记录不受影响）
+func pruneDeliveries(deliveries *bbolt.Bucket, limit int) error {
+	var completed [][]byte
+	err := deliveries.ForEach(func(k, v []byte) error {
+		var delivery model.WebhookDelivery
+		if err := json.Unmarshal(v, &delivery); err != nil {
+			return err
+		}
+		if !delivery.Pending() {
+			completed = append(completed, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(completed)-limit; i++ {
+		if err := deliveries.Delete(completed[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queueKey 投递在待投递队列中的键
+func queueKey(delivery *model.WebhookDelivery) []byte {
+	key := make([]byte, 8, 8+len(delivery.ID))
+	binary.BigEndian.PutUint64(key, uint64(delivery.NextAttemptAt.UnixNano()))
+	return append(key, delivery.ID...)
+}
+
+// putWebhook 在事务中写入 webhook
+func putWebhook(tx *bbolt.Tx, webhook *model.Webhook) error {
+	data, err := json.Marshal(webhook)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket([]byte(BUCKET_WEBHOOKS)).Put([]byte(webhook.ID), data)
+}
+
+// getWebhook 在事务中读取 webhook
+func getWebhook(tx *bbolt.Tx, id string) (*model.Webhook, error) {
+	b := tx.Bucket([]byte(BUCKET_WEBHOOKS))
+	if b == nil {
+		return nil, fmt.Errorf("bucket not found")
+	}
+
+	data := b.Get([]byte(id))
+	if data == nil {
+		return nil, &ErrWebhookNotFound{ID: id}
+	}
+
+	var webhook model.Webhook
+	if err := json.Unmarshal(data, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
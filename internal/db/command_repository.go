@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// CommandHistoryLimit 每个节点保留的已结束命令数
+const CommandHistoryLimit = 100
+
+// CommandRepository 定义节点命令执行记录存储接口
+type CommandRepository interface {
+	// Create 保存新命令，同一节点已有相同幂等键的命令时返回 ErrDuplicateCommand
+	Create(ctx context.Context, execution *model.CommandExecution) error
+
+	// FindByID 根据 ID 查找命令
+	FindByID(ctx context.Context, id string) (*model.CommandExecution, error)
+
+	// List 列出节点的命令（最新的在前），mac 为空表示全部节点，status 为空表示不限制
+	List(ctx context.Context, mac, status string, limit int) ([]*model.CommandExecution, error)
+
+	// Update 读-改-写命令，并维护未结束命令索引和历史记录上限
+	Update(ctx context.Context, id string, mutate func(execution *model.CommandExecution) error) (*model.CommandExecution, error)
+
+	// Active 列出所有未结束的命令
+	Active(ctx context.Context) ([]*model.CommandExecution, error)
+
+	// DeleteByNode 删除节点的全部命令
+	DeleteByNode(ctx context.Context, mac string) error
+}
+
+// ErrCommandNotFound 命令不存在错误
+type ErrCommandNotFound struct {
+	ID string
+}
+
+func (e *ErrCommandNotFound) Error() string {
+	return "command not found"
+}
+
+// IsCommandNotFound 判断错误是否为命令不存在
+func IsCommandNotFound(err error) bool {
+	var notFound *ErrCommandNotFound
+	return errors.As(err, &notFound)
+}
+
+// ErrDuplicateCommand 同一节点已存在相同幂等键的命令
+type ErrDuplicateCommand struct {
+	// ID 已存在命令的 ID
+	ID string
+}
+
+func (e *ErrDuplicateCommand) Error() string {
+	return "command with the same idempotency key already exists"
+}
+
+// IsDuplicateCommand 判断错误是否为重复命令
+func IsDuplicateCommand(err error) bool {
+	var duplicate *ErrDuplicateCommand
+	return errors.As(err, &duplicate)
+}
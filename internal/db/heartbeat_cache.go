@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// HeartbeatCache 心跳写回缓存
+// 心跳时间保存在内存中并按固定间隔批量刷新到存储，
+// 避免每条 MQTT 心跳都触发一次完整的节点写入和 fsync。
+// HeartbeatCache 同时实现 NodeRepository，读取时用内存中更新的心跳覆盖存储中的值
+type HeartbeatCache struct {
+	repo     NodeRepository
+	store    HeartbeatStore
+	interval time.Duration
+	logger   *zap.Logger
+
+	mu sync.Mutex
+	// 最新心跳：mac → 时间
+	latest map[string]time.Time
+	// 尚未持久化的心跳：mac → 时间
+	pending map[string]time.Time
+}
+
+// NewHeartbeatCache 创建心跳写回缓存
+func NewHeartbeatCache(repo NodeRepository, store HeartbeatStore, interval time.Duration, logger *zap.Logger) *HeartbeatCache {
+	return &HeartbeatCache{
+		repo:     repo,
+		store:    store,
+		interval: interval,
+		logger:   logger,
+		latest:   make(map[string]time.Time),
+		pending:  make(map[string]time.Time),
+	}
+}
+
+// Touch 记录节点心跳（仅写内存，等待批量刷新）
+func (c *HeartbeatCache) Touch(mac string, at time.Time) {
+	mac = model.NormalizeMAC(mac)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if at.After(c.latest[mac]) {
+		c.latest[mac] = at
+		c.pending[mac] = at
+	}
+}
+
+// LastHeartbeat 获取内存中记录的节点最新心跳
+func (c *HeartbeatCache) LastHeartbeat(mac string) (time.Time, bool) {
+	mac = model.NormalizeMAC(mac)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.latest[mac]
+	return t, ok
+}
+
+// Pending 返回尚未持久化的心跳数量
+func (c *HeartbeatCache) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.pending)
+}
+
+// Flush 将待持久化的心跳批量写入存储
+func (c *HeartbeatCache) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	batch := c.pending
+	c.pending = make(map[string]time.Time)
+	c.mu.Unlock()
+
+	if err := c.store.UpdateHeartbeats(ctx, batch); err != nil {
+		// 写入失败，放回待刷新队列（保留更新的值）
+		c.mu.Lock()
+		for mac, at := range batch {
+			if at.After(c.pending[mac]) {
+				c.pending[mac] = at
+			}
+		}
+		c.mu.Unlock()
+		return err
+	}
+
+	c.logger.Debug("heartbeats flushed", zap.Int("count", len(batch)))
+	return nil
+}
+
+// Run 按刷新间隔周期性写回心跳，直到 context 取消
+// 最后一次写回由调用方在关闭存储前通过 Flush 完成
+func (c *HeartbeatCache) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil {
+				c.logger.Error("failed to flush heartbeats", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// overlay 用内存中更新的心跳覆盖节点心跳
+func (c *HeartbeatCache) overlay(nodes ...*model.Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, node := range nodes {
+		if at, ok := c.latest[model.NormalizeMAC(node.MAC)]; ok && at.After(node.LastHeartbeat) {
+			node.LastHeartbeat = at
+		}
+	}
+}
+
+// Save 保存或更新节点，写入的心跳视为已持久化
+func (c *HeartbeatCache) Save(ctx context.Context, node *model.Node) error {
+	c.overlay(node)
+
+	if err := c.repo.Save(ctx, node); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if at, ok := c.pending[node.MAC]; ok && !at.After(node.LastHeartbeat) {
+		delete(c.pending, node.MAC)
+	}
+	if node.LastHeartbeat.After(c.latest[node.MAC]) {
+		c.latest[node.MAC] = node.LastHeartbeat
+	}
+
+	return nil
+}
+
+// FindByMAC 根据 MAC 地址查找节点
+func (c *HeartbeatCache) FindByMAC(ctx context.Context, mac string) (*model.Node, error) {
+	node, err := c.repo.FindByMAC(ctx, mac)
+	if err != nil {
+		return nil, err
+	}
+
+	c.overlay(node)
+	return node, nil
+}
+
+// List 列出所有节点
+func (c *HeartbeatCache) List(ctx context.Context) ([]*model.Node, error) {
+	nodes, err := c.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.overlay(nodes...)
+	return nodes, nil
+}
+
+// ListByStatus 按状态筛选节点
+func (c *HeartbeatCache) ListByStatus(ctx context.Context, status string) ([]*model.Node, error) {
+	nodes, err := c.repo.ListByStatus(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+
+	c.overlay(nodes...)
+	return nodes, nil
+}
+
+// UpdateStatus 更新节点状态（带转换验证）
+func (c *HeartbeatCache) UpdateStatus(ctx context.Context, mac string, status string) error {
+	return c.repo.UpdateStatus(ctx, mac, status)
+}
+
+// Delete 删除节点，同时丢弃其缓存的心跳
+func (c *HeartbeatCache) Delete(ctx context.Context, mac string) error {
+	if err := c.repo.Delete(ctx, mac); err != nil {
+		return err
+	}
+
+	mac = model.NormalizeMAC(mac)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.latest, mac)
+	delete(c.pending, mac)
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// benchNodes 心跳基准测试中的节点数量
+const benchNodes = 1000
+
+// setupHeartbeatBench 在临时数据库中创建 benchNodes 个节点，返回节点 MAC
+func setupHeartbeatBench(b *testing.B) (*BoltNodeRepository, []string) {
+	b.Helper()
+
+	repo := NewBoltNodeRepository(newTestDB(b), zap.NewNop())
+	macs := make([]string, benchNodes)
+	for i := range macs {
+		macs[i] = fmt.Sprintf("aabbcc%06x", i)
+		saveTestNode(b, repo, macs[i])
+	}
+	return repo, macs
+}
+
+// BenchmarkHeartbeatSave 每条心跳消息读取节点并完整保存一次（写回缓存之前的处理方式）
+func BenchmarkHeartbeatSave(b *testing.B) {
+	ctx := context.Background()
+	repo, macs := setupHeartbeatBench(b)
+	base := time.Now()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		node, err := repo.FindByMAC(ctx, macs[i%len(macs)])
+		if err != nil {
+			b.Fatal(err)
+		}
+		node.LastHeartbeat = base.Add(time.Duration(i) * time.Millisecond)
+		if err := repo.Save(ctx, node); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "msgs/s")
+}
+
+// BenchmarkHeartbeatCache 每条心跳消息读取节点并写入缓存，每轮（所有节点各一条心跳）批量刷新一次
+func BenchmarkHeartbeatCache(b *testing.B) {
+	ctx := context.Background()
+	repo, macs := setupHeartbeatBench(b)
+	cache := NewHeartbeatCache(repo, repo, time.Minute, zap.NewNop())
+	base := time.Now()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mac := macs[i%len(macs)]
+		if _, err := cache.FindByMAC(ctx, mac); err != nil {
+			b.Fatal(err)
+		}
+		cache.Touch(mac, base.Add(time.Duration(i)*time.Millisecond))
+		if (i+1)%len(macs) == 0 {
+			if err := cache.Flush(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	if err := cache.Flush(ctx); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "msgs/s")
+}
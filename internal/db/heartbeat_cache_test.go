@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// newTestCache 创建基于临时数据库的心跳缓存
+func newTestCache(t testing.TB) (*HeartbeatCache, *BoltNodeRepository) {
+	t.Helper()
+
+	repo := NewBoltNodeRepository(newTestDB(t), zap.NewNop())
+	return NewHeartbeatCache(repo, repo, time.Minute, zap.NewNop()), repo
+}
+
+func TestHeartbeatCacheTouchOverlaysReads(t *testing.T) {
+	ctx := context.Background()
+	cache, repo := newTestCache(t)
+	saveTestNode(t, repo, "aabbccddee01")
+	saveTestNode(t, repo, "aabbccddee02")
+
+	beat := time.Now().Add(time.Minute).Truncate(time.Second)
+	cache.Touch("aa:bb:cc:dd:ee:01", beat)
+
+	node, err := cache.FindByMAC(ctx, "aabbccddee01")
+	if err != nil {
+		t.Fatalf("FindByMAC: %v", err)
+	}
+	if !node.LastHeartbeat.Equal(beat) {
+		t.Errorf("FindByMAC heartbeat = %v, want %v", node.LastHeartbeat, beat)
+	}
+
+	nodes, err := cache.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, n := range nodes {
+		if got := n.LastHeartbeat.Equal(beat); got != (n.MAC == "aabbccddee01") {
+			t.Errorf("List %s heartbeat = %v", n.MAC, n.LastHeartbeat)
+		}
+	}
+
+	// 尚未刷新，存储中仍为旧值
+	stored, _ := repo.FindByMAC(ctx, "aabbccddee01")
+	if stored.LastHeartbeat.Equal(beat) {
+		t.Error("heartbeat persisted before Flush")
+	}
+	if cache.Pending() != 1 {
+		t.Errorf("Pending = %d, want 1", cache.Pending())
+	}
+
+	// 更早的心跳不会覆盖
+	cache.Touch("aabbccddee01", beat.Add(-time.Hour))
+	if got, _ := cache.LastHeartbeat("aabbccddee01"); !got.Equal(beat) {
+		t.Errorf("LastHeartbeat = %v after older Touch, want %v", got, beat)
+	}
+}
+
+func TestHeartbeatCacheFlushKeepsResourceVersion(t *testing.T) {
+	ctx := context.Background()
+	cache, repo := newTestCache(t)
+	saved := saveTestNode(t, repo, "aabbccddeeff")
+
+	beat := time.Now().Add(time.Minute).Truncate(time.Second)
+	cache.Touch("aabbccddeeff", beat)
+	// 已删除节点的心跳被跳过
+	cache.Touch("001122334455", beat)
+
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if cache.Pending() != 0 {
+		t.Errorf("Pending = %d after Flush, want 0", cache.Pending())
+	}
+
+	stored, err := repo.FindByMAC(ctx, "aabbccddeeff")
+	if err != nil {
+		t.Fatalf("FindByMAC: %v", err)
+	}
+	if !stored.LastHeartbeat.Equal(beat) {
+		t.Errorf("stored heartbeat = %v, want %v", stored.LastHeartbeat, beat)
+	}
+	if stored.ResourceVersion != saved.ResourceVersion {
+		t.Errorf("ResourceVersion = %d after Flush, want %d", stored.ResourceVersion, saved.ResourceVersion)
+	}
+	if _, err := repo.FindByMAC(ctx, "001122334455"); !IsNodeNotFound(err) {
+		t.Errorf("Flush created unknown node: %v", err)
+	}
+
+	// 调用方持有的版本在刷新后仍然有效
+	stored.Notes = "after flush"
+	if err := cache.Save(ctx, stored); err != nil {
+		t.Fatalf("Save after Flush: %v", err)
+	}
+}
+
+// failingStore 写入总是失败的 HeartbeatStore
+type failingStore struct{}
+
+func (failingStore) UpdateHeartbeats(context.Context, map[string]time.Time) error {
+	return errors.New("disk full")
+}
+
+func TestHeartbeatCacheFlushFailureRequeues(t *testing.T) {
+	repo := NewBoltNodeRepository(newTestDB(t), zap.NewNop())
+	cache := NewHeartbeatCache(repo, failingStore{}, time.Minute, zap.NewNop())
+
+	cache.Touch("aabbccddeeff", time.Now())
+	if err := cache.Flush(context.Background()); err == nil {
+		t.Fatal("Flush succeeded with failing store")
+	}
+	if cache.Pending() != 1 {
+		t.Errorf("Pending = %d after failed Flush, want 1", cache.Pending())
+	}
+}
+
+func TestSaveKeepsNewerHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	cache, repo := newTestCache(t)
+	saveTestNode(t, repo, "aabbccddeeff")
+
+	// 写入方在心跳刷新之前读取节点
+	stale, err := repo.FindByMAC(ctx, "aabbccddeeff")
+	if err != nil {
+		t.Fatalf("FindByMAC: %v", err)
+	}
+
+	beat := time.Now().Add(time.Minute).Truncate(time.Second)
+	cache.Touch("aabbccddeeff", beat)
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	// 刷新不递增版本，旧副本的保存成功，但不能回退心跳
+	stale.Notes = "stale copy"
+	if err := repo.Save(ctx, stale); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	stored, _ := repo.FindByMAC(ctx, "aabbccddeeff")
+	if !stored.LastHeartbeat.Equal(beat) {
+		t.Errorf("heartbeat rolled back to %v, want %v", stored.LastHeartbeat, beat)
+	}
+	if stored.Notes != "stale copy" {
+		t.Errorf("notes = %q, want %q", stored.Notes, "stale copy")
+	}
+}
+
+func TestHeartbeatCacheConcurrentSaveAndFlush(t *testing.T) {
+	ctx := context.Background()
+	cache, repo := newTestCache(t)
+	saveTestNode(t, repo, "aabbccddeeff")
+
+	base := time.Now().Truncate(time.Second)
+	const beats = 200
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= beats; i++ {
+			cache.Touch("aabbccddeeff", base.Add(time.Duration(i)*time.Second))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < beats/4; i++ {
+			if err := cache.Flush(ctx); err != nil {
+				t.Errorf("Flush: %v", err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < beats/4; i++ {
+			_, err := UpdateWithRetry(ctx, cache, "aabbccddeeff", func(node *model.Node) error {
+				node.Notes = time.Now().String()
+				return nil
+			})
+			if err != nil {
+				t.Errorf("UpdateWithRetry: %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	if err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	want := base.Add(beats * time.Second)
+	stored, _ := repo.FindByMAC(ctx, "aabbccddeeff")
+	if !stored.LastHeartbeat.Equal(want) {
+		t.Errorf("stored heartbeat = %v, want %v", stored.LastHeartbeat, want)
+	}
+	if cache.Pending() != 0 {
+		t.Errorf("Pending = %d, want 0", cache.Pending())
+	}
+}
+
+func TestHeartbeatCacheDeleteDropsHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	cache, repo := newTestCache(t)
+	saveTestNode(t, repo, "aabbccddeeff")
+
+	cache.Touch("aabbccddeeff", time.Now())
+	if err := cache.Delete(ctx, "aabbccddeeff"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := cache.LastHeartbeat("aabbccddeeff"); ok || cache.Pending() != 0 {
+		t.Error("heartbeat kept for deleted node")
+	}
+}
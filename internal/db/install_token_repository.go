@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 安装令牌拒绝原因
+const (
+	INSTALL_TOKEN_MISSING    = "missing"    // 请求未携带令牌
+	INSTALL_TOKEN_NOT_ISSUED = "not issued" // 节点没有有效的安装令牌
+	INSTALL_TOKEN_MISMATCH   = "mismatch"   // 令牌不匹配
+	INSTALL_TOKEN_EXPIRED    = "expired"    // 令牌已过期
+	INSTALL_TOKEN_REUSED     = "reused"     // 令牌已用于该资源
+)
+
+// InstallTokenRepository 定义节点安装令牌存储接口
+type InstallTokenRepository interface {
+	// Issue 为节点签发安装令牌，替换节点已有的令牌（旧令牌立即失效）
+	Issue(ctx context.Context, token *model.InstallToken) error
+
+	// Consume 校验令牌并将其标记为已用于指定资源
+	Consume(ctx context.Context, mac, resource, token string, now time.Time) error
+
+	// Verify 校验令牌但不标记使用，用于安装过程中可能多次请求的资源（如 cloud-init 数据）
+	Verify(ctx context.Context, mac, resource, token string, now time.Time) error
+
+	// Revoke 删除节点的安装令牌
+	Revoke(ctx context.Context, mac string) error
+}
+
+// ErrInstallTokenRejected 安装令牌校验失败错误
+type ErrInstallTokenRejected struct {
+	MAC      string
+	Resource string
+	Reason   string
+}
+
+func (e *ErrInstallTokenRejected) Error() string {
+	return fmt.Sprintf("install token rejected for %s (%s): %s", e.MAC, e.Resource, e.Reason)
+}
+
+// IsInstallTokenRejected 判断错误是否为安装令牌校验失败
+func IsInstallTokenRejected(err error) bool {
+	var rejected *ErrInstallTokenRejected
+	return errors.As(err, &rejected)
+}
@@ -2,13 +2,14 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/lucheng0127/nodefoundry/internal/model"
 )
 
 // NodeRepository 定义节点存储接口
 type NodeRepository interface {
-	// Save 保存或更新节点
+	// Save 保存或更新节点（比较并交换，版本不一致时返回 ErrVersionConflict）
 	Save(ctx context.Context, node *model.Node) error
 
 	// FindByMAC 根据 MAC 地址查找节点
@@ -27,6 +28,12 @@ type NodeRepository interface {
 	Delete(ctx context.Context, mac string) error
 }
 
+// HeartbeatStore 批量持久化节点心跳
+type HeartbeatStore interface {
+	// UpdateHeartbeats 批量更新节点心跳时间（mac → 心跳时间）
+	UpdateHeartbeats(ctx context.Context, heartbeats map[string]time.Time) error
+}
+
 // ErrNodeNotFound 节点不存在错误
 type ErrNodeNotFound struct {
 	MAC string
@@ -54,3 +61,14 @@ type ErrInvalidStatusTransition struct {
 func (e *ErrInvalidStatusTransition) Error() string {
 	return "invalid status transition"
 }
+
+// ErrVersionConflict 资源版本冲突错误（节点已被其他写入方修改）
+type ErrVersionConflict struct {
+	MAC      string
+	Expected uint64
+	Actual   uint64
+}
+
+func (e *ErrVersionConflict) Error() string {
+	return "resource version conflict"
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// ProfileRepository 定义安装配置存储接口
+type ProfileRepository interface {
+	// Create 保存新配置
+	Create(ctx context.Context, profile *model.InstallProfile) error
+
+	// FindByName 根据名称查找配置
+	FindByName(ctx context.Context, name string) (*model.InstallProfile, error)
+
+	// FindByGroup 查找分配给节点分组的配置
+	FindByGroup(ctx context.Context, group string) (*model.InstallProfile, error)
+
+	// List 列出所有配置
+	List(ctx context.Context) ([]*model.InstallProfile, error)
+
+	// Update 读-改-写配置
+	Update(ctx context.Context, name string, mutate func(profile *model.InstallProfile) error) (*model.InstallProfile, error)
+
+	// Delete 删除配置
+	Delete(ctx context.Context, name string) error
+}
+
+// ErrProfileNotFound 安装配置不存在错误（按分组查找时 Name 为空）
+type ErrProfileNotFound struct {
+	Name  string
+	Group string
+}
+
+func (e *ErrProfileNotFound) Error() string {
+	return "install profile not found"
+}
+
+// ErrProfileAlreadyExists 安装配置已存在错误
+type ErrProfileAlreadyExists struct {
+	Name string
+}
+
+func (e *ErrProfileAlreadyExists) Error() string {
+	return "install profile already exists"
+}
+
+// ErrProfileGroupConflict 分组已分配给其他安装配置
+type ErrProfileGroupConflict struct {
+	Group string
+	// Profile 已使用该分组的配置
+	Profile string
+}
+
+func (e *ErrProfileGroupConflict) Error() string {
+	return "group " + e.Group + " is already assigned to install profile " + e.Profile
+}
+
+// IsProfileNotFound 判断错误是否为安装配置不存在
+func IsProfileNotFound(err error) bool {
+	var notFound *ErrProfileNotFound
+	return errors.As(err, &notFound)
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// MaxConflictRetries 版本冲突时的最大重试次数
+const MaxConflictRetries = 5
+
+// UpdateWithRetry 读取节点、应用修改并以比较并交换方式保存
+// 如果保存时发生版本冲突，重新读取最新节点并再次应用 mutate，直至成功或超过重试次数
+// mutate 可能被调用多次，必须只依赖传入的节点状态
+func UpdateWithRetry(ctx context.Context, repo NodeRepository, mac string, mutate func(node *model.Node) error) (*model.Node, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= MaxConflictRetries; attempt++ {
+		node, err := repo.FindByMAC(ctx, mac)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := mutate(node); err != nil {
+			return nil, err
+		}
+
+		err = repo.Save(ctx, node)
+		if err == nil {
+			return node, nil
+		}
+
+		if !IsVersionConflict(err) {
+			return nil, err
+		}
+		lastErr = err
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+// IsVersionConflict 判断错误是否为版本冲突
+func IsVersionConflict(err error) bool {
+	var conflict *ErrVersionConflict
+	return errors.As(err, &conflict)
+}
+
+// IsNodeNotFound 判断错误是否为节点不存在
+func IsNodeNotFound(err error) bool {
+	var notFound *ErrNodeNotFound
+	return errors.As(err, &notFound)
+}
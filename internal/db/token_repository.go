@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// TokenRepository 定义 API token 存储接口
+type TokenRepository interface {
+	// Create 保存新 token
+	Create(ctx context.Context, token *model.APIToken) error
+
+	// FindByID 根据 ID 查找 token
+	FindByID(ctx context.Context, id string) (*model.APIToken, error)
+
+	// FindByHash 根据 token 摘要查找 token
+	FindByHash(ctx context.Context, hash string) (*model.APIToken, error)
+
+	// List 列出所有 token
+	List(ctx context.Context) ([]*model.APIToken, error)
+
+	// Revoke 吊销 token
+	Revoke(ctx context.Context, id string) error
+
+	// Touch 记录 token 最近使用时间
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+// ErrTokenNotFound token 不存在错误
+type ErrTokenNotFound struct {
+	ID string
+}
+
+func (e *ErrTokenNotFound) Error() string {
+	return "token not found"
+}
+
+// IsTokenNotFound 判断错误是否为 token 不存在
+func IsTokenNotFound(err error) bool {
+	var notFound *ErrTokenNotFound
+	return errors.As(err, &notFound)
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// WebhookDeliveryHistoryLimit 每个 webhook 保留的已完成投递记录数
+const WebhookDeliveryHistoryLimit = 100
+
+// WebhookRepository 定义 webhook 订阅和投递队列存储接口
+type WebhookRepository interface {
+	// Create 保存新 webhook
+	Create(ctx context.Context, webhook *model.Webhook) error
+
+	// FindByID 根据 ID 查找 webhook
+	FindByID(ctx context.Context, id string) (*model.Webhook, error)
+
+	// List 列出所有 webhook
+	List(ctx context.Context) ([]*model.Webhook, error)
+
+	// Update 读-改-写 webhook
+	Update(ctx context.Context, id string, mutate func(webhook *model.Webhook) error) (*model.Webhook, error)
+
+	// Delete 删除 webhook 及其全部投递记录
+	Delete(ctx context.Context, id string) error
+
+	// SaveDelivery 创建或更新投递记录，并维护待投递队列和历史记录上限
+	SaveDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+
+	// ListDeliveries 列出 webhook 的投递记录（最新的在前）
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*model.WebhookDelivery, error)
+
+	// DueDeliveries 返回下次尝试时间不晚于 now 的待投递记录（最早的在前）
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error)
+}
+
+// ErrWebhookNotFound webhook 不存在错误
+type ErrWebhookNotFound struct {
+	ID string
+}
+
+func (e *ErrWebhookNotFound) Error() string {
+	return "webhook not found"
+}
+
+// IsWebhookNotFound 判断错误是否为 webhook 不存在
+func IsWebhookNotFound(err error) bool {
+	var notFound *ErrWebhookNotFound
+	return errors.As(err, &notFound)
+}
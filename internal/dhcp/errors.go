@@ -23,6 +23,8 @@ var (
 	ErrLeaseNotFound = errors.New("lease not found")
 	// ErrLeaseExpired 租约已过期
 	ErrLeaseExpired = errors.New("lease expired")
+	// ErrIPConflict 节点的静态 IP 已租给其他节点
+	ErrIPConflict = errors.New("static IP leased to another node")
 	// ErrInterfaceNotFound 网卡接口未找到
 	ErrInterfaceNotFound = errors.New("interface not found")
 )
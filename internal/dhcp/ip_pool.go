@@ -3,8 +3,11 @@ package dhcp
 import (
 	"encoding/binary"
 	"net"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
 )
 
 // IPManager IP 地址池管理器
@@ -113,6 +116,33 @@ func (m *IPManager) AllocateIP(mac string, requestedIP net.IP) (net.IP, error) {
 	return nil, ErrIPPoolExhausted
 }
 
+// Reserve 为节点保留固定的静态 IP
+// 节点持有的其他地址会被释放；IP 不在地址池内时无需租约，在地址池内且已租给其他节点时返回 ErrIPConflict
+func (m *IPManager) Reserve(mac string, ip net.IP) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	normalizedMAC := normalizeMAC(mac)
+	ip = ip.To4()
+	if ip == nil {
+		return ErrInvalidIP
+	}
+
+	if existing, ok := m.leases[normalizedMAC]; ok && !existing.IP.Equal(ip) {
+		delete(m.allocated, existing.IP.String())
+		delete(m.leases, normalizedMAC)
+	}
+
+	if !m.isIPInPool(ip) {
+		return nil
+	}
+	if owner, ok := m.allocated[ip.String()]; ok && owner != normalizedMAC {
+		return ErrIPConflict
+	}
+	m.allocateIP(normalizedMAC, ip)
+	return nil
+}
+
 // ReleaseIP 释放 IP
 func (m *IPManager) ReleaseIP(ip net.IP) error {
 	m.mu.Lock()
@@ -140,6 +170,24 @@ func (m *IPManager) ReleaseIP(ip net.IP) error {
 	return nil
 }
 
+// ReleaseByMAC 释放 MAC 地址持有的租约（MAC 可为任意格式）
+func (m *IPManager) ReleaseByMAC(mac string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	target := model.NormalizeMAC(mac)
+	for key, lease := range m.leases {
+		if model.NormalizeMAC(key) != target {
+			continue
+		}
+		delete(m.leases, key)
+		delete(m.allocated, lease.IP.String())
+		return nil
+	}
+
+	return ErrLeaseNotFound
+}
+
 // RenewLease 续租
 func (m *IPManager) RenewLease(mac string) error {
 	m.mu.Lock()
@@ -174,6 +222,56 @@ func (m *IPManager) GetLease(mac string) (net.IP, error) {
 	return lease.IP, nil
 }
 
+// Leases 返回所有租约的副本（按 IP 排序，包含已过期但尚未释放的租约）
+func (m *IPManager) Leases() []Lease {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	leases := make([]Lease, 0, len(m.leases))
+	for _, lease := range m.leases {
+		leases = append(leases, Lease{
+			MAC:       lease.MAC,
+			IP:        append(net.IP(nil), lease.IP...),
+			ExpiresAt: lease.ExpiresAt,
+		})
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		return m.ipToInt(leases[i].IP.To4()) < m.ipToInt(leases[j].IP.To4())
+	})
+	return leases
+}
+
+// PoolStats IP 池使用情况
+type PoolStats struct {
+	Size      int `json:"size"`
+	Allocated int `json:"allocated"`
+	Expired   int `json:"expired"` // 已过期但尚未释放的租约（仍占用地址）
+}
+
+// Subnet 返回地址池所在子网（CIDR 表示）
+func (m *IPManager) Subnet() string {
+	network := &net.IPNet{IP: m.start.Mask(m.netmask), Mask: m.netmask}
+	return network.String()
+}
+
+// Stats 返回 IP 池使用情况
+func (m *IPManager) Stats() PoolStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	stats := PoolStats{Allocated: len(m.allocated)}
+	if start, end := m.ipToInt(m.start), m.ipToInt(m.end); end >= start {
+		stats.Size = int(end-start) + 1
+	}
+	for _, lease := range m.leases {
+		if now.After(lease.ExpiresAt) {
+			stats.Expired++
+		}
+	}
+	return stats
+}
+
 // allocateIP 内部方法：分配 IP 并创建租约
 func (m *IPManager) allocateIP(mac string, ip net.IP) net.IP {
 	m.leases[mac] = &Lease{
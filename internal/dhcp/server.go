@@ -2,8 +2,11 @@ package dhcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/insomniacslk/dhcp/dhcpv4"
@@ -11,19 +14,27 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/metrics"
 	"github.com/lucheng0127/nodefoundry/internal/model"
 )
 
 // DHCPServer DHCP 服务器
 type DHCPServer struct {
-	addr        string
-	iface       string // 绑定的网卡接口名（如 eth0），空则监听所有接口
-	repo        db.NodeRepository
-	logger      *zap.Logger
-	server      *server4.Server
-	ipManager   *IPManager
-	tftpServer  string // TFTP 服务器 IP
-	proxyMode   bool   // ProxyDHCP 模式
+	addr       string
+	iface      string // 绑定的网卡接口名（如 eth0），空则监听所有接口
+	repo       db.NodeRepository
+	logger     *zap.Logger
+	server     *server4.Server
+	ipManager  *IPManager
+	tftpServer string // TFTP 服务器 IP
+	proxyMode  bool   // ProxyDHCP 模式
+	metrics    *metrics.Metrics
+	events     *events.Bus
+
+	// 运行状态（健康检查使用）
+	running  atomic.Bool
+	serveErr atomic.Value // error
 }
 
 // NewDHCPServer 创建 DHCP 服务器
@@ -41,6 +52,16 @@ func (s *DHCPServer) SetIPManager(ipm *IPManager) {
 	s.ipManager = ipm
 }
 
+// SetMetrics 设置指标收集
+func (s *DHCPServer) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
+
+// SetEventBus 设置事件总线（发布节点发现和租约分配事件）
+func (s *DHCPServer) SetEventBus(bus *events.Bus) {
+	s.events = bus
+}
+
 // SetTFTPServer 设置 TFTP 服务器地址
 func (s *DHCPServer) SetTFTPServer(tftp string) {
 	s.tftpServer = tftp
@@ -77,8 +98,12 @@ func (s *DHCPServer) Start(ctx context.Context) error {
 	)
 
 	// 在 goroutine 中启动服务器
+	s.running.Store(true)
 	go func() {
-		if err := s.server.Serve(); err != nil {
+		err := s.server.Serve()
+		s.running.Store(false)
+		if err != nil && ctx.Err() == nil {
+			s.serveErr.Store(err)
 			s.logger.Error("DHCP server error", zap.Error(err))
 		}
 	}()
@@ -86,6 +111,7 @@ func (s *DHCPServer) Start(ctx context.Context) error {
 	// 等待 context 取消
 	<-ctx.Done()
 
+	s.running.Store(false)
 	s.logger.Info("DHCP server shutting down")
 	if s.server != nil {
 		s.server.Close()
@@ -94,35 +120,64 @@ func (s *DHCPServer) Start(ctx context.Context) error {
 	return nil
 }
 
+// Status 返回 DHCP 监听是否在运行，以及监听异常退出时的错误
+func (s *DHCPServer) Status() (bool, error) {
+	err, _ := s.serveErr.Load().(error)
+	return s.running.Load(), err
+}
+
+// IPManager 返回 IP 池管理器（未配置时为 nil）
+func (s *DHCPServer) IPManager() *IPManager {
+	return s.ipManager
+}
+
+// DHCP 报文处理结果（指标标签）
+const (
+	OUTCOME_IGNORED        = "ignored"
+	OUTCOME_INVALID        = "invalid"
+	OUTCOME_ERROR          = "error"
+	OUTCOME_POOL_EXHAUSTED = "pool_exhausted"
+	OUTCOME_NO_LEASE       = "no_lease"
+	OUTCOME_IP_CONFLICT    = "ip_conflict"
+	OUTCOME_SEND_ERROR     = "send_error"
+	OUTCOME_PROXY_OFFER    = "proxy_offer"
+)
+
 // handleDHCP 处理 DHCP 请求
 func (s *DHCPServer) handleDHCP(conn net.PacketConn, peer net.Addr, msg *dhcpv4.DHCPv4) {
 	if msg == nil {
 		return
 	}
 
+	outcome := s.dispatch(conn, peer, msg)
+	s.metrics.DHCPPacket(messageTypeLabel(msg.MessageType()), outcome)
+}
+
+// dispatch 按模式和消息类型处理请求，返回处理结果
+func (s *DHCPServer) dispatch(conn net.PacketConn, peer net.Addr, msg *dhcpv4.DHCPv4) string {
 	// ProxyDHCP 模式：只响应 DISCOVER
 	if s.proxyMode {
 		if msg.MessageType() == dhcpv4.MessageTypeDiscover {
-			s.handleProxyDiscover(conn, peer, msg)
+			return s.handleProxyDiscover(conn, peer, msg)
 		}
-		return
+		return OUTCOME_IGNORED
 	}
 
 	// 标准模式：处理 DISCOVER 和 REQUEST
 	if msg.MessageType() != dhcpv4.MessageTypeDiscover && msg.MessageType() != dhcpv4.MessageTypeRequest {
-		return
+		return OUTCOME_IGNORED
 	}
 
-	s.handleStandard(conn, peer, msg)
+	return s.handleStandard(conn, peer, msg)
 }
 
-// handleStandard 标准模式处理
-func (s *DHCPServer) handleStandard(conn net.PacketConn, peer net.Addr, msg *dhcpv4.DHCPv4) {
+// handleStandard 标准模式处理，返回处理结果
+func (s *DHCPServer) handleStandard(conn net.PacketConn, peer net.Addr, msg *dhcpv4.DHCPv4) string {
 	// 提取 MAC 地址
 	mac := msg.ClientHWAddr.String()
 	if mac == "" {
 		s.logger.Warn("DHCP message with empty MAC address")
-		return
+		return OUTCOME_INVALID
 	}
 
 	// 规范化 MAC 地址
@@ -135,60 +190,50 @@ func (s *DHCPServer) handleStandard(conn net.PacketConn, peer net.Addr, msg *dhc
 	)
 
 	// 获取现有节点或创建新节点
-	node, err := s.repo.FindByMAC(context.Background(), normalizedMAC)
+	node, err := s.ensureNode(context.Background(), normalizedMAC)
 	if err != nil {
-		// 节点不存在，创建新节点
-		node, err = model.NewNode(normalizedMAC, model.STATE_DISCOVERED)
-		if err != nil {
-			s.logger.Error("failed to create node", zap.Error(err))
-			return
-		}
-	}
-
-	// 保存节点信息（状态更新）
-	if err := s.repo.Save(context.Background(), node); err != nil {
 		s.logger.Error("failed to save node",
 			zap.String("mac", normalizedMAC),
 			zap.Error(err),
 		)
-		return
+		return OUTCOME_ERROR
 	}
 
 	// 构建 DHCPOFFER 或 DHCPACK
-	resp, err := s.buildResponse(msg, normalizedMAC)
+	resp, err := s.buildResponse(msg, node)
 	if err != nil {
-		s.logger.Error("failed to build DHCP response", zap.Error(err))
-		return
-	}
-
-	// 如果分配了 IP 且有 IP 管理器，持久化网络配置到节点
-	if s.ipManager != nil && resp.YourIPAddr != nil && !resp.YourIPAddr.IsUnspecified() {
-		// 更新节点的网络配置
-		node.IP = resp.YourIPAddr.String()
-		if s.ipManager.netmask != nil {
-			node.Netmask = s.ipManager.netmask.String()
-		}
-		if s.ipManager.gateway != nil {
-			node.Gateway = s.ipManager.gateway.String()
+		s.logger.Error("failed to build DHCP response", zap.String("mac", normalizedMAC), zap.Error(err))
+		switch {
+		case errors.Is(err, ErrIPPoolExhausted):
+			return OUTCOME_POOL_EXHAUSTED
+		case errors.Is(err, ErrIPConflict):
+			return OUTCOME_IP_CONFLICT
+		case errors.Is(err, ErrLeaseNotFound), errors.Is(err, ErrLeaseExpired):
+			return OUTCOME_NO_LEASE
+		default:
+			return OUTCOME_ERROR
 		}
-		if len(s.ipManager.dns) > 0 {
-			dnsStr := ""
-			for i, dns := range s.ipManager.dns {
-				if i > 0 {
-					dnsStr += ","
-				}
-				dnsStr += dns.String()
+	}
+	// 如果分配了 IP 且有 IP 管理器，持久化网络配置到节点（静态网络配置由运维固定，不覆盖）
+	// 网络配置未变化（如续租）时不重复保存
+	if s.ipManager != nil && !node.StaticNetwork && resp.YourIPAddr != nil && !resp.YourIPAddr.IsUnspecified() &&
+		s.networkConfigChanged(node, resp.YourIPAddr.String()) {
+		// 更新节点的网络配置并保存到数据库（冲突时基于最新节点重试）
+		assignedIP := resp.YourIPAddr.String()
+		updated, err := db.UpdateWithRetry(context.Background(), s.repo, normalizedMAC, func(n *model.Node) error {
+			// 读取节点后被并发设置为静态网络时不覆盖
+			if !n.StaticNetwork {
+				s.applyNetworkConfig(n, assignedIP)
 			}
-			node.DNS = dnsStr
-		}
-
-		// 保存网络配置到数据库
-		if err := s.repo.Save(context.Background(), node); err != nil {
+			return nil
+		})
+		if err != nil {
 			s.logger.Error("failed to save network config",
 				zap.String("mac", normalizedMAC),
 				zap.Error(err),
 			)
 		} else {
+			node = updated
 			s.logger.Info("network config saved",
 				zap.String("mac", normalizedMAC),
 				zap.String("ip", node.IP),
@@ -211,14 +256,135 @@ func (s *DHCPServer) handleStandard(conn net.PacketConn, peer net.Addr, msg *dhc
 			zap.String("mac", normalizedMAC),
 			zap.Error(err),
 		)
+		return OUTCOME_SEND_ERROR
+	}
+
+	// ACK 表示客户端已确认使用该地址
+	if s.ipManager != nil && resp.MessageType() == dhcpv4.MessageTypeAck {
+		s.events.Publish(events.NodeEvent(events.EVENT_LEASE_ALLOCATED, node, map[string]interface{}{
+			"ip":         resp.YourIPAddr.String(),
+			"subnet":     s.ipManager.Subnet(),
+			"lease_time": int(s.ipManager.leaseTime.Seconds()),
+		}))
+	}
+
+	return messageTypeLabel(resp.MessageType())
+}
+
+// messageTypeLabel DHCP 消息类型的指标标签（如 discover、offer）
+func messageTypeLabel(t dhcpv4.MessageType) string {
+	switch t {
+	case dhcpv4.MessageTypeDiscover, dhcpv4.MessageTypeOffer, dhcpv4.MessageTypeRequest,
+		dhcpv4.MessageTypeDecline, dhcpv4.MessageTypeAck, dhcpv4.MessageTypeNak,
+		dhcpv4.MessageTypeRelease, dhcpv4.MessageTypeInform:
+		return strings.ToLower(t.String())
+	default:
+		return "other"
 	}
 }
 
-// handleProxyDiscover ProxyDHCP 模式下的 DISCOVER 处理
-func (s *DHCPServer) handleProxyDiscover(conn net.PacketConn, peer net.Addr, msg *dhcpv4.DHCPv4) {
+// ensureNode 获取节点，不存在时创建 discovered 节点
+// 已存在的节点直接返回，不写入数据库（避免每次 DHCP 请求递增 ResourceVersion）
+func (s *DHCPServer) ensureNode(ctx context.Context, mac string) (*model.Node, error) {
+	node, err := s.repo.FindByMAC(ctx, mac)
+	if err == nil {
+		return node, nil
+	}
+	if !db.IsNodeNotFound(err) {
+		return nil, err
+	}
+
+	// 节点不存在，创建新节点
+	node, err = model.NewNode(mac, model.STATE_DISCOVERED)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Save(ctx, node)
+	if db.IsVersionConflict(err) {
+		// 并发请求已创建该节点，直接读取
+		return s.repo.FindByMAC(ctx, mac)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.events.Publish(events.NodeEvent(events.EVENT_NODE_DISCOVERED, node, map[string]interface{}{
+		"source": "dhcp",
+	}))
+	return node, nil
+}
+
+// applyNetworkConfig 将分配的 IP 及 IP 池网络参数写入节点
+func (s *DHCPServer) applyNetworkConfig(node *model.Node, ip string) {
+	node.IP = ip
+	if s.ipManager.netmask != nil {
+		// IPMask.String() 为十六进制格式，节点记录使用点分十进制
+		node.Netmask = net.IP(s.ipManager.netmask).String()
+	}
+	if s.ipManager.gateway != nil {
+		node.Gateway = s.ipManager.gateway.String()
+	}
+	if len(s.ipManager.dns) > 0 {
+		dnsStr := ""
+		for i, dns := range s.ipManager.dns {
+			if i > 0 {
+				dnsStr += ","
+			}
+			dnsStr += dns.String()
+		}
+		node.DNS = dnsStr
+	}
+}
+
+// networkConfigChanged 判断分配的 IP 及 IP 池网络参数是否与节点记录不同
+func (s *DHCPServer) networkConfigChanged(node *model.Node, ip string) bool {
+	updated := *node
+	s.applyNetworkConfig(&updated, ip)
+	return updated.IP != node.IP || updated.Netmask != node.Netmask ||
+		updated.Gateway != node.Gateway || updated.DNS != node.DNS
+}
+
+// applyStaticNetwork 按节点固定的静态网络配置设置应答中的 IP 和网络参数
+// IP 位于地址池内时为节点保留该地址
+func (s *DHCPServer) applyStaticNetwork(resp *dhcpv4.DHCPv4, node *model.Node) error {
+	ip := net.ParseIP(node.IP).To4()
+	mask, err := model.ParseNetmask(node.Netmask)
+	if ip == nil || err != nil {
+		return fmt.Errorf("invalid static network config: ip %q netmask %q", node.IP, node.Netmask)
+	}
+
+	leaseTime := 24 * time.Hour
+	if s.ipManager != nil {
+		if err := s.ipManager.Reserve(node.MAC, ip); err != nil {
+			return fmt.Errorf("failed to reserve static IP %s: %w", node.IP, err)
+		}
+		leaseTime = s.ipManager.leaseTime
+	}
+
+	resp.YourIPAddr = ip
+	resp.UpdateOption(dhcpv4.OptSubnetMask(mask))
+	if gateway := net.ParseIP(node.Gateway).To4(); gateway != nil {
+		resp.UpdateOption(dhcpv4.OptRouter(gateway))
+	}
+	var dns []net.IP
+	for _, server := range strings.Split(node.DNS, ",") {
+		if addr := net.ParseIP(strings.TrimSpace(server)).To4(); addr != nil {
+			dns = append(dns, addr)
+		}
+	}
+	if len(dns) > 0 {
+		resp.UpdateOption(dhcpv4.OptDNS(dns...))
+	}
+	resp.UpdateOption(dhcpv4.OptIPAddressLeaseTime(leaseTime))
+	return nil
+}
+
+// handleProxyDiscover ProxyDHCP 模式下的 DISCOVER 处理，返回处理结果
+func (s *DHCPServer) handleProxyDiscover(conn net.PacketConn, peer net.Addr, msg *dhcpv4.DHCPv4) string {
 	mac := msg.ClientHWAddr.String()
 	if mac == "" {
-		return
+		return OUTCOME_INVALID
 	}
 
 	normalizedMAC := model.NormalizeMAC(mac)
@@ -232,7 +398,7 @@ func (s *DHCPServer) handleProxyDiscover(conn net.PacketConn, peer net.Addr, msg
 	resp, err := s.buildProxyOffer(msg)
 	if err != nil {
 		s.logger.Error("failed to build ProxyDHCP offer", zap.Error(err))
-		return
+		return OUTCOME_ERROR
 	}
 
 	// 发送响应（使用广播地址）
@@ -241,11 +407,15 @@ func (s *DHCPServer) handleProxyDiscover(conn net.PacketConn, peer net.Addr, msg
 			zap.String("mac", normalizedMAC),
 			zap.Error(err),
 		)
+		return OUTCOME_SEND_ERROR
 	}
+
+	return OUTCOME_PROXY_OFFER
 }
 
 // buildResponse 构建标准 DHCP 响应
-func (s *DHCPServer) buildResponse(req *dhcpv4.DHCPv4, normalizedMAC string) (*dhcpv4.DHCPv4, error) {
+// 节点固定了静态网络配置时按节点记录应答，否则从 IP 池分配
+func (s *DHCPServer) buildResponse(req *dhcpv4.DHCPv4, node *model.Node) (*dhcpv4.DHCPv4, error) {
 	var respType dhcpv4.MessageType
 
 	switch req.MessageType() {
@@ -268,8 +438,12 @@ func (s *DHCPServer) buildResponse(req *dhcpv4.DHCPv4, normalizedMAC string) (*d
 	// 使用原始 MAC 地址（保留格式）
 	mac := req.ClientHWAddr.String()
 
-	// IP 分配（如果配置了 IP 池）
-	if s.ipManager != nil {
+	// IP 分配（静态网络配置优先，其次 IP 池）
+	if node.StaticNetwork {
+		if err := s.applyStaticNetwork(resp, node); err != nil {
+			return nil, err
+		}
+	} else if s.ipManager != nil {
 		var assignedIP net.IP
 		if req.MessageType() == dhcpv4.MessageTypeDiscover {
 			// 分配新 IP
@@ -0,0 +1,188 @@
+package dhcp
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// newTestServer 创建使用临时数据库和 10.0.0.100-10.0.0.110 地址池的 DHCP 服务器
+func newTestServer(t *testing.T) (*DHCPServer, db.NodeRepository) {
+	t.Helper()
+
+	bdb, err := db.InitializeDB(filepath.Join(t.TempDir(), "nodes.db"), zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitializeDB: %v", err)
+	}
+	t.Cleanup(func() { bdb.Close() })
+
+	ipm, err := NewIPManager("10.0.0.100", "10.0.0.110", "255.255.255.0", "10.0.0.1", []string{"10.0.0.53"}, 3600)
+	if err != nil {
+		t.Fatalf("NewIPManager: %v", err)
+	}
+	repo := db.NewBoltNodeRepository(bdb, zap.NewNop())
+	s := NewDHCPServer(":67", "", repo, zap.NewNop())
+	s.SetIPManager(ipm)
+	return s, repo
+}
+
+// exchange 处理一条 DHCP 请求并读取服务器发送的应答
+func exchange(t *testing.T, s *DHCPServer, msg *dhcpv4.DHCPv4) (string, *dhcpv4.DHCPv4) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	outcome := s.dispatch(conn, client.LocalAddr(), msg)
+
+	buf := make([]byte, 1500)
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := client.ReadFrom(buf)
+	if err != nil {
+		return outcome, nil
+	}
+	resp, err := dhcpv4.FromBytes(buf[:n])
+	if err != nil {
+		t.Fatalf("invalid DHCP response: %v", err)
+	}
+	return outcome, resp
+}
+
+func TestStaticNetworkNotOverwritten(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestServer(t)
+
+	hw, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	node, _ := model.NewNode(hw.String(), model.STATE_DISCOVERED)
+	node.IP = "10.0.0.105"
+	node.Netmask = "255.255.255.0"
+	node.Gateway = "10.0.0.254"
+	node.DNS = "1.1.1.1"
+	node.StaticNetwork = true
+	if err := repo.Save(ctx, node); err != nil {
+		t.Fatal(err)
+	}
+
+	discover, _ := dhcpv4.NewDiscovery(hw)
+	outcome, offer := exchange(t, s, discover)
+	if outcome != "offer" || offer == nil {
+		t.Fatalf("outcome = %s, offer = %v", outcome, offer)
+	}
+	if !offer.YourIPAddr.Equal(net.ParseIP("10.0.0.105")) {
+		t.Errorf("offered %s, want pinned 10.0.0.105", offer.YourIPAddr)
+	}
+	if routers := offer.Router(); len(routers) != 1 || !routers[0].Equal(net.ParseIP("10.0.0.254")) {
+		t.Errorf("router = %v, want 10.0.0.254", routers)
+	}
+	if dns := offer.DNS(); len(dns) != 1 || !dns[0].Equal(net.ParseIP("1.1.1.1")) {
+		t.Errorf("dns = %v, want 1.1.1.1", dns)
+	}
+
+	request, _ := dhcpv4.NewRequestFromOffer(offer)
+	if outcome, ack := exchange(t, s, request); outcome != "ack" || !ack.YourIPAddr.Equal(net.ParseIP("10.0.0.105")) {
+		t.Fatalf("outcome = %s, ack = %v", outcome, ack)
+	}
+
+	stored, _ := repo.FindByMAC(ctx, node.MAC)
+	if stored.IP != "10.0.0.105" || stored.Gateway != "10.0.0.254" || stored.DNS != "1.1.1.1" || !stored.StaticNetwork {
+		t.Errorf("static config overwritten: %+v", stored)
+	}
+
+	// 静态 IP 在地址池内，已为该节点保留
+	other, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+	discover, _ = dhcpv4.NewDiscovery(other, dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(net.ParseIP("10.0.0.105"))))
+	if _, offer := exchange(t, s, discover); offer == nil || offer.YourIPAddr.Equal(net.ParseIP("10.0.0.105")) {
+		t.Errorf("pinned IP offered to another node: %v", offer)
+	}
+}
+
+func TestStaticNetworkConflict(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestServer(t)
+
+	// 另一个节点先从地址池获得 10.0.0.100
+	other, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+	discover, _ := dhcpv4.NewDiscovery(other)
+	if _, offer := exchange(t, s, discover); offer == nil || !offer.YourIPAddr.Equal(net.ParseIP("10.0.0.100")) {
+		t.Fatalf("unexpected offer %v", offer)
+	}
+
+	hw, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	node, _ := model.NewNode(hw.String(), model.STATE_DISCOVERED)
+	node.IP = "10.0.0.100"
+	node.Netmask = "255.255.255.0"
+	node.StaticNetwork = true
+	if err := repo.Save(ctx, node); err != nil {
+		t.Fatal(err)
+	}
+
+	discover, _ = dhcpv4.NewDiscovery(hw)
+	if outcome, offer := exchange(t, s, discover); outcome != OUTCOME_IP_CONFLICT || offer != nil {
+		t.Errorf("outcome = %s, offer = %v, want ip_conflict without response", outcome, offer)
+	}
+}
+
+func TestDynamicNetworkSaved(t *testing.T) {
+	s, repo := newTestServer(t)
+
+	hw, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	discover, _ := dhcpv4.NewDiscovery(hw)
+	if outcome, _ := exchange(t, s, discover); outcome != "offer" {
+		t.Fatalf("outcome = %s", outcome)
+	}
+
+	stored, err := repo.FindByMAC(context.Background(), hw.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.IP != "10.0.0.100" || stored.Netmask != "255.255.255.0" || stored.Gateway != "10.0.0.1" || stored.StaticNetwork {
+		t.Errorf("dynamic config not saved: %+v", stored)
+	}
+}
+
+func TestExistingNodeNotSaved(t *testing.T) {
+	s, repo := newTestServer(t)
+
+	hw, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	discover, _ := dhcpv4.NewDiscovery(hw)
+	if outcome, _ := exchange(t, s, discover); outcome != "offer" {
+		t.Fatalf("outcome = %s", outcome)
+	}
+	saved, err := repo.FindByMAC(context.Background(), hw.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复的 DISCOVER 分配相同地址，节点记录不变
+	for i := 0; i < 3; i++ {
+		if outcome, _ := exchange(t, s, discover); outcome != "offer" {
+			t.Fatalf("outcome = %s", outcome)
+		}
+	}
+	stored, err := repo.FindByMAC(context.Background(), hw.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ResourceVersion != saved.ResourceVersion {
+		t.Errorf("ResourceVersion = %d, want %d", stored.ResourceVersion, saved.ResourceVersion)
+	}
+	if stored.IP != saved.IP {
+		t.Errorf("IP = %s, want %s", stored.IP, saved.IP)
+	}
+}
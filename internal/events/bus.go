@@ -0,0 +1,190 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultBufferSize 默认保留的最近事件数（用于断线续传）
+const DefaultBufferSize = 1024
+
+// subscriberBuffer 订阅者通道容量，超过后视为消费过慢并断开
+const subscriberBuffer = 256
+
+// Bus 进程内事件总线
+// 最近的事件保存在环形缓冲区中，订阅者可以从指定事件 ID 之后续传。
+// Publish 允许在 nil 接收者上调用，未启用事件的组件无需判空。
+type Bus struct {
+	mu     sync.Mutex
+	ring   []Event
+	head   int // 最旧事件在 ring 中的位置
+	count  int
+	nextID uint64
+	subs   map[*Subscription]struct{}
+	closed bool
+	logger *zap.Logger
+}
+
+// Subscription 事件订阅
+type Subscription struct {
+	bus    *Bus
+	filter *Filter
+	ch     chan Event
+	// dropped 订阅者消费过慢被断开（需通过 Last-Event-ID 续传）
+	dropped bool
+}
+
+// NewBus 创建事件总线，size 为保留的最近事件数
+func NewBus(size int, logger *zap.Logger) *Bus {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &Bus{
+		ring:   make([]Event, size),
+		nextID: 1,
+		subs:   make(map[*Subscription]struct{}),
+		logger: logger,
+	}
+}
+
+// Publish 发布事件：分配 ID、写入缓冲区并投递给匹配的订阅者
+// 投递不阻塞，通道已满的订阅者会被断开
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e.ID = b.nextID
+	b.nextID++
+
+	if b.count < len(b.ring) {
+		b.ring[(b.head+b.count)%len(b.ring)] = e
+		b.count++
+	} else {
+		b.ring[b.head] = e
+		b.head = (b.head + 1) % len(b.ring)
+	}
+
+	for sub := range b.subs {
+		if !sub.filter.Matches(&e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			b.logger.Warn("event subscriber too slow, disconnecting", zap.Uint64("event_id", e.ID))
+			sub.dropped = true
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe 订阅事件
+// afterID > 0 时先返回缓冲区中 ID 大于 afterID 且满足过滤条件的事件；
+// complete 为 false 表示 afterID 之后的部分事件已不在缓冲区中（或服务已重启），无法完整续传
+func (b *Bus) Subscribe(filter *Filter, afterID uint64) (sub *Subscription, replay []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if afterID > 0 {
+		if afterID >= b.nextID {
+			// 客户端的事件 ID 来自重启前的服务
+			complete = false
+		} else if b.count > 0 && b.ring[b.head].ID > afterID+1 {
+			complete = false
+		}
+
+		for i := 0; i < b.count; i++ {
+			e := b.ring[(b.head+i)%len(b.ring)]
+			if e.ID > afterID && filter.Matches(&e) {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	sub = &Subscription{
+		bus:    b,
+		filter: filter,
+		ch:     make(chan Event, subscriberBuffer),
+	}
+	if b.closed {
+		close(sub.ch)
+	} else {
+		b.subs[sub] = struct{}{}
+	}
+
+	return sub, replay, complete
+}
+
+// Recent 返回缓冲区中满足过滤条件的最近 limit 个事件（按 ID 升序），limit <= 0 表示全部
+func (b *Bus) Recent(filter *Filter, limit int) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var matched []Event
+	for i := b.count - 1; i >= 0; i-- {
+		e := b.ring[(b.head+i)%len(b.ring)]
+		if !filter.Matches(&e) {
+			continue
+		}
+		matched = append(matched, e)
+		if limit > 0 && len(matched) == limit {
+			break
+		}
+	}
+
+	// 倒序收集，翻转为按 ID 升序
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return matched
+}
+
+// Close 关闭所有订阅（服务关闭时结束长连接），之后的订阅会立即结束
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Events 返回事件通道，订阅关闭或被断开时通道关闭
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Dropped 订阅是否因消费过慢被断开
+func (s *Subscription) Dropped() bool {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.dropped
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.ch)
+	}
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 事件类型
+const (
+	// 节点首次出现（DHCP 发现或 API 注册）
+	EVENT_NODE_DISCOVERED = "node.discovered"
+	// 节点状态变化（data: from、to、source）
+	EVENT_NODE_STATUS_CHANGED = "node.status_changed"
+	// 节点属性被编辑（标签、备注、网络配置等）
+	EVENT_NODE_UPDATED = "node.updated"
+	// 节点被删除
+	EVENT_NODE_DELETED = "node.deleted"
+	// 安装器上报安装进度（data: stage、message）
+	EVENT_NODE_INSTALL_PROGRESS = "node.install_progress"
+	// 节点超过心跳超时未上报
+	EVENT_HEARTBEAT_LOST = "node.heartbeat_lost"
+	// 心跳丢失的节点恢复上报
+	EVENT_HEARTBEAT_RESTORED = "node.heartbeat_restored"
+	// DHCP 确认分配 IP（ACK）
+	EVENT_LEASE_ALLOCATED = "dhcp.lease_allocated"
+	// 命令结束（agent 上报成功/失败，或超时）
+	EVENT_COMMAND_RESULT = "command.result"
+)
+
+// Types 所有事件类型
+var Types = []string{
+	EVENT_NODE_DISCOVERED,
+	EVENT_NODE_STATUS_CHANGED,
+	EVENT_NODE_UPDATED,
+	EVENT_NODE_DELETED,
+	EVENT_NODE_INSTALL_PROGRESS,
+	EVENT_HEARTBEAT_LOST,
+	EVENT_HEARTBEAT_RESTORED,
+	EVENT_LEASE_ALLOCATED,
+	EVENT_COMMAND_RESULT,
+}
+
+// IsValidType 验证事件类型是否有效
+func IsValidType(t string) bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidTypePattern 验证事件类型过滤条件：具体类型或分组通配（如 node.*）
+func IsValidTypePattern(t string) bool {
+	if IsValidType(t) {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(t, ".*")
+	if !ok {
+		return false
+	}
+	for _, known := range Types {
+		if strings.HasPrefix(known, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesType 判断事件类型是否满足过滤条件（为空表示全部类型）
+func MatchesType(patterns map[string]bool, t string) bool {
+	return len(patterns) == 0 || patterns[t] || patterns[typeGroup(t)]
+}
+
+// Event 节点事件
+type Event struct {
+	// ID 由事件总线分配，单调递增（服务重启后重新从 1 开始）
+	ID   uint64    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	MAC  string    `json:"mac,omitempty"`
+	// Labels 事件发生时节点的标签，用于按标签过滤
+	Labels map[string]string      `json:"labels,omitempty"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
+
+// NodeEvent 根据节点构造事件（携带节点当前标签）
+func NodeEvent(eventType string, node *model.Node, data map[string]interface{}) Event {
+	e := Event{Type: eventType, Data: data}
+	if node != nil {
+		e.MAC = node.MAC
+		if len(node.Labels) > 0 {
+			e.Labels = make(map[string]string, len(node.Labels))
+			for k, v := range node.Labels {
+				e.Labels[k] = v
+			}
+		}
+	}
+	return e
+}
+
+// StatusChanged 构造节点状态变化事件
+func StatusChanged(node *model.Node, from, source string) Event {
+	return NodeEvent(EVENT_NODE_STATUS_CHANGED, node, map[string]interface{}{
+		"from":   from,
+		"to":     node.Status,
+		"source": source,
+	})
+}
+
+// Filter 事件过滤条件，各条件同时满足才匹配，空条件不限制
+type Filter struct {
+	MACs   map[string]bool
+	Types  map[string]bool
+	Labels *model.LabelSelector
+}
+
+// Matches 判断事件是否满足过滤条件
+func (f *Filter) Matches(e *Event) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.MACs) > 0 && !f.MACs[e.MAC] {
+		return false
+	}
+	if !MatchesType(f.Types, e.Type) {
+		return false
+	}
+	if !f.Labels.Empty() && !f.Labels.Matches(e.Labels) {
+		return false
+	}
+	return true
+}
+
+// typeGroup 事件类型所属分组的通配形式（如 node.discovered → node.*）
+func typeGroup(t string) string {
+	if idx := strings.Index(t, "."); idx > 0 {
+		return t[:idx] + ".*"
+	}
+	return t
+}
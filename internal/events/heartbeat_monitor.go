@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+)
+
+// HeartbeatMonitor 定期检查节点心跳，发布心跳丢失和恢复事件
+type HeartbeatMonitor struct {
+	repo     db.NodeRepository
+	bus      *Bus
+	timeout  time.Duration
+	interval time.Duration
+	logger   *zap.Logger
+
+	// lost 当前处于心跳丢失状态的节点；nil 表示尚未完成首次检查
+	lost map[string]bool
+}
+
+// NewHeartbeatMonitor 创建心跳监视器
+// 节点最近心跳早于 timeout 视为丢失，每 interval 检查一次
+func NewHeartbeatMonitor(repo db.NodeRepository, bus *Bus, timeout, interval time.Duration, logger *zap.Logger) *HeartbeatMonitor {
+	return &HeartbeatMonitor{
+		repo:     repo,
+		bus:      bus,
+		timeout:  timeout,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run 周期性检查心跳，直到 ctx 取消
+func (m *HeartbeatMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// check 比较节点心跳与上一次检查结果，仅在状态变化时发布事件
+// 首次检查只记录状态：启动前已经丢失心跳的节点不会产生事件
+func (m *HeartbeatMonitor) check(ctx context.Context, now time.Time) {
+	nodes, err := m.repo.List(ctx)
+	if err != nil {
+		m.logger.Warn("heartbeat monitor failed to list nodes", zap.Error(err))
+		return
+	}
+
+	first := m.lost == nil
+	lost := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		// 从未上报心跳的节点（尚未运行 agent）不参与检查
+		if node.LastHeartbeat.IsZero() {
+			continue
+		}
+
+		isLost := now.Sub(node.LastHeartbeat) > m.timeout
+		if isLost {
+			lost[node.MAC] = true
+		}
+		if first {
+			continue
+		}
+
+		wasLost := m.lost[node.MAC]
+		switch {
+		case isLost && !wasLost:
+			m.logger.Warn("node heartbeat lost",
+				zap.String("mac", node.MAC),
+				zap.Time("last_heartbeat", node.LastHeartbeat),
+			)
+			m.bus.Publish(NodeEvent(EVENT_HEARTBEAT_LOST, node, map[string]interface{}{
+				"last_heartbeat": node.LastHeartbeat,
+				"status":         node.Status,
+			}))
+		case !isLost && wasLost:
+			m.logger.Info("node heartbeat restored", zap.String("mac", node.MAC))
+			m.bus.Publish(NodeEvent(EVENT_HEARTBEAT_RESTORED, node, map[string]interface{}{
+				"last_heartbeat": node.LastHeartbeat,
+				"status":         node.Status,
+			}))
+		}
+	}
+
+	m.lost = lost
+}
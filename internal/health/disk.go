@@ -0,0 +1,45 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+)
+
+// 磁盘空间阈值
+const (
+	// 可用空间低于该比例时为 degraded
+	DiskDegradedFreeRatio = 0.10
+	// 可用空间低于该值时为 down（bbolt 无法扩展文件时写入会失败）
+	DiskMinFreeBytes = 100 << 20
+)
+
+// DiskCheck 检查 path 所在文件系统的可用空间
+func DiskCheck(path string) CheckFunc {
+	return func(ctx context.Context) Result {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			return Down(fmt.Sprintf("statfs failed: %v", err), map[string]interface{}{"path": path})
+		}
+
+		total := stat.Blocks * uint64(stat.Bsize)
+		free := stat.Bavail * uint64(stat.Bsize)
+		details := map[string]interface{}{
+			"path":        path,
+			"total_bytes": total,
+			"free_bytes":  free,
+		}
+		if total > 0 {
+			details["free_ratio"] = float64(free) / float64(total)
+		}
+
+		switch {
+		case free < DiskMinFreeBytes:
+			return Down("disk space critically low", details)
+		case total > 0 && float64(free)/float64(total) < DiskDegradedFreeRatio:
+			return Degraded("disk space low", details)
+		default:
+			return OK(details)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 组件状态
+const (
+	STATUS_OK       = "ok"
+	STATUS_DEGRADED = "degraded" // 可用但需要关注（如 IP 池接近耗尽）
+	STATUS_DOWN     = "down"
+)
+
+// DefaultCheckTimeout 单个检查的默认超时时间
+const DefaultCheckTimeout = 2 * time.Second
+
+// Result 检查结果
+type Result struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// CheckFunc 组件检查函数
+type CheckFunc func(ctx context.Context) Result
+
+// ComponentReport 单个组件的检查报告
+type ComponentReport struct {
+	Result
+	// 关键组件 down 时整体状态为 down
+	Critical bool   `json:"critical"`
+	Duration string `json:"duration"`
+}
+
+// Report 整体健康报告
+type Report struct {
+	Status     string                     `json:"status"`
+	Uptime     string                     `json:"uptime"`
+	Components map[string]ComponentReport `json:"components"`
+}
+
+// check 已注册的检查
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker 组件健康检查注册表
+type Checker struct {
+	checks    []check
+	timeout   time.Duration
+	startTime time.Time
+	mu        sync.RWMutex
+}
+
+// NewChecker 创建健康检查注册表
+func NewChecker(startTime time.Time) *Checker {
+	return &Checker{
+		timeout:   DefaultCheckTimeout,
+		startTime: startTime,
+	}
+}
+
+// Register 注册组件检查，critical 表示该组件 down 时服务不可用
+func (c *Checker) Register(name string, critical bool, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Uptime 进程运行时长
+func (c *Checker) Uptime() time.Duration {
+	return time.Since(c.startTime)
+}
+
+// Run 并发执行所有检查并汇总
+// 任一关键组件 down 时整体为 down；其他组件 down 或 degraded 时整体为 degraded
+func (c *Checker) Run(ctx context.Context) *Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	report := &Report{
+		Status:     STATUS_OK,
+		Uptime:     c.Uptime().Round(time.Second).String(),
+		Components: make(map[string]ComponentReport, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, chk := range checks {
+		wg.Add(1)
+		go func(chk check) {
+			defer wg.Done()
+			component := c.runCheck(ctx, chk)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[chk.name] = component
+		}(chk)
+	}
+	wg.Wait()
+
+	for _, component := range report.Components {
+		switch {
+		case component.Status == STATUS_DOWN && component.Critical:
+			report.Status = STATUS_DOWN
+		case component.Status != STATUS_OK && report.Status == STATUS_OK:
+			report.Status = STATUS_DEGRADED
+		}
+	}
+
+	return report
+}
+
+// runCheck 执行单个检查，超时视为 down
+func (c *Checker) runCheck(ctx context.Context, chk check) ComponentReport {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+	go func() {
+		done <- chk.fn(ctx)
+	}()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Result{Status: STATUS_DOWN, Message: "check timed out"}
+	}
+
+	return ComponentReport{
+		Result:   result,
+		Critical: chk.critical,
+		Duration: time.Since(start).Round(time.Microsecond).String(),
+	}
+}
+
+// OK 构造正常结果
+func OK(details map[string]interface{}) Result {
+	return Result{Status: STATUS_OK, Details: details}
+}
+
+// Degraded 构造降级结果
+func Degraded(message string, details map[string]interface{}) Result {
+	return Result{Status: STATUS_DEGRADED, Message: message, Details: details}
+}
+
+// Down 构造故障结果
+func Down(message string, details map[string]interface{}) Result {
+	return Result{Status: STATUS_DOWN, Message: message, Details: details}
+}
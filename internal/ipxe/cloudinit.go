@@ -0,0 +1,235 @@
+package ipxe
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// cloud-init NoCloud 数据源的文件（ds=nocloud-net;s=<CloudInitURL>）
+const (
+	CloudInitUserData      = "user-data"
+	CloudInitMetaData      = "meta-data"
+	CloudInitVendorData    = "vendor-data"
+	CloudInitNetworkConfig = "network-config"
+)
+
+// CloudInitFiles NoCloud 数据源提供的全部文件
+var CloudInitFiles = []string{CloudInitUserData, CloudInitMetaData, CloudInitVendorData, CloudInitNetworkConfig}
+
+// cloudInitInterface network-config 中节点网卡的名称（按 MAC 匹配，不重命名网卡）
+const cloudInitInterface = "nodefoundry0"
+
+// cloudConfig 内置 user-data（#cloud-config）
+type cloudConfig struct {
+	Hostname          string           `yaml:"hostname"`
+	ManageEtcHosts    bool             `yaml:"manage_etc_hosts"`
+	SSHAuthorizedKeys []string         `yaml:"ssh_authorized_keys,omitempty"`
+	CACerts           *cloudCACerts    `yaml:"ca_certs,omitempty"`
+	BootCmd           [][]string       `yaml:"bootcmd"`
+	WriteFiles        []cloudWriteFile `yaml:"write_files"`
+	RunCmd            [][]string       `yaml:"runcmd"`
+	PhoneHome         *cloudPhoneHome  `yaml:"phone_home"`
+}
+
+// cloudPhoneHome 首次启动配置完成后回调服务器（上报安装完成）
+type cloudPhoneHome struct {
+	URL   string   `yaml:"url"`
+	Post  []string `yaml:"post"`
+	Tries int      `yaml:"tries"`
+}
+
+// cloudCACerts 安装到系统信任库的 CA
+type cloudCACerts struct {
+	Trusted []string `yaml:"trusted"`
+}
+
+// cloudWriteFile 写入节点的文件
+type cloudWriteFile struct {
+	Path        string `yaml:"path"`
+	Content     string `yaml:"content"`
+	Permissions string `yaml:"permissions"`
+}
+
+// cloudMetaData meta-data，instance-id 变化时 cloud-init 重新执行首次启动配置
+type cloudMetaData struct {
+	InstanceID    string `yaml:"instance-id"`
+	LocalHostname string `yaml:"local-hostname"`
+}
+
+// cloudNetworkConfig network-config（version 2）
+type cloudNetworkConfig struct {
+	Version   int                      `yaml:"version"`
+	Ethernets map[string]cloudEthernet `yaml:"ethernets"`
+}
+
+// cloudEthernet 网卡配置
+type cloudEthernet struct {
+	Match       map[string]string `yaml:"match"`
+	DHCP4       bool              `yaml:"dhcp4"`
+	Addresses   []string          `yaml:"addresses,omitempty"`
+	Routes      []cloudRoute      `yaml:"routes,omitempty"`
+	Nameservers *cloudNameservers `yaml:"nameservers,omitempty"`
+}
+
+// cloudRoute 路由
+type cloudRoute struct {
+	To  string `yaml:"to"`
+	Via string `yaml:"via"`
+}
+
+// cloudNameservers DNS 服务器
+type cloudNameservers struct {
+	Addresses []string `yaml:"addresses"`
+}
+
+// GenerateCloudInit 生成节点的 cloud-init NoCloud 数据文件
+// token 为安装令牌，user-data 中的 agent 下载地址携带该令牌
+func (g *PreseedGenerator) GenerateCloudInit(ctx context.Context, mac, file, token string) (string, error) {
+	node, err := g.repo.FindByMAC(ctx, model.NormalizeMAC(mac))
+	if err != nil {
+		return "", err
+	}
+
+	switch file {
+	case CloudInitUserData:
+		return g.cloudInitUserData(ctx, node, token)
+	case CloudInitMetaData:
+		return marshalCloudInit("", &cloudMetaData{
+			InstanceID:    cloudInitInstanceID(node),
+			LocalHostname: g.getHostname(node),
+		})
+	case CloudInitVendorData:
+		// 节点配置全部在 user-data 中，vendor-data 为空配置
+		return "#cloud-config\n{}\n", nil
+	case CloudInitNetworkConfig:
+		return marshalCloudInit("", g.cloudInitNetwork(node))
+	default:
+		return "", fmt.Errorf("unknown cloud-init file: %s", file)
+	}
+}
+
+// cloudInitUserData 生成 user-data
+// 节点的安装配置定义了应答文件时渲染该模板（如 Ubuntu autoinstall），否则生成安装 agent 的 cloud-config
+func (g *PreseedGenerator) cloudInitUserData(ctx context.Context, node *model.Node, token string) (string, error) {
+	profile, err := resolveProfile(ctx, g.profiles, node)
+	if err != nil {
+		return "", err
+	}
+	data := g.answerData(node, profile, token)
+	if profile.AnswerFile != "" {
+		return renderAnswerFile(profile, data)
+	}
+
+	config := &cloudConfig{
+		Hostname:          data.Hostname,
+		ManageEtcHosts:    true,
+		SSHAuthorizedKeys: g.sshKeys,
+		// 每个安装周期只上报一次安装开始，上报失败不影响启动
+		BootCmd: [][]string{
+			{"cloud-init-per", "instance", "nodefoundry-started", "sh", "-c",
+				fmt.Sprintf(`curl -fsS -X POST "%s" || true`, data.Progress.Started)},
+		},
+		WriteFiles: []cloudWriteFile{{
+			Path: "/etc/default/nodefoundry-agent",
+			Content: fmt.Sprintf("NF_MAC=%s\nNF_MQTT_BROKER=%s\nNF_LOG_LEVEL=info\nNF_HEARTBEAT_INTERVAL=30\n",
+				node.MAC, data.MQTTBroker),
+			Permissions: "0644",
+		}},
+		RunCmd: [][]string{
+			{"curl", "-fsSL", "-o", "/usr/local/bin/nodefoundry-agent", data.AgentURL},
+			{"chmod", "+x", "/usr/local/bin/nodefoundry-agent"},
+			{"curl", "-fsSL", "-o", "/etc/systemd/system/nodefoundry-agent.service", data.AgentServiceURL},
+			{"systemctl", "daemon-reload"},
+			{"systemctl", "enable", "--now", "nodefoundry-agent.service"},
+			// agent 未安装成功时上报安装失败，phone_home 随后上报的 finished 不再生效
+			{"sh", "-c", fmt.Sprintf(`test -x /usr/local/bin/nodefoundry-agent && systemctl is-enabled --quiet nodefoundry-agent.service || curl -fsS -X POST --data "message=agent installation failed" "%s"`,
+				data.Progress.Failed)},
+		},
+		PhoneHome: &cloudPhoneHome{
+			URL:   data.Progress.Finished,
+			Post:  []string{"instance_id", "hostname"},
+			Tries: 10,
+		},
+	}
+	// agent 通过 HTTPS 下载时先信任服务器 CA（ca_certs 在 runcmd 之前执行）
+	if len(g.caPEM) > 0 {
+		config.CACerts = &cloudCACerts{Trusted: []string{string(g.caPEM)}}
+	}
+	return marshalCloudInit("#cloud-config\n", config)
+}
+
+// cloudInitInstanceID 节点本次安装周期的 instance-id，重装时变化
+func cloudInitInstanceID(node *model.Node) string {
+	if node.InstallStartedAt.IsZero() {
+		return "nodefoundry-" + node.MAC
+	}
+	return fmt.Sprintf("nodefoundry-%s-%d", node.MAC, node.InstallStartedAt.Unix())
+}
+
+// cloudInitNetwork 生成网络配置：按 MAC 匹配引导网卡，有静态 IP 时使用节点记录中的配置，否则使用 DHCP
+// 节点记录的子网掩码无效时（如旧版本写入的数据）回退到 DHCP，避免安装器取不到网络配置
+func (g *PreseedGenerator) cloudInitNetwork(node *model.Node) *cloudNetworkConfig {
+	eth := cloudEthernet{
+		Match: map[string]string{"macaddress": formatMAC(node.MAC)},
+		DHCP4: true,
+	}
+	if node.IP != "" {
+		mask, err := model.ParseNetmask(node.Netmask)
+		if err != nil {
+			g.logger.Warn("invalid static network config, falling back to DHCP in cloud-init network-config",
+				zap.String("mac", node.MAC),
+				zap.String("ip", node.IP),
+				zap.String("netmask", node.Netmask),
+				zap.Error(err),
+			)
+		} else {
+			eth.DHCP4 = false
+			ones, _ := mask.Size()
+			eth.Addresses = []string{fmt.Sprintf("%s/%d", node.IP, ones)}
+			if node.Gateway != "" {
+				eth.Routes = []cloudRoute{{To: "0.0.0.0/0", Via: node.Gateway}}
+			}
+			var dns []string
+			for _, server := range strings.Split(node.DNS, ",") {
+				if server = strings.TrimSpace(server); server != "" {
+					dns = append(dns, server)
+				}
+			}
+			if len(dns) > 0 {
+				eth.Nameservers = &cloudNameservers{Addresses: dns}
+			}
+		}
+	}
+
+	return &cloudNetworkConfig{
+		Version:   2,
+		Ethernets: map[string]cloudEthernet{cloudInitInterface: eth},
+	}
+}
+
+// formatMAC 将标准化的 MAC（无分隔符）转换为冒号分隔格式
+func formatMAC(mac string) string {
+	if len(mac) != 12 {
+		return mac
+	}
+	parts := make([]string, 0, 6)
+	for i := 0; i < 12; i += 2 {
+		parts = append(parts, mac[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
+
+// marshalCloudInit 序列化为 YAML，header 为文件头（如 #cloud-config）
+func marshalCloudInit(header string, v interface{}) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return header + string(data), nil
+}
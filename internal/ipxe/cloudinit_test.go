@@ -0,0 +1,53 @@
+package ipxe
+
+import (
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+func TestCloudInitNetwork(t *testing.T) {
+	match := map[string]string{"macaddress": "aa:bb:cc:dd:ee:ff"}
+
+	tests := []struct {
+		name     string
+		node     model.Node
+		want     cloudEthernet
+		wantWarn bool
+	}{
+		{name: "dhcp", node: model.Node{},
+			want: cloudEthernet{Match: match, DHCP4: true}},
+		{name: "static",
+			node: model.Node{IP: "10.0.0.5", Netmask: "255.255.255.0", Gateway: "10.0.0.1", DNS: "10.0.0.53, 8.8.8.8"},
+			want: cloudEthernet{Match: match, Addresses: []string{"10.0.0.5/24"},
+				Routes:      []cloudRoute{{To: "0.0.0.0/0", Via: "10.0.0.1"}},
+				Nameservers: &cloudNameservers{Addresses: []string{"10.0.0.53", "8.8.8.8"}}}},
+		{name: "missing netmask", node: model.Node{IP: "10.0.0.5"},
+			want: cloudEthernet{Match: match, DHCP4: true}, wantWarn: true},
+		{name: "hex netmask", node: model.Node{IP: "10.0.0.5", Netmask: "ffffff00"},
+			want: cloudEthernet{Match: match, DHCP4: true}, wantWarn: true},
+		{name: "non-contiguous netmask", node: model.Node{IP: "10.0.0.5", Netmask: "255.0.255.0"},
+			want: cloudEthernet{Match: match, DHCP4: true}, wantWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.WarnLevel)
+			g := NewPreseedGenerator("10.0.0.1:8080", "", nil, zap.New(core))
+
+			tt.node.MAC = "aabbccddeeff"
+			config := g.cloudInitNetwork(&tt.node)
+			if got := config.Ethernets[cloudInitInterface]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ethernet = %+v, want %+v", got, tt.want)
+			}
+			if warned := logs.Len() > 0; warned != tt.wantWarn {
+				t.Errorf("warning logged = %v, want %v", warned, tt.wantWarn)
+			}
+		})
+	}
+}
@@ -3,9 +3,12 @@ package ipxe
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/lucheng0127/nodefoundry/internal/auth"
 	"github.com/lucheng0127/nodefoundry/internal/db"
 	"github.com/lucheng0127/nodefoundry/internal/model"
 )
@@ -15,7 +18,16 @@ type Generator struct {
 	serverAddr string
 	mirrorURL  string
 	repo       db.NodeRepository
-	logger     *zap.Logger
+	// 安装令牌（未设置时安装脚本不携带令牌）
+	installTokens   db.InstallTokenRepository
+	installTokenTTL time.Duration
+	// iPXE 固件信任服务器 CA 时使用的 HTTPS 地址（为空表示使用 HTTP）
+	tlsAddr string
+	// 引导脚本模板
+	templates *Templates
+	// 安装配置（未设置时使用内置配置）
+	profiles db.ProfileRepository
+	logger   *zap.Logger
 }
 
 // NewGenerator 创建 iPXE 脚本生成器
@@ -24,10 +36,46 @@ func NewGenerator(serverAddr string, mirrorURL string, repo db.NodeRepository, l
 		serverAddr: serverAddr,
 		mirrorURL:  mirrorURL,
 		repo:       repo,
+		templates:  builtinTemplates,
 		logger:     logger,
 	}
 }
 
+// SetInstallTokens 设置安装令牌存储，安装脚本将携带安装令牌访问 preseed
+func (g *Generator) SetInstallTokens(repo db.InstallTokenRepository, ttl time.Duration) {
+	g.installTokens = repo
+	g.installTokenTTL = ttl
+}
+
+// SetTLS 设置 HTTPS 服务器地址，iPXE 脚本中的服务器 URL 改用 HTTPS
+// 仅当 iPXE 固件编译时内置了服务器 CA（TRUST=ca.crt）时启用
+func (g *Generator) SetTLS(tlsAddr string) {
+	g.tlsAddr = tlsAddr
+}
+
+// SetTemplates 设置 iPXE 模板（默认使用内置模板）
+func (g *Generator) SetTemplates(templates *Templates) {
+	g.templates = templates
+}
+
+// SetProfiles 设置安装配置存储，安装脚本按节点的安装配置引导安装内核
+func (g *Generator) SetProfiles(repo db.ProfileRepository) {
+	g.profiles = repo
+}
+
+// ResolveProfile 返回节点使用的安装配置
+func (g *Generator) ResolveProfile(ctx context.Context, node *model.Node) (*model.InstallProfile, error) {
+	return resolveProfile(ctx, g.profiles, node)
+}
+
+// nodeURL iPXE 脚本访问服务器的基础 URL
+func (g *Generator) nodeURL() string {
+	if g.tlsAddr != "" {
+		return "https://" + g.tlsAddr
+	}
+	return "http://" + g.serverAddr
+}
+
 // GenerateByStatus 根据节点状态生成 iPXE 脚本
 func (g *Generator) GenerateByStatus(ctx context.Context, mac string) (string, error) {
 	mac = model.NormalizeMAC(mac)
@@ -37,68 +85,136 @@ func (g *Generator) GenerateByStatus(ctx context.Context, mac string) (string, e
 		return "", err
 	}
 
-	switch node.Status {
-	case model.STATE_DISCOVERED:
-		return g.generateWaitLoopScript(mac), nil
-	case model.STATE_INSTALLING:
-		return g.generateInstallScript(mac), nil
-	case model.STATE_INSTALLED:
-		return g.generateLocalBootScript(), nil
-	default:
+	return g.GenerateForNode(ctx, node)
+}
+
+// GenerateForNode 根据已读取的节点状态生成 iPXE 脚本
+func (g *Generator) GenerateForNode(ctx context.Context, node *model.Node) (string, error) {
+	if !model.IsValidStatus(node.Status) {
 		return "", fmt.Errorf("unknown node status: %s", node.Status)
 	}
+
+	data, err := g.scriptData(ctx, node)
+	if err != nil {
+		return "", err
+	}
+	return g.templates.Render(data.Template, node.Status, data)
 }
 
-// generateWaitLoopScript 生成等待循环脚本
-func (g *Generator) generateWaitLoopScript(mac string) string {
-	return fmt.Sprintf(`#!ipxe
-set node_url http://%s
-set mac %s
-
-:loop
-echo Node in discovered state, waiting for installation trigger...
-sleep 90
-chain ${node_url}/boot/${mac}/boot.ipxe || goto loop
-`, g.serverAddr, mac)
+// selectTemplateSet 选择节点使用的模板集：
+// ipxe-template 标签指定的模板集 → 与 group 标签同名的模板集 → default
+func (g *Generator) selectTemplateSet(node *model.Node) string {
+	if name := node.Labels[model.LABEL_IPXE_TEMPLATE]; name != "" {
+		if g.templates.Has(name) {
+			return name
+		}
+		// 引导脚本不能因为标签错误而中断，回退到默认模板
+		g.logger.Warn("unknown ipxe template set, using default",
+			zap.String("mac", node.MAC),
+			zap.String("template", name),
+		)
+		return DefaultTemplateSet
+	}
+	if group := node.Labels[model.LABEL_GROUP]; group != "" && g.templates.Has(group) {
+		return group
+	}
+	return DefaultTemplateSet
 }
 
-// generateInstallScript 生成安装脚本
-func (g *Generator) generateInstallScript(mac string) string {
-	// 获取节点信息以获取网络配置
-	ctx := context.Background()
-	node, err := g.repo.FindByMAC(ctx, mac)
-	netParams := ""
-	if err == nil && node.IP != "" {
-		// 如果节点有分配的 IP，传递网络参数到 preseed
-		// 格式: ?ip=xxx&netmask=xxx&gateway=xxx&dns=xxx
-		netParams = fmt.Sprintf("?ip=%s", node.IP)
+// scriptData 准备模板数据，安装中的节点同时签发安装令牌
+func (g *Generator) scriptData(ctx context.Context, node *model.Node) (*ScriptData, error) {
+	// 模板只读取节点数据，使用副本避免修改共享的节点记录
+	copied := *node
+	labels := make(map[string]string, len(node.Labels))
+	for k, v := range node.Labels {
+		labels[k] = v
+	}
+	copied.Labels = labels
+
+	data := &ScriptData{
+		Node:   &copied,
+		Labels: labels,
+		Network: ScriptNetwork{
+			IP:      node.IP,
+			Netmask: node.Netmask,
+			Gateway: node.Gateway,
+			DNS:     node.DNS,
+		},
+		Server: ScriptServer{
+			NodeURL: g.nodeURL(),
+			HTTPURL: "http://" + g.serverAddr,
+			Mirror:  g.mirrorURL,
+		},
+		Template: g.selectTemplateSet(node),
+	}
+	if node.Status != model.STATE_INSTALLING {
+		return data, nil
+	}
+
+	profile, err := g.ResolveProfile(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+
+	// preseed URL 参数：安装令牌 + 节点网络配置
+	// 参数: token、ip、netmask、gateway、dns
+	query := url.Values{}
+	token := ""
+	if g.installTokens != nil {
+		token, err = g.issueInstallToken(ctx, node)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("token", token)
+	}
+
+	// 如果节点有分配的 IP，传递网络参数到 preseed
+	if node.IP != "" {
+		query.Set("ip", node.IP)
 		if node.Netmask != "" {
-			netParams += fmt.Sprintf("&netmask=%s", node.Netmask)
+			query.Set("netmask", node.Netmask)
 		}
 		if node.Gateway != "" {
-			netParams += fmt.Sprintf("&gateway=%s", node.Gateway)
+			query.Set("gateway", node.Gateway)
 		}
 		if node.DNS != "" {
-			netParams += fmt.Sprintf("&dns=%s", node.DNS)
+			query.Set("dns", node.DNS)
 		}
 	}
 
-	return fmt.Sprintf(`#!ipxe
-set node_url http://%s
-set mac %s
-set arch ${buildarch}
+	data.PreseedURL = data.Server.HTTPURL + "/preseed/" + node.MAC + "/preseed.cfg"
+	if len(query) > 0 {
+		data.PreseedURL += "?" + query.Encode()
+	}
+
+	// cloud-init 在地址后追加文件名，携带令牌时使用 %s 占位，网络配置由 network-config 提供
+	data.CloudInitURL = data.Server.HTTPURL + "/cloud-init/" + node.MAC + "/"
+	if token != "" {
+		data.CloudInitURL += "%s?" + url.Values{"token": {token}}.Encode()
+	}
 
-kernel https://%s/debian/dists/bookworm/main/installer-${arch}/current/images/netboot/debian-installer/${arch}/linux
-initrd https://%s/debian/dists/bookworm/main/installer-${arch}/current/images/netboot/debian-installer/${arch}/initrd.gz
-imgargs linux auto=true priority=critical url=${node_url}/preseed/${mac}/preseed.cfg%s
-boot
-`, g.serverAddr, mac, g.mirrorURL, g.mirrorURL, netParams)
+	if err := renderProfile(profile, data); err != nil {
+		return nil, err
+	}
+	return data, nil
 }
 
-// generateLocalBootScript 生成本地启动脚本
-func (g *Generator) generateLocalBootScript() string {
-	return `#!ipxe
-echo Booting from local disk...
-exit
-`
+// issueInstallToken 为本次下发的安装脚本签发新令牌，之前脚本中的令牌随之失效
+// 引导脚本无需认证即可获取，每次签发新令牌使泄露的旧令牌无法继续使用；
+// 防止他人冒充节点获取脚本需要配合 NF_NODE_ALLOWED_CIDRS 限制来源网段
+func (g *Generator) issueInstallToken(ctx context.Context, node *model.Node) (string, error) {
+	token, err := auth.NewInstallToken(node.MAC, node.InstallStartedAt, g.installTokenTTL)
+	if err != nil {
+		return "", err
+	}
+
+	if err := g.installTokens.Issue(ctx, token); err != nil {
+		return "", fmt.Errorf("failed to issue install token: %w", err)
+	}
+
+	g.logger.Info("install token issued",
+		zap.String("mac", node.MAC),
+		zap.Time("expires_at", token.ExpiresAt),
+	)
+	return token.Token, nil
 }
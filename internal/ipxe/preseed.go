@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strings"
 
 	"go.uber.org/zap"
 
 	"github.com/lucheng0127/nodefoundry/internal/db"
 	"github.com/lucheng0127/nodefoundry/internal/model"
+	"github.com/lucheng0127/nodefoundry/internal/tlsutil"
 )
 
 // PreseedGenerator preseed 配置生成器
@@ -16,7 +18,14 @@ type PreseedGenerator struct {
 	serverAddr string
 	mirrorURL  string
 	repo       db.NodeRepository
-	logger     *zap.Logger
+	// HTTPS 服务器地址和需要安装到节点的 CA（PEM），tlsAddr 为空表示使用 HTTP
+	tlsAddr string
+	caPEM   []byte
+	// 安装配置（未设置时使用内置 preseed）
+	profiles db.ProfileRepository
+	// 写入节点的 SSH 公钥（authorized_keys 格式，每项一个公钥）
+	sshKeys []string
+	logger  *zap.Logger
 }
 
 // NewPreseedGenerator 创建 preseed 生成器
@@ -29,12 +38,30 @@ func NewPreseedGenerator(serverAddr string, mirrorURL string, repo db.NodeReposi
 	}
 }
 
+// SetTLS 设置 HTTPS 服务器地址，late_command 通过 HTTPS 下载 agent
+// caPEM 非空时先将其安装到目标系统的信任库
+func (g *PreseedGenerator) SetTLS(tlsAddr string, caPEM []byte) {
+	g.tlsAddr = tlsAddr
+	g.caPEM = caPEM
+}
+
+// SetProfiles 设置安装配置存储，节点的安装配置定义了应答文件时使用该模板
+func (g *PreseedGenerator) SetProfiles(repo db.ProfileRepository) {
+	g.profiles = repo
+}
+
+// SetSSHAuthorizedKeys 设置写入节点的 SSH 公钥（cloud-init 和应答文件模板使用）
+func (g *PreseedGenerator) SetSSHAuthorizedKeys(keys []string) {
+	g.sshKeys = keys
+}
+
 // Generate 生成 preseed 配置
 func (g *PreseedGenerator) Generate(ctx context.Context, mac string) (string, error) {
 	return g.GenerateWithQuery(ctx, mac, url.Values{})
 }
 
-// GenerateWithQuery 生成 preseed 配置（支持查询参数）
+// GenerateWithQuery 生成应答文件（支持查询参数）
+// 节点的安装配置定义了应答文件时渲染该模板，否则生成内置的 Debian preseed
 func (g *PreseedGenerator) GenerateWithQuery(ctx context.Context, mac string, query url.Values) (string, error) {
 	mac = model.NormalizeMAC(mac)
 
@@ -93,10 +120,25 @@ d-i netcfg/confirm_static boolean true`
 d-i netcfg/disable_dhcp boolean false`
 	}
 
-	// 生成 late_command（Agent 安装 + MAC 地址注入）
-	lateCommand := g.generateLateCommand()
+	// 生成 early_command（进度上报）和 late_command（Agent 安装 + MAC 地址注入 + 进度上报）
+	progress := g.progressURLs(node.MAC, query.Get("token"))
+	earlyCommand := g.generateEarlyCommand(progress)
+	lateCommand := g.generateLateCommand(node.MAC, query.Get("token"), progress)
 
-	preseed := fmt.Sprintf(`d-i debian-installer/locale string en_US
+	profile, err := resolveProfile(ctx, g.profiles, node)
+	if err != nil {
+		return "", err
+	}
+	if profile.AnswerFile != "" {
+		data := g.answerData(node, profile, query.Get("token"))
+		data.Network = ScriptNetwork{IP: ip, Netmask: netmask, Gateway: gateway, DNS: dns}
+		data.EarlyCommand = earlyCommand
+		data.LateCommand = lateCommand
+		return renderAnswerFile(profile, data)
+	}
+
+	preseed := fmt.Sprintf(`%s
+d-i debian-installer/locale string en_US
 d-i keyboard-configuration/xkb-keymap select us
 d-i netcfg/choose_interface select auto
 d-i netcfg/get_hostname string %s
@@ -116,7 +158,7 @@ d-i partman-partitioning/confirm_write_new_label boolean true
 d-i partman/choose_partition select finish
 d-i partman/confirm boolean true
 d-i partman/confirm_nooverwrite boolean true
-d-i pkgsel/upgrade select none
+d-i pkgsel/upgrade select none%s
 d-i grub-installer/only_debian boolean true
 d-i grub-installer/with_other_os boolean true
 d-i grub-installer/bootdev string default
@@ -124,11 +166,46 @@ d-i finish-install/reboot_in_progress note
 
 # Install NodeFoundry agent
 %s
-`, hostname, netcfgSection, g.mirrorURL, lateCommand)
+`, earlyCommand, hostname, netcfgSection, g.mirrorURL, g.pkgselInclude(), lateCommand)
 
 	return preseed, nil
 }
 
+// answerData 准备应答文件模板数据，网络配置取自节点记录
+func (g *PreseedGenerator) answerData(node *model.Node, profile *model.InstallProfile, token string) *AnswerData {
+	agentURL, serviceURL := g.agentURLs(node.MAC, token)
+	labels := make(map[string]string, len(node.Labels))
+	for k, v := range node.Labels {
+		labels[k] = v
+	}
+	copied := *node
+	copied.Labels = labels
+
+	return &AnswerData{
+		Node:     &copied,
+		Labels:   labels,
+		Hostname: g.getHostname(node),
+		Network:  ScriptNetwork{IP: node.IP, Netmask: node.Netmask, Gateway: node.Gateway, DNS: node.DNS},
+		Server: ScriptServer{
+			NodeURL: g.agentBaseURL(),
+			HTTPURL: "http://" + g.serverAddr,
+			Mirror:  g.mirrorURL,
+		},
+		Profile:           newScriptProfile(profile),
+		AgentURL:          agentURL,
+		AgentServiceURL:   serviceURL,
+		MQTTBroker:        g.getServerIP() + ":1883",
+		CACert:            string(g.caPEM),
+		SSHAuthorizedKeys: g.sshKeys,
+		Progress:          g.progressURLs(node.MAC, token),
+	}
+}
+
+// progressURLs 安装进度上报地址（安装器无法信任自签 CA，始终使用 HTTP）
+func (g *PreseedGenerator) progressURLs(mac, token string) ProgressURLs {
+	return newProgressURLs("http://"+g.serverAddr, mac, token)
+}
+
 // getHostname 获取节点主机名
 func (g *PreseedGenerator) getHostname(node *model.Node) string {
 	if node.Hostname != "" {
@@ -137,20 +214,77 @@ func (g *PreseedGenerator) getHostname(node *model.Node) string {
 	return "node-" + node.MAC
 }
 
+// pkgselInclude 额外安装的软件包（安装 CA 需要 ca-certificates）
+func (g *PreseedGenerator) pkgselInclude() string {
+	if len(g.caPEM) == 0 {
+		return ""
+	}
+	return "\nd-i pkgsel/include string ca-certificates"
+}
+
+// agentBaseURL agent 下载地址
+func (g *PreseedGenerator) agentBaseURL() string {
+	if g.tlsAddr != "" {
+		return "https://" + g.tlsAddr
+	}
+	return "http://" + g.serverAddr
+}
+
+// generateCAInstall 生成将服务器 CA 写入目标系统信任库的命令
+// PEM 每行作为 printf 的独立参数（仅包含 base64 字符、空格和 '-'，无需转义）
+func (g *PreseedGenerator) generateCAInstall() string {
+	if len(g.caPEM) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`  printf '%%s\n' '%s' > /target/usr/local/share/ca-certificates/nodefoundry-ca.crt && \
+  in-target update-ca-certificates && \
+`, strings.Join(tlsutil.PEMLines(g.caPEM), "' '"))
+}
+
+// generateEarlyCommand 生成 early_command：上报安装开始，并在后台等待 /target 挂载后上报分区完成
+// 上报失败不影响安装
+func (g *PreseedGenerator) generateEarlyCommand(progress ProgressURLs) string {
+	return fmt.Sprintf(`d-i preseed/early_command string \
+  wget -q -O /dev/null --post-data "" "%s" || true ; \
+  ( while ! grep -qs " /target " /proc/mounts ; do sleep 5 ; done ; \
+  wget -q -O /dev/null --post-data "" "%s" ) > /dev/null 2>&1 &`,
+		progress.Started, progress.Partitioned)
+}
+
 // generateLateCommand 生成 late_command（Agent 安装 + MAC 地址注入）
-func (g *PreseedGenerator) generateLateCommand() string {
+// token 非空时 agent 下载地址携带节点 MAC 和安装令牌
+// 执行成功后上报安装完成；失败时上报安装失败并返回非零，安装器停止并显示错误
+func (g *PreseedGenerator) generateLateCommand(mac, token string, progress ProgressURLs) string {
+	agentURL, serviceURL := g.agentURLs(mac, token)
+
 	return fmt.Sprintf(`d-i preseed/late_command string \
-  DHCP_iface=$(ip route | grep default | awk '{print $$5}') && \
+  if DHCP_iface=$(ip route | grep default | awk '{print $$5}') && \
   DHCP_MAC=$$(cat /sys/class/net/$${DHCP_iface}/address | tr -d ':') && \
   echo "Detected DHCP MAC: $${DHCP_MAC}" > /target/var/log/nodefoundry-agent-install.log && \
-  in-target wget http://%s/agent/nodefoundry-agent -O /usr/local/bin/nodefoundry-agent && \
+%s  in-target wget "%s" -O /usr/local/bin/nodefoundry-agent && \
   in-target chmod +x /usr/local/bin/nodefoundry-agent && \
-  in-target wget http://%s/agent/nodefoundry-agent.service -O /etc/systemd/system/nodefoundry-agent.service && \
+  in-target wget "%s" -O /etc/systemd/system/nodefoundry-agent.service && \
   in-target sh -c 'echo "NF_MAC=$${DHCP_MAC}" > /etc/default/nodefoundry-agent' && \
   in-target sh -c 'echo "NF_MQTT_BROKER=%s:1883" >> /etc/default/nodefoundry-agent' && \
   in-target sh -c 'echo "NF_LOG_LEVEL=info" >> /etc/default/nodefoundry-agent' && \
   in-target sh -c 'echo "NF_HEARTBEAT_INTERVAL=30" >> /etc/default/nodefoundry-agent' && \
-  in-target systemctl enable nodefoundry-agent.service`, g.serverAddr, g.serverAddr, g.getServerIP())
+  in-target systemctl enable nodefoundry-agent.service ; then \
+  wget -q -O /dev/null --post-data "" "%s" || true ; \
+  else \
+  wget -q -O /dev/null --post-data "message=late_command failed" "%s" ; false ; \
+  fi`,
+		g.generateCAInstall(), agentURL, serviceURL, g.getServerIP(), progress.Finished, progress.Failed)
+}
+
+// agentURLs agent 二进制和 systemd 服务文件的下载地址
+// token 非空时携带节点 MAC 和安装令牌
+func (g *PreseedGenerator) agentURLs(mac, token string) (string, string) {
+	agentQuery := ""
+	if token != "" {
+		agentQuery = "?" + url.Values{"mac": {mac}, "token": {token}}.Encode()
+	}
+	return g.agentBaseURL() + "/agent/nodefoundry-agent" + agentQuery,
+		g.agentBaseURL() + "/agent/nodefoundry-agent.service" + agentQuery
 }
 
 // getServerIP 从 serverAddr 中提取 IP 地址
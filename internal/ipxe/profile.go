@@ -0,0 +1,220 @@
+package ipxe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"text/template"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// builtinProfile 内置的默认安装配置：从 NF_MIRROR_URL 安装 Debian bookworm，应答文件使用内置 preseed
+var builtinProfile = model.InstallProfile{
+	Name:        model.PROFILE_DEFAULT,
+	Description: "Debian bookworm (built-in)",
+	Distro:      "debian",
+	Release:     "bookworm",
+	KernelURL:   "https://{{ .Server.Mirror }}/debian/dists/bookworm/main/installer-${arch}/current/images/netboot/debian-installer/${arch}/linux",
+	InitrdURL:   "https://{{ .Server.Mirror }}/debian/dists/bookworm/main/installer-${arch}/current/images/netboot/debian-installer/${arch}/initrd.gz",
+	KernelArgs:  "auto=true priority=critical url={{ .PreseedURL }}",
+}
+
+// BuiltinProfile 返回内置的默认安装配置
+func BuiltinProfile() *model.InstallProfile {
+	profile := builtinProfile
+	return &profile
+}
+
+// ScriptProfile 模板中的安装配置
+type ScriptProfile struct {
+	Name    string
+	Distro  string
+	Release string
+	// Arch 目标架构，为空表示使用 iPXE 的 ${buildarch}
+	Arch string
+	// KernelURL、InitrdURL、KernelArgs 渲染后的安装内核地址和内核参数（仅 iPXE 模板有值）
+	KernelURL  string
+	InitrdURL  string
+	KernelArgs string
+}
+
+// AnswerData 应答文件模板可以使用的数据
+type AnswerData struct {
+	// Node 节点记录（副本）
+	Node *model.Node
+	// Labels 节点标签，不存在的标签为空字符串
+	Labels map[string]string
+	// Hostname 节点主机名，未设置时为 node-<MAC>
+	Hostname string
+	// Network 节点的静态网络配置，未分配 IP 时各字段为空（使用 DHCP）
+	Network ScriptNetwork
+	// Server 服务器地址，NodeURL 为 agent 下载使用的地址
+	Server ScriptServer
+	// Profile 安装配置的名称、发行版、版本和架构
+	Profile ScriptProfile
+	// AgentURL、AgentServiceURL agent 二进制和 systemd 服务文件的下载地址（携带安装令牌）
+	AgentURL        string
+	AgentServiceURL string
+	// MQTTBroker agent 连接的 MQTT Broker
+	MQTTBroker string
+	// CACert 需要安装到节点的服务器 CA（PEM），未使用私有 CA 时为空
+	CACert string
+	// SSHAuthorizedKeys 写入节点的 SSH 公钥（NF_SSH_AUTHORIZED_KEYS）
+	SSHAuthorizedKeys []string
+	// Progress 安装进度上报地址
+	Progress ProgressURLs
+	// EarlyCommand、LateCommand 内置 preseed 的 early_command（上报安装开始和分区完成）
+	// 和 late_command（安装并启用 agent，上报安装完成或失败），可直接写入 Debian preseed
+	// 通过 cloud-init user-data 提供时为空
+	EarlyCommand string
+	LateCommand  string
+}
+
+// ProgressURLs 安装进度上报地址（POST，携带安装令牌，失败原因通过表单字段 message 传递）
+type ProgressURLs struct {
+	Started     string
+	Partitioned string
+	Finished    string
+	Failed      string
+}
+
+// newProgressURLs 节点的安装进度上报地址，baseURL 为服务器的 HTTP 基础 URL
+func newProgressURLs(baseURL, mac, token string) ProgressURLs {
+	query := ""
+	if token != "" {
+		query = "?" + url.Values{"token": {token}}.Encode()
+	}
+	stageURL := func(stage string) string {
+		return baseURL + "/install/" + mac + "/" + stage + query
+	}
+	return ProgressURLs{
+		Started:     stageURL(model.INSTALL_STAGE_STARTED),
+		Partitioned: stageURL(model.INSTALL_STAGE_PARTITIONED),
+		Finished:    stageURL(model.INSTALL_STAGE_FINISHED),
+		Failed:      stageURL(model.INSTALL_STAGE_FAILED),
+	}
+}
+
+// resolveProfile 选择节点的安装配置：
+// install-profile 标签指定的配置 → 分配给节点分组的配置 → 名为 default 的配置 → 内置配置
+// 标签指定的配置不存在时返回错误，避免安装错误的系统
+func resolveProfile(ctx context.Context, repo db.ProfileRepository, node *model.Node) (*model.InstallProfile, error) {
+	if repo == nil {
+		return BuiltinProfile(), nil
+	}
+
+	if name := node.Labels[model.LABEL_INSTALL_PROFILE]; name != "" {
+		profile, err := repo.FindByName(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find install profile %q: %w", name, err)
+		}
+		return profile, nil
+	}
+
+	if group := node.Labels[model.LABEL_GROUP]; group != "" {
+		profile, err := repo.FindByGroup(ctx, group)
+		if err == nil {
+			return profile, nil
+		}
+		if !db.IsProfileNotFound(err) {
+			return nil, err
+		}
+	}
+
+	profile, err := repo.FindByName(ctx, model.PROFILE_DEFAULT)
+	if db.IsProfileNotFound(err) {
+		return BuiltinProfile(), nil
+	}
+	return profile, err
+}
+
+// newScriptProfile 安装配置的元数据（不含渲染后的地址）
+func newScriptProfile(profile *model.InstallProfile) ScriptProfile {
+	return ScriptProfile{
+		Name:    profile.Name,
+		Distro:  profile.Distro,
+		Release: profile.Release,
+		Arch:    profile.Arch,
+	}
+}
+
+// renderProfile 渲染安装配置中的内核地址和参数，结果写入 data.Profile
+func renderProfile(profile *model.InstallProfile, data *ScriptData) error {
+	sp := newScriptProfile(profile)
+	data.Profile = &sp
+
+	fields := []struct {
+		name   string
+		text   string
+		target *string
+	}{
+		{"kernel_url", profile.KernelURL, &sp.KernelURL},
+		{"initrd_url", profile.InitrdURL, &sp.InitrdURL},
+		{"kernel_args", profile.KernelArgs, &sp.KernelArgs},
+	}
+	for _, f := range fields {
+		out, err := renderText(f.name, f.text, data)
+		if err != nil {
+			return fmt.Errorf("install profile %s: %w", profile.Name, err)
+		}
+		*f.target = out
+	}
+	return nil
+}
+
+// renderAnswerFile 渲染安装配置的应答文件
+func renderAnswerFile(profile *model.InstallProfile, data *AnswerData) (string, error) {
+	out, err := renderText("answer_file", profile.AnswerFile, data)
+	if err != nil {
+		return "", fmt.Errorf("install profile %s: %w", profile.Name, err)
+	}
+	return out, nil
+}
+
+// renderText 解析并渲染模板字符串
+func renderText(name, text string, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ValidateProfile 用示例数据渲染安装配置中的模板，检查语法和字段名错误
+func ValidateProfile(profile *model.InstallProfile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+
+	data := sampleScriptData(DefaultTemplateSet, model.STATE_INSTALLING)
+	if err := renderProfile(profile, data); err != nil {
+		return err
+	}
+
+	if profile.AnswerFile != "" {
+		answer := &AnswerData{
+			Node:              data.Node,
+			Labels:            data.Labels,
+			Hostname:          data.Node.Hostname,
+			Network:           data.Network,
+			Server:            data.Server,
+			Profile:           newScriptProfile(profile),
+			AgentURL:          data.Server.HTTPURL + "/agent/nodefoundry-agent?mac=" + data.Node.MAC + "&token=nfi_example",
+			AgentServiceURL:   data.Server.HTTPURL + "/agent/nodefoundry-agent.service?mac=" + data.Node.MAC + "&token=nfi_example",
+			MQTTBroker:        "192.168.1.10:1883",
+			SSHAuthorizedKeys: []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIExample admin@example"},
+			Progress:          newProgressURLs(data.Server.HTTPURL, data.Node.MAC, "nfi_example"),
+		}
+		if _, err := renderAnswerFile(profile, answer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package ipxe
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// DefaultTemplateSet 默认模板集名称，未通过标签选择模板集的节点使用
+const DefaultTemplateSet = "default"
+
+// templateExt 模板文件扩展名，文件名为节点状态（如 installing.ipxe）
+const templateExt = ".ipxe"
+
+// 内置的默认模板
+//
+//go:embed templates/*.ipxe
+var builtinFiles embed.FS
+
+// builtinTemplates 内置模板集，编译时嵌入，解析失败属于程序错误
+var builtinTemplates = mustLoadBuiltin()
+
+// templateStates 每个模板集需要提供的模板（按节点状态）
+var templateStates = []string{model.STATE_DISCOVERED, model.STATE_INSTALLING, model.STATE_INSTALLED, model.STATE_FAILED}
+
+// ScriptData iPXE 模板可以使用的数据
+type ScriptData struct {
+	// Node 节点记录（副本），如 .Node.MAC、.Node.Hostname、.Node.Status
+	Node *model.Node
+	// Labels 节点标签，不存在的标签为空字符串，如 .Labels.rack
+	Labels map[string]string
+	// Network 节点的静态网络配置，未分配 IP 时各字段为空
+	Network ScriptNetwork
+	// Server 服务器地址
+	Server ScriptServer
+	// PreseedURL 安装应答文件 URL（携带安装令牌和网络参数），仅 installing 模板有值
+	PreseedURL string
+	// CloudInitURL cloud-init NoCloud 数据源地址，用于内核参数 ds=nocloud-net;s=<CloudInitURL>，仅 installing 模板有值
+	// 携带安装令牌时文件名位置为 %s（cloud-init 将其替换为 user-data 等文件名）
+	CloudInitURL string
+	// Profile 节点的安装配置（内核地址和参数已渲染），仅 installing 模板有值
+	Profile *ScriptProfile
+	// Template 选中的模板集名称
+	Template string
+}
+
+// ScriptNetwork 节点静态网络配置
+type ScriptNetwork struct {
+	IP      string
+	Netmask string
+	Gateway string
+	// DNS 逗号分隔的 DNS 服务器
+	DNS string
+}
+
+// ScriptServer 脚本中使用的服务器地址
+type ScriptServer struct {
+	// NodeURL iPXE 访问服务器的基础 URL（NF_TLS_IPXE 启用时为 HTTPS）
+	NodeURL string
+	// HTTPURL 服务器的 HTTP 基础 URL（Debian 安装器无法信任自签 CA，应答文件始终通过 HTTP 获取）
+	HTTPURL string
+	// Mirror 软件源主机（NF_MIRROR_URL）
+	Mirror string
+}
+
+// Templates iPXE 模板集合：模板集名称 → 节点状态 → 模板
+type Templates struct {
+	sets map[string]map[string]*template.Template
+}
+
+// LoadTemplates 加载内置模板和覆盖目录中的模板，并用示例数据逐个渲染校验
+// 目录结构（dir 为空时只使用内置模板）：
+//
+//	<dir>/installing.ipxe      覆盖 default 模板集中的模板
+//	<dir>/<name>/installing.ipxe  模板集 <name>，缺少的状态使用 default 模板集中的模板
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{sets: make(map[string]map[string]*template.Template)}
+	t.sets[DefaultTemplateSet] = make(map[string]*template.Template)
+	for state, tmpl := range builtinTemplates.sets[DefaultTemplateSet] {
+		t.sets[DefaultTemplateSet][state] = tmpl
+	}
+	if dir == "" {
+		return t, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先加载 default 模板集的覆盖，其他模板集以它为基础
+	if err := t.loadSet(os.DirFS(dir), DefaultTemplateSet, dir); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		name := entry.Name()
+		if name == DefaultTemplateSet {
+			return nil, fmt.Errorf("template set %q is reserved, put its templates in %s directly", name, dir)
+		}
+		// 模板集通过标签选择，名称需要是合法的标签值
+		if err := model.ValidateLabels(map[string]string{model.LABEL_IPXE_TEMPLATE: name}); err != nil {
+			return nil, fmt.Errorf("invalid template set name %q: must be a valid label value", name)
+		}
+
+		t.sets[name] = make(map[string]*template.Template)
+		for state, tmpl := range t.sets[DefaultTemplateSet] {
+			t.sets[name][state] = tmpl
+		}
+		setDir := filepath.Join(dir, name)
+		if err := t.loadSet(os.DirFS(setDir), name, setDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// mustLoadBuiltin 加载内置模板
+func mustLoadBuiltin() *Templates {
+	t := &Templates{sets: map[string]map[string]*template.Template{
+		DefaultTemplateSet: make(map[string]*template.Template),
+	}}
+	sub, err := fs.Sub(builtinFiles, "templates")
+	if err == nil {
+		err = t.loadSet(sub, DefaultTemplateSet, "templates")
+	}
+	if err == nil {
+		err = t.validate()
+	}
+	if err != nil {
+		panic(fmt.Sprintf("invalid builtin ipxe templates: %v", err))
+	}
+	return t
+}
+
+// loadSet 读取目录中的模板文件（<状态>.ipxe）到模板集，覆盖已有的同名模板
+// 其他扩展名的文件忽略；未知状态的模板文件视为错误，避免文件名拼写错误时静默使用默认模板
+func (t *Templates) loadSet(fsys fs.FS, name, dir string) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != templateExt {
+			continue
+		}
+		state := strings.TrimSuffix(entry.Name(), templateExt)
+		if !model.IsValidStatus(state) {
+			return fmt.Errorf("%s: unknown node status %q, expected one of %s",
+				filepath.Join(dir, entry.Name()), state, strings.Join(templateStates, ", "))
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(entry.Name()).Option("missingkey=zero").Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Join(dir, entry.Name()), err)
+		}
+		t.sets[name][state] = tmpl
+	}
+	return nil
+}
+
+// validate 用示例数据渲染所有模板，检查字段名错误和脚本头
+func (t *Templates) validate() error {
+	for _, name := range t.Names() {
+		for _, state := range templateStates {
+			tmpl := t.sets[name][state]
+			if tmpl == nil {
+				return fmt.Errorf("template set %q: missing template for state %q", name, state)
+			}
+
+			script, err := render(tmpl, sampleScriptData(name, state))
+			if err != nil {
+				return fmt.Errorf("template set %q: %w", name, err)
+			}
+			if !strings.HasPrefix(script, "#!ipxe") {
+				return fmt.Errorf("template set %q: %s: rendered script must start with #!ipxe", name, tmpl.Name())
+			}
+		}
+	}
+	return nil
+}
+
+// sampleScriptData 校验模板使用的示例数据
+func sampleScriptData(name, state string) *ScriptData {
+	node := &model.Node{
+		MAC:       "525400123456",
+		IP:        "192.168.1.100",
+		Netmask:   "255.255.255.0",
+		Gateway:   "192.168.1.1",
+		DNS:       "8.8.8.8,8.8.4.4",
+		Hostname:  "node-525400123456",
+		Status:    state,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Labels:    map[string]string{model.LABEL_GROUP: "example"},
+	}
+	if state == model.STATE_FAILED {
+		node.InstallStage = model.INSTALL_STAGE_FAILED
+		node.InstallError = "late_command failed"
+	}
+	data := &ScriptData{
+		Node:     node,
+		Labels:   node.Labels,
+		Network:  ScriptNetwork{IP: node.IP, Netmask: node.Netmask, Gateway: node.Gateway, DNS: node.DNS},
+		Server:   ScriptServer{NodeURL: "http://192.168.1.10:8080", HTTPURL: "http://192.168.1.10:8080", Mirror: "deb.debian.org"},
+		Template: name,
+	}
+	if state == model.STATE_INSTALLING {
+		data.PreseedURL = data.Server.HTTPURL + "/preseed/" + node.MAC + "/preseed.cfg?token=nfi_example"
+		data.CloudInitURL = data.Server.HTTPURL + "/cloud-init/" + node.MAC + "/%s?token=nfi_example"
+		// 内置配置的模板在编译时确定，渲染不会失败
+		_ = renderProfile(BuiltinProfile(), data)
+	}
+	return data
+}
+
+// render 渲染模板
+func render(tmpl *template.Template, data *ScriptData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Names 返回所有模板集名称（按名称排序）
+func (t *Templates) Names() []string {
+	names := make([]string, 0, len(t.sets))
+	for name := range t.sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has 模板集是否存在
+func (t *Templates) Has(name string) bool {
+	_, ok := t.sets[name]
+	return ok
+}
+
+// Render 使用模板集中对应状态的模板渲染脚本
+func (t *Templates) Render(name, state string, data *ScriptData) (string, error) {
+	set, ok := t.sets[name]
+	if !ok {
+		return "", fmt.Errorf("unknown ipxe template set: %s", name)
+	}
+	tmpl, ok := set[state]
+	if !ok {
+		return "", fmt.Errorf("unknown node status: %s", state)
+	}
+	return render(tmpl, data)
+}
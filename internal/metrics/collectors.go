@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// collectTimeout 采集时读取数据的超时时间
+const collectTimeout = 5 * time.Second
+
+// nodeCollector 在抓取时统计节点数量
+type nodeCollector struct {
+	repo        db.NodeRepository
+	aliveWindow time.Duration
+
+	nodes *prometheus.Desc
+	alive *prometheus.Desc
+	up    *prometheus.Desc
+}
+
+// NewNodeCollector 创建节点采集器
+// aliveWindow 内有心跳的节点视为在线
+func NewNodeCollector(repo db.NodeRepository, aliveWindow time.Duration) prometheus.Collector {
+	return &nodeCollector{
+		repo:        repo,
+		aliveWindow: aliveWindow,
+		nodes: prometheus.NewDesc(namespace+"_nodes",
+			"Number of nodes, by status.", []string{"status"}, nil),
+		alive: prometheus.NewDesc(namespace+"_nodes_alive",
+			"Number of nodes with a recent heartbeat, by status.", []string{"status"}, nil),
+		up: prometheus.NewDesc(namespace+"_nodes_scrape_success",
+			"Whether listing nodes for this scrape succeeded.", nil, nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *nodeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.nodes
+	ch <- c.alive
+	ch <- c.up
+}
+
+// Collect 实现 prometheus.Collector
+func (c *nodeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	nodes, err := c.repo.List(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+
+	// 所有状态都输出，避免计数归零时序列消失
+	counts := make(map[string]int)
+	alive := make(map[string]int)
+	for _, status := range []string{model.STATE_DISCOVERED, model.STATE_INSTALLING, model.STATE_INSTALLED, model.STATE_FAILED} {
+		counts[status] = 0
+		alive[status] = 0
+	}
+
+	now := time.Now()
+	for _, node := range nodes {
+		counts[node.Status]++
+		if !node.LastHeartbeat.IsZero() && now.Sub(node.LastHeartbeat) <= c.aliveWindow {
+			alive[node.Status]++
+		}
+	}
+
+	for status, n := range counts {
+		ch <- prometheus.MustNewConstMetric(c.nodes, prometheus.GaugeValue, float64(n), status)
+		ch <- prometheus.MustNewConstMetric(c.alive, prometheus.GaugeValue, float64(alive[status]), status)
+	}
+}
+
+// PoolUsage 单个地址池的使用情况
+type PoolUsage struct {
+	Subnet string
+	Size   int
+	Used   int
+}
+
+// poolCollector 在抓取时读取 IP 池使用情况
+type poolCollector struct {
+	source func() []PoolUsage
+
+	size *prometheus.Desc
+	used *prometheus.Desc
+	free *prometheus.Desc
+}
+
+// NewPoolCollector 创建 IP 池采集器
+func NewPoolCollector(source func() []PoolUsage) prometheus.Collector {
+	labels := []string{"subnet"}
+	return &poolCollector{
+		source: source,
+		size: prometheus.NewDesc(namespace+"_dhcp_pool_size",
+			"Number of addresses in the DHCP pool.", labels, nil),
+		used: prometheus.NewDesc(namespace+"_dhcp_pool_used",
+			"Number of allocated addresses in the DHCP pool.", labels, nil),
+		free: prometheus.NewDesc(namespace+"_dhcp_pool_free",
+			"Number of free addresses in the DHCP pool.", labels, nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.size
+	ch <- c.used
+	ch <- c.free
+}
+
+// Collect 实现 prometheus.Collector
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, pool := range c.source() {
+		free := pool.Size - pool.Used
+		if free < 0 {
+			free = 0
+		}
+		ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(pool.Size), pool.Subnet)
+		ch <- prometheus.MustNewConstMetric(c.used, prometheus.GaugeValue, float64(pool.Used), pool.Subnet)
+		ch <- prometheus.MustNewConstMetric(c.free, prometheus.GaugeValue, float64(free), pool.Subnet)
+	}
+}
@@ -0,0 +1,235 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名前缀
+const namespace = "nodefoundry"
+
+// Metrics Prometheus 指标集合
+// 记录方法允许在 nil 接收者上调用，未启用指标的组件无需判空
+type Metrics struct {
+	registry *prometheus.Registry
+
+	dhcpPackets       *prometheus.CounterVec
+	bootRequests      *prometheus.CounterVec
+	preseedRequests   *prometheus.CounterVec
+	cloudInitRequests *prometheus.CounterVec
+	installProgress   *prometheus.CounterVec
+	mqttReceived      *prometheus.CounterVec
+	mqttInvalid       *prometheus.CounterVec
+	repoDuration      *prometheus.HistogramVec
+	httpRequests      *prometheus.CounterVec
+	httpDuration      *prometheus.HistogramVec
+	httpRateLimited   *prometheus.CounterVec
+}
+
+// New 创建指标集合（使用独立的 registry，并包含 Go 运行时和进程指标）
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		dhcpPackets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dhcp",
+			Name:      "packets_total",
+			Help:      "DHCP packets received, by message type and outcome.",
+		}, []string{"type", "outcome"}),
+
+		bootRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "boot_script_requests_total",
+			Help:      "iPXE boot script requests, by node state.",
+		}, []string{"state"}),
+
+		preseedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "preseed_requests_total",
+			Help:      "Preseed requests, by node state and result.",
+		}, []string{"state", "result"}),
+
+		cloudInitRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cloud_init_requests_total",
+			Help:      "Cloud-init NoCloud datasource requests, by file and result.",
+		}, []string{"file", "result"}),
+
+		installProgress: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "install_progress_reports_total",
+			Help:      "Installer progress reports, by stage and result.",
+		}, []string{"stage", "result"}),
+
+		mqttReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "mqtt",
+			Name:      "messages_received_total",
+			Help:      "MQTT messages received, by message kind.",
+		}, []string{"kind"}),
+
+		mqttInvalid: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "mqtt",
+			Name:      "messages_invalid_total",
+			Help:      "MQTT messages rejected, by reason.",
+		}, []string{"reason"}),
+
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "operation_duration_seconds",
+			Help:      "Node repository operation latency, by operation and result.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"operation", "result"}),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests, by method, route and status code.",
+		}, []string{"method", "route", "code"}),
+
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+
+		httpRateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "rate_limited_total",
+			Help:      "HTTP requests rejected by rate limiting, by route group and limit scope (ip or mac).",
+		}, []string{"group", "scope"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.dhcpPackets,
+		m.bootRequests,
+		m.preseedRequests,
+		m.cloudInitRequests,
+		m.installProgress,
+		m.mqttReceived,
+		m.mqttInvalid,
+		m.repoDuration,
+		m.httpRequests,
+		m.httpDuration,
+		m.httpRateLimited,
+	)
+
+	return m
+}
+
+// MustRegister 注册额外的采集器（如节点、IP 池采集器）
+func (m *Metrics) MustRegister(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+// Handler 返回 /metrics 处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// DHCPPacket 记录一个 DHCP 报文的处理结果
+func (m *Metrics) DHCPPacket(msgType, outcome string) {
+	if m == nil {
+		return
+	}
+	m.dhcpPackets.WithLabelValues(msgType, outcome).Inc()
+}
+
+// BootScriptRequest 记录 iPXE 脚本请求（state 为节点状态，未知节点为 unknown）
+func (m *Metrics) BootScriptRequest(state string) {
+	if m == nil {
+		return
+	}
+	m.bootRequests.WithLabelValues(state).Inc()
+}
+
+// PreseedRequest 记录 preseed 请求
+func (m *Metrics) PreseedRequest(state, result string) {
+	if m == nil {
+		return
+	}
+	m.preseedRequests.WithLabelValues(state, result).Inc()
+}
+
+// CloudInitRequest 记录 cloud-init 数据请求
+func (m *Metrics) CloudInitRequest(file, result string) {
+	if m == nil {
+		return
+	}
+	m.cloudInitRequests.WithLabelValues(file, result).Inc()
+}
+
+// InstallProgress 记录安装器进度上报
+func (m *Metrics) InstallProgress(stage, result string) {
+	if m == nil {
+		return
+	}
+	m.installProgress.WithLabelValues(stage, result).Inc()
+}
+
+// MQTTMessage 记录收到的 MQTT 消息
+func (m *Metrics) MQTTMessage(kind string) {
+	if m == nil {
+		return
+	}
+	m.mqttReceived.WithLabelValues(kind).Inc()
+}
+
+// MQTTInvalid 记录被拒绝的 MQTT 消息
+func (m *Metrics) MQTTInvalid(reason string) {
+	if m == nil {
+		return
+	}
+	m.mqttInvalid.WithLabelValues(reason).Inc()
+}
+
+// RateLimited 记录被限速拒绝的 HTTP 请求（客户端地址只写入日志，避免标签爆炸）
+func (m *Metrics) RateLimited(group, scope string) {
+	if m == nil {
+		return
+	}
+	m.httpRateLimited.WithLabelValues(group, scope).Inc()
+}
+
+// ObserveRepository 记录 repository 操作耗时
+func (m *Metrics) ObserveRepository(operation, result string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.repoDuration.WithLabelValues(operation, result).Observe(duration.Seconds())
+}
+
+// GinMiddleware 记录 HTTP 请求数和耗时（route 为路由模板，避免 MAC 等参数导致标签爆炸）
+func (m *Metrics) GinMiddleware() gin.HandlerFunc {
+	if m == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+
+		m.httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
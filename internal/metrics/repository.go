@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// InstrumentedStore 需要被计时的存储（节点存储 + 心跳批量写入）
+type InstrumentedStore interface {
+	db.NodeRepository
+	db.HeartbeatStore
+}
+
+// InstrumentedRepository 记录 repository 操作耗时的装饰器
+type InstrumentedRepository struct {
+	inner   InstrumentedStore
+	metrics *Metrics
+}
+
+// InstrumentRepository 为存储添加操作耗时指标
+func InstrumentRepository(inner InstrumentedStore, metrics *Metrics) *InstrumentedRepository {
+	return &InstrumentedRepository{inner: inner, metrics: metrics}
+}
+
+// observe 记录单次操作耗时
+// 节点不存在属于正常查询结果，版本冲突会由调用方重试，均不计为错误
+func (r *InstrumentedRepository) observe(operation string, start time.Time, err error) {
+	result := "ok"
+	switch {
+	case err == nil, db.IsNodeNotFound(err):
+	case db.IsVersionConflict(err):
+		result = "conflict"
+	default:
+		result = "error"
+	}
+	r.metrics.ObserveRepository(operation, result, time.Since(start))
+}
+
+// Save 实现 db.NodeRepository
+func (r *InstrumentedRepository) Save(ctx context.Context, node *model.Node) error {
+	start := time.Now()
+	err := r.inner.Save(ctx, node)
+	r.observe("save", start, err)
+	return err
+}
+
+// FindByMAC 实现 db.NodeRepository
+func (r *InstrumentedRepository) FindByMAC(ctx context.Context, mac string) (*model.Node, error) {
+	start := time.Now()
+	node, err := r.inner.FindByMAC(ctx, mac)
+	r.observe("find_by_mac", start, err)
+	return node, err
+}
+
+// List 实现 db.NodeRepository
+func (r *InstrumentedRepository) List(ctx context.Context) ([]*model.Node, error) {
+	start := time.Now()
+	nodes, err := r.inner.List(ctx)
+	r.observe("list", start, err)
+	return nodes, err
+}
+
+// ListByStatus 实现 db.NodeRepository
+func (r *InstrumentedRepository) ListByStatus(ctx context.Context, status string) ([]*model.Node, error) {
+	start := time.Now()
+	nodes, err := r.inner.ListByStatus(ctx, status)
+	r.observe("list_by_status", start, err)
+	return nodes, err
+}
+
+// UpdateStatus 实现 db.NodeRepository
+func (r *InstrumentedRepository) UpdateStatus(ctx context.Context, mac string, status string) error {
+	start := time.Now()
+	err := r.inner.UpdateStatus(ctx, mac, status)
+	r.observe("update_status", start, err)
+	return err
+}
+
+// Delete 实现 db.NodeRepository
+func (r *InstrumentedRepository) Delete(ctx context.Context, mac string) error {
+	start := time.Now()
+	err := r.inner.Delete(ctx, mac)
+	r.observe("delete", start, err)
+	return err
+}
+
+// UpdateHeartbeats 实现 db.HeartbeatStore
+func (r *InstrumentedRepository) UpdateHeartbeats(ctx context.Context, heartbeats map[string]time.Time) error {
+	start := time.Now()
+	err := r.inner.UpdateHeartbeats(ctx, heartbeats)
+	r.observe("update_heartbeats", start, err)
+	return err
+}
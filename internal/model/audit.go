@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// ACTOR_ANONYMOUS 未启用认证时的操作者
+const ACTOR_ANONYMOUS = "anonymous"
+
+// AuditEntry 一次修改类 API 请求的审计记录（只追加，不修改）
+type AuditEntry struct {
+	// ID 写入顺序递增
+	ID        uint64    `json:"id"`
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	// Actor 操作者：API token 名称，未启用认证时为 anonymous
+	Actor   string `json:"actor"`
+	TokenID string `json:"token_id,omitempty"`
+	Role    string `json:"role,omitempty"`
+	// RemoteAddr 请求来源地址
+	RemoteAddr string `json:"remote_addr"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	// Action 操作名称（即 OpenAPI operationId，如 put_nodes_mac）
+	Action string `json:"action"`
+	// MAC 目标节点，Target 其他目标资源（token、webhook 的 ID，安装配置的名称）
+	MAC    string `json:"mac,omitempty"`
+	Target string `json:"target,omitempty"`
+	// Details 操作参数，如节点操作类型、命令名称、批量操作的选择器和结果统计
+	Details map[string]interface{} `json:"details,omitempty"`
+	// 请求结果
+	Status     int    `json:"status"`
+	Code       string `json:"code,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
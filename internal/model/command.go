@@ -0,0 +1,115 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// 命令执行状态
+const (
+	COMMAND_PENDING   = "pending"   // 已下发，等待 agent 确认
+	COMMAND_RUNNING   = "running"   // agent 已开始执行
+	COMMAND_SUCCEEDED = "succeeded" // 执行成功
+	COMMAND_FAILED    = "failed"    // 执行失败或下发失败
+	COMMAND_TIMED_OUT = "timed_out" // 超过截止时间仍未收到最终结果
+)
+
+// commandTransitions 命令状态转换规则（结束状态不可再变化）
+var commandTransitions = map[string][]string{
+	COMMAND_PENDING: {COMMAND_RUNNING, COMMAND_SUCCEEDED, COMMAND_FAILED, COMMAND_TIMED_OUT},
+	COMMAND_RUNNING: {COMMAND_SUCCEEDED, COMMAND_FAILED, COMMAND_TIMED_OUT},
+}
+
+// commandNamePattern 命令名称格式
+var commandNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+// CommandExecution 一次下发给节点 agent 的命令及其执行结果
+type CommandExecution struct {
+	// ID 以创建时间开头，按字典序即按时间排序
+	ID      string                 `json:"id"`
+	MAC     string                 `json:"mac"`
+	Command string                 `json:"command"`
+	Args    map[string]interface{} `json:"args,omitempty"`
+	Status  string                 `json:"status"`
+	// RequestedBy 发起命令的 API token 名称（未启用认证时为空）
+	RequestedBy string `json:"requested_by,omitempty"`
+	// IdempotencyKey 幂等键，同一节点相同键的请求只下发一次
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	// Deadline 截止时间，之后仍未结束的命令标记为 timed_out
+	Deadline   time.Time `json:"deadline"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+	// Progress agent 上报的最新进度
+	Progress string `json:"progress,omitempty"`
+	// agent 上报的执行结果
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// NewCommandExecution 创建待下发的命令
+func NewCommandExecution(mac, command string, args map[string]interface{}, timeout time.Duration, now time.Time) *CommandExecution {
+	return &CommandExecution{
+		ID:        newSortableID(now),
+		MAC:       NormalizeMAC(mac),
+		Command:   command,
+		Args:      args,
+		Status:    COMMAND_PENDING,
+		CreatedAt: now,
+		Deadline:  now.Add(timeout),
+	}
+}
+
+// Validate 验证命令数据
+func (e *CommandExecution) Validate() error {
+	if e.ID == "" {
+		return errors.New("command id is required")
+	}
+	if !IsValidMAC(e.MAC) {
+		return fmt.Errorf("invalid MAC address: %s", e.MAC)
+	}
+	if err := ValidateCommandName(e.Command); err != nil {
+		return err
+	}
+	if !IsValidCommandStatus(e.Status) {
+		return fmt.Errorf("invalid command status: %s", e.Status)
+	}
+	return nil
+}
+
+// Finished 命令是否已结束
+func (e *CommandExecution) Finished() bool {
+	_, active := commandTransitions[e.Status]
+	return !active
+}
+
+// CanTransitionTo 检查是否可以转换到目标状态
+func (e *CommandExecution) CanTransitionTo(status string) error {
+	for _, allowed := range commandTransitions[e.Status] {
+		if allowed == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid command status transition from %s to %s", e.Status, status)
+}
+
+// ValidateCommandName 验证命令名称（小写字母开头，仅含小写字母、数字、- 和 _）
+func ValidateCommandName(name string) error {
+	if !commandNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid command name: %q", name)
+	}
+	return nil
+}
+
+// IsValidCommandStatus 验证命令状态是否有效
+func IsValidCommandStatus(status string) bool {
+	switch status {
+	case COMMAND_PENDING, COMMAND_RUNNING, COMMAND_SUCCEEDED, COMMAND_FAILED, COMMAND_TIMED_OUT:
+		return true
+	}
+	return false
+}
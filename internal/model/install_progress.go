@@ -0,0 +1,67 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 安装器上报的安装进度
+const (
+	INSTALL_STAGE_STARTED     = "started"     // 安装器启动（preseed early_command、cloud-init bootcmd）
+	INSTALL_STAGE_PARTITIONED = "partitioned" // 分区完成，目标系统已挂载
+	INSTALL_STAGE_FINISHED    = "finished"    // 安装完成（late_command 执行成功、cloud-init phone_home）
+	INSTALL_STAGE_FAILED      = "failed"      // 安装失败
+)
+
+// InstallStages 所有安装进度（按安装顺序）
+var InstallStages = []string{
+	INSTALL_STAGE_STARTED,
+	INSTALL_STAGE_PARTITIONED,
+	INSTALL_STAGE_FINISHED,
+	INSTALL_STAGE_FAILED,
+}
+
+// installErrorMaxLen 安装错误信息最大长度（字符数）
+const installErrorMaxLen = 512
+
+// IsValidInstallStage 验证安装进度是否有效
+func IsValidInstallStage(stage string) bool {
+	for _, s := range InstallStages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyInstallProgress 记录安装器上报的进度，仅 installing 节点可上报
+// finished 将节点转换为 installed（不再依赖 agent 上报），failed 转换为 failed 并记录错误信息
+func (n *Node) ApplyInstallProgress(stage, message string) error {
+	if !IsValidInstallStage(stage) {
+		return fmt.Errorf("invalid install stage: %s", stage)
+	}
+	if n.Status != STATE_INSTALLING {
+		return fmt.Errorf("node is not installing: %s", n.Status)
+	}
+
+	n.InstallStage = stage
+	switch stage {
+	case INSTALL_STAGE_FINISHED:
+		n.Status = STATE_INSTALLED
+	case INSTALL_STAGE_FAILED:
+		// 错误信息会显示在 iPXE 脚本和日志中，控制字符替换为空格
+		message = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return ' '
+			}
+			return r
+		}, strings.TrimSpace(message))
+		if runes := []rune(message); len(runes) > installErrorMaxLen {
+			message = string(runes[:installErrorMaxLen])
+		}
+		n.Status = STATE_FAILED
+		n.InstallError = message
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// 安装令牌保护的资源，每个资源只能使用一次
+const (
+	INSTALL_RESOURCE_PRESEED       = "preseed"
+	INSTALL_RESOURCE_AGENT_SERVICE = "agent.service"
+)
+
+// 只校验令牌、不标记使用的资源（安装过程中会多次请求）
+const (
+	INSTALL_RESOURCE_AGENT      = "agent"      // agent 二进制（支持断点续传）
+	INSTALL_RESOURCE_CLOUD_INIT = "cloud-init" // cloud-init NoCloud 数据
+	INSTALL_RESOURCE_PROGRESS   = "progress"   // 安装进度上报
+)
+
+// InstallResources 安装令牌保护的一次性资源
+var InstallResources = []string{
+	INSTALL_RESOURCE_PRESEED,
+	INSTALL_RESOURCE_AGENT_SERVICE,
+}
+
+// InstallToken 节点安装令牌
+// 令牌需要嵌入下发的 iPXE 安装脚本，因此保存明文；
+// 每次下发安装脚本都会签发新令牌，令牌短期有效，节点安装完成后即删除
+type InstallToken struct {
+	MAC   string `json:"mac"`
+	Token string `json:"token"`
+	// 签发时节点的安装开始时间，用于区分不同的安装周期
+	InstallStartedAt time.Time            `json:"install_started_at"`
+	CreatedAt        time.Time            `json:"created_at"`
+	ExpiresAt        time.Time            `json:"expires_at"`
+	Used             map[string]time.Time `json:"used,omitempty"`
+}
+
+// Expired 判断令牌在指定时间是否已过期
+func (t *InstallToken) Expired(now time.Time) bool {
+	return now.After(t.ExpiresAt)
+}
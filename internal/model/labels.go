@@ -0,0 +1,156 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 有特殊含义的标签
+const (
+	// LABEL_GROUP 节点分组，未单独指定时按分组选择 iPXE 模板集和安装配置
+	LABEL_GROUP = "group"
+	// LABEL_IPXE_TEMPLATE 节点使用的 iPXE 模板集
+	LABEL_IPXE_TEMPLATE = "ipxe-template"
+	// LABEL_INSTALL_PROFILE 节点使用的安装配置，优先于分组的配置
+	LABEL_INSTALL_PROFILE = "install-profile"
+)
+
+// 标签键、值格式：字母数字开头和结尾，中间允许 - _ . /
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,61}[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]{0,61}[A-Za-z0-9])?)?$`)
+)
+
+// ValidateLabels 验证标签键值格式
+func ValidateLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if !labelKeyPattern.MatchString(k) {
+			return fmt.Errorf("invalid label key: %q", k)
+		}
+		if !labelValuePattern.MatchString(v) {
+			return fmt.Errorf("invalid label value for %q: %q", k, v)
+		}
+	}
+	return nil
+}
+
+// 标签选择条件运算符
+const (
+	labelOpEquals    = "="
+	labelOpNotEquals = "!="
+	labelOpExists    = "exists"
+	labelOpNotExists = "!exists"
+)
+
+// labelRequirement 单个标签选择条件
+type labelRequirement struct {
+	key   string
+	op    string
+	value string
+}
+
+// LabelSelector 标签选择器
+// 语法：逗号分隔的条件，所有条件同时满足才匹配
+//   - key=value  标签等于 value
+//   - key!=value 标签不存在或不等于 value
+//   - key        标签存在
+//   - !key       标签不存在
+type LabelSelector struct {
+	requirements []labelRequirement
+}
+
+// ParseLabelSelector 解析标签选择器，空字符串匹配所有节点
+func ParseLabelSelector(selector string) (*LabelSelector, error) {
+	s := &LabelSelector{}
+
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var req labelRequirement
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			req = labelRequirement{key: strings.TrimSpace(kv[0]), op: labelOpNotEquals, value: strings.TrimSpace(kv[1])}
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(part, "=", 2)
+			req = labelRequirement{key: strings.TrimSpace(kv[0]), op: labelOpEquals, value: strings.TrimSpace(kv[1])}
+		case strings.HasPrefix(part, "!"):
+			req = labelRequirement{key: strings.TrimSpace(part[1:]), op: labelOpNotExists}
+		default:
+			req = labelRequirement{key: part, op: labelOpExists}
+		}
+
+		if !labelKeyPattern.MatchString(req.key) {
+			return nil, fmt.Errorf("invalid label selector %q: invalid key", part)
+		}
+		if !labelValuePattern.MatchString(req.value) {
+			return nil, fmt.Errorf("invalid label selector %q: invalid value", part)
+		}
+
+		s.requirements = append(s.requirements, req)
+	}
+
+	return s, nil
+}
+
+// Empty 选择器是否没有任何条件
+func (s *LabelSelector) Empty() bool {
+	return s == nil || len(s.requirements) == 0
+}
+
+// Matches 判断标签集合是否满足选择器
+func (s *LabelSelector) Matches(labels map[string]string) bool {
+	if s == nil {
+		return true
+	}
+
+	for _, req := range s.requirements {
+		value, exists := labels[req.key]
+		switch req.op {
+		case labelOpEquals:
+			if !exists || value != req.value {
+				return false
+			}
+		case labelOpNotEquals:
+			if exists && value == req.value {
+				return false
+			}
+		case labelOpExists:
+			if !exists {
+				return false
+			}
+		case labelOpNotExists:
+			if exists {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// String 返回选择器的规范字符串形式
+func (s *LabelSelector) String() string {
+	if s == nil {
+		return ""
+	}
+
+	parts := make([]string, 0, len(s.requirements))
+	for _, req := range s.requirements {
+		switch req.op {
+		case labelOpEquals, labelOpNotEquals:
+			parts = append(parts, req.key+req.op+req.value)
+		case labelOpExists:
+			parts = append(parts, req.key)
+		case labelOpNotExists:
+			parts = append(parts, "!"+req.key)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
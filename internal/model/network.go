@@ -0,0 +1,64 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ValidateNetworkConfig 校验节点静态网络配置
+// ip 与 netmask 必须同时设置或同时为空；gateway 和 dns 需要 ip，gateway 必须位于 ip/netmask 网段内
+func ValidateNetworkConfig(ip, netmask, gateway, dns string) error {
+	if ip == "" {
+		if netmask != "" || gateway != "" || dns != "" {
+			return fmt.Errorf("netmask, gateway and dns require ip")
+		}
+		return nil
+	}
+
+	addr := net.ParseIP(ip).To4()
+	if addr == nil {
+		return fmt.Errorf("invalid ip: %s", ip)
+	}
+	if netmask == "" {
+		return fmt.Errorf("netmask is required when ip is set")
+	}
+	mask, err := ParseNetmask(netmask)
+	if err != nil {
+		return err
+	}
+
+	if gateway != "" {
+		gw := net.ParseIP(gateway).To4()
+		if gw == nil {
+			return fmt.Errorf("invalid gateway: %s", gateway)
+		}
+		network := &net.IPNet{IP: addr.Mask(mask), Mask: mask}
+		if !network.Contains(gw) {
+			return fmt.Errorf("gateway %s is not in %s", gateway, network)
+		}
+	}
+
+	if dns != "" {
+		for _, server := range strings.Split(dns, ",") {
+			if net.ParseIP(strings.TrimSpace(server)) == nil {
+				return fmt.Errorf("invalid dns server: %s", server)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseNetmask 解析点分十进制子网掩码（如 255.255.255.0）
+func ParseNetmask(netmask string) (net.IPMask, error) {
+	ip := net.ParseIP(netmask).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("invalid netmask: %s", netmask)
+	}
+	mask := net.IPMask(ip)
+	if ones, bits := mask.Size(); ones == 0 && bits == 0 {
+		return nil, fmt.Errorf("invalid netmask: %s", netmask)
+	}
+	return mask, nil
+}
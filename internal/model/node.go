@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -11,15 +12,29 @@ import (
 type Node struct {
 	MAC           string          `json:"mac"`
 	IP            string          `json:"ip,omitempty"`
-	Netmask       string          `json:"netmask,omitempty"`       // 子网掩码
-	Gateway       string          `json:"gateway,omitempty"`       // 网关
-	DNS           string          `json:"dns,omitempty"`           // DNS 服务器（逗号分隔）
+	Netmask       string          `json:"netmask,omitempty"` // 子网掩码
+	Gateway       string          `json:"gateway,omitempty"` // 网关
+	DNS           string          `json:"dns,omitempty"`     // DNS 服务器（逗号分隔）
 	Hostname      string          `json:"hostname,omitempty"`
 	Status        string          `json:"status"`
 	LastHeartbeat time.Time       `json:"last_heartbeat,omitempty"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
 	Extra         json.RawMessage `json:"extra,omitempty"`
+	// Labels 节点标签（如 rack=a1、group=gpu）
+	Labels map[string]string `json:"labels,omitempty"`
+	// Notes 运维备注
+	Notes string `json:"notes,omitempty"`
+	// InstallStartedAt 最近一次进入 installing 状态的时间
+	InstallStartedAt time.Time `json:"install_started_at,omitempty"`
+	// InstallStage 本次安装中安装器上报的最新进度（started、partitioned、finished、failed）
+	InstallStage string `json:"install_stage,omitempty"`
+	// InstallError 安装器上报失败时的错误信息
+	InstallError string `json:"install_error,omitempty"`
+	// StaticNetwork 网络配置由运维通过 API 固定，DHCP 按该配置应答且不再覆盖
+	StaticNetwork bool `json:"static_network,omitempty"`
+	// ResourceVersion 资源版本，每次写入单调递增，用于乐观并发控制
+	ResourceVersion uint64 `json:"resource_version"`
 }
 
 // 状态常量
@@ -27,6 +42,8 @@ const (
 	STATE_DISCOVERED = "discovered"
 	STATE_INSTALLING = "installing"
 	STATE_INSTALLED  = "installed"
+	// STATE_FAILED 安装器上报安装失败，需要重新触发安装
+	STATE_FAILED = "failed"
 )
 
 // 所有有效状态
@@ -34,6 +51,7 @@ var validStates = map[string]bool{
 	STATE_DISCOVERED: true,
 	STATE_INSTALLING: true,
 	STATE_INSTALLED:  true,
+	STATE_FAILED:     true,
 }
 
 // IsValidStatus 验证状态是否有效
@@ -41,15 +59,16 @@ func IsValidStatus(status string) bool {
 	return validStates[status]
 }
 
-// 状态转换规则（MVP：单向，不支持回退或重装）
+// 状态转换规则（不支持回退，已安装节点可重装，安装失败的节点可重新安装）
 var stateTransitions = map[string][]string{
 	STATE_DISCOVERED: {STATE_INSTALLING},
-	STATE_INSTALLING: {STATE_INSTALLED},
-	STATE_INSTALLED:  {}, // 已安装状态不能转换
+	STATE_INSTALLING: {STATE_INSTALLED, STATE_FAILED},
+	STATE_INSTALLED:  {STATE_INSTALLING}, // 重装
+	STATE_FAILED:     {STATE_INSTALLING}, // 重新安装
 }
 
 // CanTransitionTo 检查状态转换是否合法
-// MVP: discovered → installing → installed (单向，不支持回退或重装)
+// discovered → installing → installed/failed，installed/failed → installing（重装）
 func (n *Node) CanTransitionTo(newStatus string) error {
 	// 检查新状态是否有效
 	if !IsValidStatus(newStatus) {
@@ -76,6 +95,22 @@ func (n *Node) CanTransitionTo(newStatus string) error {
 	return fmt.Errorf("invalid status transition: %s -> %s", n.Status, newStatus)
 }
 
+// StartInstall 将节点转换为 installing 并记录安装开始时间
+func (n *Node) StartInstall() error {
+	if n.Status == STATE_INSTALLING {
+		return fmt.Errorf("node is already installing")
+	}
+	if err := n.CanTransitionTo(STATE_INSTALLING); err != nil {
+		return err
+	}
+
+	n.Status = STATE_INSTALLING
+	n.InstallStartedAt = time.Now()
+	n.InstallStage = ""
+	n.InstallError = ""
+	return nil
+}
+
 // Validate 验证节点数据
 func (n *Node) Validate() error {
 	if n.MAC == "" {
@@ -126,6 +161,27 @@ func NormalizeMAC(mac string) string {
 	return string(result)
 }
 
+// IsValidHostname 验证主机名格式（RFC 1123，每段 1-63 个字母数字或连字符）
+func IsValidHostname(hostname string) bool {
+	if hostname == "" || len(hostname) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(hostname, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // IsValidMAC 验证 MAC 地址格式
 func IsValidMAC(mac string) bool {
 	normalized := NormalizeMAC(mac)
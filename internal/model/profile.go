@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PROFILE_DEFAULT 未指定安装配置的节点使用的配置名称
+// 数据库中没有同名配置时使用内置的 Debian bookworm 配置
+const PROFILE_DEFAULT = "default"
+
+// InstallProfile 操作系统安装配置：安装内核、内核参数和应答文件
+// KernelURL、InitrdURL、KernelArgs 和 AnswerFile 为 text/template 模板
+type InstallProfile struct {
+	// Name 配置名称，通过节点标签 install-profile 引用
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Distro 发行版（如 debian、ubuntu），Release 版本（如 bookworm、24.04）
+	Distro  string `json:"distro"`
+	Release string `json:"release,omitempty"`
+	// Arch 目标架构，为空表示使用 iPXE 的 ${buildarch}
+	Arch string `json:"arch,omitempty"`
+	// KernelURL、InitrdURL 安装内核和 initrd 的地址
+	KernelURL string `json:"kernel_url"`
+	InitrdURL string `json:"initrd_url"`
+	// KernelArgs 内核参数，通常包含应答文件地址 {{ .PreseedURL }}
+	KernelArgs string `json:"kernel_args,omitempty"`
+	// AnswerFile 应答文件（preseed、autoinstall 等），为空时使用内置的 Debian preseed
+	AnswerFile string `json:"answer_file,omitempty"`
+	// Groups 默认使用该配置的节点分组（group 标签的值），一个分组只能属于一个配置
+	Groups    []string  `json:"groups,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate 验证安装配置（模板语法由 ipxe 包校验）
+func (p *InstallProfile) Validate() error {
+	if p.Name == "" {
+		return errors.New("profile name is required")
+	}
+	// 配置通过标签选择，名称需要是合法的标签值
+	if err := ValidateLabels(map[string]string{LABEL_INSTALL_PROFILE: p.Name}); err != nil {
+		return fmt.Errorf("invalid profile name: %q", p.Name)
+	}
+	if p.Distro == "" {
+		return errors.New("distro is required")
+	}
+	if p.KernelURL == "" || p.InitrdURL == "" {
+		return errors.New("kernel_url and initrd_url are required")
+	}
+	for _, group := range p.Groups {
+		if group == "" {
+			return errors.New("group must not be empty")
+		}
+		if err := ValidateLabels(map[string]string{LABEL_GROUP: group}); err != nil {
+			return fmt.Errorf("invalid group: %q", group)
+		}
+	}
+	return nil
+}
+
+// HasGroup 配置是否分配给分组
+func (p *InstallProfile) HasGroup(group string) bool {
+	for _, g := range p.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
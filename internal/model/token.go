@@ -0,0 +1,72 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// API token 角色
+const (
+	ROLE_VIEWER   = "viewer"   // 只读
+	ROLE_OPERATOR = "operator" // 节点操作
+	ROLE_ADMIN    = "admin"    // token 管理
+)
+
+// 角色权限等级，高等级包含低等级的所有权限
+var roleLevels = map[string]int{
+	ROLE_VIEWER:   1,
+	ROLE_OPERATOR: 2,
+	ROLE_ADMIN:    3,
+}
+
+// IsValidRole 验证角色是否有效
+func IsValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RoleAllows 判断角色是否满足所需角色
+func RoleAllows(role, required string) bool {
+	return roleLevels[role] >= roleLevels[required] && IsValidRole(role)
+}
+
+// APIToken API 访问令牌（仅保存摘要）
+type APIToken struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Role       string    `json:"role"`
+	Hash       string    `json:"hash"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  time.Time `json:"revoked_at,omitempty"`
+}
+
+// Validate 验证 token 数据
+func (t *APIToken) Validate() error {
+	if t.ID == "" {
+		return errors.New("token id is required")
+	}
+	if t.Name == "" {
+		return errors.New("token name is required")
+	}
+	if !IsValidRole(t.Role) {
+		return fmt.Errorf("invalid role: %s", t.Role)
+	}
+	if t.Hash == "" {
+		return errors.New("token hash is required")
+	}
+	return nil
+}
+
+// Active 判断 token 在指定时间是否可用（未吊销且未过期）
+func (t *APIToken) Active(now time.Time) bool {
+	if !t.RevokedAt.IsZero() {
+		return false
+	}
+	if !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt) {
+		return false
+	}
+	return true
+}
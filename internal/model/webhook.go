@@ -0,0 +1,109 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Webhook 事件订阅：匹配的事件以签名 JSON 推送到 URL
+type Webhook struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+	// Events 订阅的事件类型（支持 node.* 形式），为空表示全部事件
+	Events []string `json:"events,omitempty"`
+	// Labels 节点标签选择器，为空表示不限制
+	Labels string `json:"labels,omitempty"`
+	// Secret HMAC-SHA256 签名密钥
+	Secret    string    `json:"secret"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate 验证 webhook 数据（事件类型由调用方校验）
+func (w *Webhook) Validate() error {
+	if w.ID == "" {
+		return errors.New("webhook id is required")
+	}
+	if err := ValidateWebhookURL(w.URL); err != nil {
+		return err
+	}
+	if w.Secret == "" {
+		return errors.New("webhook secret is required")
+	}
+	if _, err := ParseLabelSelector(w.Labels); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateWebhookURL 验证 webhook URL（仅允许 http/https 绝对地址）
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook url: %q", raw)
+	}
+	return nil
+}
+
+// webhook 投递状态
+const (
+	DELIVERY_PENDING   = "pending"   // 等待投递或重试
+	DELIVERY_SUCCEEDED = "succeeded" // 收到 2xx 响应
+	DELIVERY_FAILED    = "failed"    // 重试次数用尽或 webhook 已停用
+)
+
+// WebhookDelivery 一次事件投递（包含所有重试）
+type WebhookDelivery struct {
+	// ID 以创建时间开头，按字典序即按时间排序
+	ID        string `json:"id"`
+	WebhookID string `json:"webhook_id"`
+	EventID   uint64 `json:"event_id,omitempty"`
+	EventType string `json:"event_type"`
+	// Payload 请求体，重试时原样发送
+	Payload json.RawMessage `json:"payload"`
+	Status  string          `json:"status"`
+	// Attempts 已尝试次数
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt time.Time `json:"last_attempt_at,omitempty"`
+	// 最近一次尝试的结果
+	ResponseCode int       `json:"response_code,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	CompletedAt  time.Time `json:"completed_at,omitempty"`
+}
+
+// NewWebhookDelivery 创建待投递记录
+func NewWebhookDelivery(webhookID string, eventID uint64, eventType string, payload []byte, now time.Time) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:            newSortableID(now),
+		WebhookID:     webhookID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        DELIVERY_PENDING,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+// Pending 是否仍需投递
+func (d *WebhookDelivery) Pending() bool {
+	return d.Status == DELIVERY_PENDING
+}
+
+// newSortableID 生成按时间排序的 ID（纳秒时间戳 + 随机后缀）
+func newSortableID(now time.Time) string {
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	return fmt.Sprintf("%016x%s", now.UnixNano(), hex.EncodeToString(buf))
+}
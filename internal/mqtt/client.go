@@ -11,16 +11,39 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/metrics"
 	"github.com/lucheng0127/nodefoundry/internal/model"
 )
 
-// Client MQTT 客户端（仅接收状态）
+// Client MQTT 客户端（接收状态、下发命令）
 type Client struct {
-	broker       string
-	client       mqtt.Client
-	repo         db.NodeRepository
-	logger       *zap.Logger
-	connectChan  chan bool
+	broker        string
+	client        mqtt.Client
+	repo          db.NodeRepository
+	heartbeats    HeartbeatRecorder
+	installTokens InstallTokenRevoker
+	metrics       *metrics.Metrics
+	events        *events.Bus
+	// 命令结果处理（更新命令执行状态）
+	commandResults CommandResultHandler
+	logger         *zap.Logger
+	connectChan    chan bool
+}
+
+// HeartbeatRecorder 记录节点心跳（不立即持久化）
+type HeartbeatRecorder interface {
+	Touch(mac string, at time.Time)
+}
+
+// InstallTokenRevoker 吊销节点安装令牌
+type InstallTokenRevoker interface {
+	Revoke(ctx context.Context, mac string) error
+}
+
+// CommandResultHandler 处理 agent 上报的命令结果
+type CommandResultHandler interface {
+	HandleResult(ctx context.Context, node *model.Node, result *CommandResultMessage)
 }
 
 // StatusMessage 状态消息结构
@@ -41,6 +64,33 @@ func NewClient(broker string, repo db.NodeRepository, logger *zap.Logger) *Clien
 	}
 }
 
+// SetHeartbeatRecorder 设置心跳记录器
+// 设置后，仅心跳时间变化的状态消息只写入记录器，不再触发节点保存
+func (c *Client) SetHeartbeatRecorder(recorder HeartbeatRecorder) {
+	c.heartbeats = recorder
+}
+
+// SetInstallTokenRevoker 设置安装令牌吊销器，节点上报 installed 后令牌立即失效
+func (c *Client) SetInstallTokenRevoker(revoker InstallTokenRevoker) {
+	c.installTokens = revoker
+}
+
+// SetMetrics 设置指标收集
+func (c *Client) SetMetrics(m *metrics.Metrics) {
+	c.metrics = m
+}
+
+// SetEventBus 设置事件总线（发布节点状态变化和命令结果事件）
+func (c *Client) SetEventBus(bus *events.Bus) {
+	c.events = bus
+}
+
+// SetCommandResultHandler 设置命令结果处理器
+// 设置后命令结果由处理器更新执行状态并发布事件，不再直接转发为事件
+func (c *Client) SetCommandResultHandler(handler CommandResultHandler) {
+	c.commandResults = handler
+}
+
 // Start 启动 MQTT 客户端
 func (c *Client) Start(ctx context.Context) error {
 	opts := mqtt.NewClientOptions()
@@ -86,6 +136,15 @@ func (c *Client) onConnect(client mqtt.Client) {
 
 	c.logger.Info("subscribed to status topic", zap.String("topic", topic))
 
+	// 订阅命令结果主题
+	resultTopic := "node/+/command/result"
+	if token := client.Subscribe(resultTopic, 1, c.onCommandResult); token.Wait() && token.Error() != nil {
+		c.logger.Error("failed to subscribe to command result topic", zap.Error(token.Error()))
+		return
+	}
+
+	c.logger.Info("subscribed to command result topic", zap.String("topic", resultTopic))
+
 	select {
 	case c.connectChan <- true:
 	default:
@@ -106,11 +165,13 @@ func (c *Client) onStatusMessage(client mqtt.Client, msg mqtt.Message) {
 		zap.String("topic", topic),
 		zap.String("payload", string(payload)),
 	)
+	c.metrics.MQTTMessage("status")
 
 	// 解析 topic: node/{mac}/status
 	parts := strings.Split(topic, "/")
 	if len(parts) != 3 || parts[0] != "node" || parts[2] != "status" {
 		c.logger.Warn("invalid topic format", zap.String("topic", topic))
+		c.metrics.MQTTInvalid("invalid_topic")
 		return
 	}
 
@@ -123,6 +184,7 @@ func (c *Client) onStatusMessage(client mqtt.Client, msg mqtt.Message) {
 			zap.String("mac", mac),
 			zap.Error(err),
 		)
+		c.metrics.MQTTInvalid("malformed_payload")
 		return
 	}
 
@@ -132,58 +194,114 @@ func (c *Client) onStatusMessage(client mqtt.Client, msg mqtt.Message) {
 			zap.String("mac", mac),
 			zap.String("status", statusMsg.Status),
 		)
+		c.metrics.MQTTInvalid("invalid_status")
 		return
 	}
 
-	// 获取现有节点
 	ctx := context.Background()
-	node, err := c.repo.FindByMAC(ctx, mac)
-	if err != nil {
+	now := time.Now()
+
+	// 仅心跳：状态与上报字段均未变化时只记录心跳，由缓存批量写回
+	if c.heartbeats != nil {
+		node, err := c.repo.FindByMAC(ctx, mac)
+		if err != nil {
+			c.logger.Warn("received status from unknown node",
+				zap.String("mac", mac),
+				zap.Error(err),
+			)
+			c.metrics.MQTTInvalid("unknown_node")
+			return
+		}
+		if !statusChanges(node, &statusMsg, now) {
+			if statusMsg.Status != node.Status && !staleInstallReport(node, &statusMsg, now) {
+				c.logger.Warn("invalid status transition",
+					zap.String("mac", mac),
+					zap.String("from", node.Status),
+					zap.String("to", statusMsg.Status),
+				)
+				c.metrics.MQTTInvalid("invalid_transition")
+			}
+			c.heartbeats.Touch(mac, now)
+			return
+		}
+	}
+
+	// 基于最新节点应用状态消息，版本冲突时自动重试，
+	// 避免用过期快照覆盖并发写入（如 API 触发的安装）
+	transitioned := false
+	invalidTransition := false
+	from := ""
+	node, err := db.UpdateWithRetry(ctx, c.repo, mac, func(node *model.Node) error {
+		transitioned = false
+		invalidTransition = false
+		from = node.Status
+
+		// 检查状态转换是否合法
+		if staleInstallReport(node, &statusMsg, now) {
+			c.logger.Debug("ignoring installed report from agent started before install",
+				zap.String("mac", mac),
+			)
+		} else if err := node.CanTransitionTo(statusMsg.Status); err != nil {
+			c.logger.Warn("invalid status transition",
+				zap.String("mac", mac),
+				zap.String("from", node.Status),
+				zap.String("to", statusMsg.Status),
+				zap.Error(err),
+			)
+			invalidTransition = true
+			// 即使状态转换无效，仍更新心跳时间
+		} else {
+			node.Status = statusMsg.Status
+			transitioned = true
+		}
+
+		node.LastHeartbeat = now
+		// 运维固定的静态网络配置不被 agent 上报的地址覆盖
+		if statusMsg.IP != "" && !node.StaticNetwork {
+			node.IP = statusMsg.IP
+		}
+		if statusMsg.Hostname != "" {
+			node.Hostname = statusMsg.Hostname
+		}
+		return nil
+	})
+	if db.IsNodeNotFound(err) {
 		c.logger.Warn("received status from unknown node",
 			zap.String("mac", mac),
 			zap.Error(err),
 		)
+		c.metrics.MQTTInvalid("unknown_node")
 		return
 	}
-
-	// 检查状态转换是否合法
-	if err := node.CanTransitionTo(statusMsg.Status); err != nil {
-		c.logger.Warn("invalid status transition",
+	if err != nil {
+		c.logger.Error("failed to update node status",
 			zap.String("mac", mac),
-			zap.String("from", node.Status),
-			zap.String("to", statusMsg.Status),
 			zap.Error(err),
 		)
-		// 即使状态转换无效，仍更新心跳时间
-		node.LastHeartbeat = time.Now()
-		if statusMsg.IP != "" {
-			node.IP = statusMsg.IP
-		}
-		if statusMsg.Hostname != "" {
-			node.Hostname = statusMsg.Hostname
-		}
-		c.repo.Save(ctx, node)
 		return
 	}
 
-	// 更新节点状态
-	node.Status = statusMsg.Status
-	node.LastHeartbeat = time.Now()
-	if statusMsg.IP != "" {
-		node.IP = statusMsg.IP
-	}
-	if statusMsg.Hostname != "" {
-		node.Hostname = statusMsg.Hostname
+	if invalidTransition {
+		c.metrics.MQTTInvalid("invalid_transition")
 	}
 
-	if err := c.repo.Save(ctx, node); err != nil {
-		c.logger.Error("failed to update node status",
-			zap.String("mac", mac),
-			zap.Error(err),
-		)
+	if !transitioned {
 		return
 	}
 
+	if from != node.Status {
+		c.events.Publish(events.StatusChanged(node, from, "agent"))
+	}
+
+	if node.Status == model.STATE_INSTALLED && c.installTokens != nil {
+		if err := c.installTokens.Revoke(ctx, mac); err != nil {
+			c.logger.Warn("failed to revoke install token",
+				zap.String("mac", mac),
+				zap.Error(err),
+			)
+		}
+	}
+
 	c.logger.Info("node status updated",
 		zap.String("mac", mac),
 		zap.String("status", statusMsg.Status),
@@ -191,3 +309,151 @@ func (c *Client) onStatusMessage(client mqtt.Client, msg mqtt.Message) {
 		zap.String("hostname", node.Hostname),
 	)
 }
+
+// CommandResultMessage agent 上报的命令执行结果（node/{mac}/command/result）
+// status 为 running 时表示已确认开始执行，Progress 非空时为进度更新
+type CommandResultMessage struct {
+	ID       string `json:"id,omitempty"`
+	Command  string `json:"command"`
+	Status   string `json:"status"`
+	Progress string `json:"progress,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// onCommandResult 处理命令结果消息，交给命令结果处理器或转发为 command.result 事件
+func (c *Client) onCommandResult(client mqtt.Client, msg mqtt.Message) {
+	topic := msg.Topic()
+	c.metrics.MQTTMessage("command_result")
+
+	// 解析 topic: node/{mac}/command/result
+	parts := strings.Split(topic, "/")
+	if len(parts) != 4 || parts[0] != "node" || parts[2] != "command" || parts[3] != "result" {
+		c.logger.Warn("invalid topic format", zap.String("topic", topic))
+		c.metrics.MQTTInvalid("invalid_topic")
+		return
+	}
+
+	mac := model.NormalizeMAC(parts[1])
+
+	var result CommandResultMessage
+	if err := json.Unmarshal(msg.Payload(), &result); err != nil || result.Command == "" {
+		c.logger.Warn("failed to parse command result",
+			zap.String("mac", mac),
+			zap.String("payload", string(msg.Payload())),
+		)
+		c.metrics.MQTTInvalid("malformed_payload")
+		return
+	}
+
+	node, err := c.repo.FindByMAC(context.Background(), mac)
+	if err != nil {
+		c.logger.Warn("received command result from unknown node",
+			zap.String("mac", mac),
+			zap.Error(err),
+		)
+		c.metrics.MQTTInvalid("unknown_node")
+		return
+	}
+
+	c.logger.Info("command result received",
+		zap.String("mac", mac),
+		zap.String("id", result.ID),
+		zap.String("command", result.Command),
+		zap.String("status", result.Status),
+	)
+
+	if c.commandResults != nil {
+		c.commandResults.HandleResult(context.Background(), node, &result)
+		return
+	}
+
+	data := map[string]interface{}{
+		"command": result.Command,
+		"status":  result.Status,
+	}
+	if result.ID != "" {
+		data["id"] = result.ID
+	}
+	if result.ExitCode != nil {
+		data["exit_code"] = *result.ExitCode
+	}
+	if result.Error != "" {
+		data["error"] = result.Error
+	}
+	c.events.Publish(events.NodeEvent(events.EVENT_COMMAND_RESULT, node, data))
+}
+
+// statusChanges 判断状态消息是否会改变节点的持久化字段（心跳时间除外）
+// 非法的状态转换不会被应用，因此不视为变化
+func statusChanges(node *model.Node, msg *StatusMessage, now time.Time) bool {
+	if msg.Status != node.Status && node.CanTransitionTo(msg.Status) == nil && !staleInstallReport(node, msg, now) {
+		return true
+	}
+	// 固定静态网络配置的节点不会采用上报的地址
+	if msg.IP != "" && msg.IP != node.IP && !node.StaticNetwork {
+		return true
+	}
+	if msg.Hostname != "" && msg.Hostname != node.Hostname {
+		return true
+	}
+	return false
+}
+
+// staleInstallReport 判断是否为重装期间旧系统 agent 的 installed 上报
+// agent 启动时间早于本次安装开始时间，说明节点尚未重启进入新系统，不能据此结束安装
+func staleInstallReport(node *model.Node, msg *StatusMessage, now time.Time) bool {
+	if node.Status != model.STATE_INSTALLING || msg.Status != model.STATE_INSTALLED {
+		return false
+	}
+	if node.InstallStartedAt.IsZero() || msg.Uptime <= 0 {
+		return false
+	}
+
+	bootTime := now.Add(-time.Duration(msg.Uptime) * time.Second)
+	return bootTime.Before(node.InstallStartedAt)
+}
+
+// IsConnected 判断是否已连接到 MQTT Broker
+func (c *Client) IsConnected() bool {
+	return c.client != nil && c.client.IsConnectionOpen()
+}
+
+// CommandMessage 下发给 agent 的命令消息
+type CommandMessage struct {
+	// ID 命令 ID，agent 上报结果时原样带回
+	ID      string                 `json:"id,omitempty"`
+	Command string                 `json:"command"`
+	Args    map[string]interface{} `json:"args,omitempty"`
+	// Deadline 截止时间，agent 不再执行过期的命令
+	Deadline time.Time `json:"deadline,omitempty"`
+	// IdempotencyKey 幂等键，agent 据此忽略重复投递（为空时使用 ID）
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// PublishCommand 向节点发布命令（node/{mac}/command）
+func (c *Client) PublishCommand(mac string, msg CommandMessage) error {
+	if c.client == nil || !c.client.IsConnected() {
+		return fmt.Errorf("MQTT client not connected")
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	topic := fmt.Sprintf("node/%s/command", model.NormalizeMAC(mac))
+	token := c.client.Publish(topic, 1, false, payload)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("failed to publish command: %w", token.Error())
+	}
+
+	c.logger.Info("command published",
+		zap.String("topic", topic),
+		zap.String("id", msg.ID),
+		zap.String("command", msg.Command),
+	)
+	return nil
+}
@@ -0,0 +1,98 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// fakeMessage 测试用 MQTT 消息
+type fakeMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (m *fakeMessage) Duplicate() bool   { return false }
+func (m *fakeMessage) Qos() byte         { return 1 }
+func (m *fakeMessage) Retained() bool    { return false }
+func (m *fakeMessage) Topic() string     { return m.topic }
+func (m *fakeMessage) MessageID() uint16 { return 0 }
+func (m *fakeMessage) Payload() []byte   { return m.payload }
+func (m *fakeMessage) Ack()              {}
+
+// statusMessage 构造节点状态消息
+func statusMessage(t *testing.T, mac string, msg *StatusMessage) *fakeMessage {
+	t.Helper()
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeMessage{topic: "node/" + mac + "/status", payload: payload}
+}
+
+// newTestClient 创建使用临时数据库和心跳缓存的客户端
+func newTestClient(t *testing.T) (*Client, *db.BoltNodeRepository, *db.HeartbeatCache) {
+	t.Helper()
+
+	bdb, err := db.InitializeDB(filepath.Join(t.TempDir(), "nodes.db"), zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitializeDB: %v", err)
+	}
+	t.Cleanup(func() { bdb.Close() })
+
+	repo := db.NewBoltNodeRepository(bdb, zap.NewNop())
+	cache := db.NewHeartbeatCache(repo, repo, time.Minute, zap.NewNop())
+	c := NewClient("", cache, zap.NewNop())
+	c.SetHeartbeatRecorder(cache)
+	return c, repo, cache
+}
+
+func TestStatusMessageIP(t *testing.T) {
+	tests := []struct {
+		name        string
+		static      bool
+		wantIP      string
+		wantVersion func(saved uint64) uint64
+	}{
+		// 固定静态地址的节点只记录心跳，不保存节点
+		{name: "pinned", static: true, wantIP: "10.0.0.5", wantVersion: func(v uint64) uint64 { return v }},
+		{name: "dhcp", wantIP: "10.0.0.99", wantVersion: func(v uint64) uint64 { return v + 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c, repo, cache := newTestClient(t)
+
+			node, _ := model.NewNode("aabbccddeeff", model.STATE_INSTALLED)
+			node.IP = "10.0.0.5"
+			node.Netmask = "255.255.255.0"
+			node.StaticNetwork = tt.static
+			if err := repo.Save(ctx, node); err != nil {
+				t.Fatal(err)
+			}
+			saved, _ := repo.FindByMAC(ctx, node.MAC)
+
+			c.onStatusMessage(nil, statusMessage(t, node.MAC, &StatusMessage{Status: model.STATE_INSTALLED, IP: "10.0.0.99"}))
+
+			stored, _ := repo.FindByMAC(ctx, node.MAC)
+			if stored.IP != tt.wantIP {
+				t.Errorf("IP = %s, want %s", stored.IP, tt.wantIP)
+			}
+			if want := tt.wantVersion(saved.ResourceVersion); stored.ResourceVersion != want {
+				t.Errorf("ResourceVersion = %d, want %d", stored.ResourceVersion, want)
+			}
+			if tt.static && cache.Pending() != 1 {
+				t.Errorf("heartbeat not recorded in cache, pending = %d", cache.Pending())
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 限速触发后再次记录日志的最小间隔，避免持续请求的客户端刷屏
+const reportInterval = time.Minute
+
+// sweepInterval 清理空闲 bucket 的间隔
+const sweepInterval = time.Minute
+
+// Limit 令牌桶参数：每秒补充 Rate 个令牌，最多积累 Burst 个
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled 是否启用限速（Rate 或 Burst 为 0 表示不限制）
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// String 以 ParseLimit 接受的格式输出
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "0"
+	}
+	return strconv.FormatFloat(l.Rate, 'f', -1, 64) + "," + strconv.Itoa(l.Burst)
+}
+
+// ParseLimit 解析 "每秒请求数,突发数" 格式（如 2,20），"0" 或空字符串表示不限制；
+// 省略突发数时取每秒请求数向上取整
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+
+	rateStr, burstStr, hasBurst := strings.Cut(s, ",")
+	rate, err := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+	if err != nil || rate < 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: rate must be a non-negative number", s)
+	}
+
+	burst := int(math.Ceil(rate))
+	if hasBurst {
+		burst, err = strconv.Atoi(strings.TrimSpace(burstStr))
+		if err != nil || burst < 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: burst must be a non-negative integer", s)
+		}
+	}
+	return Limit{Rate: rate, Burst: burst}, nil
+}
+
+// Result 一次限速检查的结果
+type Result struct {
+	Allowed bool
+	// RetryAfter 被拒绝时距下一个可用令牌的时间
+	RetryAfter time.Duration
+	// Rejected 需要记录日志时为上次记录以来被拒绝的请求数，否则为 0
+	Rejected int
+}
+
+// bucket 单个客户端的令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// 上次记录日志以来被拒绝的请求数
+	rejected   int
+	reportedAt time.Time
+}
+
+// Limiter 按键（如客户端 IP、节点 MAC）独立限速的令牌桶集合，可并发使用
+type Limiter struct {
+	limit     Limit
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// New 创建限速器
+func New(limit Limit) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Limit 返回限速参数
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// Allow 消耗 key 的一个令牌
+func (l *Limiter) Allow(key string) Result {
+	if !l.limit.Enabled() {
+		return Result{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, l.limit)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Result{Allowed: true}
+	}
+
+	result := Result{
+		RetryAfter: time.Duration((1 - b.tokens) / l.limit.Rate * float64(time.Second)),
+	}
+	b.rejected++
+	if now.Sub(b.reportedAt) >= reportInterval {
+		result.Rejected = b.rejected
+		b.rejected = 0
+		b.reportedAt = now
+	}
+	return result
+}
+
+// refill 按经过的时间补充令牌
+func (b *bucket) refill(now time.Time, limit Limit) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+}
+
+// sweep 定期删除令牌已补满的 bucket（等同于新建），避免客户端数量无限增长
+// 调用方需持有锁
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		b.refill(now, l.limit)
+		if b.tokens >= float64(l.limit.Burst) && now.Sub(b.reportedAt) >= reportInterval {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "", want: Limit{}},
+		{in: "0", want: Limit{}},
+		{in: "2,20", want: Limit{Rate: 2, Burst: 20}},
+		{in: " 0.5 , 3 ", want: Limit{Rate: 0.5, Burst: 3}},
+		{in: "2.5", want: Limit{Rate: 2.5, Burst: 3}},
+		{in: "-1,5", wantErr: true},
+		{in: "x,5", wantErr: true},
+		{in: "1,-5", wantErr: true},
+		{in: "Inf,5", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLimit(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterBurst(t *testing.T) {
+	l := New(Limit{Rate: 1, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if r := l.Allow("10.0.0.1"); !r.Allowed {
+			t.Fatalf("request %d within burst rejected", i)
+		}
+	}
+
+	// 令牌耗尽后拒绝，首次拒绝需要记录日志
+	r := l.Allow("10.0.0.1")
+	if r.Allowed || r.RetryAfter <= 0 || r.RetryAfter > time.Second || r.Rejected != 1 {
+		t.Fatalf("over burst = %+v, want rejected with RetryAfter <= 1s", r)
+	}
+	// 同一分钟内的后续拒绝不再记录日志
+	if r := l.Allow("10.0.0.1"); r.Allowed || r.Rejected != 0 {
+		t.Errorf("second rejection = %+v, want not reported", r)
+	}
+
+	// 各个键独立计数
+	if r := l.Allow("10.0.0.2"); !r.Allowed {
+		t.Error("other key rejected")
+	}
+}
+
+func TestLimiterRefill(t *testing.T) {
+	l := New(Limit{Rate: 20, Burst: 1})
+
+	if r := l.Allow("aabbccddeeff"); !r.Allowed {
+		t.Fatal("first request rejected")
+	}
+	r := l.Allow("aabbccddeeff")
+	if r.Allowed {
+		t.Fatal("request over burst allowed")
+	}
+
+	// 每秒补充 20 个令牌，等待 RetryAfter 后可再次请求
+	time.Sleep(r.RetryAfter + 10*time.Millisecond)
+	if r := l.Allow("aabbccddeeff"); !r.Allowed {
+		t.Errorf("request after refill rejected: %+v", r)
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	l := New(Limit{})
+	for i := 0; i < 100; i++ {
+		if r := l.Allow("10.0.0.1"); !r.Allowed {
+			t.Fatalf("request %d rejected by disabled limiter", i)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package server
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"golang.org/x/crypto/ssh"
 )
 
 // Config 服务配置
@@ -29,6 +34,10 @@ type Config struct {
 	LogLevel string
 	// iPXE 脚本中的服务器地址
 	ServerAddr string
+	// iPXE 模板覆盖目录（为空只使用内置模板）
+	IPXETemplateDir string
+	// 写入节点的 SSH 公钥文件（authorized_keys 格式，cloud-init 使用）
+	SSHAuthorizedKeysFile string
 	// IP 池配置
 	DHCPIPPoolStart string
 	DHCPIPPoolEnd   string
@@ -36,6 +45,44 @@ type Config struct {
 	DHCPGateway     string
 	DHCPDNS         []string
 	DHCPLeaseTime   int
+	// 心跳批量写回间隔（秒）
+	HeartbeatFlushInterval int
+	// 是否启用 API token 认证
+	AuthEnabled bool
+	// NF_AUTH_ENABLED 是否显式设置，未设置时数据库中存在 token 即启用认证
+	AuthExplicit bool
+	// 允许访问节点端点的来源网段（CIDR）
+	NodeAllowedCIDRs []string
+	// 节点安装令牌有效期（秒）
+	InstallTokenTTL int
+	// TLS 配置
+	TLSEnabled bool
+	// HTTPS 服务地址
+	HTTPSAddr string
+	// 用户提供的证书、私钥和需要分发给节点的 CA（均为空时自动生成本地 CA）
+	TLSCertFile string
+	TLSKeyFile  string
+	TLSCAFile   string
+	// 自动生成证书的保存目录
+	TLSDir string
+	// iPXE/preseed 脚本中的 HTTPS 服务器地址
+	ServerTLSAddr string
+	// iPXE 固件已内置 CA（编译时 TRUST=ca.crt），iPXE 脚本使用 HTTPS
+	TLSIPXE bool
+	// 事件流保留的最近事件数（断线续传窗口）
+	EventBufferSize int
+	// 是否提供内嵌 Web 控制台（/ui/）
+	DashboardEnabled bool
+	// /metrics 是否无需认证（默认启用认证时需要 viewer token）
+	MetricsPublic bool
+	// 审计记录保留天数，0 表示不按时间删除
+	AuditRetentionDays int
+	// 审计记录最多保留条数，0 表示不限制
+	AuditMaxEntries int
+	// 限速（"每秒请求数,突发数"，0 表示不限制）：节点端点按来源 IP 和节点 MAC，/api/v1 按来源 IP
+	RateLimitNodeIP  string
+	RateLimitNodeMAC string
+	RateLimitAPIIP   string
 }
 
 // LoadConfig 从环境变量加载配置
@@ -59,6 +106,42 @@ func LoadConfig() *Config {
 	// 解析 DHCP 租约时间
 	dhcpLeaseTime := parseInt(getEnv("NF_DHCP_LEASE_TIME", "86400"), 86400)
 
+	// 解析心跳写回间隔
+	heartbeatFlushInterval := parseInt(getEnv("NF_HEARTBEAT_FLUSH_INTERVAL", "30"), 30)
+	if heartbeatFlushInterval < 1 {
+		heartbeatFlushInterval = 1
+	}
+
+	// 解析安装令牌有效期
+	installTokenTTL := parseInt(getEnv("NF_INSTALL_TOKEN_TTL", "7200"), 7200)
+	if installTokenTTL < 60 {
+		installTokenTTL = 60
+	}
+
+	// 解析事件缓冲区大小
+	eventBufferSize := parseInt(getEnv("NF_EVENT_BUFFER_SIZE", "1024"), 1024)
+	if eventBufferSize < 16 {
+		eventBufferSize = 16
+	}
+
+	// 解析审计记录保留策略（负数视为不限制）
+	auditRetentionDays := parseInt(getEnv("NF_AUDIT_RETENTION_DAYS", "90"), 90)
+	if auditRetentionDays < 0 {
+		auditRetentionDays = 0
+	}
+	auditMaxEntries := parseInt(getEnv("NF_AUDIT_MAX_ENTRIES", "100000"), 100000)
+	if auditMaxEntries < 0 {
+		auditMaxEntries = 0
+	}
+
+	// TLS：HTTPS 服务器地址默认为 ServerAddr 的主机 + HTTPSAddr 的端口
+	httpsAddr := getEnv("NF_HTTPS_ADDR", ":8443")
+	serverTLSAddr := getEnv("NF_SERVER_TLS_ADDR", "")
+	if serverTLSAddr == "" {
+		serverTLSAddr = joinHostPort(hostOf(serverAddr), portOf(httpsAddr))
+	}
+	dbPath := getEnv("NF_DB_PATH", "/var/lib/nodefoundry/nodes.db")
+
 	// 解析 ProxyDHCP 模式
 	dhcpProxyMode := parseBool(getEnv("NF_DHCP_PROXY_MODE", "false"))
 
@@ -81,18 +164,110 @@ func LoadConfig() *Config {
 		DHCPProxyMode:   dhcpProxyMode,
 		MQTTBroker:      getEnv("NF_MQTT_BROKER", "localhost:1883"),
 		MirrorURL:       mirrorURL,
-		DBPath:          getEnv("NF_DB_PATH", "/var/lib/nodefoundry/nodes.db"),
+		DBPath:          dbPath,
 		LogLevel:        getEnv("NF_LOG_LEVEL", "info"),
 		ServerAddr:      serverAddr,
+		IPXETemplateDir: getEnv("NF_IPXE_TEMPLATE_DIR", ""),
+
+		SSHAuthorizedKeysFile: getEnv("NF_SSH_AUTHORIZED_KEYS", ""),
+
 		DHCPIPPoolStart: getEnv("NF_DHCP_IP_POOL_START", ""),
 		DHCPIPPoolEnd:   getEnv("NF_DHCP_IP_POOL_END", ""),
 		DHCPNetmask:     getEnv("NF_DHCP_NETMASK", "255.255.255.0"),
 		DHCPGateway:     getEnv("NF_DHCP_GATEWAY", ""),
 		DHCPDNS:         dhcpDNS,
 		DHCPLeaseTime:   dhcpLeaseTime,
+
+		HeartbeatFlushInterval: heartbeatFlushInterval,
+		AuthEnabled:            parseBool(getEnv("NF_AUTH_ENABLED", "false")),
+		AuthExplicit:           getEnv("NF_AUTH_ENABLED", "") != "",
+		NodeAllowedCIDRs:       parseDNSList(getEnv("NF_NODE_ALLOWED_CIDRS", "")),
+		InstallTokenTTL:        installTokenTTL,
+
+		TLSEnabled:    parseBool(getEnv("NF_TLS_ENABLED", "false")),
+		HTTPSAddr:     httpsAddr,
+		TLSCertFile:   getEnv("NF_TLS_CERT_FILE", ""),
+		TLSKeyFile:    getEnv("NF_TLS_KEY_FILE", ""),
+		TLSCAFile:     getEnv("NF_TLS_CA_FILE", ""),
+		TLSDir:        getEnv("NF_TLS_DIR", filepath.Join(filepath.Dir(dbPath), "tls")),
+		ServerTLSAddr: serverTLSAddr,
+		TLSIPXE:       parseBool(getEnv("NF_TLS_IPXE", "false")),
+
+		EventBufferSize: eventBufferSize,
+
+		DashboardEnabled: parseBool(getEnv("NF_DASHBOARD_ENABLED", "true")),
+		MetricsPublic:    parseBool(getEnv("NF_METRICS_PUBLIC", "false")),
+
+		AuditRetentionDays: auditRetentionDays,
+		AuditMaxEntries:    auditMaxEntries,
+
+		RateLimitNodeIP:  getEnv("NF_RATE_LIMIT_NODE_IP", "5,30"),
+		RateLimitNodeMAC: getEnv("NF_RATE_LIMIT_NODE_MAC", "1,10"),
+		RateLimitAPIIP:   getEnv("NF_RATE_LIMIT_API_IP", "50,100"),
 	}
 }
 
+// ParseCIDRs 解析 CIDR 列表（单个 IP 视为 /32）
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			cidr += "/32"
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// hostOf 提取地址中的主机部分（无端口时原样返回）
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// portOf 提取地址中的端口部分
+func portOf(addr string) string {
+	if _, port, err := net.SplitHostPort(addr); err == nil {
+		return port
+	}
+	return ""
+}
+
+// joinHostPort 拼接主机和端口（端口为空或为 443 时省略）
+func joinHostPort(host, port string) string {
+	if port == "" || port == "443" {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// loadAuthorizedKeys 读取 authorized_keys 格式的公钥文件（忽略空行和 # 注释）
+func loadAuthorizedKeys(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line)); err != nil {
+			return nil, fmt.Errorf("invalid public key %q: %w", line, err)
+		}
+		keys = append(keys, line)
+	}
+	return keys, nil
+}
+
 // parseDNSList 解析 DNS 列表（逗号分隔）
 func parseDNSList(s string) []string {
 	if s == "" {
@@ -135,6 +310,21 @@ func (c *Config) GetHeartbeatInterval() time.Duration {
 	return 60 * time.Second
 }
 
+// GetHeartbeatFlushInterval 获取心跳批量写回间隔
+func (c *Config) GetHeartbeatFlushInterval() time.Duration {
+	return time.Duration(c.HeartbeatFlushInterval) * time.Second
+}
+
+// GetInstallTokenTTL 获取节点安装令牌有效期
+func (c *Config) GetInstallTokenTTL() time.Duration {
+	return time.Duration(c.InstallTokenTTL) * time.Second
+}
+
+// GetAuditRetention 获取审计记录保留期限（0 表示不按时间删除）
+func (c *Config) GetAuditRetention() time.Duration {
+	return time.Duration(c.AuditRetentionDays) * 24 * time.Hour
+}
+
 // GetIPXESleepInterval 获取 iPXE 等待循环的睡眠时间
 func (c *Config) GetIPXESleepInterval() time.Duration {
 	// 默认 90 秒
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/dhcp"
+	"github.com/lucheng0127/nodefoundry/internal/health"
+	"github.com/lucheng0127/nodefoundry/internal/mqtt"
+)
+
+// IP 池使用率达到该比例时为 degraded
+const poolDegradedUtilization = 0.9
+
+// newHealthChecker 注册各组件的健康检查
+// 数据库、MQTT、DHCP 监听和数据库磁盘为关键组件；IP 池耗尽只影响新节点，不视为关键
+func newHealthChecker(config *Config, boltDB *bbolt.DB, dhcpServer *dhcp.DHCPServer, mqttClient *mqtt.Client) *health.Checker {
+	checker := health.NewChecker(time.Now())
+
+	checker.Register("database", true, func(ctx context.Context) health.Result {
+		nodes, err := db.CheckDB(boltDB)
+		if err != nil {
+			return health.Down(err.Error(), nil)
+		}
+		return health.OK(map[string]interface{}{"path": config.DBPath, "nodes": nodes})
+	})
+
+	checker.Register("mqtt", true, func(ctx context.Context) health.Result {
+		details := map[string]interface{}{"broker": config.MQTTBroker}
+		if !mqttClient.IsConnected() {
+			return health.Down("not connected to broker", details)
+		}
+		return health.OK(details)
+	})
+
+	checker.Register("dhcp", true, func(ctx context.Context) health.Result {
+		details := map[string]interface{}{
+			"addr":       config.DHCPAddr,
+			"interface":  config.DHCPInterface,
+			"proxy_mode": config.DHCPProxyMode,
+		}
+		running, err := dhcpServer.Status()
+		if err != nil {
+			return health.Down("listener stopped: "+err.Error(), details)
+		}
+		if !running {
+			return health.Down("listener not running", details)
+		}
+		return health.OK(details)
+	})
+
+	if ipManager := dhcpServer.IPManager(); ipManager != nil {
+		checker.Register("ip_pool", false, func(ctx context.Context) health.Result {
+			stats := ipManager.Stats()
+			details := map[string]interface{}{
+				"size":      stats.Size,
+				"allocated": stats.Allocated,
+				"expired":   stats.Expired,
+			}
+			if stats.Size == 0 {
+				return health.Down("pool is empty", details)
+			}
+
+			utilization := float64(stats.Allocated) / float64(stats.Size)
+			details["utilization"] = utilization
+			switch {
+			case stats.Allocated >= stats.Size:
+				return health.Down("pool exhausted", details)
+			case utilization >= poolDegradedUtilization:
+				return health.Degraded("pool nearly exhausted", details)
+			default:
+				return health.OK(details)
+			}
+		})
+	}
+
+	checker.Register("disk", true, health.DiskCheck(filepath.Dir(config.DBPath)))
+
+	return checker
+}
@@ -15,21 +15,41 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/lucheng0127/nodefoundry/internal/api"
+	"github.com/lucheng0127/nodefoundry/internal/audit"
+	"github.com/lucheng0127/nodefoundry/internal/command"
+	"github.com/lucheng0127/nodefoundry/internal/dashboard"
 	"github.com/lucheng0127/nodefoundry/internal/db"
 	"github.com/lucheng0127/nodefoundry/internal/dhcp"
+	"github.com/lucheng0127/nodefoundry/internal/events"
 	"github.com/lucheng0127/nodefoundry/internal/ipxe"
+	"github.com/lucheng0127/nodefoundry/internal/metrics"
 	"github.com/lucheng0127/nodefoundry/internal/mqtt"
+	"github.com/lucheng0127/nodefoundry/internal/ratelimit"
+	"github.com/lucheng0127/nodefoundry/internal/tlsutil"
+	"github.com/lucheng0127/nodefoundry/internal/webhook"
 )
 
 // Server 服务器
 type Server struct {
 	config     *Config
 	httpServer *http.Server
-	dhcpServer *dhcp.DHCPServer
-	mqttClient *mqtt.Client
-	repo       db.NodeRepository
-	db         *bbolt.DB
-	logger     *zap.Logger
+	// 启用 TLS 时的 HTTPS 服务器（此时 httpServer 仅提供固件所需的端点）
+	httpsServer *http.Server
+	tlsBundle   *tlsutil.Bundle
+	dhcpServer  *dhcp.DHCPServer
+	mqttClient  *mqtt.Client
+	heartbeats  *db.HeartbeatCache
+	events      *events.Bus
+	// 心跳丢失检测（发布 heartbeat_lost/restored 事件）
+	heartbeatMonitor *events.HeartbeatMonitor
+	webhooks         *webhook.Dispatcher
+	// 命令超时检测
+	commands *command.Service
+	// 审计记录保留策略
+	auditRetention *audit.Retention
+	repo           db.NodeRepository
+	db             *bbolt.DB
+	logger         *zap.Logger
 }
 
 // NewServer 创建服务器
@@ -40,21 +60,168 @@ func NewServer(config *Config, logger *zap.Logger) (*Server, error) {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
-	// 创建 repository
-	repo := db.NewBoltNodeRepository(boltDB, logger)
+	// Prometheus 指标
+	m := metrics.New()
+
+	// 创建 repository，心跳通过写回缓存批量持久化
+	boltRepo := metrics.InstrumentRepository(db.NewBoltNodeRepository(boltDB, logger), m)
+	heartbeats := db.NewHeartbeatCache(boltRepo, boltRepo, config.GetHeartbeatFlushInterval(), logger)
+	repo := db.NodeRepository(heartbeats)
+	m.MustRegister(metrics.NewNodeCollector(repo, 3*config.GetHeartbeatInterval()))
+
+	// 节点事件总线
+	bus := events.NewBus(config.EventBufferSize, logger)
+	heartbeatMonitor := events.NewHeartbeatMonitor(repo, bus, 3*config.GetHeartbeatInterval(), config.GetHeartbeatInterval()/2, logger)
 
 	// 创建 iPXE 生成器
 	ipxeGen := ipxe.NewGenerator(config.ServerAddr, config.MirrorURL, repo, logger)
 	preseedGen := ipxe.NewPreseedGenerator(config.ServerAddr, config.MirrorURL, repo, logger)
+	if config.IPXETemplateDir != "" {
+		templates, err := ipxe.LoadTemplates(config.IPXETemplateDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NF_IPXE_TEMPLATE_DIR: %w", err)
+		}
+		ipxeGen.SetTemplates(templates)
+		logger.Info("ipxe templates loaded",
+			zap.String("dir", config.IPXETemplateDir),
+			zap.Strings("sets", templates.Names()),
+		)
+	}
+
+	// 节点安装令牌：安装脚本携带一次性令牌访问 preseed 和 agent
+	installTokens := db.NewBoltInstallTokenRepository(boltDB, logger)
+	ipxeGen.SetInstallTokens(installTokens, config.GetInstallTokenTTL())
+
+	// 安装配置：按节点或分组选择安装的操作系统
+	profiles := db.NewBoltProfileRepository(boltDB, logger)
+	ipxeGen.SetProfiles(profiles)
+	preseedGen.SetProfiles(profiles)
+
+	// cloud-init 写入节点的 SSH 公钥
+	if config.SSHAuthorizedKeysFile != "" {
+		keys, err := loadAuthorizedKeys(config.SSHAuthorizedKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NF_SSH_AUTHORIZED_KEYS: %w", err)
+		}
+		preseedGen.SetSSHAuthorizedKeys(keys)
+		logger.Info("ssh authorized keys loaded",
+			zap.String("file", config.SSHAuthorizedKeysFile),
+			zap.Int("keys", len(keys)),
+		)
+	}
 
 	// 创建 API handler
 	apiHandler := api.NewHandler(repo, ipxeGen, preseedGen, logger)
+	apiHandler.SetInstallTokens(installTokens)
+	apiHandler.SetProfiles(profiles)
+	apiHandler.SetMetrics(m)
+	apiHandler.SetMetricsPublic(config.MetricsPublic)
+	apiHandler.SetEventBus(bus)
+
+	// webhook：事件写入持久化投递队列
+	webhooks := db.NewBoltWebhookRepository(boltDB, logger)
+	webhookDispatcher := webhook.NewDispatcher(webhooks, bus, logger)
+	apiHandler.SetWebhooks(webhooks, webhookDispatcher)
+
+	// API 认证：未显式设置 NF_AUTH_ENABLED 时，创建过 token 即启用
+	tokens := db.NewBoltTokenRepository(boltDB, logger)
+	if !config.AuthExplicit {
+		exists, err := hasTokens(tokens)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list API tokens: %w", err)
+		}
+		config.AuthEnabled = exists
+	}
+	if config.AuthEnabled {
+		apiHandler.SetTokenRepository(tokens)
+		logger.Info("API authentication enabled", zap.Bool("explicit", config.AuthExplicit))
+	} else if config.AuthExplicit {
+		logger.Warn("API authentication disabled by NF_AUTH_ENABLED, all API requests are accepted without a token")
+	} else {
+		logger.Warn("API authentication disabled because no API token exists, create one with 'nodefoundry token create' or set NF_AUTH_ENABLED=true")
+	}
+
+	// 审计记录：修改类 API 请求的操作者、来源、目标和结果
+	auditLog := db.NewBoltAuditRepository(boltDB, logger)
+	apiHandler.SetAuditLog(auditLog)
+	auditRetention := audit.NewRetention(auditLog, config.GetAuditRetention(), config.AuditMaxEntries, logger)
+
+	// 节点端点来源限制
+	nodeNetworks, err := ParseCIDRs(config.NodeAllowedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NF_NODE_ALLOWED_CIDRS: %w", err)
+	}
+	apiHandler.SetNodeAllowedNetworks(nodeNetworks)
+	if len(nodeNetworks) == 0 {
+		// 引导脚本无需认证，任何能访问服务器的客户端都可以冒充节点获取其中的安装令牌
+		logger.Warn("node endpoints are open to all networks, set NF_NODE_ALLOWED_CIDRS to keep install tokens away from other hosts")
+	}
+
+	// 限速：防止异常的 PXE 循环或客户端反复请求
+	limits := make(map[string]ratelimit.Limit)
+	for name, value := range map[string]string{
+		"NF_RATE_LIMIT_NODE_IP":  config.RateLimitNodeIP,
+		"NF_RATE_LIMIT_NODE_MAC": config.RateLimitNodeMAC,
+		"NF_RATE_LIMIT_API_IP":   config.RateLimitAPIIP,
+	} {
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		limits[name] = limit
+	}
+	apiHandler.SetNodeRateLimits(limits["NF_RATE_LIMIT_NODE_IP"], limits["NF_RATE_LIMIT_NODE_MAC"])
+	apiHandler.SetAPIRateLimit(limits["NF_RATE_LIMIT_API_IP"])
+	logger.Info("rate limits configured",
+		zap.Stringer("node_ip", limits["NF_RATE_LIMIT_NODE_IP"]),
+		zap.Stringer("node_mac", limits["NF_RATE_LIMIT_NODE_MAC"]),
+		zap.Stringer("api_ip", limits["NF_RATE_LIMIT_API_IP"]),
+	)
+
+	// TLS
+	var tlsBundle *tlsutil.Bundle
+	if config.TLSEnabled {
+		tlsBundle, err = loadTLS(config, logger)
+		if err != nil {
+			return nil, err
+		}
+		preseedGen.SetTLS(config.ServerTLSAddr, tlsBundle.CAPEM)
+		if config.TLSIPXE {
+			ipxeGen.SetTLS(config.ServerTLSAddr)
+		}
+		apiHandler.SetCACertificate(tlsBundle.CAPEM)
+	}
+
+	// Web 控制台（在线判断与心跳丢失检测使用相同的超时）
+	var console *dashboard.Dashboard
+	if config.DashboardEnabled {
+		console = dashboard.New(dashboard.Config{
+			HeartbeatTimeout: 3 * config.GetHeartbeatInterval(),
+			AuthEnabled:      config.AuthEnabled,
+		})
+	}
 
 	// 创建 HTTP 服务器
-	router := gin.New()
-	router.Use(gin.Recovery())
-	router.Use(gin.Logger())
-	apiHandler.RegisterRoutes(router)
+	router := newRouter(m)
+	var httpsServer *http.Server
+	if tlsBundle != nil {
+		// 完整 API 通过 HTTPS 提供，HTTP 仅保留 iPXE/preseed/cloud-init/安装进度/CA 证书
+		tlsRouter := newRouter(m)
+		apiHandler.RegisterRoutes(tlsRouter)
+		apiHandler.RegisterPlainRoutes(router)
+		if console != nil {
+			console.RegisterRoutes(tlsRouter)
+		}
+		httpsServer = &http.Server{
+			Addr:    config.HTTPSAddr,
+			Handler: tlsRouter,
+		}
+	} else {
+		apiHandler.RegisterRoutes(router)
+		if console != nil {
+			console.RegisterRoutes(router)
+		}
+	}
 
 	httpServer := &http.Server{
 		Addr:    config.HTTPAddr,
@@ -63,6 +230,8 @@ func NewServer(config *Config, logger *zap.Logger) (*Server, error) {
 
 	// 创建 DHCP 服务器
 	dhcpServer := dhcp.NewDHCPServer(config.DHCPAddr, config.DHCPInterface, repo, logger)
+	dhcpServer.SetMetrics(m)
+	dhcpServer.SetEventBus(bus)
 
 	// 配置 IP 池（如果设置）
 	if config.DHCPIPPoolStart != "" && config.DHCPIPPoolEnd != "" {
@@ -78,6 +247,11 @@ func NewServer(config *Config, logger *zap.Logger) (*Server, error) {
 			return nil, fmt.Errorf("failed to create IP manager: %w", err)
 		}
 		dhcpServer.SetIPManager(ipManager)
+		apiHandler.SetLeasePool(ipManager)
+		m.MustRegister(metrics.NewPoolCollector(func() []metrics.PoolUsage {
+			stats := ipManager.Stats()
+			return []metrics.PoolUsage{{Subnet: ipManager.Subnet(), Size: stats.Size, Used: stats.Allocated}}
+		}))
 	}
 
 	// 设置 TFTP 服务器
@@ -92,18 +266,93 @@ func NewServer(config *Config, logger *zap.Logger) (*Server, error) {
 
 	// 创建 MQTT 客户端
 	mqttClient := mqtt.NewClient(config.MQTTBroker, repo, logger)
+	mqttClient.SetHeartbeatRecorder(heartbeats)
+	mqttClient.SetInstallTokenRevoker(installTokens)
+	mqttClient.SetMetrics(m)
+	mqttClient.SetEventBus(bus)
+
+	// 远程命令：记录执行状态，reboot 等节点操作也通过它下发
+	commands := db.NewBoltCommandRepository(boltDB, logger)
+	commandService := command.NewService(commands, repo, mqttClient, bus, logger)
+	mqttClient.SetCommandResultHandler(commandService)
+	apiHandler.SetCommands(commands, commandService)
+	apiHandler.SetCommandPublisher(commandService)
+
+	// 组件健康检查
+	apiHandler.SetHealthChecker(newHealthChecker(config, boltDB, dhcpServer, mqttClient))
 
 	return &Server{
-		config:     config,
-		httpServer: httpServer,
-		dhcpServer: dhcpServer,
-		mqttClient: mqttClient,
-		repo:       repo,
-		db:         boltDB,
-		logger:     logger,
+		config:      config,
+		httpServer:  httpServer,
+		httpsServer: httpsServer,
+		tlsBundle:   tlsBundle,
+		dhcpServer:  dhcpServer,
+		mqttClient:  mqttClient,
+		heartbeats:  heartbeats,
+		events:      bus,
+
+		heartbeatMonitor: heartbeatMonitor,
+		webhooks:         webhookDispatcher,
+		commands:         commandService,
+		auditRetention:   auditRetention,
+		repo:             repo,
+		db:               boltDB,
+		logger:           logger,
 	}, nil
 }
 
+// newRouter 创建带恢复、访问日志和请求指标中间件的路由
+func newRouter(m *metrics.Metrics) *gin.Engine {
+	router := gin.New()
+	// 请求 ID 最先分配，访问日志和错误响应都可以使用
+	router.Use(api.RequestID())
+	router.Use(api.Recovery())
+	// 先移除查询参数中的 token，再记录访问日志
+	router.Use(api.ExtractQueryToken())
+	router.Use(gin.Logger())
+	router.Use(m.GinMiddleware())
+	return router
+}
+
+// hasTokens 判断数据库中是否创建过 API token
+// 已吊销或过期的 token 同样计入，避免 token 全部过期后认证被静默关闭
+func hasTokens(tokens db.TokenRepository) (bool, error) {
+	list, err := tokens.List(context.Background())
+	if err != nil {
+		return false, err
+	}
+	return len(list) > 0, nil
+}
+
+// loadTLS 加载用户提供的证书，未提供时使用自动生成的本地 CA
+func loadTLS(config *Config, logger *zap.Logger) (*tlsutil.Bundle, error) {
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+		if config.TLSCertFile == "" || config.TLSKeyFile == "" {
+			return nil, fmt.Errorf("NF_TLS_CERT_FILE and NF_TLS_KEY_FILE must be set together")
+		}
+		bundle, err := tlsutil.Load(config.TLSCertFile, config.TLSKeyFile, config.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("TLS enabled with provided certificate", zap.String("cert", config.TLSCertFile))
+		return bundle, nil
+	}
+
+	hosts := []string{hostOf(config.ServerTLSAddr)}
+	for _, host := range []string{"localhost", "127.0.0.1"} {
+		if host != hosts[0] {
+			hosts = append(hosts, host)
+		}
+	}
+
+	bundle, err := tlsutil.EnsureAutoCert(config.TLSDir, hosts, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare TLS certificate: %w", err)
+	}
+	logger.Info("TLS enabled with local CA", zap.String("dir", config.TLSDir))
+	return bundle, nil
+}
+
 // Start 启动所有服务
 func (s *Server) Start(ctx context.Context) error {
 	// 创建 errgroup 用于管理 goroutine
@@ -125,6 +374,31 @@ func (s *Server) Start(ctx context.Context) error {
 		return nil
 	})
 
+	// 启动心跳写回
+	group.Go(func() error {
+		return s.heartbeats.Run(ctx)
+	})
+
+	// 启动心跳丢失检测
+	group.Go(func() error {
+		return s.heartbeatMonitor.Run(ctx)
+	})
+
+	// 启动 webhook 投递
+	group.Go(func() error {
+		return s.webhooks.Run(ctx)
+	})
+
+	// 启动命令超时检测
+	group.Go(func() error {
+		return s.commands.Run(ctx)
+	})
+
+	// 启动审计记录清理
+	group.Go(func() error {
+		return s.auditRetention.Run(ctx)
+	})
+
 	// 启动 HTTP 服务器
 	group.Go(func() error {
 		s.logger.Info("HTTP server starting", zap.String("addr", s.config.HTTPAddr))
@@ -134,6 +408,18 @@ func (s *Server) Start(ctx context.Context) error {
 		return nil
 	})
 
+	// 启动 HTTPS 服务器
+	if s.httpsServer != nil {
+		group.Go(func() error {
+			s.logger.Info("HTTPS server starting", zap.String("addr", s.config.HTTPSAddr))
+			err := s.httpsServer.ListenAndServeTLS(s.tlsBundle.CertFile, s.tlsBundle.KeyFile)
+			if err != nil && err != http.ErrServerClosed {
+				return fmt.Errorf("HTTPS server error: %w", err)
+			}
+			return nil
+		})
+	}
+
 	// 等待所有服务完成或出错
 	if err := group.Wait(); err != nil {
 		s.logger.Error("server error", zap.Error(err))
@@ -147,10 +433,23 @@ func (s *Server) Start(ctx context.Context) error {
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("server shutting down")
 
+	// 结束事件流长连接，否则 HTTP 服务器需等待其超时
+	s.events.Close()
+
 	// 关闭 HTTP 服务器
 	if err := s.httpServer.Shutdown(ctx); err != nil {
 		s.logger.Error("failed to shutdown HTTP server", zap.Error(err))
 	}
+	if s.httpsServer != nil {
+		if err := s.httpsServer.Shutdown(ctx); err != nil {
+			s.logger.Error("failed to shutdown HTTPS server", zap.Error(err))
+		}
+	}
+
+	// 写回尚未持久化的心跳
+	if err := s.heartbeats.Flush(ctx); err != nil {
+		s.logger.Error("failed to flush heartbeats", zap.Error(err))
+	}
 
 	// 关闭数据库
 	if s.db != nil {
@@ -0,0 +1,286 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 自动生成模式下证书目录中的文件名
+const (
+	CACertFile     = "ca.crt"
+	CAKeyFile      = "ca.key"
+	ServerCertFile = "server.crt"
+	ServerKeyFile  = "server.key"
+)
+
+// 证书有效期
+const (
+	caValidity     = 10 * 365 * 24 * time.Hour
+	serverValidity = 397 * 24 * time.Hour
+	// 服务器证书剩余有效期低于该值时重新签发
+	renewBefore = 30 * 24 * time.Hour
+)
+
+// Bundle HTTPS 证书配置
+type Bundle struct {
+	// 服务器证书和私钥文件（PEM）
+	CertFile string
+	KeyFile  string
+	// 需要分发给节点的 CA 证书（PEM），为空表示服务器证书由节点已信任的 CA 签发
+	CAPEM []byte
+}
+
+// Load 加载用户提供的证书
+// caFile 可选，指定后该 CA 会被安装到节点并通过 /ca.crt 提供下载
+func Load(certFile, keyFile, caFile string) (*Bundle, error) {
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	bundle := &Bundle{CertFile: certFile, KeyFile: keyFile}
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		if _, err := parseCertificatePEM(caPEM); err != nil {
+			return nil, fmt.Errorf("invalid CA certificate %s: %w", caFile, err)
+		}
+		bundle.CAPEM = caPEM
+	}
+
+	return bundle, nil
+}
+
+// EnsureAutoCert 在 dir 中准备自动生成的 CA 和服务器证书
+// CA 不存在时生成；服务器证书不存在、即将过期、不覆盖 hosts 或不是当前 CA 签发时重新签发
+func EnsureAutoCert(dir string, hosts []string, logger *zap.Logger) (*Bundle, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create TLS directory: %w", err)
+	}
+
+	caCert, caKey, caPEM, err := loadOrCreateCA(dir, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{
+		CertFile: filepath.Join(dir, ServerCertFile),
+		KeyFile:  filepath.Join(dir, ServerKeyFile),
+		CAPEM:    caPEM,
+	}
+
+	if reason := serverCertStale(bundle, caCert, hosts, time.Now()); reason != "" {
+		if err := issueServerCert(bundle, caCert, caKey, hosts); err != nil {
+			return nil, err
+		}
+		logger.Info("TLS server certificate issued",
+			zap.String("reason", reason),
+			zap.Strings("hosts", hosts),
+			zap.String("path", bundle.CertFile),
+		)
+	}
+
+	return bundle, nil
+}
+
+// loadOrCreateCA 读取 CA，不存在时生成
+func loadOrCreateCA(dir string, logger *zap.Logger) (*x509.Certificate, crypto.Signer, []byte, error) {
+	certPath := filepath.Join(dir, CACertFile)
+	keyPath := filepath.Join(dir, CAKeyFile)
+
+	certPEM, certErr := os.ReadFile(certPath)
+	keyPEM, keyErr := os.ReadFile(keyPath)
+	if certErr == nil && keyErr == nil {
+		cert, err := parseCertificatePEM(certPEM)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid CA certificate %s: %w", certPath, err)
+		}
+		key, err := parsePrivateKeyPEM(keyPEM)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid CA key %s: %w", keyPath, err)
+		}
+		return cert, key, certPEM, nil
+	}
+	if !errors.Is(certErr, os.ErrNotExist) || !errors.Is(keyErr, os.ErrNotExist) {
+		// 只有一半文件存在时不覆盖，避免丢失已分发给节点的 CA
+		return nil, nil, nil, fmt.Errorf("incomplete CA in %s: need both %s and %s", dir, CACertFile, CAKeyFile)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{CommonName: "NodeFoundry Local CA", Organization: []string{"NodeFoundry"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := writeKey(keyPath, key); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to write CA certificate: %w", err)
+	}
+
+	logger.Info("TLS local CA generated", zap.String("path", certPath))
+	return cert, key, certPEM, nil
+}
+
+// serverCertStale 判断服务器证书是否需要重新签发，返回原因（空字符串表示无需签发）
+func serverCertStale(bundle *Bundle, caCert *x509.Certificate, hosts []string, now time.Time) string {
+	pair, err := tls.LoadX509KeyPair(bundle.CertFile, bundle.KeyFile)
+	if err != nil {
+		return "missing"
+	}
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return "invalid"
+	}
+
+	if now.Add(renewBefore).After(cert.NotAfter) {
+		return "expiring"
+	}
+	if err := cert.CheckSignatureFrom(caCert); err != nil {
+		return "ca changed"
+	}
+	for _, host := range hosts {
+		if err := cert.VerifyHostname(host); err != nil {
+			return "hosts changed"
+		}
+	}
+	return ""
+}
+
+// issueServerCert 用 CA 签发覆盖 hosts 的服务器证书
+func issueServerCert(bundle *Bundle, caCert *x509.Certificate, caKey crypto.Signer, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate server key: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{CommonName: "nodefoundry", Organization: []string{"NodeFoundry"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(serverValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return fmt.Errorf("failed to create server certificate: %w", err)
+	}
+
+	if err := writeKey(bundle.KeyFile, key); err != nil {
+		return err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(bundle.CertFile, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write server certificate: %w", err)
+	}
+	return nil
+}
+
+// writeKey 以 0600 权限写入 PKCS#8 私钥
+func writeKey(path string, key crypto.Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	return nil
+}
+
+// parseCertificatePEM 解析 PEM 中的第一个证书
+func parseCertificatePEM(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no certificate PEM block found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// parsePrivateKeyPEM 解析 PKCS#8 或 EC 私钥
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no private key PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key type")
+		}
+		return signer, nil
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+// randomSerial 生成随机证书序列号
+func randomSerial() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
+
+// PEMLines 将 PEM 内容拆分为去除空行的行列表（用于嵌入脚本）
+func PEMLines(data []byte) []string {
+	var lines []string
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			lines = append(lines, string(trimmed))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,367 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+// 投递请求头
+const (
+	HeaderEvent     = "X-NodeFoundry-Event"
+	HeaderDelivery  = "X-NodeFoundry-Delivery"
+	HeaderTimestamp = "X-NodeFoundry-Timestamp"
+	// 签名：sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+	HeaderSignature = "X-NodeFoundry-Signature"
+)
+
+// EVENT_WEBHOOK_TEST 测试投递的事件类型
+const EVENT_WEBHOOK_TEST = "webhook.test"
+
+// 投递参数
+const (
+	// MaxAttempts 最大尝试次数，之后标记为失败
+	MaxAttempts = 10
+	// 重试间隔从 baseBackoff 开始指数增长，最大 maxBackoff
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+	// requestTimeout 单次投递超时
+	requestTimeout = 10 * time.Second
+	// pollInterval 检查到期重试的间隔
+	pollInterval = time.Second
+	// batchSize 每轮最多处理的投递数
+	batchSize = 50
+	// concurrency 同时进行的投递数
+	concurrency = 4
+	// responseExcerpt 投递记录中保存的响应体长度
+	responseExcerpt = 512
+)
+
+// Dispatcher 订阅事件总线，将匹配的事件写入持久化投递队列并按指数退避投递
+type Dispatcher struct {
+	repo   db.WebhookRepository
+	bus    *events.Bus
+	client *http.Client
+	logger *zap.Logger
+	// wake 有新投递入队时唤醒投递循环
+	wake chan struct{}
+}
+
+// NewDispatcher 创建 webhook 投递器
+func NewDispatcher(repo db.WebhookRepository, bus *events.Bus, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo: repo,
+		bus:  bus,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			// 不跟随重定向，避免签名请求被转发到其他地址
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Run 订阅事件并投递，直到 ctx 取消
+// 重启后继续投递队列中尚未完成的记录
+func (d *Dispatcher) Run(ctx context.Context) error {
+	go d.deliverLoop(ctx)
+
+	var lastID uint64
+	for {
+		sub, replay, complete := d.bus.Subscribe(nil, lastID)
+		if !complete {
+			d.logger.Warn("webhook dispatcher missed events while disconnected", zap.Uint64("after_id", lastID))
+		}
+		for i := range replay {
+			d.enqueue(ctx, &replay[i])
+			lastID = replay[i].ID
+		}
+
+		dropped := d.consume(ctx, sub, &lastID)
+		sub.Close()
+		if !dropped {
+			return nil
+		}
+	}
+}
+
+// consume 处理订阅中的事件，返回订阅是否因消费过慢被断开（需要续传）
+func (d *Dispatcher) consume(ctx context.Context, sub *events.Subscription, lastID *uint64) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case e, ok := <-sub.Events():
+			if !ok {
+				return sub.Dropped()
+			}
+			d.enqueue(ctx, &e)
+			*lastID = e.ID
+		}
+	}
+}
+
+// enqueue 为匹配事件的每个已启用 webhook 创建投递记录
+func (d *Dispatcher) enqueue(ctx context.Context, e *events.Event) {
+	webhooks, err := d.repo.List(ctx)
+	if err != nil {
+		d.logger.Error("failed to list webhooks", zap.Error(err))
+		return
+	}
+
+	var payload []byte
+	queued := false
+	for _, webhook := range webhooks {
+		if !Matches(webhook, e) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = json.Marshal(e); err != nil {
+				d.logger.Error("failed to encode event", zap.Uint64("event_id", e.ID), zap.Error(err))
+				return
+			}
+		}
+
+		delivery := model.NewWebhookDelivery(webhook.ID, e.ID, e.Type, payload, time.Now())
+		if err := d.repo.SaveDelivery(ctx, delivery); err != nil {
+			if !db.IsWebhookNotFound(err) {
+				d.logger.Error("failed to queue webhook delivery",
+					zap.String("webhook_id", webhook.ID),
+					zap.Uint64("event_id", e.ID),
+					zap.Error(err),
+				)
+			}
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Matches 判断 webhook 是否订阅该事件
+func Matches(webhook *model.Webhook, e *events.Event) bool {
+	if !webhook.Enabled {
+		return false
+	}
+
+	types := make(map[string]bool, len(webhook.Events))
+	for _, t := range webhook.Events {
+		types[t] = true
+	}
+	if !events.MatchesType(types, e.Type) {
+		return false
+	}
+
+	selector, err := model.ParseLabelSelector(webhook.Labels)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(e.Labels)
+}
+
+// deliverLoop 周期性投递到期的记录
+func (d *Dispatcher) deliverLoop(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// deliverDue 并发投递一批到期记录
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	due, err := d.repo.DueDeliveries(ctx, time.Now(), batchSize)
+	if err != nil {
+		d.logger.Error("failed to load webhook queue", zap.Error(err))
+		return
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, delivery := range due {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(delivery *model.WebhookDelivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			d.attempt(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+// attempt 投递一次并更新记录：成功或重试次数用尽时完成，否则按指数退避重新入队
+func (d *Dispatcher) attempt(ctx context.Context, delivery *model.WebhookDelivery) {
+	webhook, err := d.repo.FindByID(ctx, delivery.WebhookID)
+	if err != nil {
+		if !db.IsWebhookNotFound(err) {
+			d.logger.Error("failed to load webhook", zap.String("webhook_id", delivery.WebhookID), zap.Error(err))
+		}
+		return
+	}
+
+	now := time.Now()
+	if !webhook.Enabled {
+		delivery.Status = model.DELIVERY_FAILED
+		delivery.Error = "webhook disabled"
+		delivery.CompletedAt = now
+	} else {
+		d.send(ctx, webhook, delivery)
+		if delivery.Status == model.DELIVERY_PENDING {
+			if delivery.Attempts >= MaxAttempts {
+				delivery.Status = model.DELIVERY_FAILED
+				delivery.CompletedAt = delivery.LastAttemptAt
+			} else {
+				delivery.NextAttemptAt = delivery.LastAttemptAt.Add(Backoff(delivery.Attempts))
+			}
+		}
+	}
+
+	if err := d.repo.SaveDelivery(ctx, delivery); err != nil && !db.IsWebhookNotFound(err) {
+		d.logger.Error("failed to update webhook delivery",
+			zap.String("webhook_id", delivery.WebhookID),
+			zap.String("delivery_id", delivery.ID),
+			zap.Error(err),
+		)
+	}
+
+	switch delivery.Status {
+	case model.DELIVERY_FAILED:
+		d.logger.Warn("webhook delivery failed",
+			zap.String("webhook_id", delivery.WebhookID),
+			zap.String("delivery_id", delivery.ID),
+			zap.Int("attempts", delivery.Attempts),
+			zap.String("error", delivery.Error),
+		)
+	case model.DELIVERY_PENDING:
+		d.logger.Debug("webhook delivery will be retried",
+			zap.String("webhook_id", delivery.WebhookID),
+			zap.String("delivery_id", delivery.ID),
+			zap.Int("attempts", delivery.Attempts),
+			zap.Time("next_attempt_at", delivery.NextAttemptAt),
+		)
+	}
+}
+
+// send 发送一次签名请求，2xx 响应标记为成功
+func (d *Dispatcher) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) {
+	start := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = start
+	delivery.ResponseCode = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "NodeFoundry-Webhook/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, responseExcerpt))
+	delivery.ResponseCode = resp.StatusCode
+	delivery.ResponseBody = string(body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		delivery.Status = model.DELIVERY_SUCCEEDED
+		delivery.CompletedAt = time.Now()
+		return
+	}
+	delivery.Error = fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+}
+
+// Test 同步发送一次测试事件（不重试），结果记录在投递历史中
+func (d *Dispatcher) Test(ctx context.Context, webhook *model.Webhook) (*model.WebhookDelivery, error) {
+	e := events.Event{
+		Type: EVENT_WEBHOOK_TEST,
+		Time: time.Now(),
+		Data: map[string]interface{}{"webhook_id": webhook.ID},
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := model.NewWebhookDelivery(webhook.ID, 0, e.Type, payload, e.Time)
+	d.send(ctx, webhook, delivery)
+	if delivery.Pending() {
+		delivery.Status = model.DELIVERY_FAILED
+		delivery.CompletedAt = delivery.LastAttemptAt
+	}
+
+	if err := d.repo.SaveDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Backoff 第 attempts 次失败后的重试间隔
+func Backoff(attempts int) time.Duration {
+	backoff := baseBackoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// Sign 计算投递签名
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+)
+
+func TestSign(t *testing.T) {
+	// 参考值：printf '1700000000.{"type":"node.discovered"}' | openssl dgst -sha256 -hmac whsec_test
+	got := Sign("whsec_test", "1700000000", []byte(`{"type":"node.discovered"}`))
+	want := "sha256=a9016a20f99122ff3914a824049a3c3b9a71ffb8e74f01552903e707cac58853"
+	if got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: baseBackoff},
+		{attempts: 1, want: 10 * time.Second},
+		{attempts: 2, want: 20 * time.Second},
+		{attempts: 3, want: 40 * time.Second},
+		{attempts: 9, want: 2560 * time.Second},
+		{attempts: 10, want: maxBackoff},
+		{attempts: 1000, want: maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// receiver 记录收到的投递，前 failures 个请求返回 500
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rv *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+	rv.requests = append(rv.requests, r)
+	rv.bodies = append(rv.bodies, body)
+	if len(rv.requests) <= rv.failures {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newTestDispatcher 创建使用临时数据库的投递器，并注册指向 url 的 webhook
+func newTestDispatcher(t *testing.T, url string) (*Dispatcher, db.WebhookRepository, *model.Webhook) {
+	t.Helper()
+
+	bdb, err := db.InitializeDB(filepath.Join(t.TempDir(), "webhooks.db"), zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitializeDB: %v", err)
+	}
+	t.Cleanup(func() { bdb.Close() })
+
+	repo := db.NewBoltWebhookRepository(bdb, zap.NewNop())
+	webhook := &model.Webhook{ID: "wh1", URL: url, Secret: "whsec_test", Enabled: true, CreatedAt: time.Now()}
+	if err := repo.Create(context.Background(), webhook); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return NewDispatcher(repo, nil, zap.NewNop()), repo, webhook
+}
+
+func TestDeliveryRetry(t *testing.T) {
+	rv := &receiver{failures: 1}
+	server := httptest.NewServer(rv)
+	defer server.Close()
+
+	ctx := context.Background()
+	d, repo, webhook := newTestDispatcher(t, server.URL)
+	payload := []byte(`{"id":1,"type":"node.discovered"}`)
+	delivery := model.NewWebhookDelivery(webhook.ID, 1, "node.discovered", payload, time.Now())
+	if err := repo.SaveDelivery(ctx, delivery); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次投递失败，按退避时间重新入队
+	d.deliverDue(ctx)
+	deliveries, err := repo.ListDeliveries(ctx, webhook.ID, 10)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("ListDeliveries = %v, %v", deliveries, err)
+	}
+	failed := deliveries[0]
+	if failed.Status != model.DELIVERY_PENDING || failed.Attempts != 1 || failed.ResponseCode != http.StatusInternalServerError {
+		t.Fatalf("after failure: %+v", failed)
+	}
+	if want := failed.LastAttemptAt.Add(Backoff(1)); !failed.NextAttemptAt.Equal(want) {
+		t.Errorf("NextAttemptAt = %v, want %v", failed.NextAttemptAt, want)
+	}
+	if due, _ := repo.DueDeliveries(ctx, time.Now(), batchSize); len(due) != 0 {
+		t.Fatalf("delivery due before backoff elapsed: %d", len(due))
+	}
+
+	// 退避时间到期后重试成功
+	due, err := repo.DueDeliveries(ctx, failed.NextAttemptAt, batchSize)
+	if err != nil || len(due) != 1 {
+		t.Fatalf("DueDeliveries = %v, %v", due, err)
+	}
+	d.attempt(ctx, due[0])
+	deliveries, _ = repo.ListDeliveries(ctx, webhook.ID, 10)
+	if got := deliveries[0]; got.Status != model.DELIVERY_SUCCEEDED || got.Attempts != 2 || got.CompletedAt.IsZero() {
+		t.Fatalf("after retry: %+v", got)
+	}
+
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+	if len(rv.requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(rv.requests))
+	}
+	for i, req := range rv.requests {
+		if string(rv.bodies[i]) != string(payload) {
+			t.Errorf("request %d body = %s", i, rv.bodies[i])
+		}
+		timestamp := req.Header.Get(HeaderTimestamp)
+		if got, want := req.Header.Get(HeaderSignature), Sign(webhook.Secret, timestamp, payload); got != want {
+			t.Errorf("request %d signature = %s, want %s", i, got, want)
+		}
+		if req.Header.Get(HeaderDelivery) != delivery.ID || req.Header.Get(HeaderEvent) != "node.discovered" {
+			t.Errorf("request %d headers = %v", i, req.Header)
+		}
+	}
+}
+
+func TestDeliveryAttemptsExhausted(t *testing.T) {
+	rv := &receiver{failures: MaxAttempts}
+	server := httptest.NewServer(rv)
+	defer server.Close()
+
+	ctx := context.Background()
+	d, repo, webhook := newTestDispatcher(t, server.URL)
+	delivery := model.NewWebhookDelivery(webhook.ID, 1, "node.discovered", []byte(`{}`), time.Now())
+	delivery.Attempts = MaxAttempts - 1
+
+	// 最后一次尝试失败后标记为失败，不再重试
+	d.attempt(ctx, delivery)
+	if delivery.Status != model.DELIVERY_FAILED || delivery.Attempts != MaxAttempts || delivery.CompletedAt.IsZero() {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	if due, _ := repo.DueDeliveries(ctx, time.Now().Add(2*maxBackoff), batchSize); len(due) != 0 {
+		t.Errorf("failed delivery still queued: %d", len(due))
+	}
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListLeases 获取 DHCP 地址池统计和所有租约
+func (c *Client) ListLeases(ctx context.Context) (*LeaseList, error) {
+	var list LeaseList
+	if _, err := c.do(ctx, newRequestWith(http.MethodGet, "/api/v1/leases", nil, nil, nil), &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// ReleaseLease 释放节点的 DHCP 租约
+func (c *Client) ReleaseLease(ctx context.Context, mac string) error {
+	path := "/api/v1/leases/" + url.PathEscape(mac)
+	_, err := c.do(ctx, newRequestWith(http.MethodDelete, path, nil, nil, nil), nil)
+	return err
+}
+
+// ListTokens 列出 API token（需要 admin 角色）
+func (c *Client) ListTokens(ctx context.Context) ([]*Token, error) {
+	var tokens []*Token
+	if _, err := c.do(ctx, newRequestWith(http.MethodGet, "/api/v1/tokens", nil, nil, nil), &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CreateToken 创建 API token，返回的 Token 字段为明文（仅此一次）
+func (c *Client) CreateToken(ctx context.Context, req *CreateTokenRequest) (*Token, error) {
+	var token Token
+	if _, err := c.do(ctx, newRequestWith(http.MethodPost, "/api/v1/tokens", nil, req, nil), &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeToken 吊销 API token
+func (c *Client) RevokeToken(ctx context.Context, id string) error {
+	path := "/api/v1/tokens/" + url.PathEscape(id)
+	_, err := c.do(ctx, newRequestWith(http.MethodDelete, path, nil, nil, nil), nil)
+	return err
+}
+
+// ListWebhooks 列出 webhook（需要 admin 角色）
+func (c *Client) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+	var webhooks []*Webhook
+	if _, err := c.do(ctx, newRequestWith(http.MethodGet, "/api/v1/webhooks", nil, nil, nil), &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// CreateWebhook 创建 webhook，未指定 Secret 时返回自动生成的密钥（仅此一次）
+func (c *Client) CreateWebhook(ctx context.Context, req *WebhookRequest) (*Webhook, error) {
+	var webhook Webhook
+	if _, err := c.do(ctx, newRequestWith(http.MethodPost, "/api/v1/webhooks", nil, req, nil), &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetWebhook 获取 webhook
+func (c *Client) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+	var webhook Webhook
+	if _, err := c.do(ctx, newRequestWith(http.MethodGet, webhookPath(id), nil, nil, nil), &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// UpdateWebhook 修改 webhook（nil 字段保持不变）
+func (c *Client) UpdateWebhook(ctx context.Context, id string, req *WebhookRequest) (*Webhook, error) {
+	var webhook Webhook
+	if _, err := c.do(ctx, newRequestWith(http.MethodPatch, webhookPath(id), nil, req, nil), &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// DeleteWebhook 删除 webhook
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := c.do(ctx, newRequestWith(http.MethodDelete, webhookPath(id), nil, nil, nil), nil)
+	return err
+}
+
+// ListWebhookDeliveries 列出 webhook 投递历史（最新的在前），limit 为 0 表示服务端默认
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id string, limit int) ([]*WebhookDelivery, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var deliveries []*WebhookDelivery
+	r := newRequestWith(http.MethodGet, webhookPath(id)+"/deliveries", query, nil, nil)
+	if _, err := c.do(ctx, r, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// TestWebhook 同步发送一次测试事件并返回投递结果
+func (c *Client) TestWebhook(ctx context.Context, id string) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	if _, err := c.do(ctx, newRequestWith(http.MethodPost, webhookPath(id)+"/test", nil, nil, nil), &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// HealthLive 存活检查
+func (c *Client) HealthLive(ctx context.Context) (*HealthReport, error) {
+	var report HealthReport
+	if _, err := c.do(ctx, newRequestWith(http.MethodGet, "/health/live", nil, nil, nil), &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// HealthReady 就绪检查，关键组件 down 时同时返回报告和 503 错误
+func (c *Client) HealthReady(ctx context.Context) (*HealthReport, error) {
+	r := newRequestWith(http.MethodGet, "/health/ready", nil, nil, nil)
+	// 503 响应同样包含报告
+	r.okStatus = http.StatusServiceUnavailable
+
+	var report HealthReport
+	resp, err := c.do(ctx, r, &report)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return &report, &Error{StatusCode: resp.StatusCode, Message: "server is not ready"}
+	}
+	return &report, nil
+}
+
+// CACertificate 下载服务器 CA 证书（PEM），用于校验服务器 TLS 证书
+func (c *Client) CACertificate(ctx context.Context) ([]byte, error) {
+	r := newRequestWith(http.MethodGet, "/ca.crt", nil, nil, nil)
+	r.header.Set("Accept", "application/x-pem-file")
+
+	resp, cancel, err := c.send(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// webhookPath webhook 资源路径
+func webhookPath(id string) string {
+	return "/api/v1/webhooks/" + url.PathEscape(id)
+}
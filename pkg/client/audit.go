@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListAudit 获取一页审计记录（最新的在前，需要 admin 角色，opts 可为 nil）
+func (c *Client) ListAudit(ctx context.Context, opts *AuditOptions) (*AuditList, error) {
+	query := auditQuery(opts)
+	if opts != nil {
+		if opts.Limit > 0 {
+			query.Set("limit", strconv.Itoa(opts.Limit))
+		}
+		if opts.Cursor != "" {
+			query.Set("cursor", opts.Cursor)
+		}
+	}
+
+	list := &AuditList{}
+	resp, err := c.do(ctx, newRequestWith(http.MethodGet, "/api/v1/audit", query, nil, nil), &list.Entries)
+	if err != nil {
+		return nil, err
+	}
+	list.NextCursor = resp.Header.Get("X-Next-Cursor")
+	return list, nil
+}
+
+// ExportAudit 以 JSON Lines 格式（按时间升序，每行一条记录）将审计记录写入 w
+func (c *Client) ExportAudit(ctx context.Context, opts *AuditOptions, w io.Writer) error {
+	r := newRequestWith(http.MethodGet, "/api/v1/audit/export", auditQuery(opts), nil, nil)
+	r.header.Set("Accept", "application/x-ndjson")
+	// 导出时间取决于记录数量，不使用请求超时
+	r.stream = true
+
+	resp, cancel, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// auditQuery 审计记录的过滤条件
+func auditQuery(opts *AuditOptions) url.Values {
+	query := url.Values{}
+	if opts == nil {
+		return query
+	}
+	if opts.Actor != "" {
+		query.Set("actor", opts.Actor)
+	}
+	if opts.MAC != "" {
+		query.Set("mac", opts.MAC)
+	}
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.Format(time.RFC3339))
+	}
+	if !opts.Until.IsZero() {
+		query.Set("until", opts.Until.Format(time.RFC3339))
+	}
+	return query
+}
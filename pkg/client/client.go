@@ -0,0 +1,310 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 默认配置
+const (
+	// DefaultTimeout 单个请求（不含事件流）的默认超时时间
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxRetries 临时错误的默认重试次数
+	DefaultMaxRetries = 3
+	// DefaultRetryBackoff 首次重试前的等待时间，之后每次加倍
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// maxRetryBackoff 单次重试最长等待时间（包括服务端 Retry-After）
+	maxRetryBackoff = 30 * time.Second
+	// bulkPollInterval 服务端未返回 Retry-After 时查询后台批量操作的间隔
+	bulkPollInterval = 2 * time.Second
+)
+
+// Client NodeFoundry REST API 客户端，可并发使用
+type Client struct {
+	base         *url.URL
+	token        string
+	userAgent    string
+	http         *http.Client
+	timeout      time.Duration
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// Option 客户端选项
+type Option func(*Client)
+
+// WithToken 设置 API token（Authorization: Bearer）
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient 使用自定义 HTTP 客户端（如配置了 CA 证书的 TLS 客户端）
+// 客户端自身的 Timeout 同样会中断事件流，建议保持为 0 并使用 WithTimeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.http = httpClient
+	}
+}
+
+// WithTimeout 设置单个请求的超时时间，0 表示只受 context 限制
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetry 设置临时错误的重试次数和首次重试等待时间，maxRetries 为 0 表示不重试
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithUserAgent 设置 User-Agent
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New 创建客户端，baseURL 为服务器地址（如 https://10.0.0.1:8443）
+func New(baseURL string, opts ...Option) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid server URL: %q", baseURL)
+	}
+
+	c := &Client{
+		base:         base,
+		userAgent:    "nodefoundry-client",
+		http:         &http.Client{},
+		timeout:      DefaultTimeout,
+		maxRetries:   DefaultMaxRetries,
+		retryBackoff: DefaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// request 一次 API 调用
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	header http.Header
+	// stream 为 true 时不设置请求超时，由调用方关闭响应体
+	stream bool
+	// okStatus 视为成功的额外状态码（响应体不是 ErrorResponse）
+	okStatus int
+}
+
+// retryable 请求是否可安全重试：只读请求或携带幂等键的请求
+func (r *request) retryable() bool {
+	switch r.method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	}
+	return r.header.Get("Idempotency-Key") != ""
+}
+
+// do 发送请求并将 JSON 响应解码到 out（可为 nil），临时错误按配置重试
+func (c *Client) do(ctx context.Context, r *request, out interface{}) (*http.Response, error) {
+	resp, cancel, err := c.send(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// send 发送请求并返回 2xx 响应，调用方负责关闭响应体并调用 cancel
+func (c *Client) send(ctx context.Context, r *request) (*http.Response, context.CancelFunc, error) {
+	var body []byte
+	if r.body != nil {
+		var err error
+		if body, err = json.Marshal(r.body); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		reqCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.timeout > 0 && !r.stream {
+			reqCtx, cancel = context.WithTimeout(ctx, c.timeout)
+		}
+
+		req, err := c.newRequest(reqCtx, r, body)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+
+		resp, err := c.http.Do(req)
+		if err == nil && resp.StatusCode == r.okStatus {
+			return resp, cancel, nil
+		}
+		if err == nil {
+			err = checkResponse(resp)
+			if err == nil {
+				return resp, cancel, nil
+			}
+			resp.Body.Close()
+		}
+		cancel()
+
+		wait, retry := c.shouldRetry(ctx, r, attempt, err)
+		if !retry {
+			return nil, nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// newRequest 创建 HTTP 请求
+func (c *Client) newRequest(ctx context.Context, r *request, body []byte) (*http.Request, error) {
+	u := *c.base
+	u.Path = c.base.Path + r.path
+	u.RawQuery = r.query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// shouldRetry 判断是否重试以及重试前的等待时间
+// 重试网络错误和 429、502、503、504 响应；429/503 携带 Retry-After 时按其等待
+func (c *Client) shouldRetry(ctx context.Context, r *request, attempt int, err error) (time.Duration, bool) {
+	if attempt >= c.maxRetries || !r.retryable() || ctx.Err() != nil {
+		return 0, false
+	}
+
+	wait := c.retryBackoff << attempt
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		default:
+			return 0, false
+		}
+		if apiErr.RetryAfter > 0 {
+			wait = apiErr.RetryAfter
+		}
+	}
+	if wait > maxRetryBackoff {
+		wait = maxRetryBackoff
+	}
+	return wait, true
+}
+
+// checkResponse 将非 2xx 响应转换为 *Error
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var body struct {
+		Error     string                 `json:"error"`
+		Code      string                 `json:"code"`
+		Details   map[string]interface{} `json:"details"`
+		RequestID string                 `json:"request_id"`
+	}
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(data)),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		apiErr.Message = body.Error
+		apiErr.Code = body.Code
+		apiErr.Details = body.Details
+		if body.RequestID != "" {
+			apiErr.RequestID = body.RequestID
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	apiErr.RetryAfter = retryAfter(resp)
+	return apiErr
+}
+
+// retryAfter 解析 Retry-After 头（秒），未设置或无效时返回 0
+func retryAfter(resp *http.Response) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// RequestOption 单个请求的附加选项
+type RequestOption func(*request)
+
+// IfMatch 仅在节点资源版本匹配时执行修改，否则返回 412（IsPreconditionFailed）
+func IfMatch(resourceVersion uint64) RequestOption {
+	return func(r *request) {
+		r.header.Set("If-Match", `"`+strconv.FormatUint(resourceVersion, 10)+`"`)
+	}
+}
+
+// newRequestWith 创建请求并应用请求选项
+func newRequestWith(method, path string, query url.Values, body interface{}, opts []RequestOption) *request {
+	r := &request{method: method, path: path, query: query, body: body, header: http.Header{}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// nodePath 节点资源路径
+func nodePath(mac string) string {
+	return "/api/v1/nodes/" + url.PathEscape(mac)
+}
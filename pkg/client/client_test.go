@@ -0,0 +1,369 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/lucheng0127/nodefoundry/internal/api"
+	"github.com/lucheng0127/nodefoundry/internal/db"
+	"github.com/lucheng0127/nodefoundry/internal/events"
+	"github.com/lucheng0127/nodefoundry/internal/model"
+	"github.com/lucheng0127/nodefoundry/internal/ratelimit"
+)
+
+// testServer 基于临时数据库的 API 服务
+type testServer struct {
+	*httptest.Server
+	handler *api.Handler
+	repo    db.NodeRepository
+	bus     *events.Bus
+	// requests 已处理的请求数
+	requests atomic.Int32
+	// failures 前 failures 个请求直接返回 failStatus
+	failures   atomic.Int32
+	failStatus int
+	retryAfter string
+}
+
+// newTestServer 启动 API 服务，setup 可在注册路由前配置处理器
+func newTestServer(t *testing.T, setup func(h *api.Handler)) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	bdb, err := db.InitializeDB(filepath.Join(t.TempDir(), "nodes.db"), zap.NewNop())
+	if err != nil {
+		t.Fatalf("InitializeDB: %v", err)
+	}
+	t.Cleanup(func() { bdb.Close() })
+
+	s := &testServer{
+		repo: db.NewBoltNodeRepository(bdb, zap.NewNop()),
+		bus:  events.NewBus(0, zap.NewNop()),
+	}
+	s.handler = api.NewHandler(s.repo, nil, nil, zap.NewNop())
+	s.handler.SetEventBus(s.bus)
+	if setup != nil {
+		setup(s.handler)
+	}
+
+	r := gin.New()
+	r.Use(api.RequestID(), s.injectFailures)
+	s.handler.RegisterRoutes(r)
+	s.Server = httptest.NewServer(r)
+	t.Cleanup(func() {
+		s.bus.Close()
+		s.Close()
+	})
+	return s
+}
+
+// injectFailures 模拟网关或服务端临时错误
+func (s *testServer) injectFailures(c *gin.Context) {
+	s.requests.Add(1)
+	if s.failures.Add(-1) >= 0 {
+		if s.retryAfter != "" {
+			c.Header("Retry-After", s.retryAfter)
+		}
+		c.AbortWithStatusJSON(s.failStatus, gin.H{"error": "injected failure", "code": CodeServiceUnavailable})
+	}
+}
+
+// fail 让接下来的 n 个请求返回 status
+func (s *testServer) fail(n int, status int, retryAfter string) {
+	s.failStatus = status
+	s.retryAfter = retryAfter
+	s.failures.Store(int32(n))
+}
+
+// newTestClient 创建访问测试服务的客户端，重试等待时间缩短为 1ms
+func newTestClient(t *testing.T, s *testServer, opts ...Option) *Client {
+	t.Helper()
+
+	opts = append([]Option{WithRetry(DefaultMaxRetries, time.Millisecond)}, opts...)
+	c, err := New(s.URL, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+// saveNode 在服务端保存节点
+func (s *testServer) saveNode(t *testing.T, mac string) *model.Node {
+	t.Helper()
+
+	node, err := model.NewNode(mac, model.STATE_DISCOVERED)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.repo.Save(context.Background(), node); err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func TestErrorMapping(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, func(h *api.Handler) {
+		h.SetAPIRateLimit(ratelimit.Limit{Rate: 0.001, Burst: 3})
+	})
+	c := newTestClient(t, s, WithRetry(0, 0))
+	saved := s.saveNode(t, "aabbccddeeff")
+	notes := "edited"
+
+	tests := []struct {
+		name   string
+		call   func() error
+		status int
+		code   string
+		is     func(error) bool
+	}{
+		{name: "not found", status: http.StatusNotFound, code: CodeNodeNotFound, is: IsNotFound,
+			call: func() error { _, err := c.GetNode(ctx, "001122334455"); return err }},
+		{name: "conflict", status: http.StatusConflict, code: CodeNodeAlreadyExists, is: IsConflict,
+			call: func() error {
+				_, err := c.RegisterNode(ctx, &RegisterNodeRequest{MAC: "aa:bb:cc:dd:ee:ff"})
+				return err
+			}},
+		{name: "precondition failed", status: http.StatusPreconditionFailed, code: CodePreconditionFailed, is: IsPreconditionFailed,
+			call: func() error {
+				_, err := c.PatchNode(ctx, saved.MAC, &NodePatch{Notes: &notes}, IfMatch(saved.ResourceVersion+1))
+				return err
+			}},
+		// 限速桶容量为 3，前三个请求已耗尽令牌
+		{name: "rate limited", status: http.StatusTooManyRequests, code: CodeRateLimited, is: IsRateLimited,
+			call: func() error { _, err := c.GetNode(ctx, saved.MAC); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want *Error", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Code != tt.code {
+				t.Errorf("status = %d code = %s, want %d %s", apiErr.StatusCode, apiErr.Code, tt.status, tt.code)
+			}
+			if !tt.is(err) {
+				t.Errorf("predicate false for %v", err)
+			}
+			if apiErr.RequestID == "" {
+				t.Error("missing request ID")
+			}
+			if ErrorCode(err) != tt.code || StatusCode(err) != tt.status {
+				t.Errorf("ErrorCode/StatusCode = %s/%d", ErrorCode(err), StatusCode(err))
+			}
+			if tt.status == http.StatusTooManyRequests {
+				if apiErr.RetryAfter < time.Second {
+					t.Errorf("RetryAfter = %s, want >= 1s", apiErr.RetryAfter)
+				}
+				if apiErr.Details["scope"] != "ip" {
+					t.Errorf("details = %v", apiErr.Details)
+				}
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		failures   int
+		status     int
+		retryAfter string
+		call       func(c *Client) error
+		wantErr    int
+		wantTries  int32
+		minElapsed time.Duration
+	}{
+		{name: "503 then success", failures: 2, status: http.StatusServiceUnavailable, wantTries: 3,
+			call: func(c *Client) error { _, err := c.GetNode(ctx, "aabbccddeeff"); return err }},
+		{name: "502 then success", failures: 1, status: http.StatusBadGateway, wantTries: 2,
+			call: func(c *Client) error { _, err := c.GetNode(ctx, "aabbccddeeff"); return err }},
+		{name: "retry after", failures: 1, status: http.StatusServiceUnavailable, retryAfter: "1", wantTries: 2, minElapsed: time.Second,
+			call: func(c *Client) error { _, err := c.GetNode(ctx, "aabbccddeeff"); return err }},
+		{name: "retries exhausted", failures: 10, status: http.StatusServiceUnavailable, wantErr: http.StatusServiceUnavailable,
+			wantTries: DefaultMaxRetries + 1,
+			call:      func(c *Client) error { _, err := c.GetNode(ctx, "aabbccddeeff"); return err }},
+		{name: "500 not retried", failures: 1, status: http.StatusInternalServerError, wantErr: http.StatusInternalServerError, wantTries: 1,
+			call: func(c *Client) error { _, err := c.GetNode(ctx, "aabbccddeeff"); return err }},
+		{name: "post not retried", failures: 1, status: http.StatusServiceUnavailable, wantErr: http.StatusServiceUnavailable, wantTries: 1,
+			call: func(c *Client) error {
+				_, err := c.RegisterNode(ctx, &RegisterNodeRequest{MAC: "00:11:22:33:44:55"})
+				return err
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, nil)
+			s.saveNode(t, "aabbccddeeff")
+			c := newTestClient(t, s)
+			s.fail(tt.failures, tt.status, tt.retryAfter)
+
+			start := time.Now()
+			err := tt.call(c)
+			if StatusCode(err) != tt.wantErr || (tt.wantErr == 0 && err != nil) {
+				t.Fatalf("err = %v, want status %d", err, tt.wantErr)
+			}
+			if got := s.requests.Load(); got != tt.wantTries {
+				t.Errorf("requests = %d, want %d", got, tt.wantTries)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minElapsed {
+				t.Errorf("elapsed = %s, want >= %s", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestRetryRateLimited(t *testing.T) {
+	s := newTestServer(t, func(h *api.Handler) {
+		h.SetAPIRateLimit(ratelimit.Limit{Rate: 1, Burst: 1})
+	})
+	s.saveNode(t, "aabbccddeeff")
+	c := newTestClient(t, s)
+
+	ctx := context.Background()
+	if _, err := c.GetNode(ctx, "aabbccddeeff"); err != nil {
+		t.Fatalf("GetNode: %v", err)
+	}
+	// 令牌耗尽，服务端返回 429 和 Retry-After，客户端按其等待后重试成功
+	start := time.Now()
+	if _, err := c.GetNode(ctx, "aabbccddeeff"); err != nil {
+		t.Fatalf("GetNode after 429: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want Retry-After of 1s", elapsed)
+	}
+	if got := s.requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+}
+
+func TestBulkNodesBackgroundOperation(t *testing.T) {
+	s := newTestServer(t, nil)
+	for _, mac := range []string{"aabbccddee01", "aabbccddee02", "aabbccddee03"} {
+		s.saveNode(t, mac)
+	}
+	// 单个请求的超时短于批次间等待的总时间（2s）
+	c := newTestClient(t, s, WithTimeout(500*time.Millisecond))
+
+	prod := "prod"
+	resp, err := c.BulkNodes(context.Background(), &BulkRequest{
+		Selector:            BulkSelector{Status: NodeDiscovered},
+		Action:              ActionLabel,
+		Labels:              map[string]*string{"env": &prod},
+		WaveSize:            1,
+		WaveIntervalSeconds: 1,
+	})
+	if err != nil {
+		t.Fatalf("BulkNodes: %v", err)
+	}
+	if resp.Matched != 3 || resp.Succeeded != 3 {
+		t.Errorf("response = %+v, want 3 succeeded", resp)
+	}
+
+	node, err := c.GetNode(context.Background(), "aabbccddee03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Labels["env"] != "prod" {
+		t.Errorf("labels = %v, want env=prod", node.Labels)
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	s := newTestServer(t, nil)
+	c := newTestClient(t, s)
+	node := s.saveNode(t, "aabbccddeeff")
+	other := s.saveNode(t, "001122334455")
+
+	s.bus.Publish(events.NodeEvent(events.EVENT_NODE_DISCOVERED, node, nil))
+	s.bus.Publish(events.NodeEvent(events.EVENT_NODE_UPDATED, other, nil))
+	s.bus.Publish(events.StatusChanged(node, model.STATE_DISCOVERED, "api"))
+	s.bus.Publish(events.NodeEvent(events.EVENT_NODE_DELETED, node, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 从事件 1 之后续传，只接收该节点的事件
+	done := errors.New("done")
+	var got []*Event
+	err := c.StreamEvents(ctx, &EventFilter{MACs: []string{"aa:bb:cc:dd:ee:ff"}, LastEventID: 1}, func(e *Event) error {
+		got = append(got, e)
+		if e.Type == events.EVENT_NODE_DELETED {
+			return done
+		}
+		return nil
+	})
+	if !errors.Is(err, done) {
+		t.Fatalf("StreamEvents = %v, want handler error", err)
+	}
+	if len(got) != 2 || got[0].ID != 3 || got[0].Type != events.EVENT_NODE_STATUS_CHANGED || got[1].ID != 4 {
+		t.Fatalf("events = %+v", got)
+	}
+	if got[0].MAC != node.MAC || got[0].Data["to"] != model.STATE_DISCOVERED || got[0].Data["source"] != "api" {
+		t.Errorf("status event = %+v", got[0])
+	}
+}
+
+func TestStreamEventsLive(t *testing.T) {
+	s := newTestServer(t, nil)
+	c := newTestClient(t, s)
+	node := s.saveNode(t, "aabbccddeeff")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan *Event, 1)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.StreamEvents(ctx, &EventFilter{Types: []string{"node.*"}}, func(e *Event) error {
+			received <- e
+			return errors.New("done")
+		})
+	}()
+
+	// 订阅建立前发布的事件不会投递，持续发布直到收到
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case e := <-received:
+			if e.Type != events.EVENT_NODE_UPDATED || e.MAC != node.MAC || e.ID == 0 {
+				t.Errorf("event = %+v", e)
+			}
+			if err := <-errc; err == nil || err.Error() != "done" {
+				t.Errorf("StreamEvents = %v", err)
+			}
+			return
+		case <-ticker.C:
+			s.bus.Publish(events.NodeEvent(events.EVENT_NODE_UPDATED, node, nil))
+		case <-ctx.Done():
+			t.Fatal("no event received")
+		}
+	}
+}
+
+func TestStreamEventsStopsOnClientError(t *testing.T) {
+	s := newTestServer(t, nil)
+	c := newTestClient(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := c.StreamEvents(ctx, &EventFilter{Types: []string{"node.unknown"}}, func(*Event) error { return nil })
+	if StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("StreamEvents = %v, want 400", err)
+	}
+}
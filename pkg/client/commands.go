@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SendCommand 向节点 agent 下发命令
+// 设置 IdempotencyKey 时请求失败会自动重试，重复的请求返回已有命令
+func (c *Client) SendCommand(ctx context.Context, mac string, req *CommandRequest) (*CommandExecution, error) {
+	r := newRequestWith(http.MethodPost, nodePath(mac)+"/commands", nil, req, nil)
+	if req.IdempotencyKey != "" {
+		r.header.Set("Idempotency-Key", req.IdempotencyKey)
+	}
+
+	var execution CommandExecution
+	if _, err := c.do(ctx, r, &execution); err != nil {
+		return nil, err
+	}
+	return &execution, nil
+}
+
+// GetCommand 获取节点的命令
+func (c *Client) GetCommand(ctx context.Context, mac, id string) (*CommandExecution, error) {
+	var execution CommandExecution
+	path := nodePath(mac) + "/commands/" + url.PathEscape(id)
+	if _, err := c.do(ctx, newRequestWith(http.MethodGet, path, nil, nil, nil), &execution); err != nil {
+		return nil, err
+	}
+	return &execution, nil
+}
+
+// WaitCommand 每隔 interval 轮询，直到命令结束或 ctx 结束
+func (c *Client) WaitCommand(ctx context.Context, mac, id string, interval time.Duration) (*CommandExecution, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		execution, err := c.GetCommand(ctx, mac, id)
+		if err != nil {
+			return nil, err
+		}
+		if execution.Finished() {
+			return execution, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return execution, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListNodeCommands 列出节点的命令（最新的在前，opts 可为 nil）
+func (c *Client) ListNodeCommands(ctx context.Context, mac string, opts *ListCommandsOptions) ([]*CommandExecution, error) {
+	return c.listCommands(ctx, nodePath(mac)+"/commands", opts, false)
+}
+
+// ListCommands 列出所有节点的命令（最新的在前，opts 可为 nil）
+func (c *Client) ListCommands(ctx context.Context, opts *ListCommandsOptions) ([]*CommandExecution, error) {
+	return c.listCommands(ctx, "/api/v1/commands", opts, true)
+}
+
+// listCommands 查询命令列表
+func (c *Client) listCommands(ctx context.Context, path string, opts *ListCommandsOptions, withMAC bool) ([]*CommandExecution, error) {
+	query := url.Values{}
+	if opts != nil {
+		if withMAC && opts.MAC != "" {
+			query.Set("mac", opts.MAC)
+		}
+		if opts.Status != "" {
+			query.Set("status", opts.Status)
+		}
+		if opts.Limit > 0 {
+			query.Set("limit", strconv.Itoa(opts.Limit))
+		}
+	}
+
+	var executions []*CommandExecution
+	if _, err := c.do(ctx, newRequestWith(http.MethodGet, path, query, nil, nil), &executions); err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
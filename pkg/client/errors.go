@@ -0,0 +1,108 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 服务端错误码（Error.Code），含义见服务端 API 文档
+const (
+	CodeInvalidRequest       = "INVALID_REQUEST"
+	CodeValidationFailed     = "VALIDATION_FAILED"
+	CodeInvalidMAC           = "INVALID_MAC"
+	CodeUnknownOperation     = "UNKNOWN_OPERATION"
+	CodeUnauthenticated      = "UNAUTHENTICATED"
+	CodeForbidden            = "FORBIDDEN"
+	CodeInstallTokenInvalid  = "INSTALL_TOKEN_INVALID"
+	CodeNotFound             = "NOT_FOUND"
+	CodeNodeNotFound         = "NODE_NOT_FOUND"
+	CodeCommandNotFound      = "COMMAND_NOT_FOUND"
+	CodeTokenNotFound        = "TOKEN_NOT_FOUND"
+	CodeWebhookNotFound      = "WEBHOOK_NOT_FOUND"
+	CodeLeaseNotFound        = "LEASE_NOT_FOUND"
+	CodeProfileNotFound      = "PROFILE_NOT_FOUND"
+	CodeNodeAlreadyExists    = "NODE_ALREADY_EXISTS"
+	CodeProfileAlreadyExists = "PROFILE_ALREADY_EXISTS"
+	CodeProfileGroupConflict = "PROFILE_GROUP_CONFLICT"
+	CodeInvalidTransition    = "INVALID_TRANSITION"
+	CodeNodeNotInstalling    = "NODE_NOT_INSTALLING"
+	CodeAgentNotRunning      = "AGENT_NOT_RUNNING"
+	CodeVersionConflict      = "VERSION_CONFLICT"
+	CodePreconditionFailed   = "PRECONDITION_FAILED"
+	CodeRateLimited          = "RATE_LIMITED"
+	CodePoolExhausted        = "POOL_EXHAUSTED"
+	CodeFeatureDisabled      = "FEATURE_DISABLED"
+	CodeServiceUnavailable   = "SERVICE_UNAVAILABLE"
+	CodeInternal             = "INTERNAL_ERROR"
+)
+
+// Error API 返回的错误（服务端 ErrorResponse）
+type Error struct {
+	StatusCode int
+	Message    string
+	// Code 服务端错误码，如 NODE_NOT_FOUND（旧版本服务端为空）
+	Code string
+	// Details 错误详情，如校验失败的字段
+	Details map[string]interface{}
+	// RequestID 请求 ID，用于在服务端日志中定位请求
+	RequestID string
+	// RetryAfter 服务端建议的重试等待时间（Retry-After 头）
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s (HTTP %d %s)", e.Message, e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// ErrorCode 返回 API 错误的错误码，非 API 错误返回空字符串
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// StatusCode 返回 API 错误的 HTTP 状态码，非 API 错误返回 0
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound 资源不存在
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict 资源冲突（如节点已存在、节点状态不允许下发命令）
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsPreconditionFailed If-Match 资源版本不匹配
+func IsPreconditionFailed(err error) bool {
+	return StatusCode(err) == http.StatusPreconditionFailed
+}
+
+// IsUnauthorized 未提供 token 或 token 无效
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
+
+// IsForbidden token 角色权限不足
+func IsForbidden(err error) bool {
+	return StatusCode(err) == http.StatusForbidden
+}
+
+// IsRateLimited 请求过于频繁（重试次数用尽后仍被限速），可按 Error.RetryAfter 等待
+func IsRateLimited(err error) bool {
+	return StatusCode(err) == http.StatusTooManyRequests
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventStreamGap 事件缺口：续传位置已不在服务端缓冲中，部分事件丢失
+const EventStreamGap = "stream.gap"
+
+// defaultReconnectDelay 事件流断开后的默认重连间隔（服务端可通过 retry 字段修改）
+const defaultReconnectDelay = 3 * time.Second
+
+// Event 节点事件
+type Event struct {
+	// ID 单调递增（服务重启后重新从 1 开始），EventStreamGap 事件为 0
+	ID     uint64                 `json:"id"`
+	Type   string                 `json:"type"`
+	Time   time.Time              `json:"time"`
+	MAC    string                 `json:"mac,omitempty"`
+	Labels map[string]string      `json:"labels,omitempty"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
+
+// EventFilter 事件过滤条件（零值表示全部事件）
+type EventFilter struct {
+	MACs []string
+	// Types 事件类型，支持 node.* 形式
+	Types []string
+	// Labels 节点标签选择器
+	Labels string
+	// LastEventID 从该事件之后开始续传，0 表示只接收新事件
+	LastEventID uint64
+}
+
+// StreamEvents 订阅事件流（SSE）并对每个事件调用 handle
+// 连接断开后携带最后收到的事件 ID 自动重连续传，直到 ctx 结束、handle 返回错误
+// 或服务端返回不可恢复的错误（如 401、400）；ctx 结束时返回 ctx.Err()
+// 缓冲溢出导致无法完整续传时，会收到类型为 EventStreamGap 的事件
+func (c *Client) StreamEvents(ctx context.Context, filter *EventFilter, handle func(*Event) error) error {
+	query := url.Values{}
+	var lastID uint64
+	if filter != nil {
+		if len(filter.MACs) > 0 {
+			query.Set("mac", strings.Join(filter.MACs, ","))
+		}
+		if len(filter.Types) > 0 {
+			query.Set("type", strings.Join(filter.Types, ","))
+		}
+		if filter.Labels != "" {
+			query.Set("labels", filter.Labels)
+		}
+		lastID = filter.LastEventID
+	}
+
+	delay := defaultReconnectDelay
+	for {
+		err := c.streamOnce(ctx, query, &lastID, &delay, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var handlerErr *handlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+		// 4xx 错误不会因重连恢复
+		if code := StatusCode(err); code >= 400 && code < 500 && code != http.StatusTooManyRequests {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// handlerError 区分回调返回的错误和连接错误
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+// streamOnce 建立一次 SSE 连接并逐条回调，直到连接断开
+func (c *Client) streamOnce(ctx context.Context, query url.Values, lastID *uint64, delay *time.Duration, handle func(*Event) error) error {
+	r := newRequestWith(http.MethodGet, "/api/v1/events", query, nil, nil)
+	r.stream = true
+	r.header.Set("Accept", "text/event-stream")
+	if *lastID > 0 {
+		r.header.Set("Last-Event-ID", strconv.FormatUint(*lastID, 10))
+	}
+
+	resp, cancel, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var eventType string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// 空行结束一条消息
+			if len(data) > 0 {
+				e, err := parseEvent(eventType, strings.Join(data, "\n"))
+				if err != nil {
+					return err
+				}
+				if e.ID > 0 {
+					*lastID = e.ID
+				}
+				if err := handle(e); err != nil {
+					return &handlerError{err: err}
+				}
+			}
+			eventType, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+				*delay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// parseEvent 解析一条 SSE 消息；EventStreamGap 消息的数据不是事件，放入 Data
+func parseEvent(eventType, data string) (*Event, error) {
+	if eventType == EventStreamGap {
+		e := &Event{Type: EventStreamGap, Time: time.Now()}
+		if err := json.Unmarshal([]byte(data), &e.Data); err != nil {
+			return nil, fmt.Errorf("invalid %s event: %w", EventStreamGap, err)
+		}
+		return e, nil
+	}
+
+	var e Event
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+	return &e, nil
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListNodes 获取一页节点（opts 可为 nil）
+func (c *Client) ListNodes(ctx context.Context, opts *ListNodesOptions) (*NodeList, error) {
+	query := url.Values{}
+	if opts != nil {
+		for key, value := range map[string]string{
+			"status":   opts.Status,
+			"label":    opts.Label,
+			"ip":       opts.IP,
+			"hostname": opts.Hostname,
+			"sort":     opts.Sort,
+			"cursor":   opts.Cursor,
+		} {
+			if value != "" {
+				query.Set(key, value)
+			}
+		}
+		if opts.HeartbeatMaxAge > 0 {
+			query.Set("heartbeat_max_age", opts.HeartbeatMaxAge.String())
+		}
+		if opts.HeartbeatMinAge > 0 {
+			query.Set("heartbeat_min_age", opts.HeartbeatMinAge.String())
+		}
+		if len(opts.Fields) > 0 {
+			query.Set("fields", strings.Join(opts.Fields, ","))
+		}
+		if opts.Limit > 0 {
+			query.Set("limit", strconv.Itoa(opts.Limit))
+		}
+	}
+
+	list := &NodeList{}
+	resp, err := c.do(ctx, newRequestWith(http.MethodGet, "/api/v1/nodes", query, nil, nil), &list.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	list.Total, _ = strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	list.NextCursor = resp.Header.Get("X-Next-Cursor")
+	return list, nil
+}
+
+// ListAllNodes 跟随分页游标获取所有匹配的节点（opts.Limit 为每页数量）
+func (c *Client) ListAllNodes(ctx context.Context, opts *ListNodesOptions) ([]*Node, error) {
+	page := ListNodesOptions{}
+	if opts != nil {
+		page = *opts
+	}
+
+	var nodes []*Node
+	for {
+		list, err := c.ListNodes(ctx, &page)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, list.Nodes...)
+		if list.NextCursor == "" {
+			return nodes, nil
+		}
+		page.Cursor = list.NextCursor
+	}
+}
+
+// GetNode 获取节点
+func (c *Client) GetNode(ctx context.Context, mac string) (*Node, error) {
+	var node Node
+	if _, err := c.do(ctx, newRequestWith(http.MethodGet, nodePath(mac), nil, nil, nil), &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// RegisterNode 手动注册节点，节点已存在时返回 409（IsConflict）
+func (c *Client) RegisterNode(ctx context.Context, req *RegisterNodeRequest) (*Node, error) {
+	var node Node
+	if _, err := c.do(ctx, newRequestWith(http.MethodPost, "/api/v1/nodes", nil, req, nil), &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// UpdateNode 执行节点操作（install、reinstall）
+func (c *Client) UpdateNode(ctx context.Context, mac string, req *UpdateNodeRequest, opts ...RequestOption) (*Node, error) {
+	var node Node
+	if _, err := c.do(ctx, newRequestWith(http.MethodPut, nodePath(mac), nil, req, opts), &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// InstallNode 触发安装 discovered 或 failed 节点
+func (c *Client) InstallNode(ctx context.Context, mac string, opts ...RequestOption) (*Node, error) {
+	return c.UpdateNode(ctx, mac, &UpdateNodeRequest{Action: ActionInstall}, opts...)
+}
+
+// ReinstallNode 重装 installed 节点
+func (c *Client) ReinstallNode(ctx context.Context, mac string, opts ...RequestOption) (*Node, error) {
+	return c.UpdateNode(ctx, mac, &UpdateNodeRequest{Action: ActionReinstall}, opts...)
+}
+
+// PatchNode 编辑节点属性
+func (c *Client) PatchNode(ctx context.Context, mac string, patch *NodePatch, opts ...RequestOption) (*Node, error) {
+	var node Node
+	if _, err := c.do(ctx, newRequestWith(http.MethodPatch, nodePath(mac), nil, patch, opts), &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// DeleteNode 删除节点并释放其 DHCP 租约
+func (c *Client) DeleteNode(ctx context.Context, mac string, opts ...RequestOption) error {
+	_, err := c.do(ctx, newRequestWith(http.MethodDelete, nodePath(mac), nil, nil, opts), nil)
+	return err
+}
+
+// BulkNodes 按选择器批量操作节点
+// 批次之间需要等待时服务端在后台执行（202），此时轮询操作直至完成，由 ctx 控制最长等待时间
+func (c *Client) BulkNodes(ctx context.Context, req *BulkRequest) (*BulkResponse, error) {
+	var raw json.RawMessage
+	resp, err := c.do(ctx, newRequestWith(http.MethodPost, "/api/v1/nodes:bulk", nil, req, nil), &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusAccepted {
+		var result BulkResponse
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return &result, nil
+	}
+
+	var operation BulkOperation
+	if err := json.Unmarshal(raw, &operation); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return c.waitBulkOperation(ctx, operation.ID, retryAfter(resp))
+}
+
+// GetBulkOperation 查询后台批量操作的进度和结果
+func (c *Client) GetBulkOperation(ctx context.Context, id string) (*BulkOperation, error) {
+	operation, _, err := c.getBulkOperation(ctx, id)
+	return operation, err
+}
+
+// getBulkOperation 查询后台批量操作，同时返回服务端建议的查询间隔
+func (c *Client) getBulkOperation(ctx context.Context, id string) (*BulkOperation, time.Duration, error) {
+	var operation BulkOperation
+	resp, err := c.do(ctx, newRequestWith(http.MethodGet, "/api/v1/operations/"+url.PathEscape(id), nil, nil, nil), &operation)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &operation, retryAfter(resp), nil
+}
+
+// waitBulkOperation 按服务端建议的间隔轮询后台批量操作，完成后返回结果
+func (c *Client) waitBulkOperation(ctx context.Context, id string, interval time.Duration) (*BulkResponse, error) {
+	for {
+		if interval <= 0 {
+			interval = bulkPollInterval
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		operation, next, err := c.getBulkOperation(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Status == BulkOperationCompleted && operation.Result != nil {
+			return operation.Result, nil
+		}
+		interval = next
+	}
+}
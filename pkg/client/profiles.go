@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListProfiles 列出安装配置（按名称排序）
+func (c *Client) ListProfiles(ctx context.Context) ([]*InstallProfile, error) {
+	var profiles []*InstallProfile
+	if _, err := c.do(ctx, newRequestWith(http.MethodGet, "/api/v1/profiles", nil, nil, nil), &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// GetProfile 获取安装配置
+func (c *Client) GetProfile(ctx context.Context, name string) (*InstallProfile, error) {
+	var profile InstallProfile
+	if _, err := c.do(ctx, newRequestWith(http.MethodGet, profilePath(name), nil, nil, nil), &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// CreateProfile 创建安装配置（需要 admin 角色），服务端校验其中的模板
+func (c *Client) CreateProfile(ctx context.Context, req *ProfileRequest) (*InstallProfile, error) {
+	var profile InstallProfile
+	if _, err := c.do(ctx, newRequestWith(http.MethodPost, "/api/v1/profiles", nil, req, nil), &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// UpdateProfile 修改安装配置（nil 字段保持不变）
+func (c *Client) UpdateProfile(ctx context.Context, name string, req *ProfileRequest) (*InstallProfile, error) {
+	var profile InstallProfile
+	if _, err := c.do(ctx, newRequestWith(http.MethodPatch, profilePath(name), nil, req, nil), &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// DeleteProfile 删除安装配置
+func (c *Client) DeleteProfile(ctx context.Context, name string) error {
+	_, err := c.do(ctx, newRequestWith(http.MethodDelete, profilePath(name), nil, nil, nil), nil)
+	return err
+}
+
+// profilePath 安装配置的 API 路径
+func profilePath(name string) string {
+	return "/api/v1/profiles/" + url.PathEscape(name)
+}
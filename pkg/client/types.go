@@ -0,0 +1,413 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 节点状态
+const (
+	NodeDiscovered = "discovered"
+	NodeInstalling = "installing"
+	NodeInstalled  = "installed"
+	NodeFailed     = "failed"
+)
+
+// 安装进度（Node.InstallStage）
+const (
+	InstallStageStarted     = "started"
+	InstallStagePartitioned = "partitioned"
+	InstallStageFinished    = "finished"
+	InstallStageFailed      = "failed"
+)
+
+// 节点操作（UpdateNode、BulkNodes）
+const (
+	ActionInstall   = "install"
+	ActionReinstall = "reinstall"
+	ActionReboot    = "reboot"
+	ActionLabel     = "label"
+	ActionDelete    = "delete"
+)
+
+// 命令执行状态
+const (
+	CommandPending   = "pending"
+	CommandRunning   = "running"
+	CommandSucceeded = "succeeded"
+	CommandFailed    = "failed"
+	CommandTimedOut  = "timed_out"
+)
+
+// Node 节点
+type Node struct {
+	MAC           string            `json:"mac"`
+	IP            string            `json:"ip,omitempty"`
+	Netmask       string            `json:"netmask,omitempty"`
+	Gateway       string            `json:"gateway,omitempty"`
+	DNS           string            `json:"dns,omitempty"`
+	Hostname      string            `json:"hostname,omitempty"`
+	Status        string            `json:"status"`
+	LastHeartbeat time.Time         `json:"last_heartbeat,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	Extra         json.RawMessage   `json:"extra,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Notes         string            `json:"notes,omitempty"`
+	// InstallStartedAt 最近一次进入 installing 状态的时间
+	InstallStartedAt time.Time `json:"install_started_at,omitempty"`
+	// InstallStage 本次安装中安装器上报的最新进度
+	InstallStage string `json:"install_stage,omitempty"`
+	// InstallError 安装器上报失败时的错误信息
+	InstallError string `json:"install_error,omitempty"`
+	// StaticNetwork 网络配置由 API 固定，DHCP 按该配置应答且不再覆盖
+	StaticNetwork bool `json:"static_network,omitempty"`
+	// ResourceVersion 资源版本，可通过 IfMatch 实现乐观并发控制
+	ResourceVersion uint64 `json:"resource_version"`
+}
+
+// RegisterNodeRequest 注册节点请求
+// 指定 IP 时必须同时指定 Netmask，节点网络配置固定为静态
+type RegisterNodeRequest struct {
+	MAC     string `json:"mac"`
+	IP      string `json:"ip,omitempty"`
+	Netmask string `json:"netmask,omitempty"`
+	Gateway string `json:"gateway,omitempty"`
+	DNS     string `json:"dns,omitempty"`
+}
+
+// UpdateNodeRequest 更新节点请求（action: install、reinstall）
+type UpdateNodeRequest struct {
+	Action string `json:"action"`
+}
+
+// NodePatch 节点属性修改（JSON Merge Patch），nil 字段保持不变
+// 修改网络配置时 IP 与 Netmask 必须同时有值或同时为空；设置 IP 后节点固定为静态网络，IP 设为空字符串恢复由 DHCP 分配
+type NodePatch struct {
+	Hostname *string `json:"hostname,omitempty"`
+	IP       *string `json:"ip,omitempty"`
+	Netmask  *string `json:"netmask,omitempty"`
+	Gateway  *string `json:"gateway,omitempty"`
+	DNS      *string `json:"dns,omitempty"`
+	Notes    *string `json:"notes,omitempty"`
+	// Labels 按键合并，值为 nil 表示删除该标签
+	Labels map[string]*string `json:"labels,omitempty"`
+}
+
+// ListNodesOptions 节点列表查询参数（零值表示不限制）
+type ListNodesOptions struct {
+	// Status 节点状态（逗号分隔）
+	Status string
+	// Label 标签选择器，如 rack=a1,!maintenance
+	Label string
+	// IP IP 地址或 CIDR
+	IP string
+	// Hostname 主机名通配符，如 edge-*
+	Hostname string
+	// HeartbeatMaxAge / HeartbeatMinAge 按最近心跳时间过滤
+	HeartbeatMaxAge time.Duration
+	HeartbeatMinAge time.Duration
+	// Sort 排序字段，前缀 - 表示降序，如 -created_at
+	Sort string
+	// Fields 只返回这些字段
+	Fields []string
+	// Limit 单页数量，0 表示服务端默认
+	Limit int
+	// Cursor 上一页返回的 NextCursor
+	Cursor string
+}
+
+// NodeList 一页节点
+type NodeList struct {
+	Nodes []*Node
+	// Total 过滤后的节点总数
+	Total int
+	// NextCursor 下一页游标，为空表示没有更多
+	NextCursor string
+}
+
+// BulkSelector 批量操作节点选择器，多个条件同时满足才匹配
+type BulkSelector struct {
+	MACs   []string `json:"macs,omitempty"`
+	Labels string   `json:"labels,omitempty"`
+	Status string   `json:"status,omitempty"`
+}
+
+// BulkRequest 批量操作请求
+type BulkRequest struct {
+	Selector BulkSelector `json:"selector"`
+	// Action install、reinstall、reboot、label、delete
+	Action string `json:"action"`
+	// Labels label 操作的标签变更，值为 nil 表示删除
+	Labels              map[string]*string `json:"labels,omitempty"`
+	DryRun              bool               `json:"dry_run,omitempty"`
+	Concurrency         int                `json:"concurrency,omitempty"`
+	WaveSize            int                `json:"wave_size,omitempty"`
+	WaveIntervalSeconds int                `json:"wave_interval_seconds,omitempty"`
+}
+
+// BulkResult 单个节点的批量操作结果
+type BulkResult struct {
+	MAC    string `json:"mac"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+	// Code 失败或跳过原因的错误码
+	Code string `json:"code,omitempty"`
+	Node *Node  `json:"node,omitempty"`
+}
+
+// BulkResponse 批量操作响应
+type BulkResponse struct {
+	Action    string       `json:"action"`
+	DryRun    bool         `json:"dry_run"`
+	Matched   int          `json:"matched"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Skipped   int          `json:"skipped"`
+	Results   []BulkResult `json:"results"`
+}
+
+// 后台批量操作状态（BulkOperation.Status）
+const (
+	BulkOperationRunning   = "running"
+	BulkOperationCompleted = "completed"
+)
+
+// BulkOperation 服务端后台执行的批量操作（批次之间需要等待时）
+type BulkOperation struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	Action  string `json:"action"`
+	Matched int    `json:"matched"`
+	// WaitSeconds 批次间等待的总时间
+	WaitSeconds int        `json:"wait_seconds"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	// Result 完成后的批量操作结果
+	Result *BulkResponse `json:"result,omitempty"`
+}
+
+// CommandRequest 下发命令请求
+type CommandRequest struct {
+	Command string                 `json:"command"`
+	Args    map[string]interface{} `json:"args,omitempty"`
+	// TimeoutSeconds 截止时间，0 表示服务端默认
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// IdempotencyKey 幂等键，设置后请求在失败时可安全重试
+	IdempotencyKey string `json:"-"`
+}
+
+// CommandExecution 命令及其执行结果
+type CommandExecution struct {
+	ID             string                 `json:"id"`
+	MAC            string                 `json:"mac"`
+	Command        string                 `json:"command"`
+	Args           map[string]interface{} `json:"args,omitempty"`
+	Status         string                 `json:"status"`
+	RequestedBy    string                 `json:"requested_by,omitempty"`
+	IdempotencyKey string                 `json:"idempotency_key,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	Deadline       time.Time              `json:"deadline"`
+	StartedAt      time.Time              `json:"started_at,omitempty"`
+	FinishedAt     time.Time              `json:"finished_at,omitempty"`
+	Progress       string                 `json:"progress,omitempty"`
+	ExitCode       *int                   `json:"exit_code,omitempty"`
+	Stdout         string                 `json:"stdout,omitempty"`
+	Stderr         string                 `json:"stderr,omitempty"`
+	Error          string                 `json:"error,omitempty"`
+}
+
+// Finished 命令是否已结束
+func (e *CommandExecution) Finished() bool {
+	switch e.Status {
+	case CommandSucceeded, CommandFailed, CommandTimedOut:
+		return true
+	}
+	return false
+}
+
+// ListCommandsOptions 命令列表查询参数
+type ListCommandsOptions struct {
+	// MAC 只列出该节点的命令（ListCommands）
+	MAC    string
+	Status string
+	// Limit 数量，0 表示服务端默认
+	Limit int
+}
+
+// Lease DHCP 租约
+type Lease struct {
+	MAC       string    `json:"mac"`
+	IP        string    `json:"ip"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Expired   bool      `json:"expired"`
+}
+
+// PoolStats DHCP 地址池统计
+type PoolStats struct {
+	Size      int `json:"size"`
+	Allocated int `json:"allocated"`
+	Expired   int `json:"expired"`
+}
+
+// LeaseList 地址池及其租约
+type LeaseList struct {
+	Subnet string    `json:"subnet"`
+	Stats  PoolStats `json:"stats"`
+	Leases []Lease   `json:"leases"`
+}
+
+// CreateTokenRequest 创建 API token 请求
+type CreateTokenRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+	// ExpiresIn 有效期（如 720h），为空表示永不过期
+	ExpiresIn string `json:"expires_in,omitempty"`
+}
+
+// Token API token 信息
+type Token struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// Token 明文，仅在创建时返回一次
+	Token string `json:"token,omitempty"`
+}
+
+// WebhookRequest 创建或修改 webhook 请求（修改时 nil 字段保持不变）
+type WebhookRequest struct {
+	URL         *string   `json:"url,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Events      *[]string `json:"events,omitempty"`
+	Labels      *string   `json:"labels,omitempty"`
+	Secret      *string   `json:"secret,omitempty"`
+	Enabled     *bool     `json:"enabled,omitempty"`
+}
+
+// Webhook webhook 信息
+type Webhook struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Events      []string  `json:"events"`
+	Labels      string    `json:"labels,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Secret 签名密钥，仅在创建时返回一次
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookDelivery 一次事件投递
+type WebhookDelivery struct {
+	ID            string          `json:"id"`
+	WebhookID     string          `json:"webhook_id"`
+	EventID       uint64          `json:"event_id,omitempty"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at,omitempty"`
+	LastAttemptAt time.Time       `json:"last_attempt_at,omitempty"`
+	ResponseCode  int             `json:"response_code,omitempty"`
+	ResponseBody  string          `json:"response_body,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	DurationMs    int64           `json:"duration_ms,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	CompletedAt   time.Time       `json:"completed_at,omitempty"`
+}
+
+// HealthReport 健康检查报告（存活检查只有 Status 和 Uptime）
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Uptime     string                     `json:"uptime"`
+	Components map[string]ComponentReport `json:"components,omitempty"`
+}
+
+// ComponentReport 单个组件的检查结果
+type ComponentReport struct {
+	Status   string                 `json:"status"`
+	Message  string                 `json:"message,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+	Critical bool                   `json:"critical"`
+	Duration string                 `json:"duration"`
+}
+
+// AuditEntry 一次修改类 API 请求的审计记录
+type AuditEntry struct {
+	ID         uint64                 `json:"id"`
+	Time       time.Time              `json:"time"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	Actor      string                 `json:"actor"`
+	TokenID    string                 `json:"token_id,omitempty"`
+	Role       string                 `json:"role,omitempty"`
+	RemoteAddr string                 `json:"remote_addr"`
+	Method     string                 `json:"method"`
+	Path       string                 `json:"path"`
+	Action     string                 `json:"action"`
+	MAC        string                 `json:"mac,omitempty"`
+	Target     string                 `json:"target,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+	Status     int                    `json:"status"`
+	Code       string                 `json:"code,omitempty"`
+	DurationMs int64                  `json:"duration_ms"`
+}
+
+// AuditOptions 审计记录查询参数（零值表示不限制）
+type AuditOptions struct {
+	// Actor 操作者（token 名称或 ID）
+	Actor string
+	// MAC 目标节点
+	MAC string
+	// Since/Until 时间范围 [Since, Until)
+	Since time.Time
+	Until time.Time
+	// Limit 单页数量，0 表示服务端默认（导出时忽略）
+	Limit int
+	// Cursor 上一页返回的 NextCursor（导出时忽略）
+	Cursor string
+}
+
+// AuditList 一页审计记录（最新的在前）
+type AuditList struct {
+	Entries []*AuditEntry
+	// NextCursor 下一页游标，为空表示没有更多
+	NextCursor string
+}
+
+// InstallProfile 操作系统安装配置
+type InstallProfile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Distro      string `json:"distro"`
+	Release     string `json:"release,omitempty"`
+	// Arch 目标架构，为空表示使用 iPXE 的 ${buildarch}
+	Arch string `json:"arch,omitempty"`
+	// KernelURL、InitrdURL、KernelArgs、AnswerFile 为服务端渲染的 text/template 模板
+	KernelURL  string `json:"kernel_url"`
+	InitrdURL  string `json:"initrd_url"`
+	KernelArgs string `json:"kernel_args,omitempty"`
+	AnswerFile string `json:"answer_file,omitempty"`
+	// Groups 默认使用该配置的节点分组（group 标签的值）
+	Groups    []string  `json:"groups,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProfileRequest 创建或修改安装配置请求（修改时 nil 字段保持不变，名称不可修改）
+type ProfileRequest struct {
+	Name        *string   `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Distro      *string   `json:"distro,omitempty"`
+	Release     *string   `json:"release,omitempty"`
+	Arch        *string   `json:"arch,omitempty"`
+	KernelURL   *string   `json:"kernel_url,omitempty"`
+	InitrdURL   *string   `json:"initrd_url,omitempty"`
+	KernelArgs  *string   `json:"kernel_args,omitempty"`
+	AnswerFile  *string   `json:"answer_file,omitempty"`
+	Groups      *[]string `json:"groups,omitempty"`
+}